@@ -41,7 +41,6 @@ import (
 	"shellman/cli/internal/projectstate"
 	"shellman/cli/internal/protocol"
 	"shellman/cli/internal/systempicker"
-	"shellman/cli/internal/tmux"
 	"shellman/cli/internal/turn"
 )
 
@@ -120,7 +119,7 @@ func main() {
 						os.Stderr,
 						turn.NewRegisterClient(cfg.WorkerBaseURL),
 						turn.RealDialer{},
-						tmux.NewAdapterWithSocket(&tmux.RealExec{}, cfg.TmuxSocket),
+						newPaneBackend(cfg),
 					)
 				},
 				newRuntimeLogger(os.Stderr).With("module", "serve"),
@@ -134,7 +133,7 @@ func main() {
 				os.Stderr,
 				turn.NewRegisterClient(cfg.WorkerBaseURL),
 				turn.RealDialer{},
-				tmux.NewAdapterWithSocket(&tmux.RealExec{}, cfg.TmuxSocket),
+				newPaneBackend(cfg),
 			)
 		},
 		RunMigrateUp: runMigrateUp,
@@ -248,6 +247,16 @@ func runLegacy(
 	}
 	gatewayLocalServer := buildGatewayLocalAPIServer(configDir, tmuxService, systempicker.PickDirectory)
 	httpExec, autoCompleteExec := newGatewayExecutors(gatewayLocalServer, "local-agent-gateway-http")
+	// The gateway server accepts completions too, so it needs the loops that drain their follow-ups.
+	loopCtx, cancelLoops := context.WithCancel(ctx)
+	defer cancelLoops()
+	for _, loop := range gatewayLocalServer.BackgroundLoops() {
+		go func() {
+			if err := loop.Run(loopCtx); err != nil {
+				logger.Warn("local api loop stopped", "loop", loop.Name, "err", err)
+			}
+		}()
+	}
 
 	wsClient := turn.NewWSClient(sock)
 	return runWSRuntime(ctx, wsClient, tmuxService, httpExec, autoCompleteExec, logger)
@@ -282,8 +291,9 @@ func runWSRuntime(
 			out := make([]taskStateProject, 0, len(projects))
 			for _, project := range projects {
 				out = append(out, taskStateProject{
-					ProjectID: project.ProjectID,
-					RepoRoot:  project.RepoRoot,
+					ProjectID:  project.ProjectID,
+					RepoRoot:   project.RepoRoot,
+					TmuxSocket: project.TmuxSocket,
 				})
 			}
 			return out, nil
@@ -296,9 +306,17 @@ func runWSRuntime(
 		}
 		return wsClient.Send(ctx, string(raw))
 	})
+	if source, ok := tmuxService.(panePIDSource); ok {
+		taskStateActor.SetResourceSampler(runtimePaneResources, panePIDOnSocket(source), paneResourceSampleInterval)
+	}
 	paneBaseline := loadPaneRuntimeBaselineFromDB(logger.With("module", "status_baseline"))
 	registry.SetPaneRuntimeBaseline(paneBaseline)
-	outputSource := NewControlModeHub(runtimeCtx, runtimeTmuxSocket, logger.With("module", "control_mode_hub"))
+	var outputSource paneOutputRealtimeSource
+	if source, ok := tmuxService.(paneOutputRealtimeSource); ok {
+		outputSource = source
+	} else {
+		outputSource = NewControlModeHub(runtimeCtx, runtimeTmuxSocket, logger.With("module", "control_mode_hub"))
+	}
 	registry.ConfigureRuntime(runtimeCtx, wsClient, tmuxService, inputTracker, autoCompleteExec, outputSource, taskStateActor)
 	bindMessageLoop(wsClient, handler, registry, inputTracker, logger.With("module", "message_loop"))
 	go runTaskStateActorLoop(runtimeCtx, taskStateActor)
@@ -515,7 +533,11 @@ func runLocalLegacy(ctx context.Context, out io.Writer, cfg config.Config) error
 	if err != nil {
 		return err
 	}
-	tmuxAdapter := tmux.NewAdapterWithSocket(&tmux.RealExec{}, cfg.TmuxSocket)
+	panes := newPaneBackend(cfg)
+	var paneOutput localapi.PaneOutputSource = NewControlModeHub(ctx, cfg.TmuxSocket, newRuntimeLogger(os.Stderr).With("module", "run_recording"))
+	if source, ok := panes.(localapi.PaneOutputSource); ok {
+		paneOutput = source
+	}
 	historyStore, err := historydb.NewStore(globalDBGORM)
 	if err != nil {
 		return err
@@ -527,8 +549,10 @@ func runLocalLegacy(ctx context.Context, out io.Writer, cfg config.Config) error
 		AppProgramsStore:    appProgramsStore,
 		HelperConfigStore:   helperCfgStore,
 		ProjectsStore:       projectsStore,
-		PaneService:         tmuxAdapter,
-		TaskPromptSender:    tmuxAdapter,
+		PaneService:         panes,
+		PaneOutputSource:    paneOutput,
+		PaneResources:       runtimePaneResources,
+		TaskPromptSender:    panes,
 		PickDirectory:       systempicker.PickDirectory,
 		FSBrowser:           fsbrowser.NewService(),
 		DirHistory:          historyStore,
@@ -577,9 +601,17 @@ func runLocalLegacy(ctx context.Context, out io.Writer, cfg config.Config) error
 		}
 		return nil
 	})
+	for _, loop := range localServer.BackgroundLoops() {
+		mgr.AddRun(loop.Name, loop.Run)
+	}
 	mgr.AddRun("local-agent-loop", func(runCtx context.Context) error {
-		return startLocalAgentLoop(runCtx, cfg.LocalPort, turn.RealDialer{}, tmuxAdapter, httpExecRef.Exec, autoCompleteExec, newRuntimeLogger(os.Stderr).With("module", "local_agent_loop"))
+		return startLocalAgentLoop(runCtx, cfg.LocalPort, turn.RealDialer{}, panes, httpExecRef.Exec, autoCompleteExec, newRuntimeLogger(os.Stderr).With("module", "local_agent_loop"))
 	})
+	if closer, ok := panes.(io.Closer); ok {
+		mgr.AddShutdown("close-pane-backend", func(context.Context) error {
+			return closer.Close()
+		})
+	}
 	mgr.AddShutdown("close-helper-config", func(context.Context) error {
 		return helperCfgStore.Close()
 	})
@@ -966,6 +998,20 @@ func buildAgentLoopRunner(cfg config.Config, helperStore localapi.HelperConfigSt
 	}); err != nil {
 		return nil, endpoint, model
 	}
+	if err := registry.Register(&agentloopadapter.TaskCurrentSignalTool{
+		Exec: func(ctx context.Context, taskID, signal, scope string) (string, *agentloop.ToolError) {
+			_ = ctx
+			path := "/api/v1/tasks/" + url.PathEscape(strings.TrimSpace(taskID)) + "/pane/signal"
+			payload := map[string]any{
+				"signal": signal,
+				"scope":  scope,
+				"source": "sidecar",
+			}
+			return callTaskTool(http.MethodPost, path, payload)
+		},
+	}); err != nil {
+		return nil, endpoint, model
+	}
 	if err := registry.Register(&agentloopadapter.WriteStdinTool{
 		Exec: func(ctx context.Context, taskID, input string, timeoutMs int) (string, *agentloop.ToolError) {
 			_ = ctx
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os/exec"
+	"sync"
+
+	"shellman/cli/internal/bridge"
+	"shellman/cli/internal/config"
+	"shellman/cli/internal/localapi"
+	"shellman/cli/internal/ptyhost"
+	"shellman/cli/internal/tmux"
+)
+
+// paneBackend is what both the local API and the turn runtime need from whatever hosts the panes.
+type paneBackend interface {
+	bridge.TmuxService
+	localapi.PaneService
+	localapi.TaskPromptSender
+}
+
+var (
+	_ paneBackend = (*tmux.Adapter)(nil)
+	_ paneBackend = (*ptyhost.Host)(nil)
+)
+
+var (
+	ptyHostOnce sync.Once
+	ptyHostInst *ptyhost.Host
+)
+
+// newPaneBackend returns the pane backend cfg selects. The PTY host keeps its panes in this process, so every caller
+// shares one; tmux adapters are stateless and made per caller.
+func newPaneBackend(cfg config.Config) paneBackend {
+	if usePTYBackend(cfg) {
+		ptyHostOnce.Do(func() {
+			ptyHostInst = ptyhost.NewHost(ptyhost.Options{HistoryLines: cfg.HistoryLines})
+		})
+		return ptyHostInst
+	}
+	return tmux.NewAdapterWithSocket(&tmux.RealExec{}, cfg.TmuxSocket)
+}
+
+func usePTYBackend(cfg config.Config) bool {
+	switch cfg.PaneBackend {
+	case config.PaneBackendPTY:
+		return true
+	case config.PaneBackendAuto:
+		_, err := exec.LookPath("tmux")
+		return err != nil
+	}
+	return false
+}
@@ -40,7 +40,14 @@ type controlModeSubscription struct {
 	out    chan string
 }
 
+// controlModeSessionKey identifies a tmux session on one of the servers the hub follows; "" is the hub's own socket.
+type controlModeSessionKey struct {
+	socket  string
+	session string
+}
+
 type controlModeSessionWatcher struct {
+	key         controlModeSessionKey
 	session     string
 	client      controlSessionClient
 	nextID      int
@@ -56,7 +63,7 @@ type ControlModeHub struct {
 	onClose func(target, reason string)
 
 	mu       sync.Mutex
-	sessions map[string]*controlModeSessionWatcher
+	sessions map[controlModeSessionKey]*controlModeSessionWatcher
 }
 
 func NewControlModeHub(ctx context.Context, socket string, logger *slog.Logger) *ControlModeHub {
@@ -78,38 +85,54 @@ func newControlModeHubWithFactory(ctx context.Context, socket string, logger *sl
 		socket:   strings.TrimSpace(socket),
 		logger:   logger,
 		factory:  factory,
-		sessions: map[string]*controlModeSessionWatcher{},
+		sessions: map[controlModeSessionKey]*controlModeSessionWatcher{},
 	}
 }
 
+// Subscribe streams the output of target on the hub's own tmux server.
 func (h *ControlModeHub) Subscribe(target string) (<-chan string, func(), error) {
 	if h == nil {
 		return nil, nil, fmt.Errorf("nil control mode hub")
 	}
+	return h.SubscribeOnSocket(h.socket, target)
+}
+
+// SubscribeOnSocket streams the output of target on the tmux server listening on socket. One control-mode client is
+// attached per session and server, so panes of several servers can be followed at once.
+func (h *ControlModeHub) SubscribeOnSocket(socket, target string) (<-chan string, func(), error) {
+	if h == nil {
+		return nil, nil, fmt.Errorf("nil control mode hub")
+	}
+	socket = strings.TrimSpace(socket)
+	if socket == "" {
+		socket = h.socket
+	}
 	target = strings.TrimSpace(target)
 	if target == "" {
 		return nil, nil, fmt.Errorf("empty target")
 	}
-	session, err := resolveSessionFromPaneTarget(h.socket, target)
+	session, err := resolveSessionFromPaneTarget(socket, target)
 	if err != nil {
 		return nil, nil, err
 	}
+	key := controlModeSessionKey{socket: socket, session: session}
 
 	h.mu.Lock()
-	watcher, ok := h.sessions[session]
+	watcher, ok := h.sessions[key]
 	if !ok {
-		client, err := h.factory(h.ctx, h.socket, session)
+		client, err := h.factory(h.ctx, socket, session)
 		if err != nil {
 			h.mu.Unlock()
 			return nil, nil, err
 		}
 		watcher = &controlModeSessionWatcher{
+			key:         key,
 			session:     session,
 			client:      client,
 			subs:        map[int]controlModeSubscription{},
 			utf8Pending: map[string][]byte{},
 		}
-		h.sessions[session] = watcher
+		h.sessions[key] = watcher
 		go h.loopSession(watcher)
 	}
 	watcher.nextID++
@@ -120,15 +143,15 @@ func (h *ControlModeHub) Subscribe(target string) (<-chan string, func(), error)
 	h.mu.Unlock()
 	if refresher, ok := watcher.client.(controlSessionPaneMapRefresher); ok {
 		if err := refresher.RefreshPaneMap(); err != nil {
-			h.logger.Warn("control mode pane map refresh failed", "session", session, "target", target, "error", err)
+			h.logger.Warn("control mode pane map refresh failed", "socket", socket, "session", session, "target", target, "error", err)
 		}
 	}
-	h.logger.Debug("control mode subscribe", "session", session, "target", target, "subs_total", subsTotal)
+	h.logger.Debug("control mode subscribe", "socket", socket, "session", session, "target", target, "subs_total", subsTotal)
 
 	unsubscribe := func() {
 		h.mu.Lock()
 		defer h.mu.Unlock()
-		w := h.sessions[session]
+		w := h.sessions[key]
 		if w == nil {
 			return
 		}
@@ -137,10 +160,10 @@ func (h *ControlModeHub) Subscribe(target string) (<-chan string, func(), error)
 		sessionClosed := false
 		if len(w.subs) == 0 {
 			_ = w.client.Close()
-			delete(h.sessions, session)
+			delete(h.sessions, key)
 			sessionClosed = true
 		}
-		h.logger.Debug("control mode unsubscribe", "session", session, "target", target, "subs_remaining", subsRemaining, "session_closed", sessionClosed)
+		h.logger.Debug("control mode unsubscribe", "socket", socket, "session", session, "target", target, "subs_remaining", subsRemaining, "session_closed", sessionClosed)
 	}
 	return out, unsubscribe, nil
 }
@@ -189,7 +212,7 @@ func (h *ControlModeHub) loopSession(w *controlModeSessionWatcher) {
 			if data == "" {
 				continue
 			}
-			h.broadcast(w.session, target, data)
+			h.broadcast(w.key, target, data)
 		}
 	}
 }
@@ -200,9 +223,9 @@ func (h *ControlModeHub) onSessionLoopExit(w *controlModeSessionWatcher) {
 	}
 	closedTargets := map[string]struct{}{}
 	h.mu.Lock()
-	existing := h.sessions[w.session]
+	existing := h.sessions[w.key]
 	if existing == w {
-		delete(h.sessions, w.session)
+		delete(h.sessions, w.key)
 	}
 	for _, sub := range w.subs {
 		target := strings.TrimSpace(sub.target)
@@ -354,9 +377,10 @@ func splitUTF8Payload(data []byte) (emit []byte, pending []byte) {
 	return data, nil
 }
 
-func (h *ControlModeHub) broadcast(session, target, data string) {
+func (h *ControlModeHub) broadcast(key controlModeSessionKey, target, data string) {
+	session := key.session
 	h.mu.Lock()
-	watcher := h.sessions[session]
+	watcher := h.sessions[key]
 	if watcher == nil {
 		h.mu.Unlock()
 		return
@@ -85,6 +85,47 @@ func TestControlModeHub_SubscribeRoutesByTarget(t *testing.T) {
 	}
 }
 
+func TestControlModeHub_SubscribeOnSocketKeepsServersApart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clients := map[string]*fakeControlSessionClient{}
+	hub := newControlModeHubWithFactory(ctx, "main", testLogger(), func(_ context.Context, socket, _ string) (controlSessionClient, error) {
+		client := &fakeControlSessionClient{lines: make(chan string, 8), paneMap: map[string]string{"%1": "e2e:0.0"}}
+		clients[socket] = client
+		return client, nil
+	})
+
+	mainOut, unsubscribeMain, err := hub.Subscribe("e2e:0.0")
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	defer unsubscribeMain()
+	otherOut, unsubscribeOther, err := hub.SubscribeOnSocket("other", "e2e:0.0")
+	if err != nil {
+		t.Fatalf("subscribe on socket failed: %v", err)
+	}
+	defer unsubscribeOther()
+	if len(clients) != 2 || clients["main"] == nil || clients["other"] == nil {
+		t.Fatalf("expected one control client per server, got %v", clients)
+	}
+
+	clients["other"].lines <- `%output %1 other\012`
+	select {
+	case got := <-otherOut:
+		if got != "other\n" {
+			t.Fatalf("unexpected payload: %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected output of the other server")
+	}
+	select {
+	case got := <-mainOut:
+		t.Fatalf("expected no output leaking to the main server subscriber, got %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
 func TestControlModeHub_RefreshesPaneMapForNewPane(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
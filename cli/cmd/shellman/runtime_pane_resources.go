@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/panestats"
+	"shellman/cli/internal/projectstate"
+	"shellman/cli/internal/tmux"
+)
+
+// paneResourceSampleInterval is how often the runtime samples the processes of the panes it has seen.
+var paneResourceSampleInterval = 5 * time.Second
+
+// paneResourceHistoryRetention is how long downsampled pane resource history is kept in the DB.
+const paneResourceHistoryRetention = 24 * time.Hour
+
+// runtimePaneResources is shared by the runtime, which samples panes, and the local API, which serves the samples.
+var runtimePaneResources = panestats.NewSampler(panestats.Options{})
+
+type panePIDSource interface {
+	PanePID(target string) (int, error)
+}
+
+type tmuxSocketRouter interface {
+	ForSocket(socket string) *tmux.Adapter
+}
+
+// panePIDOnSocket resolves pane PIDs on the tmux server at socket when source can reach other servers, and through
+// source itself for the default socket "".
+func panePIDOnSocket(source panePIDSource) func(socket, target string) (int, error) {
+	router, _ := source.(tmuxSocketRouter)
+	return func(socket, target string) (int, error) {
+		if socket = strings.TrimSpace(socket); socket != "" && router != nil {
+			return router.ForSocket(socket).PanePID(target)
+		}
+		return source.PanePID(target)
+	}
+}
+
+type paneResourceHistoryStore interface {
+	AppendPaneResourceHistory(buckets []projectstate.PaneResourceBucket, keepSince int64) error
+}
+
+// paneResourceOwner is the task a pane is bound to and where that task's project lives.
+type paneResourceOwner struct {
+	TaskID     string
+	RepoRoot   string
+	TmuxSocket string
+}
+
+// paneResourceItem is one pane's sample in the resources part of a tmux.status delta.
+type paneResourceItem struct {
+	panestats.Sample
+	PaneTarget string `json:"pane_target"`
+	TaskID     string `json:"task_id,omitempty"`
+}
+
+// SetResourceSampler makes the actor sample the processes of every pane it has a report for each interval and
+// publish the samples in tmux.status deltas. panePID resolves a pane on the tmux server at socket, the one of the
+// project the pane's task belongs to. Must be called before Start.
+func (a *TaskStateActor) SetResourceSampler(sampler *panestats.Sampler, panePID func(socket, target string) (int, error), interval time.Duration) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.resources = sampler
+	a.panePID = panePID
+	a.resourceInterval = interval
+}
+
+func (a *TaskStateActor) SampleResources(ctx context.Context) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	sampler := a.resources
+	panePID := a.panePID
+	nowFn := a.now
+	targets := make(map[string]string, len(a.paneLatest))
+	for paneID, report := range a.paneLatest {
+		targets[paneID] = strings.TrimSpace(report.PaneTarget)
+	}
+	a.mu.Unlock()
+	if sampler == nil || panePID == nil {
+		return
+	}
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+	now := nowFn()
+
+	owners := a.paneResourceOwners()
+	ownerByKey := make(map[string]paneResourceOwner, len(targets))
+	pids := make(map[string]int, len(targets))
+	for paneID, target := range targets {
+		if target == "" {
+			target = paneID
+		}
+		owner, ok := owners[paneID]
+		if !ok {
+			owner = owners[target]
+		}
+		key := panestats.PaneKey(owner.RepoRoot, owner.TmuxSocket, paneID)
+		pid, err := panePID(owner.TmuxSocket, target)
+		if err != nil || pid <= 0 {
+			// The pane is gone; stop sampling it until it reports again.
+			sampler.Forget(key)
+			a.mu.Lock()
+			delete(a.paneLatest, paneID)
+			a.mu.Unlock()
+			continue
+		}
+		ownerByKey[key] = owner
+		pids[key] = pid
+	}
+	samples := sampler.Sample(now, pids)
+	a.persistResourceHistory(now, sampler.TakeBuckets())
+	if len(samples) == 0 {
+		return
+	}
+
+	items := make([]paneResourceItem, 0, len(samples))
+	for _, sample := range samples {
+		key := sample.PaneID
+		_, _, sample.PaneID = panestats.SplitPaneKey(key)
+		items = append(items, paneResourceItem{Sample: sample, PaneTarget: targets[sample.PaneID], TaskID: ownerByKey[key].TaskID})
+	}
+	a.emitTmuxStatusDelta(ctx, map[string]any{
+		"mode":      "delta",
+		"resources": map[string]any{"panes": items},
+	})
+}
+
+func (a *TaskStateActor) persistResourceHistory(now time.Time, buckets []panestats.Bucket) {
+	if len(buckets) == 0 {
+		return
+	}
+	a.mu.RLock()
+	storeFactory := a.storeFactory
+	a.mu.RUnlock()
+	if storeFactory == nil {
+		return
+	}
+	// Each bucket is kept under the repo root of the project its pane belonged to when it was sampled.
+	rowsByRepo := map[string][]projectstate.PaneResourceBucket{}
+	for _, b := range buckets {
+		repoRoot, socket, paneID := panestats.SplitPaneKey(b.PaneID)
+		rowsByRepo[repoRoot] = append(rowsByRepo[repoRoot], projectstate.PaneResourceBucket{
+			TmuxSocket:    socket,
+			PaneID:        paneID,
+			BucketStart:   b.Start,
+			Samples:       b.Samples,
+			CPUPercentAvg: b.CPUPercentAvg,
+			CPUPercentMax: b.CPUPercentMax,
+			RSSBytesMax:   int64(b.RSSBytesMax),
+			ThreadsMax:    b.ThreadsMax,
+			ProcessesMax:  b.ProcessesMax,
+		})
+	}
+	keepSince := now.Add(-paneResourceHistoryRetention).UTC().Unix()
+	for repoRoot, rows := range rowsByRepo {
+		if store, ok := storeFactory(repoRoot).(paneResourceHistoryStore); ok {
+			_ = store.AppendPaneResourceHistory(rows, keepSince)
+		}
+	}
+}
+
+// paneResourceOwners maps the pane ids and targets bound to tasks to the task and its project's repo root and tmux
+// socket. Panes bound to no task are sampled on the default socket and kept under no repo root.
+func (a *TaskStateActor) paneResourceOwners() map[string]paneResourceOwner {
+	out := map[string]paneResourceOwner{}
+	projects, _ := a.loadProjects()
+	a.mu.RLock()
+	storeFactory := a.storeFactory
+	a.mu.RUnlock()
+	if storeFactory == nil {
+		return out
+	}
+	for _, project := range projects {
+		store := storeFactory(project.RepoRoot)
+		if store == nil {
+			continue
+		}
+		panes, err := store.LoadPanes()
+		if err != nil {
+			continue
+		}
+		for taskID, binding := range panes {
+			for _, key := range []string{binding.PaneID, binding.PaneTarget} {
+				if key = strings.TrimSpace(key); key != "" {
+					out[key] = paneResourceOwner{TaskID: taskID, RepoRoot: project.RepoRoot, TmuxSocket: project.TmuxSocket}
+				}
+			}
+		}
+	}
+	return out
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"shellman/cli/internal/panestats"
 	"shellman/cli/internal/projectstate"
 	"shellman/cli/internal/protocol"
 )
@@ -30,8 +31,9 @@ type TaskStateSink interface {
 }
 
 type taskStateProject struct {
-	ProjectID string
-	RepoRoot  string
+	ProjectID  string
+	RepoRoot   string
+	TmuxSocket string
 }
 
 type taskStateProjectProvider func() ([]taskStateProject, error)
@@ -84,6 +86,10 @@ type TaskStateActor struct {
 	emitEvent       taskStateEventEmitter
 	now             func() time.Time
 
+	resources        *panestats.Sampler
+	panePID          func(socket, target string) (int, error)
+	resourceInterval time.Duration
+
 	projectCache map[string]taskRowsCache
 }
 
@@ -232,16 +238,26 @@ func (a *TaskStateActor) runLoop(ctx context.Context) {
 	}
 	a.mu.RLock()
 	queue := a.triggerQueue
+	sampleEvery := a.resourceInterval
+	sampling := a.resources != nil && a.panePID != nil
 	a.mu.RUnlock()
 	if queue == nil {
 		return
 	}
+	var sampleTick <-chan time.Time
+	if sampling && sampleEvery > 0 {
+		ticker := time.NewTicker(sampleEvery)
+		defer ticker.Stop()
+		sampleTick = ticker.C
+	}
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-queue:
 			a.Tick(ctx)
+		case <-sampleTick:
+			a.SampleResources(ctx)
 		}
 	}
 }
@@ -297,7 +313,7 @@ func (a *TaskStateActor) loadProjects() ([]taskStateProject, error) {
 		if projectID == "" || repoRoot == "" {
 			continue
 		}
-		out = append(out, taskStateProject{ProjectID: projectID, RepoRoot: repoRoot})
+		out = append(out, taskStateProject{ProjectID: projectID, RepoRoot: repoRoot, TmuxSocket: strings.TrimSpace(project.TmuxSocket)})
 	}
 	return out, nil
 }
@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"os"
+	"sort"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"shellman/cli/internal/panestats"
 	"shellman/cli/internal/projectstate"
 	"shellman/cli/internal/protocol"
 )
@@ -455,3 +459,88 @@ func TestTaskStateActorLoop_NilActor_ReturnsImmediately(t *testing.T) {
 		t.Fatal("expected runTaskStateActorLoop(nil) to return immediately")
 	}
 }
+
+type fakeResourceHistoryStore struct {
+	fakeTaskStateStore
+	appended  []projectstate.PaneResourceBucket
+	keepSince int64
+}
+
+func (f *fakeResourceHistoryStore) AppendPaneResourceHistory(buckets []projectstate.PaneResourceBucket, keepSince int64) error {
+	f.appended = append(f.appended, buckets...)
+	f.keepSince = keepSince
+	return nil
+}
+
+func TestTaskStateActor_SampleResources_EmitsDeltaAndPersistsHistory(t *testing.T) {
+	store := &fakeResourceHistoryStore{fakeTaskStateStore: fakeTaskStateStore{
+		panesByTask: projectstate.PanesIndex{"t1": {TaskID: "t1", PaneID: "%1", PaneTarget: "e2e:0.0"}},
+	}}
+	unbound := &fakeResourceHistoryStore{}
+	emitter := &fakeTaskStateEmitter{}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	actor := NewTaskStateActor()
+	actor.now = func() time.Time { return now }
+	actor.SetProjectProvider(func() ([]taskStateProject, error) {
+		return []taskStateProject{{ProjectID: "p1", RepoRoot: "/tmp/p1", TmuxSocket: "work"}}, nil
+	})
+	actor.SetStoreFactory(func(repoRoot string) taskStateStore {
+		if repoRoot == "/tmp/p1" {
+			return store
+		}
+		return unbound
+	})
+	actor.SetEventEmitter(emitter.emit)
+	// The project's pane is only found on the project's tmux server; the unbound pane on the default one.
+	pids := map[string]int{"work/e2e:0.0": os.Getpid(), "/e2e:0.2": os.Getpid()}
+	actor.SetResourceSampler(panestats.NewSampler(panestats.Options{}), func(socket, target string) (int, error) {
+		if pid, ok := pids[socket+"/"+target]; ok {
+			return pid, nil
+		}
+		return 0, errors.New("can't find pane: " + target)
+	}, time.Second)
+	actor.OnPaneReport(PaneStateReport{PaneID: "%1", PaneTarget: "e2e:0.0", SnapshotHash: "h1"})
+	actor.OnPaneReport(PaneStateReport{PaneID: "%2", PaneTarget: "e2e:0.1", SnapshotHash: "h2"})
+	actor.OnPaneReport(PaneStateReport{PaneID: "%3", PaneTarget: "e2e:0.2", SnapshotHash: "h3"})
+
+	actor.SampleResources(context.Background())
+	if len(emitter.messages) != 1 || emitter.messages[0].Op != "tmux.status" {
+		t.Fatalf("expected one tmux.status delta, got %#v", emitter.messages)
+	}
+	var payload struct {
+		Mode      string `json:"mode"`
+		Resources struct {
+			Panes []paneResourceItem `json:"panes"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(emitter.messages[0].Payload, &payload); err != nil {
+		t.Fatalf("decode payload failed: %v", err)
+	}
+	panes := payload.Resources.Panes
+	sort.Slice(panes, func(i, j int) bool { return panes[i].PaneID < panes[j].PaneID })
+	if payload.Mode != "delta" || len(panes) != 2 || panes[0].PaneID != "%1" || panes[0].TaskID != "t1" || panes[0].PaneTarget != "e2e:0.0" || panes[0].PID != os.Getpid() || panes[0].RSSBytes == 0 {
+		t.Fatalf("unexpected resources delta: %#v", payload)
+	}
+	if panes[1].PaneID != "%3" || panes[1].TaskID != "" || panes[1].PaneTarget != "e2e:0.2" {
+		t.Fatalf("unexpected unbound pane sample: %#v", panes[1])
+	}
+
+	// The gone pane is no longer sampled, and the finished minute is written to history.
+	now = now.Add(time.Minute)
+	actor.SampleResources(context.Background())
+	if len(store.appended) != 1 || store.appended[0].PaneID != "%1" || store.appended[0].TmuxSocket != "work" || store.appended[0].Samples != 1 {
+		t.Fatalf("expected the first minute persisted under the project, got %#v", store.appended)
+	}
+	if len(unbound.appended) != 1 || unbound.appended[0].PaneID != "%3" || unbound.appended[0].TmuxSocket != "" {
+		t.Fatalf("expected the unbound pane persisted under no project, got %#v", unbound.appended)
+	}
+	if store.keepSince != now.Add(-paneResourceHistoryRetention).Unix() {
+		t.Fatalf("unexpected retention cutoff: %d", store.keepSince)
+	}
+	actor.mu.RLock()
+	_, stillTracked := actor.paneLatest["%2"]
+	actor.mu.RUnlock()
+	if stillTracked {
+		t.Fatal("expected gone pane dropped")
+	}
+}
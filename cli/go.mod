@@ -10,6 +10,7 @@ require (
 	github.com/shirou/gopsutil/v4 v4.26.2
 	github.com/urfave/cli/v2 v2.27.7
 	golang.org/x/sys v0.41.0
+	gopkg.in/yaml.v3 v3.0.1
 	gorm.io/driver/sqlite v1.6.0
 	gorm.io/gorm v1.31.0
 	modernc.org/sqlite v1.29.10
@@ -10,6 +10,7 @@ import (
 func TaskActionToolContractNames() []string {
 	return []string{
 		"task.current.set_flag",
+		"task.current.signal",
 		"write_stdin",
 		"exec_command",
 		"readfile",
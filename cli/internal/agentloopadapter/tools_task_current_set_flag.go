@@ -16,11 +16,11 @@ func (t *TaskCurrentSetFlagTool) Spec() ResponseToolSpec {
 	return ResponseToolSpec{
 		Type:        "function",
 		Name:        t.Name(),
-		Description: "Set current task flag and status message.",
+		Description: "Set current task flag and status message. flag must be registered for the project (default: success, notify, error).",
 		Parameters: ResponseToolParameters{
 			Type: "object",
 			Properties: []ResponseToolProperty{
-				{Name: "flag", Schema: ResponseToolSchema{Type: "string", Enum: []string{"success", "notify", "error"}}},
+				{Name: "flag", Schema: ResponseToolSchema{Type: "string"}},
 				{Name: "status_message", Schema: ResponseToolSchema{Type: "string"}},
 			},
 			Required: []string{"flag", "status_message"},
@@ -45,8 +45,8 @@ func (t *TaskCurrentSetFlagTool) Execute(ctx context.Context, _ struct{}, input
 		return "", NewToolError("INVALID_JSON_INPUT", "Check task.current.set_flag JSON: require flag and status_message")
 	}
 	flag := strings.TrimSpace(req.Flag)
-	if flag != "success" && flag != "notify" && flag != "error" {
-		return "", NewToolError("INVALID_FLAG_KEY", "flag must be one of success, notify, error")
+	if flag == "" {
+		return "", NewToolError("INVALID_FLAG_KEY", "Provide a flag registered for the project")
 	}
 	statusMessage := strings.TrimSpace(req.StatusMessage)
 	if statusMessage == "" {
@@ -0,0 +1,59 @@
+package agentloopadapter
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+type TaskCurrentSignalTool struct {
+	Exec func(ctx context.Context, taskID, signal, scope string) (string, *ToolError)
+}
+
+func (t *TaskCurrentSignalTool) Name() string { return "task.current.signal" }
+
+func (t *TaskCurrentSignalTool) Spec() ResponseToolSpec {
+	return ResponseToolSpec{
+		Type:        "function",
+		Name:        t.Name(),
+		Description: "Send a signal to the job running in the current task TTY. scope=foreground (default) signals the foreground process group, scope=tree every process in the pane including its shell.",
+		Parameters: ResponseToolParameters{
+			Type: "object",
+			Properties: []ResponseToolProperty{
+				{Name: "signal", Schema: ResponseToolSchema{Type: "string", Enum: []string{"SIGINT", "SIGTERM", "SIGKILL", "SIGSTOP", "SIGCONT"}}},
+				{Name: "scope", Schema: ResponseToolSchema{Type: "string", Enum: []string{"foreground", "tree"}}},
+			},
+			Required: []string{"signal"},
+		},
+	}
+}
+
+func (t *TaskCurrentSignalTool) Execute(ctx context.Context, _ struct{}, input string, callID string) (string, *ToolError) {
+	_ = callID
+	if t == nil || t.Exec == nil {
+		return "", NewToolError("TASK_CURRENT_SIGNAL_EXEC_UNAVAILABLE", "Ensure task.current.signal exec callback is injected")
+	}
+	taskID, err := currentTaskIDFromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	req := struct {
+		Signal string `json:"signal"`
+		Scope  string `json:"scope"`
+	}{}
+	if err := json.Unmarshal([]byte(input), &req); err != nil {
+		return "", NewToolError("INVALID_JSON_INPUT", "Check task.current.signal JSON: require signal, optional scope")
+	}
+	signal := strings.ToUpper(strings.TrimSpace(req.Signal))
+	if signal == "" {
+		return "", NewToolError("INVALID_SIGNAL", "Provide one of SIGINT, SIGTERM, SIGKILL, SIGSTOP, SIGCONT")
+	}
+	scope := strings.ToLower(strings.TrimSpace(req.Scope))
+	if scope == "" {
+		scope = "foreground"
+	}
+	if scope != "foreground" && scope != "tree" {
+		return "", NewToolError("INVALID_SIGNAL_SCOPE", "scope must be foreground or tree")
+	}
+	return t.Exec(ctx, taskID, signal, scope)
+}
@@ -163,6 +163,9 @@ func bootstrapLocalRuntime(app *Application, opts StartOptions) error {
 		}
 		return nil
 	})
+	for _, loop := range localServer.BackgroundLoops() {
+		mgr.AddRun(loop.Name, loop.Run)
+	}
 	mgr.AddShutdown("http-server-shutdown", func(context.Context) error {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 		defer cancel()
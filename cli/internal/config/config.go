@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -11,6 +12,7 @@ type Config struct {
 	WorkerBaseURL                   string
 	ListenLogLevel                  string
 	TmuxSocket                      string
+	PaneBackend                     string
 	TraceStream                     bool
 	EnablePprof                     bool
 	HistoryLines                    int
@@ -43,6 +45,13 @@ var (
 	defaultLocalPort = "8000"
 )
 
+// Pane backends selectable with SHELLMAN_PANE_BACKEND. Auto picks tmux when it is installed and native PTYs otherwise.
+const (
+	PaneBackendTmux = "tmux"
+	PaneBackendPTY  = "pty"
+	PaneBackendAuto = "auto"
+)
+
 func LoadConfig() Config {
 	cfg := loadFromEnv()
 	cacheMu.Lock()
@@ -87,6 +96,12 @@ func loadFromEnv() Config {
 	}
 
 	socket := os.Getenv("SHELLMAN_TMUX_SOCKET")
+	paneBackend := strings.ToLower(strings.TrimSpace(os.Getenv("SHELLMAN_PANE_BACKEND")))
+	switch paneBackend {
+	case PaneBackendTmux, PaneBackendPTY, PaneBackendAuto:
+	default:
+		paneBackend = PaneBackendTmux
+	}
 	traceStream := os.Getenv("SHELLMAN_TRACE_STREAM") == "1"
 	enablePprof := parseBoolEnvDefault(os.Getenv("SHELLMAN_ENABLE_PPROF"), false)
 	historyLines := atoiOrDefault(os.Getenv("SHELLMAN_HISTORY_LINES"), 2000)
@@ -137,6 +152,7 @@ func loadFromEnv() Config {
 		WorkerBaseURL:                   base,
 		ListenLogLevel:                  level,
 		TmuxSocket:                      socket,
+		PaneBackend:                     paneBackend,
 		TraceStream:                     traceStream,
 		EnablePprof:                     enablePprof,
 		HistoryLines:                    historyLines,
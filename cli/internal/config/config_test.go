@@ -60,6 +60,16 @@ func TestLoadConfig_Defaults(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_PaneBackend(t *testing.T) {
+	cases := map[string]string{"": PaneBackendTmux, "PTY": PaneBackendPTY, "auto": PaneBackendAuto, "screen": PaneBackendTmux}
+	for raw, want := range cases {
+		t.Setenv("SHELLMAN_PANE_BACKEND", raw)
+		if got := LoadConfig().PaneBackend; got != want {
+			t.Fatalf("SHELLMAN_PANE_BACKEND=%q: expected %q, got %q", raw, want, got)
+		}
+	}
+}
+
 func TestLoadConfig_TurnEnabled(t *testing.T) {
 	t.Setenv("SHELLMAN_TURN_ENABLED", "1")
 	cfg := LoadConfig()
@@ -15,6 +15,12 @@ func SyncSchema(db *gorm.DB) error {
 	}
 	if err := db.AutoMigrate(
 		&Task{},
+		&TaskDependency{},
+		&TaskLabel{},
+		&TaskRevision{},
+		&ProjectFlag{},
+		&ProjectLayout{},
+		&TaskFlagEscalation{},
 		&TaskRun{},
 		&RunBinding{},
 		&RunEvent{},
@@ -25,6 +31,7 @@ func SyncSchema(db *gorm.DB) error {
 		&PMMessage{},
 		&ActionOutbox{},
 		&TmuxServer{},
+		&PaneResourceSample{},
 		&LegacyState{},
 		&DirHistory{},
 		&PaneRuntime{},
@@ -41,13 +48,18 @@ func SyncSchema(db *gorm.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_task_messages_task_created_at ON task_messages(task_id, created_at DESC);`,
 		`CREATE INDEX IF NOT EXISTS idx_pm_sessions_repo_project_updated ON pm_sessions(repo_root, project_id, updated_at DESC);`,
 		`CREATE INDEX IF NOT EXISTS idx_pm_messages_session_created_at ON pm_messages(session_id, created_at DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_task_dependencies_repo_project ON task_dependencies(repo_root, project_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_task_labels_repo_project_label ON task_labels(repo_root, project_id, label);`,
+		`CREATE INDEX IF NOT EXISTS idx_task_revisions_task_id ON task_revisions(task_id, id DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_pane_resource_samples_scope_bucket ON pane_resource_samples(repo_root, tmux_socket, pane_id, bucket_start DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_pane_resource_samples_bucket ON pane_resource_samples(bucket_start);`,
 		`CREATE INDEX IF NOT EXISTS idx_projects_sort_order ON projects(sort_order ASC, updated_at DESC);`,
 	} {
 		if err := db.Exec(stmt).Error; err != nil {
 			return err
 		}
 	}
-	return nil
+	return syncSearchIndex(db)
 }
 
 // MigrateUp syncs schema then runs data migrations (botworks-style). Kept for compatibility with OpenSQLiteWithMigrations.
@@ -16,6 +16,12 @@ func TestOpenSQLiteWithMigrations_CreatesCoreTables(t *testing.T) {
 
 	mustHave := []string{
 		"tasks",
+		"task_dependencies",
+		"task_labels",
+		"task_revisions",
+		"project_flags",
+		"project_layouts",
+		"task_flag_escalations",
 		"task_runs",
 		"run_bindings",
 		"run_events",
@@ -24,6 +30,7 @@ func TestOpenSQLiteWithMigrations_CreatesCoreTables(t *testing.T) {
 		"task_messages",
 		"action_outbox",
 		"tmux_servers",
+		"pane_resource_samples",
 		"legacy_state",
 		"dir_history",
 		"pane_runtime",
@@ -21,10 +21,82 @@ type Task struct {
 	LastModified       int64  `gorm:"column:last_modified;not null;default:0"`
 	CompletedAt        int64  `gorm:"column:completed_at;not null;default:0"`
 	LastAutoProgressAt int64  `gorm:"column:last_auto_progress_at;not null;default:0"`
+	SortOrder          int64  `gorm:"column:sort_order;not null;default:0"`
 }
 
 func (Task) TableName() string { return "tasks" }
 
+type TaskDependency struct {
+	TaskID        string `gorm:"column:task_id;primaryKey"`
+	BlockerTaskID string `gorm:"column:blocker_task_id;primaryKey;index"`
+	RepoRoot      string `gorm:"column:repo_root;not null;default:''"`
+	ProjectID     string `gorm:"column:project_id;not null;default:''"`
+	AutoProgress  bool   `gorm:"column:auto_progress;not null;default:false"`
+	CreatedAt     int64  `gorm:"column:created_at;not null;default:0"`
+}
+
+func (TaskDependency) TableName() string { return "task_dependencies" }
+
+type TaskLabel struct {
+	TaskID    string `gorm:"column:task_id;primaryKey"`
+	Label     string `gorm:"column:label;primaryKey"`
+	RepoRoot  string `gorm:"column:repo_root;not null;default:''"`
+	ProjectID string `gorm:"column:project_id;not null;default:''"`
+	CreatedAt int64  `gorm:"column:created_at;not null;default:0"`
+}
+
+func (TaskLabel) TableName() string { return "task_labels" }
+
+type TaskRevision struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement"`
+	TaskID    string `gorm:"column:task_id;not null"`
+	RepoRoot  string `gorm:"column:repo_root;not null;default:''"`
+	ProjectID string `gorm:"column:project_id;not null;default:''"`
+	Field     string `gorm:"column:field;not null"`
+	OldValue  string `gorm:"column:old_value;not null;default:''"`
+	NewValue  string `gorm:"column:new_value;not null;default:''"`
+	Source    string `gorm:"column:source;not null;default:''"`
+	CreatedAt int64  `gorm:"column:created_at;not null;default:0"`
+}
+
+func (TaskRevision) TableName() string { return "task_revisions" }
+
+type ProjectFlag struct {
+	RepoRoot             string `gorm:"column:repo_root;primaryKey"`
+	ProjectID            string `gorm:"column:project_id;primaryKey"`
+	Name                 string `gorm:"column:name;primaryKey"`
+	Severity             string `gorm:"column:severity;not null;default:'info'"`
+	Color                string `gorm:"column:color;not null;default:''"`
+	EscalationTimeoutSec int64  `gorm:"column:escalation_timeout_sec;not null;default:0"`
+	CreatedAt            int64  `gorm:"column:created_at;not null;default:0"`
+	UpdatedAt            int64  `gorm:"column:updated_at;not null;default:0"`
+}
+
+func (ProjectFlag) TableName() string { return "project_flags" }
+
+type ProjectLayout struct {
+	RepoRoot    string `gorm:"column:repo_root;primaryKey"`
+	ProjectID   string `gorm:"column:project_id;primaryKey"`
+	Name        string `gorm:"column:name;primaryKey"`
+	WindowsJSON string `gorm:"column:windows_json;not null;default:''"`
+	CreatedAt   int64  `gorm:"column:created_at;not null;default:0"`
+	UpdatedAt   int64  `gorm:"column:updated_at;not null;default:0"`
+}
+
+func (ProjectLayout) TableName() string { return "project_layouts" }
+
+type TaskFlagEscalation struct {
+	TaskID          string `gorm:"column:task_id;primaryKey"`
+	RepoRoot        string `gorm:"column:repo_root;not null;default:''"`
+	ProjectID       string `gorm:"column:project_id;not null;default:''"`
+	Flag            string `gorm:"column:flag;not null;default:''"`
+	RaisedAt        int64  `gorm:"column:raised_at;not null;default:0"`
+	LastEscalatedAt int64  `gorm:"column:last_escalated_at;not null;default:0"`
+	EscalationCount int64  `gorm:"column:escalation_count;not null;default:0"`
+}
+
+func (TaskFlagEscalation) TableName() string { return "task_flag_escalations" }
+
 type TaskRun struct {
 	RunID       string `gorm:"column:run_id;primaryKey"`
 	TaskID      string `gorm:"column:task_id;not null"`
@@ -33,6 +105,7 @@ type TaskRun struct {
 	CompletedAt int64  `gorm:"column:completed_at;not null;default:0"`
 	UpdatedAt   int64  `gorm:"column:updated_at;not null;default:0"`
 	LastError   string `gorm:"column:last_error;not null;default:''"`
+	ArtifactDir string `gorm:"column:artifact_dir;not null;default:''"`
 }
 
 func (TaskRun) TableName() string { return "task_runs" }
@@ -44,6 +117,8 @@ type RunBinding struct {
 	PaneTarget       string `gorm:"column:pane_target;not null;default:''"`
 	BindingStatus    string `gorm:"column:binding_status;not null;default:'live'"`
 	StaleReason      string `gorm:"column:stale_reason;not null;default:''"`
+	TmuxServerID     string `gorm:"column:tmux_server_id;not null;default:''"`
+	PaneCwd          string `gorm:"column:pane_cwd;not null;default:''"`
 	UpdatedAt        int64  `gorm:"column:updated_at;not null;default:0"`
 }
 
@@ -128,6 +203,7 @@ type ActionOutbox struct {
 	Status      string `gorm:"column:status;not null;default:'pending'"`
 	RetryCount  int    `gorm:"column:retry_count;not null;default:0"`
 	NextRetryAt int64  `gorm:"column:next_retry_at;not null;default:0"`
+	LastError   string `gorm:"column:last_error;not null;default:''"`
 	CreatedAt   int64  `gorm:"column:created_at;not null;default:0"`
 	UpdatedAt   int64  `gorm:"column:updated_at;not null;default:0"`
 }
@@ -143,6 +219,22 @@ type TmuxServer struct {
 
 func (TmuxServer) TableName() string { return "tmux_servers" }
 
+type PaneResourceSample struct {
+	ID            int64   `gorm:"column:id;primaryKey;autoIncrement"`
+	RepoRoot      string  `gorm:"column:repo_root;not null;default:''"`
+	TmuxSocket    string  `gorm:"column:tmux_socket;not null;default:''"`
+	PaneID        string  `gorm:"column:pane_id;not null"`
+	BucketStart   int64   `gorm:"column:bucket_start;not null;default:0"`
+	Samples       int     `gorm:"column:samples;not null;default:0"`
+	CPUPercentAvg float64 `gorm:"column:cpu_percent_avg;not null;default:0"`
+	CPUPercentMax float64 `gorm:"column:cpu_percent_max;not null;default:0"`
+	RSSBytesMax   int64   `gorm:"column:rss_bytes_max;not null;default:0"`
+	ThreadsMax    int     `gorm:"column:threads_max;not null;default:0"`
+	ProcessesMax  int     `gorm:"column:processes_max;not null;default:0"`
+}
+
+func (PaneResourceSample) TableName() string { return "pane_resource_samples" }
+
 type LegacyState struct {
 	RepoRoot  string `gorm:"column:repo_root;primaryKey"`
 	StateKey  string `gorm:"column:state_key;primaryKey"`
@@ -201,6 +293,7 @@ type Project struct {
 	DisplayName string `gorm:"column:display_name;not null;default:''"`
 	SortOrder   int64  `gorm:"column:sort_order;not null;default:0"`
 	Collapsed   bool   `gorm:"column:collapsed;not null;default:false"`
+	TmuxSocket  string `gorm:"column:tmux_socket;not null;default:''"`
 	UpdatedAt   int64  `gorm:"column:updated_at;not null;default:0"`
 }
 
@@ -0,0 +1,145 @@
+package db
+
+import "gorm.io/gorm"
+
+const (
+	SearchKindTask        = "task"
+	SearchKindNote        = "note"
+	SearchKindTaskMessage = "task_message"
+	SearchKindPMMessage   = "pm_message"
+)
+
+// searchIndexTriggers keep search_index in sync with every writer. Index rows are keyed by kind and ref_id rather
+// than by rowid: tasks have a TEXT primary key, so their SQLite rowid can change under VACUUM and must not be trusted.
+var searchIndexTriggers = []struct {
+	name string
+	body string
+}{
+	{"trg_search_tasks_ai", `AFTER INSERT ON tasks BEGIN
+  INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+  VALUES ('task', new.task_id, new.task_id, '', new.title, trim(new.description || char(10) || new.flag_desc));
+END;`},
+	{"trg_search_tasks_au", `AFTER UPDATE OF task_id, title, description, flag_desc ON tasks BEGIN
+  DELETE FROM search_index WHERE kind = 'task' AND ref_id = old.task_id;
+  INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+  VALUES ('task', new.task_id, new.task_id, '', new.title, trim(new.description || char(10) || new.flag_desc));
+END;`},
+	{"trg_search_tasks_ad", `AFTER DELETE ON tasks BEGIN
+  DELETE FROM search_index WHERE kind = 'task' AND ref_id = old.task_id;
+END;`},
+	{"trg_search_notes_ai", `AFTER INSERT ON notes BEGIN
+  INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+  VALUES ('note', CAST(new.id AS TEXT), new.task_id, '', '', new.notes);
+END;`},
+	{"trg_search_notes_au", `AFTER UPDATE OF task_id, notes ON notes BEGIN
+  DELETE FROM search_index WHERE kind = 'note' AND ref_id = CAST(old.id AS TEXT);
+  INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+  VALUES ('note', CAST(new.id AS TEXT), new.task_id, '', '', new.notes);
+END;`},
+	{"trg_search_notes_ad", `AFTER DELETE ON notes BEGIN
+  DELETE FROM search_index WHERE kind = 'note' AND ref_id = CAST(old.id AS TEXT);
+END;`},
+	{"trg_search_task_messages_ai", `AFTER INSERT ON task_messages BEGIN
+  INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+  VALUES ('task_message', CAST(new.id AS TEXT), new.task_id, '', '', new.content);
+END;`},
+	{"trg_search_task_messages_au", `AFTER UPDATE OF task_id, content ON task_messages BEGIN
+  DELETE FROM search_index WHERE kind = 'task_message' AND ref_id = CAST(old.id AS TEXT);
+  INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+  VALUES ('task_message', CAST(new.id AS TEXT), new.task_id, '', '', new.content);
+END;`},
+	{"trg_search_task_messages_ad", `AFTER DELETE ON task_messages BEGIN
+  DELETE FROM search_index WHERE kind = 'task_message' AND ref_id = CAST(old.id AS TEXT);
+END;`},
+	{"trg_search_pm_messages_ai", `AFTER INSERT ON pm_messages BEGIN
+  INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+  VALUES ('pm_message', CAST(new.id AS TEXT), '', new.session_id, '', new.content);
+END;`},
+	{"trg_search_pm_messages_au", `AFTER UPDATE OF session_id, content ON pm_messages BEGIN
+  DELETE FROM search_index WHERE kind = 'pm_message' AND ref_id = CAST(old.id AS TEXT);
+  INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+  VALUES ('pm_message', CAST(new.id AS TEXT), '', new.session_id, '', new.content);
+END;`},
+	{"trg_search_pm_messages_ad", `AFTER DELETE ON pm_messages BEGIN
+  DELETE FROM search_index WHERE kind = 'pm_message' AND ref_id = CAST(old.id AS TEXT);
+END;`},
+}
+
+// searchIndexSources pairs each indexed kind with the table it is built from.
+var searchIndexSources = []struct {
+	kind  string
+	table string
+}{
+	{SearchKindTask, "tasks"},
+	{SearchKindNote, "notes"},
+	{SearchKindTaskMessage, "task_messages"},
+	{SearchKindPMMessage, "pm_messages"},
+}
+
+// syncSearchIndex creates the FTS5 table and (re)creates its triggers, which replaces triggers written by older
+// versions. The index is rebuilt from the source tables whenever it is new or its row counts have drifted from them.
+func syncSearchIndex(db *gorm.DB) error {
+	if err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+  kind UNINDEXED, ref_id UNINDEXED, task_id UNINDEXED, session_id UNINDEXED, title, body,
+  tokenize = 'unicode61 remove_diacritics 2'
+);`).Error; err != nil {
+		return err
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for _, trigger := range searchIndexTriggers {
+			if err := tx.Exec(`DROP TRIGGER IF EXISTS ` + trigger.name).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(`CREATE TRIGGER ` + trigger.name + ` ` + trigger.body).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	inSync, err := searchIndexInSync(db)
+	if err != nil || inSync {
+		return err
+	}
+	return RebuildSearchIndex(db)
+}
+
+// searchIndexInSync reports whether search_index holds exactly one row per source row of every kind.
+func searchIndexInSync(db *gorm.DB) (bool, error) {
+	for _, source := range searchIndexSources {
+		var indexed, rows int64
+		if err := db.Raw(`SELECT COUNT(1) FROM search_index WHERE kind = ?`, source.kind).Scan(&indexed).Error; err != nil {
+			return false, err
+		}
+		if err := db.Raw(`SELECT COUNT(1) FROM ` + source.table).Scan(&rows).Error; err != nil {
+			return false, err
+		}
+		if indexed != rows {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// RebuildSearchIndex repopulates search_index from the source tables.
+func RebuildSearchIndex(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range []string{
+			`DELETE FROM search_index;`,
+			`INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+SELECT 'task', task_id, task_id, '', title, trim(description || char(10) || flag_desc) FROM tasks;`,
+			`INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+SELECT 'note', CAST(id AS TEXT), task_id, '', '', notes FROM notes;`,
+			`INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+SELECT 'task_message', CAST(id AS TEXT), task_id, '', '', content FROM task_messages;`,
+			`INSERT INTO search_index(kind, ref_id, task_id, session_id, title, body)
+SELECT 'pm_message', CAST(id AS TEXT), '', session_id, '', content FROM pm_messages;`,
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func searchIndexMatches(t *testing.T, db *gorm.DB, query string) []string {
+	t.Helper()
+
+	var refs []string
+	if err := db.Raw(`SELECT ref_id FROM search_index WHERE search_index MATCH ? ORDER BY ref_id`, query).Scan(&refs).Error; err != nil {
+		t.Fatalf("search %q failed: %v", query, err)
+	}
+	return refs
+}
+
+func TestSearchIndex_TaskUpdateSurvivesRowidChange(t *testing.T) {
+	db := openTestDB(t)
+	if err := MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&[]Task{{TaskID: "t1", Title: "alpha"}, {TaskID: "t2", Title: "beta"}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// tasks has a TEXT primary key, so VACUUM may renumber its rowids; move one by hand to the same effect.
+	if err := db.Exec(`UPDATE tasks SET rowid = rowid + 100 WHERE task_id = 't1'`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&Task{}).Where("task_id = ?", "t1").Update("title", "gamma").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if got := searchIndexMatches(t, db, "alpha"); len(got) != 0 {
+		t.Fatalf("expected the old title to be gone from the index, got %v", got)
+	}
+	if got := searchIndexMatches(t, db, "gamma"); len(got) != 1 || got[0] != "t1" {
+		t.Fatalf("expected t1 under its new title, got %v", got)
+	}
+	if got := searchIndexMatches(t, db, "beta"); len(got) != 1 || got[0] != "t2" {
+		t.Fatalf("expected t2 untouched, got %v", got)
+	}
+
+	if err := db.Where("task_id = ?", "t1").Delete(&Task{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIndexMatches(t, db, "gamma"); len(got) != 0 {
+		t.Fatalf("expected deleted task gone from the index, got %v", got)
+	}
+}
+
+func TestSyncSchema_RebuildsDriftedSearchIndex(t *testing.T) {
+	db := openTestDB(t)
+	if err := MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&Task{TaskID: "t1", Title: "alpha"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`DELETE FROM search_index`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SyncSchema(db); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIndexMatches(t, db, "alpha"); len(got) != 1 || got[0] != "t1" {
+		t.Fatalf("expected the drifted index rebuilt on startup, got %v", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	toml "github.com/pelletier/go-toml/v2"
 )
@@ -12,6 +13,22 @@ const (
 	configTOMLFileName = "config.toml"
 )
 
+const (
+	WebhookEventTaskCompleted = "task.completed"
+	WebhookEventFlagRaised    = "task.flag.raised"
+	WebhookEventFlagEscalated = "task.flag.escalated"
+	WebhookEventRunRebind     = "run.needs_rebind"
+	WebhookEventRunStalled    = "run.stalled"
+)
+
+var validWebhookEvents = map[string]struct{}{
+	WebhookEventTaskCompleted: {},
+	WebhookEventFlagRaised:    {},
+	WebhookEventFlagEscalated: {},
+	WebhookEventRunRebind:     {},
+	WebhookEventRunStalled:    {},
+}
+
 type GlobalDefaults struct {
 	SessionProgram   string `json:"session_program" toml:"session_program"`
 	HelperProgram    string `json:"helper_program" toml:"helper_program"`
@@ -23,6 +40,11 @@ type GlobalConfig struct {
 	LocalPort      int                  `json:"local_port" toml:"local_port"`
 	Defaults       GlobalDefaults       `json:"defaults" toml:"defaults"`
 	TaskCompletion TaskCompletionConfig `json:"task_completion" toml:"task_completion"`
+	TaskArchive    TaskArchiveConfig    `json:"task_archive" toml:"task_archive"`
+	RunWatchdog    RunWatchdogConfig    `json:"run_watchdog" toml:"run_watchdog"`
+	PaneRecovery   PaneRecoveryConfig   `json:"pane_recovery" toml:"pane_recovery"`
+	RunRecording   RunRecordingConfig   `json:"run_recording" toml:"run_recording"`
+	Webhooks       []WebhookConfig      `json:"webhooks" toml:"webhooks,omitempty"`
 }
 
 type TaskCompletionConfig struct {
@@ -31,6 +53,100 @@ type TaskCompletionConfig struct {
 	NotifyIdleDuration int    `json:"notify_idle_duration_seconds" toml:"notify_idle_duration_seconds"`
 }
 
+// TaskArchiveConfig controls how long archived tasks are kept. RetentionDays 0 keeps them forever.
+type TaskArchiveConfig struct {
+	RetentionDays int `json:"retention_days" toml:"retention_days"`
+}
+
+// RunWatchdogConfig controls the watchdog that marks runs stalled once their pane has been silent for too long.
+// SilenceSeconds 0 disables it. AdapterSilenceSeconds overrides the window per program adapter ("shell" for plain
+// shells); an override of 0 turns the watchdog off for that adapter. Flag is raised on the task of a stalled run,
+// and Interrupt also sends the adapter's interrupt sequence to the pane.
+type RunWatchdogConfig struct {
+	SilenceSeconds        int            `json:"silence_seconds" toml:"silence_seconds"`
+	AdapterSilenceSeconds map[string]int `json:"adapter_silence_seconds" toml:"adapter_silence_seconds,omitempty"`
+	Flag                  string         `json:"flag" toml:"flag"`
+	Interrupt             bool           `json:"interrupt" toml:"interrupt"`
+}
+
+// SilenceWindow returns how long a run of adapter may stay silent before it is stalled; 0 means never.
+func (c RunWatchdogConfig) SilenceWindow(adapter string) time.Duration {
+	adapter = strings.ToLower(strings.TrimSpace(adapter))
+	if adapter == "" {
+		adapter = "shell"
+	}
+	seconds := c.SilenceSeconds
+	if override, ok := c.AdapterSilenceSeconds[adapter]; ok {
+		seconds = override
+	}
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// PaneRecoveryConfig controls what happens to active runs when the tmux server they were bound to goes away. With
+// Enabled, their panes are recreated in the last known cwd, the task's program is relaunched (or resumed, when its
+// adapter can) and the run is rebound; otherwise runs wait in needs_rebind for a manual bind-pane.
+type PaneRecoveryConfig struct {
+	Enabled bool `json:"enabled" toml:"enabled"`
+}
+
+// RunRecordingConfig controls asciicast recordings of the panes of active runs. Enabled is the default for every
+// project and Projects overrides it per project id. MaxRecordings keeps only the newest recordings and RetentionDays
+// drops older ones; 0 lifts either limit.
+type RunRecordingConfig struct {
+	Enabled       bool            `json:"enabled" toml:"enabled"`
+	Projects      map[string]bool `json:"projects" toml:"projects,omitempty"`
+	MaxRecordings int             `json:"max_recordings" toml:"max_recordings"`
+	RetentionDays int             `json:"retention_days" toml:"retention_days"`
+}
+
+// EnabledFor reports whether runs of projectID are recorded.
+func (c RunRecordingConfig) EnabledFor(projectID string) bool {
+	if enabled, ok := c.Projects[strings.TrimSpace(projectID)]; ok {
+		return enabled
+	}
+	return c.Enabled
+}
+
+// WebhookConfig is one HTTP endpoint notified about task and run events.
+// An empty Events list subscribes the endpoint to every event. Unknown event names are kept so that they match
+// nothing: a list made only of typos subscribes the endpoint to no event rather than to all of them.
+// Deliveries are queued in the action outbox, which makes at most five attempts whatever MaxAttempts says.
+type WebhookConfig struct {
+	Name           string   `json:"name" toml:"name"`
+	URL            string   `json:"url" toml:"url"`
+	Secret         string   `json:"secret" toml:"secret"`
+	Events         []string `json:"events" toml:"events"`
+	TimeoutSeconds int      `json:"timeout_seconds" toml:"timeout_seconds"`
+	MaxAttempts    int      `json:"max_attempts" toml:"max_attempts"`
+}
+
+// UnknownEvents returns the configured event names that are not webhook events.
+func (w WebhookConfig) UnknownEvents() []string {
+	var out []string
+	for _, event := range w.Events {
+		if _, ok := validWebhookEvents[event]; !ok {
+			out = append(out, event)
+		}
+	}
+	return out
+}
+
+func (w WebhookConfig) Accepts(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	event = strings.ToLower(strings.TrimSpace(event))
+	for _, item := range w.Events {
+		if item == event {
+			return true
+		}
+	}
+	return false
+}
+
 type ConfigStore struct {
 	dir string
 }
@@ -81,9 +197,102 @@ func normalizeConfig(cfg GlobalConfig) GlobalConfig {
 	if cfg.TaskCompletion.NotifyCommand == "" {
 		cfg.TaskCompletion.NotifyEnabled = false
 	}
+	if cfg.TaskArchive.RetentionDays < 0 {
+		cfg.TaskArchive.RetentionDays = 0
+	}
+	cfg.RunWatchdog = normalizeRunWatchdog(cfg.RunWatchdog)
+	cfg.RunRecording = normalizeRunRecording(cfg.RunRecording)
+	cfg.Webhooks = normalizeWebhooks(cfg.Webhooks)
+	return cfg
+}
+
+func normalizeRunRecording(cfg RunRecordingConfig) RunRecordingConfig {
+	var projects map[string]bool
+	for projectID, enabled := range cfg.Projects {
+		projectID = strings.TrimSpace(projectID)
+		if projectID == "" {
+			continue
+		}
+		if projects == nil {
+			projects = map[string]bool{}
+		}
+		projects[projectID] = enabled
+	}
+	cfg.Projects = projects
+	if cfg.MaxRecordings < 0 {
+		cfg.MaxRecordings = 0
+	}
+	if cfg.RetentionDays < 0 {
+		cfg.RetentionDays = 0
+	}
+	return cfg
+}
+
+func normalizeRunWatchdog(cfg RunWatchdogConfig) RunWatchdogConfig {
+	if cfg.SilenceSeconds < 0 {
+		cfg.SilenceSeconds = 0
+	}
+	var adapters map[string]int
+	for adapter, seconds := range cfg.AdapterSilenceSeconds {
+		adapter = strings.ToLower(strings.TrimSpace(adapter))
+		if adapter == "" {
+			continue
+		}
+		if seconds < 0 {
+			seconds = 0
+		}
+		if adapters == nil {
+			adapters = map[string]int{}
+		}
+		adapters[adapter] = seconds
+	}
+	cfg.AdapterSilenceSeconds = adapters
+	cfg.Flag = strings.TrimSpace(cfg.Flag)
+	if cfg.Flag == "" {
+		cfg.Flag = "error"
+	}
 	return cfg
 }
 
+func normalizeWebhooks(items []WebhookConfig) []WebhookConfig {
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]WebhookConfig, 0, len(items))
+	for _, item := range items {
+		item.URL = strings.TrimSpace(item.URL)
+		if item.URL == "" {
+			continue
+		}
+		item.Name = strings.TrimSpace(item.Name)
+		if item.Name == "" {
+			item.Name = item.URL
+		}
+		item.Secret = strings.TrimSpace(item.Secret)
+		events := make([]string, 0, len(item.Events))
+		for _, event := range item.Events {
+			if event = strings.ToLower(strings.TrimSpace(event)); event != "" {
+				events = append(events, event)
+			}
+		}
+		item.Events = events
+		if item.TimeoutSeconds <= 0 {
+			item.TimeoutSeconds = 10
+		}
+		if item.TimeoutSeconds > 120 {
+			item.TimeoutSeconds = 120
+		}
+		if item.MaxAttempts <= 0 {
+			item.MaxAttempts = 3
+		}
+		if item.MaxAttempts > 10 {
+			item.MaxAttempts = 10
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
 func normalizeDefaults(defaults GlobalDefaults) GlobalDefaults {
 	sessionProgram := strings.ToLower(strings.TrimSpace(defaults.SessionProgram))
 	helperProgram := strings.ToLower(strings.TrimSpace(defaults.HelperProgram))
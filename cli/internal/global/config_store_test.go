@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfigStore_LoadOrInit_CreatesDefaultFiles(t *testing.T) {
@@ -68,3 +69,144 @@ func TestConfigStore_LoadOrInit_CreatesDefaultFiles(t *testing.T) {
 		t.Fatalf("expected terminal_font_size=13, got %d", cfg.Defaults.TerminalFontSize)
 	}
 }
+
+func TestConfigStore_LoadOrInit_NormalizesWebhooks(t *testing.T) {
+	dir := t.TempDir()
+	raw := `
+[[webhooks]]
+name = "ci"
+url = " https://hooks.example.test/shellman "
+secret = "s3cret"
+events = ["Task.Completed", "unknown.event", "run.needs_rebind"]
+
+[[webhooks]]
+url = ""
+
+[[webhooks]]
+url = "https://all.example.test"
+timeout_seconds = 500
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(raw), 0o644); err != nil {
+		t.Fatalf("write config.toml failed: %v", err)
+	}
+	cfg, err := NewConfigStore(dir).LoadOrInit()
+	if err != nil {
+		t.Fatalf("LoadOrInit failed: %v", err)
+	}
+	if len(cfg.Webhooks) != 2 {
+		t.Fatalf("expected 2 webhooks after dropping blank url, got %#v", cfg.Webhooks)
+	}
+	first := cfg.Webhooks[0]
+	if first.URL != "https://hooks.example.test/shellman" || first.Secret != "s3cret" {
+		t.Fatalf("unexpected first webhook: %#v", first)
+	}
+	if strings.Join(first.Events, ",") != "task.completed,unknown.event,run.needs_rebind" {
+		t.Fatalf("expected normalized events, got %v", first.Events)
+	}
+	if strings.Join(first.UnknownEvents(), ",") != "unknown.event" {
+		t.Fatalf("expected unknown.event reported, got %v", first.UnknownEvents())
+	}
+	if first.TimeoutSeconds != 10 || first.MaxAttempts != 3 {
+		t.Fatalf("expected default timeout/attempts, got %d/%d", first.TimeoutSeconds, first.MaxAttempts)
+	}
+	if !first.Accepts(WebhookEventTaskCompleted) || first.Accepts(WebhookEventFlagRaised) {
+		t.Fatalf("unexpected event filter result for %v", first.Events)
+	}
+	second := cfg.Webhooks[1]
+	if second.Name != second.URL || second.TimeoutSeconds != 120 {
+		t.Fatalf("unexpected second webhook: %#v", second)
+	}
+	if !second.Accepts(WebhookEventFlagRaised) {
+		t.Fatal("expected webhook without events to accept every event")
+	}
+}
+
+func TestConfigStore_LoadOrInit_WebhookWithOnlyUnknownEventsAcceptsNothing(t *testing.T) {
+	dir := t.TempDir()
+	raw := `
+[[webhooks]]
+url = "https://typo.example.test"
+events = ["task.complete", "run.stall"]
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(raw), 0o644); err != nil {
+		t.Fatalf("write config.toml failed: %v", err)
+	}
+	cfg, err := NewConfigStore(dir).LoadOrInit()
+	if err != nil {
+		t.Fatalf("LoadOrInit failed: %v", err)
+	}
+	if len(cfg.Webhooks) != 1 {
+		t.Fatalf("expected one webhook, got %#v", cfg.Webhooks)
+	}
+	hook := cfg.Webhooks[0]
+	for _, event := range []string{WebhookEventTaskCompleted, WebhookEventFlagRaised, WebhookEventFlagEscalated, WebhookEventRunRebind, WebhookEventRunStalled} {
+		if hook.Accepts(event) {
+			t.Fatalf("expected webhook with only unknown events to reject %q", event)
+		}
+	}
+	if len(hook.UnknownEvents()) != 2 {
+		t.Fatalf("expected both events reported unknown, got %v", hook.UnknownEvents())
+	}
+}
+
+func TestConfigStore_LoadOrInit_NormalizesRunWatchdog(t *testing.T) {
+	dir := t.TempDir()
+	raw := `
+[run_watchdog]
+silence_seconds = 600
+interrupt = true
+
+[run_watchdog.adapter_silence_seconds]
+" Codex " = 1800
+shell = 0
+claude = -5
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(raw), 0o644); err != nil {
+		t.Fatalf("write config.toml failed: %v", err)
+	}
+	cfg, err := NewConfigStore(dir).LoadOrInit()
+	if err != nil {
+		t.Fatalf("LoadOrInit failed: %v", err)
+	}
+	watchdog := cfg.RunWatchdog
+	if watchdog.Flag != "error" || !watchdog.Interrupt {
+		t.Fatalf("unexpected watchdog config: %#v", watchdog)
+	}
+	if got := watchdog.SilenceWindow("codex"); got != 30*time.Minute {
+		t.Fatalf("expected codex override, got %s", got)
+	}
+	if got := watchdog.SilenceWindow("cursor"); got != 10*time.Minute {
+		t.Fatalf("expected default window for cursor, got %s", got)
+	}
+	if watchdog.SilenceWindow("") != 0 || watchdog.SilenceWindow("claude") != 0 {
+		t.Fatalf("expected shell and claude disabled, got %#v", watchdog.AdapterSilenceSeconds)
+	}
+}
+
+func TestConfigStore_LoadOrInit_NormalizesRunRecording(t *testing.T) {
+	dir := t.TempDir()
+	raw := `
+[run_recording]
+enabled = false
+max_recordings = -1
+retention_days = 7
+
+[run_recording.projects]
+" p1 " = true
+p2 = false
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(raw), 0o644); err != nil {
+		t.Fatalf("write config.toml failed: %v", err)
+	}
+	cfg, err := NewConfigStore(dir).LoadOrInit()
+	if err != nil {
+		t.Fatalf("LoadOrInit failed: %v", err)
+	}
+	recording := cfg.RunRecording
+	if recording.MaxRecordings != 0 || recording.RetentionDays != 7 {
+		t.Fatalf("unexpected recording limits: %#v", recording)
+	}
+	if !recording.EnabledFor("p1") || recording.EnabledFor("p2") || recording.EnabledFor("p3") {
+		t.Fatalf("unexpected per-project recording: %#v", recording.Projects)
+	}
+}
@@ -19,6 +19,7 @@ type ActiveProject struct {
 	DisplayName string    `json:"display_name"`
 	SortOrder   int64     `json:"sort_order"`
 	Collapsed   bool      `json:"collapsed"`
+	TmuxSocket  string    `json:"tmux_socket,omitempty"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
@@ -58,6 +59,7 @@ func (s *ProjectsStore) ListProjects() ([]ActiveProject, error) {
 			DisplayName: strings.TrimSpace(row.DisplayName),
 			SortOrder:   row.SortOrder,
 			Collapsed:   row.Collapsed,
+			TmuxSocket:  strings.TrimSpace(row.TmuxSocket),
 			UpdatedAt:   updatedAt,
 		})
 	}
@@ -72,6 +74,7 @@ func (s *ProjectsStore) AddProject(p ActiveProject) error {
 	projectID := strings.TrimSpace(p.ProjectID)
 	repoRoot := strings.TrimSpace(p.RepoRoot)
 	displayName := strings.TrimSpace(p.DisplayName)
+	tmuxSocket := strings.TrimSpace(p.TmuxSocket)
 	nowMillis := time.Now().UTC().UnixNano()
 
 	return gdb.Transaction(func(tx *gorm.DB) error {
@@ -95,6 +98,7 @@ func (s *ProjectsStore) AddProject(p ActiveProject) error {
 				DisplayName: displayName,
 				SortOrder:   sortOrder,
 				Collapsed:   p.Collapsed,
+				TmuxSocket:  tmuxSocket,
 				UpdatedAt:   nowMillis,
 			}).Error
 		}
@@ -112,6 +116,9 @@ func (s *ProjectsStore) AddProject(p ActiveProject) error {
 			row.SortOrder = p.SortOrder
 		}
 		row.Collapsed = p.Collapsed
+		if tmuxSocket != "" {
+			row.TmuxSocket = tmuxSocket
+		}
 		row.UpdatedAt = nowMillis
 		return tx.Save(&row).Error
 	})
@@ -150,6 +157,21 @@ func (s *ProjectsStore) SetProjectDisplayName(projectID, displayName string) err
 	})
 }
 
+// SetProjectTmuxSocket points projectID at the tmux server listening on socket; an empty socket is the default
+// server of the process.
+func (s *ProjectsStore) SetProjectTmuxSocket(projectID, socket string) error {
+	gdb, err := s.openDB()
+	if err != nil {
+		return err
+	}
+	return gdb.Model(&db.Project{}).
+		Where("project_id = ?", strings.TrimSpace(projectID)).
+		Updates(map[string]any{
+			"tmux_socket": strings.TrimSpace(socket),
+			"updated_at":  time.Now().UTC().UnixNano(),
+		}).Error
+}
+
 func (s *ProjectsStore) openDB() (*gorm.DB, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
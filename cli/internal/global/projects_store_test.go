@@ -122,6 +122,36 @@ func TestProjectsStore_UpdateProjectDisplayName(t *testing.T) {
 	}
 }
 
+func TestProjectsStore_ProjectTmuxSocket(t *testing.T) {
+	dir := t.TempDir()
+	s := NewProjectsStore(dir)
+
+	if err := s.AddProject(ActiveProject{ProjectID: "p1", RepoRoot: "/tmp/repo1", TmuxSocket: " work "}); err != nil {
+		t.Fatalf("AddProject failed: %v", err)
+	}
+	if err := s.AddProject(ActiveProject{ProjectID: "p1", RepoRoot: "/tmp/repo1"}); err != nil {
+		t.Fatalf("AddProject refresh failed: %v", err)
+	}
+	list, err := s.ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	if len(list) != 1 || list[0].TmuxSocket != "work" {
+		t.Fatalf("expected socket kept across refresh, got %#v", list)
+	}
+
+	if err := s.SetProjectTmuxSocket("p1", ""); err != nil {
+		t.Fatalf("SetProjectTmuxSocket failed: %v", err)
+	}
+	list, err = s.ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	if list[0].TmuxSocket != "" {
+		t.Fatalf("expected socket cleared, got %q", list[0].TmuxSocket)
+	}
+}
+
 func TestProjectsStore_OpenDB_ReusesSingleHandle(t *testing.T) {
 	dir := t.TempDir()
 	s := NewProjectsStore(dir)
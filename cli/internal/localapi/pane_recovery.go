@@ -0,0 +1,237 @@
+package localapi
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shellman/cli/internal/progdetector"
+	"shellman/cli/internal/programadapter"
+	"shellman/cli/internal/projectstate"
+)
+
+const paneRecoveryInterval = 15 * time.Second
+
+// runPaneClosedReason ends the runs whose pane was closed on purpose, which keeps pane recovery from reopening it.
+const runPaneClosedReason = "pane closed"
+
+// paneRecoveryService is implemented by pane services that can tell which tmux server they are talking to and
+// whether a pane still exists on it.
+type paneRecoveryService interface {
+	PaneService
+	ServerInstanceID() (string, error)
+	PaneExists(target string) (bool, error)
+	PaneCurrentPath(target string) (string, error)
+}
+
+// PaneRecoveryLoop keeps track of where the panes of active runs live and, when pane recovery is enabled, recreates
+// the ones lost to a tmux server restart, until ctx is canceled.
+func (s *Server) PaneRecoveryLoop(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	ticker := time.NewTicker(paneRecoveryInterval)
+	defer ticker.Stop()
+	for {
+		s.paneRecoveryPass()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) paneRecoveryPass() {
+	if s.deps.ConfigStore == nil || s.deps.ProjectsStore == nil {
+		return
+	}
+	cfg, err := s.deps.ConfigStore.LoadOrInit()
+	if err != nil {
+		slog.Warn("pane recovery config load failed", "err", err)
+		return
+	}
+	if !cfg.PaneRecovery.Enabled {
+		return
+	}
+	projects, err := s.deps.ProjectsStore.ListProjects()
+	if err != nil {
+		slog.Warn("pane recovery project list failed", "err", err)
+		return
+	}
+	for _, p := range projects {
+		svc, ok := s.paneServiceOnSocket(p.TmuxSocket).(paneRecoveryService)
+		if !ok {
+			continue
+		}
+		if err := s.recoverProjectPanes(projectstate.NewStore(p.RepoRoot), p.ProjectID, p.RepoRoot, svc); err != nil {
+			slog.Warn("pane recovery failed", "project_id", p.ProjectID, "err", err)
+		}
+	}
+}
+
+// recoverProjectPanes checks the pane of every recoverable run of projectID. Only panes lost to a tmux server restart
+// are recreated: a pane last seen on another tmux server is gone even if a pane with the same id exists now, since a
+// new server hands out ids from scratch. A pane missing from the server it was seen on was closed by someone and
+// is left alone.
+func (s *Server) recoverProjectPanes(store *projectstate.Store, projectID, repoRoot string, svc paneRecoveryService) error {
+	runs, err := store.ListRecoverableRunsByProject(projectID)
+	if err != nil || len(runs) == 0 {
+		return err
+	}
+	serverID, err := svc.ServerInstanceID()
+	if err != nil {
+		// No tmux server is running, so none of the panes survived.
+		serverID = ""
+	}
+	for _, run := range runs {
+		target := strings.TrimSpace(run.PaneTarget)
+		if target == "" {
+			target = strings.TrimSpace(run.PaneID)
+		}
+		if target == "" {
+			continue
+		}
+		if run.TmuxServerID == "" || run.TmuxServerID == serverID {
+			if serverID == "" {
+				continue
+			}
+			exists, err := svc.PaneExists(target)
+			if err != nil {
+				slog.Warn("pane recovery pane lookup failed", "run_id", run.RunID, "pane_target", target, "err", err)
+				continue
+			}
+			if !exists {
+				continue
+			}
+			if err := s.keepRunPane(store, projectID, run, target, serverID, svc); err != nil {
+				return err
+			}
+			continue
+		}
+		nextServerID, err := s.recreateRunPane(store, projectID, repoRoot, run, target, serverID, svc)
+		if err != nil {
+			slog.Warn("pane recovery recreate failed", "run_id", run.RunID, "pane_target", target, "err", err)
+			continue
+		}
+		serverID = nextServerID
+	}
+	return nil
+}
+
+// keepRunPane records where a surviving pane is and rebinds its run if it was waiting for a rebind.
+func (s *Server) keepRunPane(store *projectstate.Store, projectID string, run projectstate.ActiveRunRecord, target, serverID string, svc paneRecoveryService) error {
+	cwd, err := svc.PaneCurrentPath(target)
+	if err != nil {
+		cwd = ""
+	}
+	if run.RunStatus != projectstate.RunStatusNeedsRebind && run.BindingStatus == projectstate.BindingStatusLive {
+		if run.TmuxServerID == serverID && (cwd == "" || cwd == run.PaneCwd) {
+			return nil
+		}
+		return store.ObserveRunPane(run.RunID, serverID, cwd)
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{
+		RunID:            run.RunID,
+		ServerInstanceID: detectServerInstanceID(),
+		PaneID:           run.PaneID,
+		PaneTarget:       target,
+		BindingStatus:    projectstate.BindingStatusLive,
+	}); err != nil {
+		return err
+	}
+	if err := store.ObserveRunPane(run.RunID, serverID, cwd); err != nil {
+		return err
+	}
+	if err := store.SetRunStatus(run.RunID, projectstate.RunStatusRunning); err != nil {
+		return err
+	}
+	payload := map[string]any{"pane_target": target, "tmux_server_id": serverID}
+	_ = store.AppendRunEvent(run.RunID, "run.rebound", payload)
+	s.publishEvent("run.rebound", projectID, run.TaskID, payload)
+	return nil
+}
+
+// recreateRunPane opens a new pane for run in its last cwd, relaunches the task's program there and rebinds the run
+// and task to it. It returns the id of the tmux server the new pane lives on.
+func (s *Server) recreateRunPane(store *projectstate.Store, projectID, repoRoot string, run projectstate.ActiveRunRecord, oldTarget, oldServerID string, svc paneRecoveryService) (string, error) {
+	cwd := strings.TrimSpace(run.PaneCwd)
+	if info, err := os.Stat(cwd); cwd == "" || err != nil || !info.IsDir() {
+		cwd = repoRoot
+	}
+	paneID, err := svc.CreateRootPaneInDir(cwd)
+	if err != nil {
+		return oldServerID, err
+	}
+	serverID, err := svc.ServerInstanceID()
+	if err != nil {
+		serverID = ""
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{
+		RunID:            run.RunID,
+		ServerInstanceID: detectServerInstanceID(),
+		PaneID:           paneID,
+		PaneTarget:       paneID,
+		BindingStatus:    projectstate.BindingStatusLive,
+	}); err != nil {
+		_ = svc.ClosePane(paneID)
+		return serverID, err
+	}
+	if err := store.ObserveRunPane(run.RunID, serverID, cwd); err != nil {
+		return serverID, err
+	}
+	if err := store.SetRunStatus(run.RunID, projectstate.RunStatusRunning); err != nil {
+		return serverID, err
+	}
+	if panes, err := store.LoadPanes(); err == nil {
+		panes[run.TaskID] = projectstate.PaneBinding{
+			TaskID:             run.TaskID,
+			PaneUUID:           uuid.NewString(),
+			PaneID:             paneID,
+			PaneTarget:         paneID,
+			ShellReadyRequired: true,
+		}
+		if err := store.SavePanes(panes); err != nil {
+			slog.Warn("pane recovery task pane save failed", "task_id", run.TaskID, "err", err)
+		}
+	}
+
+	command := recoveryLaunchCommand(run.ActiveAdapter, run.CurrentCommand, oldTarget)
+	payload := map[string]any{
+		"previous_pane_target":    oldTarget,
+		"previous_tmux_server_id": run.TmuxServerID,
+		"pane_id":                 paneID,
+		"pane_target":             paneID,
+		"tmux_server_id":          serverID,
+		"cwd":                     cwd,
+		"command":                 command,
+	}
+	_ = store.AppendRunEvent(run.RunID, "run.recovered", payload)
+	slog.Info("run.recovered", "project_id", projectID, "task_id", run.TaskID, "run_id", run.RunID, "pane_target", paneID)
+	s.publishEvent("run.recovered", projectID, run.TaskID, payload)
+	s.publishEvent("task.tree.updated", projectID, run.TaskID, map[string]any{})
+	if command != "" {
+		go s.sendPaneLaunchCommand(projectID, run.TaskID, paneID, command, "recovery.launch")
+	}
+	return serverID, nil
+}
+
+// recoveryLaunchCommand is what a recreated pane runs: the adapter's resume command when it has one, otherwise the
+// task's recorded command unless that was just a shell (which the new pane already is).
+func recoveryLaunchCommand(activeAdapter, currentCommand, oldTarget string) string {
+	if detector, ok := progdetector.ProgramDetectorRegistry.Get(activeAdapter); ok {
+		if command := programadapter.ResumeCommand(detector); command != "" {
+			return command
+		}
+	}
+	command := strings.TrimSpace(currentCommand)
+	// Tasks whose command was never detected carry their pane target instead.
+	if command == "" || command == oldTarget || resolveTaskAgentToolModeFromCommand(command) == taskAgentToolModeShell {
+		return ""
+	}
+	return command
+}
@@ -0,0 +1,197 @@
+package localapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+type recoveryPaneService struct {
+	fakePaneService
+	serverID string
+	panes    map[string]string
+}
+
+func (f *recoveryPaneService) ServerInstanceID() (string, error) {
+	if f.serverID == "" {
+		return "", errors.New("no server running")
+	}
+	return f.serverID, nil
+}
+
+func (f *recoveryPaneService) PaneExists(target string) (bool, error) {
+	_, ok := f.panes[target]
+	return ok, nil
+}
+
+func (f *recoveryPaneService) PaneCurrentPath(target string) (string, error) {
+	return f.panes[target], nil
+}
+
+func TestPaneRecovery_RecreatesPaneLostToServerRestart(t *testing.T) {
+	repo := t.TempDir()
+	workDir := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	cfgStore := &mutableConfigStore{cfg: global.GlobalConfig{LocalPort: 4621}}
+	panes := &recoveryPaneService{serverID: "default:100", panes: map[string]string{"%1": workDir}}
+	srv := NewServer(Deps{
+		ConfigStore:   cfgStore,
+		ProjectsStore: projects,
+		PaneService:   panes,
+		ExecuteCommand: func(context.Context, string, ...string) ([]byte, error) {
+			return nil, errors.New("tmux unavailable in test")
+		},
+	})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "agent work", nil)
+	store := projectstate.NewStore(repo)
+	adapter := "codex"
+	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{TaskID: taskID, ProjectID: "p1", ActiveAdapter: &adapter}); err != nil {
+		t.Fatalf("seed adapter failed: %v", err)
+	}
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_recovery_1", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{RunID: "r_recovery_1", PaneID: "%1", PaneTarget: "%1", BindingStatus: projectstate.BindingStatusLive}); err != nil {
+		t.Fatalf("UpsertRunBinding failed: %v", err)
+	}
+
+	srv.paneRecoveryPass()
+	if panes.rootCount != 0 {
+		t.Fatal("expected recovery to stay off until enabled")
+	}
+
+	cfgStore.cfg.PaneRecovery.Enabled = true
+	srv.paneRecoveryPass()
+	if panes.rootCount != 0 {
+		t.Fatal("expected a live pane to be left alone")
+	}
+
+	// The restarted server reuses pane id %1 for an unrelated pane; the run's pane is still gone.
+	panes.serverID = "default:200"
+	panes.panes = map[string]string{"%1": "/elsewhere"}
+	srv.paneRecoveryPass()
+	if panes.rootCount != 1 || panes.lastRootCWD != workDir {
+		t.Fatalf("expected one pane recreated in %q, got count=%d cwd=%q", workDir, panes.rootCount, panes.lastRootCWD)
+	}
+	binding, ok, err := store.GetBindingByRunID("r_recovery_1")
+	if err != nil || !ok {
+		t.Fatalf("GetBindingByRunID failed: ok=%v err=%v", ok, err)
+	}
+	if binding.PaneTarget != "pane_root_1" || binding.BindingStatus != projectstate.BindingStatusLive {
+		t.Fatalf("expected run rebound to the new pane, got %#v", binding)
+	}
+	taskPanes, err := store.LoadPanes()
+	if err != nil {
+		t.Fatalf("LoadPanes failed: %v", err)
+	}
+	if taskPanes[taskID].PaneTarget != "pane_root_1" {
+		t.Fatalf("expected task pane rebound, got %#v", taskPanes[taskID])
+	}
+	events, err := store.ListRunEvents("r_recovery_1", 0, 0)
+	if err != nil {
+		t.Fatalf("ListRunEvents failed: %v", err)
+	}
+	var recovered map[string]any
+	for _, event := range events {
+		if event.EventType == "run.recovered" {
+			recovered = event.Payload
+		}
+	}
+	if recovered == nil || recovered["command"] != "codex resume --last" || recovered["previous_tmux_server_id"] != "default:100" {
+		t.Fatalf("unexpected recovery event: %#v", events)
+	}
+
+	// The recreated pane now belongs to the new server and is not recreated again.
+	panes.panes["pane_root_1"] = workDir
+	srv.paneRecoveryPass()
+	if panes.rootCount != 1 {
+		t.Fatalf("expected no further recreation, got %d", panes.rootCount)
+	}
+}
+
+func TestPaneRecovery_LeavesPaneClosedOnSameServer(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	cfgStore := &mutableConfigStore{cfg: global.GlobalConfig{LocalPort: 4621, PaneRecovery: global.PaneRecoveryConfig{Enabled: true}}}
+	panes := &recoveryPaneService{serverID: "default:100", panes: map[string]string{"%1": repo}}
+	srv := NewServer(Deps{ConfigStore: cfgStore, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "agent work", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_recovery_closed_1", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{RunID: "r_recovery_closed_1", PaneID: "%1", PaneTarget: "%1", BindingStatus: projectstate.BindingStatusLive}); err != nil {
+		t.Fatalf("UpsertRunBinding failed: %v", err)
+	}
+	srv.paneRecoveryPass()
+
+	// The pane is closed while the tmux server keeps running.
+	delete(panes.panes, "%1")
+	srv.paneRecoveryPass()
+	srv.paneRecoveryPass()
+	if panes.rootCount != 0 {
+		t.Fatalf("expected a pane closed on the same server not to be recreated, got %d", panes.rootCount)
+	}
+	binding, ok, err := store.GetBindingByRunID("r_recovery_closed_1")
+	if err != nil || !ok || binding.PaneTarget != "%1" {
+		t.Fatalf("expected the binding left untouched, got %#v ok=%v err=%v", binding, ok, err)
+	}
+}
+
+func TestTaskBatch_ClosePanesEndsRunsOfClosedPanes(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	panes := &recoveryPaneService{serverID: "default:100", panes: map[string]string{"%1": repo}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "agent work", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_recovery_closed_2", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if err := store.SavePanes(projectstate.PanesIndex{taskID: {TaskID: taskID, PaneID: "%1", PaneTarget: "%1"}}); err != nil {
+		t.Fatalf("SavePanes failed: %v", err)
+	}
+
+	code, out := postTaskBatch(t, ts.URL, map[string]any{"project_id": "p1", "op": "close_panes", "task_ids": []string{taskID}})
+	if code != http.StatusOK || out.Succeeded != 1 {
+		t.Fatalf("expected close_panes to succeed, got %d %#v", code, out)
+	}
+	run, err := store.GetRun("r_recovery_closed_2")
+	if err != nil {
+		t.Fatalf("GetRun failed: %v", err)
+	}
+	if run.RunStatus != projectstate.RunStatusCanceled || run.LastError != runPaneClosedReason {
+		t.Fatalf("expected the run ended with its pane, got %#v", run)
+	}
+}
+
+func TestRecoveryLaunchCommand(t *testing.T) {
+	cases := []struct {
+		adapter, command, want string
+	}{
+		{adapter: "codex", command: "node", want: "codex resume --last"},
+		{adapter: "", command: "npm run dev", want: "npm run dev"},
+		{adapter: "", command: "bash", want: ""},
+		{adapter: "", command: "%4", want: ""},
+	}
+	for _, tc := range cases {
+		if got := recoveryLaunchCommand(tc.adapter, tc.command, "%4"); got != tc.want {
+			t.Fatalf("recoveryLaunchCommand(%q, %q) = %q, want %q", tc.adapter, tc.command, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package localapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/panestats"
+)
+
+// paneResourceFreshness is how old the latest kept sample may be before the endpoint samples the pane itself.
+const paneResourceFreshness = 5 * time.Second
+
+// handleGetTaskPaneResources returns the resource use of the processes in the task's pane: the latest sample, the
+// recent samples kept in memory and the downsampled history.
+func (s *Server) handleGetTaskPaneResources(w http.ResponseWriter, r *http.Request, taskID string) {
+	projectID, store, _, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	historyLimit := 60
+	if raw := strings.TrimSpace(r.URL.Query().Get("history_limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > 1440 {
+			respondError(w, http.StatusBadRequest, "INVALID_HISTORY_LIMIT", "history_limit must be between 1 and 1440")
+			return
+		}
+		historyLimit = parsed
+	}
+	panes, err := store.LoadPanes()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "PANES_LOAD_FAILED", err.Error())
+		return
+	}
+	binding, ok := panes[taskID]
+	if !ok {
+		respondError(w, http.StatusNotFound, "TASK_PANE_NOT_FOUND", "task pane binding not found")
+		return
+	}
+	paneID := strings.TrimSpace(binding.PaneID)
+	target := strings.TrimSpace(binding.PaneTarget)
+	if paneID == "" {
+		paneID = target
+	}
+	if target == "" {
+		target = paneID
+	}
+
+	// The sampler is shared with the runtime, which keys panes the same way.
+	repoRoot, _ := s.findProjectRepoRoot(projectID)
+	socket := s.projectTmuxSocket(projectID)
+	key := panestats.PaneKey(repoRoot, socket, paneID)
+
+	now := time.Now()
+	recent := s.deps.PaneResources.Recent(key)
+	alive := false
+	if provider, ok := s.paneServiceOnSocket(socket).(panePIDProvider); ok {
+		if pid, err := provider.PanePID(target); err == nil && pid > 0 {
+			alive = true
+			if len(recent) == 0 || now.Unix()-recent[len(recent)-1].SampledAt >= int64(paneResourceFreshness/time.Second) {
+				s.deps.PaneResources.Sample(now, map[string]int{key: pid})
+				recent = s.deps.PaneResources.Recent(key)
+			}
+		}
+	}
+	for i := range recent {
+		recent[i].PaneID = paneID
+	}
+	var latest *panestats.Sample
+	if len(recent) > 0 {
+		latest = &recent[len(recent)-1]
+	}
+	if recent == nil {
+		recent = []panestats.Sample{}
+	}
+	history, err := store.ListPaneResourceHistory(socket, paneID, historyLimit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "PANE_RESOURCE_HISTORY_LOAD_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{
+		"task_id":     taskID,
+		"pane_id":     paneID,
+		"pane_target": target,
+		"alive":       alive,
+		"latest":      latest,
+		"recent":      recent,
+		"history":     history,
+	})
+}
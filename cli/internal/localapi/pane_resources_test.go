@@ -0,0 +1,80 @@
+package localapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/panestats"
+	"shellman/cli/internal/projectstate"
+)
+
+func TestTaskPaneResources_SamplesPaneAndReturnsHistory(t *testing.T) {
+	repo := t.TempDir()
+	paneID := fmt.Sprintf("%%resources_%d", time.Now().UTC().UnixNano())
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	panes := &signalPaneService{pids: map[string]int{paneID: os.Getpid()}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "resources", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.SavePanes(projectstate.PanesIndex{taskID: {TaskID: taskID, PaneID: paneID, PaneTarget: paneID}}); err != nil {
+		t.Fatalf("SavePanes failed: %v", err)
+	}
+	bucketStart := time.Now().UTC().Add(-time.Minute).Unix()
+	if err := store.AppendPaneResourceHistory([]projectstate.PaneResourceBucket{{PaneID: paneID, BucketStart: bucketStart, Samples: 12, CPUPercentMax: 150, RSSBytesMax: 2 << 30}}, 0); err != nil {
+		t.Fatalf("AppendPaneResourceHistory failed: %v", err)
+	}
+	// The same pane id on another tmux server or in another project is not this pane.
+	if err := store.AppendPaneResourceHistory([]projectstate.PaneResourceBucket{{TmuxSocket: "other", PaneID: paneID, BucketStart: bucketStart, Samples: 12, CPUPercentMax: 999}}, 0); err != nil {
+		t.Fatalf("AppendPaneResourceHistory failed: %v", err)
+	}
+	if err := projectstate.NewStore(t.TempDir()).AppendPaneResourceHistory([]projectstate.PaneResourceBucket{{PaneID: paneID, BucketStart: bucketStart, Samples: 12, CPUPercentMax: 998}}, 0); err != nil {
+		t.Fatalf("AppendPaneResourceHistory failed: %v", err)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/tasks/" + taskID + "/pane/resources?history_limit=0")
+	if err != nil {
+		t.Fatalf("GET pane resources failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid history_limit, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/tasks/" + taskID + "/pane/resources")
+	if err != nil {
+		t.Fatalf("GET pane resources failed: %v", err)
+	}
+	var body struct {
+		Data struct {
+			PaneID  string                            `json:"pane_id"`
+			Alive   bool                              `json:"alive"`
+			Latest  *panestats.Sample                 `json:"latest"`
+			Recent  []panestats.Sample                `json:"recent"`
+			History []projectstate.PaneResourceBucket `json:"history"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode pane resources failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	data := body.Data
+	if resp.StatusCode != http.StatusOK || data.PaneID != paneID || !data.Alive {
+		t.Fatalf("unexpected pane resources response (%d): %#v", resp.StatusCode, data)
+	}
+	if data.Latest == nil || data.Latest.PaneID != paneID || data.Latest.PID != os.Getpid() || data.Latest.RSSBytes == 0 || data.Latest.Threads == 0 || len(data.Recent) != 1 {
+		t.Fatalf("expected a fresh sample of the pane process, got %#v", data)
+	}
+	if len(data.History) != 1 || data.History[0].BucketStart != bucketStart || data.History[0].CPUPercentMax != 150 {
+		t.Fatalf("expected stored history, got %#v", data.History)
+	}
+}
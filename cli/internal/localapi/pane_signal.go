@@ -0,0 +1,161 @@
+package localapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"shellman/cli/internal/tmux"
+)
+
+const (
+	paneSignalScopeForeground = "foreground"
+	paneSignalScopeTree       = "tree"
+
+	runEventPaneSignal = "pane.signal"
+)
+
+// paneSignalNames are the signals the pane signal API sends; everything else is rejected.
+var paneSignalNames = []string{"SIGINT", "SIGTERM", "SIGKILL", "SIGSTOP", "SIGCONT"}
+
+var errPaneProcessGone = errors.New("process already exited")
+
+// panePIDProvider is implemented by pane services that can tell which process runs in a pane.
+type panePIDProvider interface {
+	PanePID(target string) (int, error)
+}
+
+type paneSignalRequest struct {
+	Signal string `json:"signal"`
+	Scope  string `json:"scope"`
+	Source string `json:"source"`
+}
+
+// normalizePaneSignalName accepts "INT", "sigint" or "SIGINT" and returns the canonical name, "" if unsupported.
+func normalizePaneSignalName(name string) string {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name != "" && !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	for _, known := range paneSignalNames {
+		if name == known {
+			return name
+		}
+	}
+	return ""
+}
+
+// handlePostTaskPaneSignal sends a signal to the job in the foreground of the task's pane, or to every process in
+// the pane, and records it on the run bound to the pane.
+func (s *Server) handlePostTaskPaneSignal(w http.ResponseWriter, r *http.Request, taskID string) {
+	projectID, store, _, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	var req paneSignalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	signal := normalizePaneSignalName(req.Signal)
+	if signal == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_SIGNAL", "signal must be one of "+strings.Join(paneSignalNames, "|"))
+		return
+	}
+	scope := strings.ToLower(strings.TrimSpace(req.Scope))
+	if scope == "" {
+		scope = paneSignalScopeForeground
+	}
+	if scope != paneSignalScopeForeground && scope != paneSignalScopeTree {
+		respondError(w, http.StatusBadRequest, "INVALID_SIGNAL_SCOPE", "scope must be foreground or tree")
+		return
+	}
+	source := strings.TrimSpace(req.Source)
+	if source == "" {
+		source = "api"
+	}
+
+	panes, err := store.LoadPanes()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "PANES_LOAD_FAILED", err.Error())
+		return
+	}
+	binding, ok := panes[taskID]
+	if !ok {
+		respondError(w, http.StatusNotFound, "TASK_PANE_NOT_FOUND", "task pane binding not found")
+		return
+	}
+	target := strings.TrimSpace(binding.PaneTarget)
+	if target == "" {
+		target = strings.TrimSpace(binding.PaneID)
+	}
+	provider, ok := s.paneServiceFor(projectID).(panePIDProvider)
+	if !ok {
+		respondError(w, http.StatusNotImplemented, "PANE_SIGNAL_UNSUPPORTED", "pane service cannot resolve pane processes")
+		return
+	}
+	panePID, err := provider.PanePID(target)
+	if err != nil {
+		respondError(w, http.StatusConflict, "PANE_PID_UNAVAILABLE", err.Error())
+		return
+	}
+
+	payload := map[string]any{
+		"task_id":     taskID,
+		"pane_target": target,
+		"pane_pid":    panePID,
+		"signal":      signal,
+		"scope":       scope,
+		"source":      source,
+	}
+	switch scope {
+	case paneSignalScopeForeground:
+		pgid, ok := tmux.ForegroundProcessGroupID(panePID)
+		if !ok {
+			respondError(w, http.StatusConflict, "FOREGROUND_PGID_UNAVAILABLE", "cannot resolve the pane's foreground process group")
+			return
+		}
+		if pgid == panePID {
+			respondError(w, http.StatusConflict, "NO_FOREGROUND_JOB", "the pane shell is in the foreground")
+			return
+		}
+		if err := signalPaneProcess(-pgid, signal); err != nil {
+			if errors.Is(err, errPaneProcessGone) {
+				respondError(w, http.StatusConflict, "NO_FOREGROUND_JOB", "the foreground job already exited")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "PANE_SIGNAL_FAILED", err.Error())
+			return
+		}
+		payload["pgid"] = pgid
+	case paneSignalScopeTree:
+		tree := tmux.PaneProcessTree(panePID)
+		signaled := make([]int, 0, len(tree))
+		// Children first, so a dying parent cannot reap or respawn them before they are signaled.
+		for i := len(tree) - 1; i >= 0; i-- {
+			err := signalPaneProcess(tree[i], signal)
+			if errors.Is(err, errPaneProcessGone) {
+				continue
+			}
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, "PANE_SIGNAL_FAILED", err.Error())
+				return
+			}
+			signaled = append(signaled, tree[i])
+		}
+		payload["pids"] = signaled
+	}
+
+	runID := ""
+	if run, ok, err := store.FindLiveRunningRunByPaneTarget(target); err == nil && ok {
+		runID = run.RunID
+		if err := store.AppendRunEvent(runID, runEventPaneSignal, payload); err != nil {
+			respondError(w, http.StatusInternalServerError, "RUN_EVENT_APPEND_FAILED", err.Error())
+			return
+		}
+	}
+	payload["run_id"] = runID
+	respondOK(w, payload)
+}
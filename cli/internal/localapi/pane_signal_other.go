@@ -0,0 +1,9 @@
+//go:build !darwin && !linux
+
+package localapi
+
+import "errors"
+
+func signalPaneProcess(int, string) error {
+	return errors.New("pane signals are not supported on this platform")
+}
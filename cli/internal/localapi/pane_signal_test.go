@@ -0,0 +1,121 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+type signalPaneService struct {
+	fakePaneService
+	pids map[string]int
+}
+
+func (f *signalPaneService) PanePID(target string) (int, error) {
+	pid, ok := f.pids[target]
+	if !ok {
+		return 0, errors.New("can't find pane: " + target)
+	}
+	return pid, nil
+}
+
+func TestTaskPaneSignal_SignalsPaneTreeAndAuditsRun(t *testing.T) {
+	job := exec.Command("sleep", "30")
+	if err := job.Start(); err != nil {
+		t.Skipf("sleep unavailable: %v", err)
+	}
+	defer func() { _ = job.Process.Kill() }()
+	exited := make(chan error, 1)
+	go func() { exited <- job.Wait() }()
+
+	repo := t.TempDir()
+	paneTarget := fmt.Sprintf("%%signal_%d", time.Now().UTC().UnixNano())
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	panes := &signalPaneService{pids: map[string]int{paneTarget: job.Process.Pid}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "signal", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.SavePanes(projectstate.PanesIndex{taskID: {TaskID: taskID, PaneID: paneTarget, PaneTarget: paneTarget}}); err != nil {
+		t.Fatalf("SavePanes failed: %v", err)
+	}
+	runID := fmt.Sprintf("r_signal_%d", time.Now().UTC().UnixNano())
+	if err := store.InsertRun(projectstate.RunRecord{RunID: runID, TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{RunID: runID, PaneID: paneTarget, PaneTarget: paneTarget, BindingStatus: projectstate.BindingStatusLive}); err != nil {
+		t.Fatalf("UpsertRunBinding failed: %v", err)
+	}
+
+	post := func(body string) (int, map[string]any) {
+		t.Helper()
+		resp, err := http.Post(ts.URL+"/api/v1/tasks/"+taskID+"/pane/signal", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("POST pane signal failed: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var out struct {
+			Data  map[string]any `json:"data"`
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		if out.Data == nil {
+			out.Data = map[string]any{"code": out.Error.Code}
+		}
+		return resp.StatusCode, out.Data
+	}
+
+	if code, _ := post(`{"signal":"SIGHUP"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unsupported signal, got %d", code)
+	}
+	if code, _ := post(`{"signal":"TERM","scope":"session"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown scope, got %d", code)
+	}
+	// A job started outside a terminal has no foreground process group to signal.
+	if code, data := post(`{"signal":"INT"}`); code != http.StatusConflict || data["code"] != "FOREGROUND_PGID_UNAVAILABLE" {
+		t.Fatalf("expected 409 without a foreground group, got %d %#v", code, data)
+	}
+
+	code, data := post(`{"signal":"term","scope":"tree"}`)
+	if code != http.StatusOK || data["signal"] != "SIGTERM" || data["run_id"] != runID {
+		t.Fatalf("unexpected signal response %d: %#v", code, data)
+	}
+	select {
+	case err := <-exited:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Sys().(syscall.WaitStatus).Signal() != syscall.SIGTERM {
+			t.Fatalf("expected job terminated by SIGTERM, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not signaled")
+	}
+
+	events, err := store.ListRunEvents(runID, 0, 0)
+	if err != nil {
+		t.Fatalf("ListRunEvents failed: %v", err)
+	}
+	var audited map[string]any
+	for _, event := range events {
+		if event.EventType == runEventPaneSignal {
+			audited = event.Payload
+		}
+	}
+	if audited == nil || audited["scope"] != "tree" || audited["source"] != "api" || audited["signal"] != "SIGTERM" {
+		t.Fatalf("expected signal audited on the run, got %#v", events)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build darwin || linux
+
+package localapi
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+var paneSignalNumbers = map[string]syscall.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGSTOP": syscall.SIGSTOP,
+	"SIGCONT": syscall.SIGCONT,
+}
+
+// signalPaneProcess sends signal to pid, or to the process group -pid when pid is negative.
+func signalPaneProcess(pid int, signal string) error {
+	sig, ok := paneSignalNumbers[signal]
+	if !ok {
+		return fmt.Errorf("unsupported signal: %s", signal)
+	}
+	err := syscall.Kill(pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return errPaneProcessGone
+	}
+	return err
+}
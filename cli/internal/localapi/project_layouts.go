@@ -0,0 +1,362 @@
+package localapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"shellman/cli/internal/projectstate"
+	"shellman/cli/internal/tmux"
+)
+
+// paneLayoutService is implemented by pane services that can read a pane's window layout and apply one.
+type paneLayoutService interface {
+	PaneService
+	PaneLayout(target string) (tmux.PaneLayoutInfo, error)
+	SelectLayout(target, layout string) error
+}
+
+type restoredLayoutPane struct {
+	TaskID string `json:"task_id"`
+	PaneID string `json:"pane_id"`
+	Cwd    string `json:"cwd"`
+	Bound  bool   `json:"bound"`
+}
+
+type restoredLayoutWindow struct {
+	Layout        string               `json:"layout"`
+	LayoutApplied bool                 `json:"layout_applied"`
+	Panes         []restoredLayoutPane `json:"panes"`
+}
+
+func (s *Server) handleProjectLayoutRoutes(w http.ResponseWriter, r *http.Request, projectID string, parts []string) bool {
+	if len(parts) < 2 || parts[1] != "layouts" {
+		return false
+	}
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.handleListProjectLayouts(w, projectID)
+	case len(parts) == 2 && r.Method == http.MethodPost:
+		s.handleSaveProjectLayout(w, r, projectID)
+	case len(parts) == 3 && r.Method == http.MethodGet:
+		s.handleGetProjectLayout(w, projectID, parts[2])
+	case len(parts) == 3 && r.Method == http.MethodDelete:
+		s.handleDeleteProjectLayout(w, projectID, parts[2])
+	case len(parts) == 4 && parts[3] == "restore" && r.Method == http.MethodPost:
+		s.handleRestoreProjectLayout(w, projectID, parts[2])
+	case len(parts) <= 3 || (len(parts) == 4 && parts[3] == "restore"):
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+	default:
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "route not found")
+	}
+	return true
+}
+
+func (s *Server) handleListProjectLayouts(w http.ResponseWriter, projectID string) {
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	layouts, err := projectstate.NewStore(repoRoot).ListProjectLayouts(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "PROJECT_LAYOUTS_LOAD_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{"project_id": projectID, "layouts": layouts})
+}
+
+func (s *Server) handleGetProjectLayout(w http.ResponseWriter, projectID, name string) {
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	layout, found, err := projectstate.NewStore(repoRoot).GetProjectLayout(projectID, name)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "PROJECT_LAYOUTS_LOAD_FAILED", err.Error())
+		return
+	}
+	if !found {
+		respondError(w, http.StatusNotFound, "PROJECT_LAYOUT_NOT_FOUND", projectstate.ErrProjectLayoutNotFound.Error())
+		return
+	}
+	respondOK(w, map[string]any{"project_id": projectID, "layout": layout})
+}
+
+func (s *Server) handleDeleteProjectLayout(w http.ResponseWriter, projectID, name string) {
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	if err := projectstate.NewStore(repoRoot).DeleteProjectLayout(projectID, name); err != nil {
+		if errors.Is(err, projectstate.ErrProjectLayoutNotFound) {
+			respondError(w, http.StatusNotFound, "PROJECT_LAYOUT_NOT_FOUND", err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "PROJECT_LAYOUT_DELETE_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{"project_id": projectID, "name": strings.TrimSpace(name)})
+}
+
+// handleSaveProjectLayout captures the windows holding the project's task panes, with each window's layout and the
+// task and cwd of every pane, and saves them under the requested name.
+func (s *Server) handleSaveProjectLayout(w http.ResponseWriter, r *http.Request, projectID string) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	name, err := projectstate.NormalizeProjectLayoutName(req.Name)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PROJECT_LAYOUT", err.Error())
+		return
+	}
+	svc, ok := s.paneServiceFor(projectID).(paneLayoutService)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "PANE_SERVICE_UNAVAILABLE", "pane service does not support layouts")
+		return
+	}
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	store := projectstate.NewStore(repoRoot)
+	windows, err := captureProjectLayout(store, projectID, svc)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "PROJECT_LAYOUT_CAPTURE_FAILED", err.Error())
+		return
+	}
+	if len(windows) == 0 {
+		respondError(w, http.StatusConflict, "PROJECT_LAYOUT_EMPTY", "project has no live task panes")
+		return
+	}
+	layout, err := store.SaveProjectLayout(projectID, projectstate.ProjectLayoutRecord{Name: name, Windows: windows})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "PROJECT_LAYOUT_SAVE_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{"project_id": projectID, "layout": layout})
+}
+
+// captureProjectLayout groups the live panes of projectID's tasks by window. Windows are ordered by session and
+// window index, panes by pane index, which is the order their layout string lists them in.
+func captureProjectLayout(store *projectstate.Store, projectID string, svc paneLayoutService) ([]projectstate.LayoutWindow, error) {
+	tasks, err := store.ListTasksByProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	panes, err := store.LoadPanes()
+	if err != nil {
+		return nil, err
+	}
+	type capturedPane struct {
+		taskID string
+		info   tmux.PaneLayoutInfo
+	}
+	byWindow := map[string][]capturedPane{}
+	for _, task := range tasks {
+		binding, ok := panes[task.TaskID]
+		if !ok {
+			continue
+		}
+		target := strings.TrimSpace(binding.PaneTarget)
+		if target == "" {
+			target = strings.TrimSpace(binding.PaneID)
+		}
+		if target == "" {
+			continue
+		}
+		info, err := svc.PaneLayout(target)
+		if err != nil {
+			// The pane is gone; the layout is saved from what is left.
+			continue
+		}
+		byWindow[info.WindowID] = append(byWindow[info.WindowID], capturedPane{taskID: task.TaskID, info: info})
+	}
+	windowIDs := make([]string, 0, len(byWindow))
+	for windowID, items := range byWindow {
+		sort.Slice(items, func(i, j int) bool { return items[i].info.PaneIndex < items[j].info.PaneIndex })
+		windowIDs = append(windowIDs, windowID)
+	}
+	sort.Slice(windowIDs, func(i, j int) bool {
+		a, b := byWindow[windowIDs[i]][0].info, byWindow[windowIDs[j]][0].info
+		if a.SessionName != b.SessionName {
+			return a.SessionName < b.SessionName
+		}
+		return a.WindowIndex < b.WindowIndex
+	})
+	out := make([]projectstate.LayoutWindow, 0, len(windowIDs))
+	for _, windowID := range windowIDs {
+		items := byWindow[windowID]
+		window := projectstate.LayoutWindow{Layout: items[0].info.WindowLayout, Panes: make([]projectstate.LayoutPane, 0, len(items))}
+		for _, item := range items {
+			window.Panes = append(window.Panes, projectstate.LayoutPane{TaskID: item.taskID, Cwd: item.info.CurrentPath})
+		}
+		out = append(out, window)
+	}
+	return out, nil
+}
+
+// handleRestoreProjectLayout rebuilds the windows of a saved layout: one new window per saved window, split into as
+// many panes as it had, each opened in its recorded cwd, then the saved layout is applied and every pane is bound
+// to its task again (together with the task's active run).
+func (s *Server) handleRestoreProjectLayout(w http.ResponseWriter, projectID, name string) {
+	svc, ok := s.paneServiceFor(projectID).(paneLayoutService)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "PANE_SERVICE_UNAVAILABLE", "pane service does not support layouts")
+		return
+	}
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	store := projectstate.NewStore(repoRoot)
+	layout, found, err := store.GetProjectLayout(projectID, name)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "PROJECT_LAYOUTS_LOAD_FAILED", err.Error())
+		return
+	}
+	if !found {
+		respondError(w, http.StatusNotFound, "PROJECT_LAYOUT_NOT_FOUND", projectstate.ErrProjectLayoutNotFound.Error())
+		return
+	}
+	tasks, err := store.ListTasksByProject(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TASKS_LOAD_FAILED", err.Error())
+		return
+	}
+	taskExists := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		taskExists[task.TaskID] = true
+	}
+
+	windows := make([]restoredLayoutWindow, 0, len(layout.Windows))
+	bindings := map[string]string{}
+	for _, saved := range layout.Windows {
+		window, err := s.restoreLayoutWindow(saved, repoRoot, svc)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "PANE_CREATE_FAILED", err.Error())
+			return
+		}
+		for i, pane := range window.Panes {
+			if taskExists[pane.TaskID] {
+				bindings[pane.TaskID] = pane.PaneID
+				window.Panes[i].Bound = true
+			}
+		}
+		windows = append(windows, window)
+	}
+	if err := s.rebindRestoredLayoutPanes(store, projectID, bindings); err != nil {
+		respondError(w, http.StatusInternalServerError, "PANES_SAVE_FAILED", err.Error())
+		return
+	}
+	s.publishEvent("task.tree.updated", projectID, "", map[string]any{})
+	respondOK(w, map[string]any{"project_id": projectID, "name": layout.Name, "windows": windows})
+}
+
+// restoreLayoutWindow opens a new window for saved and splits it pane by pane. Each split follows the pane created
+// last, so the panes end up in their saved index order. A layout tmux rejects, e.g. one that held panes of no task,
+// leaves the window tiled instead.
+func (s *Server) restoreLayoutWindow(saved projectstate.LayoutWindow, repoRoot string, svc paneLayoutService) (restoredLayoutWindow, error) {
+	window := restoredLayoutWindow{Layout: saved.Layout, Panes: make([]restoredLayoutPane, 0, len(saved.Panes))}
+	last := ""
+	for _, pane := range saved.Panes {
+		cwd := strings.TrimSpace(pane.Cwd)
+		if info, err := os.Stat(cwd); cwd == "" || err != nil || !info.IsDir() {
+			cwd = repoRoot
+		}
+		var (
+			paneID string
+			err    error
+		)
+		if last == "" {
+			paneID, err = svc.CreateRootPaneInDir(cwd)
+		} else {
+			paneID, err = svc.CreateSiblingPaneInDir(last, cwd)
+			if err == nil {
+				// Keep room for the next split; the saved layout replaces this at the end.
+				_ = svc.SelectLayout(paneID, "tiled")
+			}
+		}
+		if err != nil {
+			return window, err
+		}
+		last = paneID
+		window.Panes = append(window.Panes, restoredLayoutPane{TaskID: pane.TaskID, PaneID: paneID, Cwd: cwd})
+	}
+	if last == "" {
+		return window, nil
+	}
+	if err := svc.SelectLayout(last, saved.Layout); err != nil {
+		slog.Warn("project layout apply failed", "pane_target", last, "err", err)
+		_ = svc.SelectLayout(last, "tiled")
+	} else {
+		window.LayoutApplied = true
+	}
+	return window, nil
+}
+
+// rebindRestoredLayoutPanes points each task in bindings (task id to pane id) at its restored pane, along with the
+// task's active run or the one waiting for a rebind.
+func (s *Server) rebindRestoredLayoutPanes(store *projectstate.Store, projectID string, bindings map[string]string) error {
+	if len(bindings) == 0 {
+		return nil
+	}
+	panes, err := store.LoadPanes()
+	if err != nil {
+		return err
+	}
+	for taskID, paneID := range bindings {
+		panes[taskID] = projectstate.PaneBinding{
+			TaskID:             taskID,
+			PaneUUID:           uuid.NewString(),
+			PaneID:             paneID,
+			PaneTarget:         paneID,
+			ShellReadyRequired: true,
+		}
+	}
+	if err := store.SavePanes(panes); err != nil {
+		return err
+	}
+	runs, err := store.ListRecoverableRunsByProject(projectID)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		paneID, ok := bindings[run.TaskID]
+		if !ok {
+			continue
+		}
+		if err := store.UpsertRunBinding(projectstate.RunBinding{
+			RunID:            run.RunID,
+			ServerInstanceID: detectServerInstanceID(),
+			PaneID:           paneID,
+			PaneTarget:       paneID,
+			BindingStatus:    projectstate.BindingStatusLive,
+		}); err != nil {
+			return err
+		}
+		if run.RunStatus == projectstate.RunStatusNeedsRebind {
+			if err := store.SetRunStatus(run.RunID, projectstate.RunStatusRunning); err != nil {
+				return err
+			}
+		}
+		payload := map[string]any{"pane_target": paneID, "previous_pane_target": run.PaneTarget, "source": "layout.restore"}
+		_ = store.AppendRunEvent(run.RunID, "run.rebound", payload)
+		s.publishEvent("run.rebound", projectID, run.TaskID, payload)
+	}
+	return nil
+}
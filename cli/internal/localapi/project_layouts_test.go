@@ -0,0 +1,161 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+	"shellman/cli/internal/tmux"
+)
+
+type layoutPaneService struct {
+	fakePaneService
+	layouts  map[string]tmux.PaneLayoutInfo
+	nextPane int
+	created  []string
+	selected []string
+}
+
+func (f *layoutPaneService) newPane(cwd string) string {
+	f.nextPane++
+	paneID := fmt.Sprintf("%%%d", 10+f.nextPane)
+	f.created = append(f.created, paneID+"@"+cwd)
+	return paneID
+}
+
+func (f *layoutPaneService) CreateRootPaneInDir(cwd string) (string, error) {
+	return f.newPane(cwd), nil
+}
+
+func (f *layoutPaneService) CreateSiblingPaneInDir(_ string, cwd string) (string, error) {
+	return f.newPane(cwd), nil
+}
+
+func (f *layoutPaneService) PaneLayout(target string) (tmux.PaneLayoutInfo, error) {
+	info, ok := f.layouts[target]
+	if !ok {
+		return tmux.PaneLayoutInfo{}, fmt.Errorf("can't find pane: %s", target)
+	}
+	return info, nil
+}
+
+func (f *layoutPaneService) SelectLayout(target, layout string) error {
+	f.selected = append(f.selected, target+" "+layout)
+	return nil
+}
+
+func TestProjectLayouts_SaveAndRestore(t *testing.T) {
+	repo := t.TempDir()
+	workDir := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	const windowLayout = "b25d,80x24,0,0{40x24,0,0,1,39x24,41,0,2}"
+	panes := &layoutPaneService{layouts: map[string]tmux.PaneLayoutInfo{
+		"%2": {SessionName: "shellman", WindowID: "@1", WindowLayout: windowLayout, PaneIndex: 1, CurrentPath: "/gone"},
+		"%1": {SessionName: "shellman", WindowID: "@1", WindowLayout: windowLayout, PaneIndex: 0, CurrentPath: workDir},
+	}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	editorTask := createTestTask(t, ts.URL, "editor", nil)
+	serverTask := createTestTask(t, ts.URL, "server", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.SavePanes(projectstate.PanesIndex{
+		editorTask: {TaskID: editorTask, PaneID: "%1", PaneTarget: "%1"},
+		serverTask: {TaskID: serverTask, PaneID: "%2", PaneTarget: "%2"},
+	}); err != nil {
+		t.Fatalf("SavePanes failed: %v", err)
+	}
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_layout_1", TaskID: serverTask, RunStatus: projectstate.RunStatusNeedsRebind}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+
+	post := func(path, body string) *http.Response {
+		t.Helper()
+		resp, err := http.Post(ts.URL+path, "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", path, err)
+		}
+		return resp
+	}
+
+	resp := post("/api/v1/projects/p1/layouts", `{"name":"bad name"}`)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid name, got %d", resp.StatusCode)
+	}
+
+	resp = post("/api/v1/projects/p1/layouts", `{"name":"dev"}`)
+	var saved struct {
+		Data struct {
+			Layout projectstate.ProjectLayoutRecord `json:"layout"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&saved); err != nil {
+		t.Fatalf("decode save response failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	windows := saved.Data.Layout.Windows
+	if resp.StatusCode != http.StatusOK || len(windows) != 1 || windows[0].Layout != windowLayout || len(windows[0].Panes) != 2 {
+		t.Fatalf("unexpected saved layout (%d): %#v", resp.StatusCode, saved.Data.Layout)
+	}
+	if windows[0].Panes[0].TaskID != editorTask || windows[0].Panes[1].TaskID != serverTask {
+		t.Fatalf("expected panes in pane index order, got %#v", windows[0].Panes)
+	}
+
+	resp = post("/api/v1/projects/p1/layouts/dev/restore", `{}`)
+	var restored struct {
+		Data struct {
+			Windows []restoredLayoutWindow `json:"windows"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&restored); err != nil {
+		t.Fatalf("decode restore response failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(restored.Data.Windows) != 1 || !restored.Data.Windows[0].LayoutApplied {
+		t.Fatalf("unexpected restore response (%d): %#v", resp.StatusCode, restored.Data)
+	}
+	if len(panes.created) != 2 || panes.created[0] != "%11@"+workDir || panes.created[1] != "%12@"+filepath.Clean(repo) {
+		t.Fatalf("expected panes recreated in their cwds, got %v", panes.created)
+	}
+	if last := panes.selected[len(panes.selected)-1]; last != "%12 "+windowLayout {
+		t.Fatalf("expected saved layout applied last, got %v", panes.selected)
+	}
+	taskPanes, err := store.LoadPanes()
+	if err != nil {
+		t.Fatalf("LoadPanes failed: %v", err)
+	}
+	if taskPanes[editorTask].PaneTarget != "%11" || taskPanes[serverTask].PaneTarget != "%12" {
+		t.Fatalf("expected tasks rebound to restored panes, got %#v", taskPanes)
+	}
+	binding, ok, err := store.GetBindingByRunID("r_layout_1")
+	if err != nil || !ok || binding.PaneTarget != "%12" {
+		t.Fatalf("expected run rebound to restored pane, got %#v ok=%v err=%v", binding, ok, err)
+	}
+	run, err := store.GetRun("r_layout_1")
+	if err != nil || run.RunStatus != projectstate.RunStatusRunning {
+		t.Fatalf("expected run running again, got %#v err=%v", run, err)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/api/v1/projects/p1/layouts/dev", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE layout failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 on delete, got %d", resp.StatusCode)
+	}
+	resp = post("/api/v1/projects/p1/layouts/dev/restore", `{}`)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 restoring a deleted layout, got %d", resp.StatusCode)
+	}
+}
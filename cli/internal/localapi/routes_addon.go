@@ -959,7 +959,12 @@ func (s *Server) handlePostTaskCommit(w http.ResponseWriter, r *http.Request, ta
 }
 
 func runGitOutput(repoRoot string, args ...string) (string, error) {
-	cmd := exec.Command("git", append([]string{"-C", repoRoot}, args...)...)
+	return runGitOutputContext(context.Background(), repoRoot, args...)
+}
+
+// runGitOutputContext is runGitOutput for callers that must not wait on git forever; git is killed when ctx ends.
+func runGitOutputContext(ctx context.Context, repoRoot string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoRoot}, args...)...)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", err
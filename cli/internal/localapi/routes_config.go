@@ -27,8 +27,22 @@ type configResponse struct {
 	TaskCompletionCommand      string                       `json:"task_completion_command"`
 	TaskCompletionIdleDuration int                          `json:"task_completion_idle_duration_seconds"`
 	TaskCompletion             taskCompletionConfigResponse `json:"task_completion"`
+	TaskArchive                global.TaskArchiveConfig     `json:"task_archive"`
+	RunWatchdog                global.RunWatchdogConfig     `json:"run_watchdog"`
+	PaneRecovery               global.PaneRecoveryConfig    `json:"pane_recovery"`
+	RunRecording               global.RunRecordingConfig    `json:"run_recording"`
 	HelperOpenAI               helperOpenAIResponse         `json:"helper_openai"`
 	AgentOpenAI                agentOpenAIResponse          `json:"agent_openai"`
+	Webhooks                   []webhookConfigResponse      `json:"webhooks"`
+}
+
+type webhookConfigResponse struct {
+	Name           string   `json:"name"`
+	URL            string   `json:"url"`
+	SecretSet      bool     `json:"secret_set"`
+	Events         []string `json:"events"`
+	TimeoutSeconds int      `json:"timeout_seconds"`
+	MaxAttempts    int      `json:"max_attempts"`
 }
 
 type taskCompletionConfigResponse struct {
@@ -42,6 +56,21 @@ func buildConfigResponse(cfg global.GlobalConfig, helper helperOpenAIResponse, a
 	if cfg.TaskCompletion.NotifyEnabled && strings.TrimSpace(cfg.TaskCompletion.NotifyCommand) != "" {
 		mode = "command"
 	}
+	webhooks := make([]webhookConfigResponse, 0, len(cfg.Webhooks))
+	for _, item := range cfg.Webhooks {
+		events := item.Events
+		if events == nil {
+			events = []string{}
+		}
+		webhooks = append(webhooks, webhookConfigResponse{
+			Name:           item.Name,
+			URL:            item.URL,
+			SecretSet:      strings.TrimSpace(item.Secret) != "",
+			Events:         events,
+			TimeoutSeconds: item.TimeoutSeconds,
+			MaxAttempts:    item.MaxAttempts,
+		})
+	}
 	return configResponse{
 		LocalPort:                  cfg.LocalPort,
 		Defaults:                   cfg.Defaults,
@@ -53,8 +82,13 @@ func buildConfigResponse(cfg global.GlobalConfig, helper helperOpenAIResponse, a
 			NotifyCommand:      cfg.TaskCompletion.NotifyCommand,
 			NotifyIdleDuration: cfg.TaskCompletion.NotifyIdleDuration,
 		},
+		TaskArchive:  cfg.TaskArchive,
+		RunWatchdog:  cfg.RunWatchdog,
+		PaneRecovery: cfg.PaneRecovery,
+		RunRecording: cfg.RunRecording,
 		HelperOpenAI: helper,
 		AgentOpenAI:  agent,
+		Webhooks:     webhooks,
 	}, nil
 }
 
@@ -114,6 +148,24 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 				NotifyCommand      *string `json:"notify_command"`
 				NotifyIdleDuration *int    `json:"notify_idle_duration_seconds"`
 			} `json:"task_completion"`
+			TaskArchive *struct {
+				RetentionDays *int `json:"retention_days"`
+			} `json:"task_archive"`
+			RunWatchdog *struct {
+				SilenceSeconds        *int            `json:"silence_seconds"`
+				AdapterSilenceSeconds *map[string]int `json:"adapter_silence_seconds"`
+				Flag                  *string         `json:"flag"`
+				Interrupt             *bool           `json:"interrupt"`
+			} `json:"run_watchdog"`
+			PaneRecovery *struct {
+				Enabled *bool `json:"enabled"`
+			} `json:"pane_recovery"`
+			RunRecording *struct {
+				Enabled       *bool            `json:"enabled"`
+				Projects      *map[string]bool `json:"projects"`
+				MaxRecordings *int             `json:"max_recordings"`
+				RetentionDays *int             `json:"retention_days"`
+			} `json:"run_recording"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
@@ -170,6 +222,62 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 				cfg.TaskCompletion.NotifyIdleDuration = *req.TaskCompletion.NotifyIdleDuration
 			}
 		}
+		if req.TaskArchive != nil && req.TaskArchive.RetentionDays != nil {
+			if *req.TaskArchive.RetentionDays < 0 {
+				respondError(w, http.StatusBadRequest, "INVALID_RETENTION_DAYS", "retention_days must be >= 0")
+				return
+			}
+			cfg.TaskArchive.RetentionDays = *req.TaskArchive.RetentionDays
+		}
+		if req.RunWatchdog != nil {
+			if req.RunWatchdog.SilenceSeconds != nil {
+				if *req.RunWatchdog.SilenceSeconds < 0 {
+					respondError(w, http.StatusBadRequest, "INVALID_SILENCE_SECONDS", "silence_seconds must be >= 0")
+					return
+				}
+				cfg.RunWatchdog.SilenceSeconds = *req.RunWatchdog.SilenceSeconds
+			}
+			if req.RunWatchdog.AdapterSilenceSeconds != nil {
+				for _, seconds := range *req.RunWatchdog.AdapterSilenceSeconds {
+					if seconds < 0 {
+						respondError(w, http.StatusBadRequest, "INVALID_SILENCE_SECONDS", "adapter_silence_seconds must be >= 0")
+						return
+					}
+				}
+				cfg.RunWatchdog.AdapterSilenceSeconds = *req.RunWatchdog.AdapterSilenceSeconds
+			}
+			if req.RunWatchdog.Flag != nil {
+				cfg.RunWatchdog.Flag = *req.RunWatchdog.Flag
+			}
+			if req.RunWatchdog.Interrupt != nil {
+				cfg.RunWatchdog.Interrupt = *req.RunWatchdog.Interrupt
+			}
+		}
+		if req.PaneRecovery != nil && req.PaneRecovery.Enabled != nil {
+			cfg.PaneRecovery.Enabled = *req.PaneRecovery.Enabled
+		}
+		if req.RunRecording != nil {
+			if req.RunRecording.Enabled != nil {
+				cfg.RunRecording.Enabled = *req.RunRecording.Enabled
+			}
+			if req.RunRecording.Projects != nil {
+				cfg.RunRecording.Projects = *req.RunRecording.Projects
+			}
+			if req.RunRecording.MaxRecordings != nil {
+				if *req.RunRecording.MaxRecordings < 0 {
+					respondError(w, http.StatusBadRequest, "INVALID_MAX_RECORDINGS", "max_recordings must be >= 0")
+					return
+				}
+				cfg.RunRecording.MaxRecordings = *req.RunRecording.MaxRecordings
+			}
+			if req.RunRecording.RetentionDays != nil {
+				if *req.RunRecording.RetentionDays < 0 {
+					respondError(w, http.StatusBadRequest, "INVALID_RETENTION_DAYS", "retention_days must be >= 0")
+					return
+				}
+				cfg.RunRecording.RetentionDays = *req.RunRecording.RetentionDays
+			}
+		}
 		if err := s.deps.ConfigStore.Save(cfg); err != nil {
 			respondError(w, http.StatusInternalServerError, "CONFIG_SAVE_FAILED", err.Error())
 			return
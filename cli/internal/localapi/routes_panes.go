@@ -47,7 +47,7 @@ func (s *Server) createRunAndLiveBinding(store *projectstate.Store, taskID, pane
 }
 
 func (s *Server) persistTaskCurrentCommand(store *projectstate.Store, taskID, projectID, paneTarget string) error {
-	currentCommand := strings.TrimSpace(s.detectPaneCurrentCommand(paneTarget))
+	currentCommand := strings.TrimSpace(s.detectPaneCurrentCommand(projectID, paneTarget))
 	if currentCommand == "" {
 		return nil
 	}
@@ -87,7 +87,7 @@ func (s *Server) handleProjectRootPaneCreate(w http.ResponseWriter, r *http.Requ
 		respondError(w, http.StatusInternalServerError, "TASK_CREATE_FAILED", err.Error())
 		return
 	}
-	paneID, err := s.deps.PaneService.CreateRootPaneInDir(repoRoot)
+	paneID, err := s.paneServiceFor(projectID).CreateRootPaneInDir(repoRoot)
 	if err != nil {
 		_ = s.rollbackTaskCreation(projectID, newTaskID)
 		respondError(w, http.StatusInternalServerError, "PANE_CREATE_FAILED", err.Error())
@@ -115,7 +115,7 @@ func (s *Server) handleProjectRootPaneCreate(w http.ResponseWriter, r *http.Requ
 		respondError(w, http.StatusInternalServerError, "PANES_SAVE_FAILED", err.Error())
 		return
 	}
-	if err := s.updateTaskStatusInternal(store, newTaskID, projectID, projectstate.StatusRunning); err != nil {
+	if err := s.updateTaskStatusInternal(store, newTaskID, projectID, projectstate.StatusRunning, projectstate.StatusTriggerRun); err != nil {
 		_ = s.rollbackTaskCreation(projectID, newTaskID)
 		respondError(w, http.StatusInternalServerError, "STATUS_UPDATE_FAILED", err.Error())
 		return
@@ -217,9 +217,9 @@ func (s *Server) handlePaneCreate(w http.ResponseWriter, r *http.Request, taskID
 
 	paneID := ""
 	if relation == "sibling" {
-		paneID, err = s.deps.PaneService.CreateSiblingPaneInDir(target, repoRoot)
+		paneID, err = s.paneServiceFor(projectID).CreateSiblingPaneInDir(target, repoRoot)
 	} else {
-		paneID, err = s.deps.PaneService.CreateChildPaneInDir(target, repoRoot)
+		paneID, err = s.paneServiceFor(projectID).CreateChildPaneInDir(target, repoRoot)
 	}
 	if err != nil {
 		_ = s.rollbackTaskCreation(projectID, newTaskID)
@@ -241,7 +241,7 @@ func (s *Server) handlePaneCreate(w http.ResponseWriter, r *http.Request, taskID
 		respondError(w, http.StatusInternalServerError, "PANES_SAVE_FAILED", err.Error())
 		return
 	}
-	if err := s.updateTaskStatusInternal(store, newTaskID, projectID, projectstate.StatusRunning); err != nil {
+	if err := s.updateTaskStatusInternal(store, newTaskID, projectID, projectstate.StatusRunning, projectstate.StatusTriggerRun); err != nil {
 		_ = s.rollbackTaskCreation(projectID, newTaskID)
 		respondError(w, http.StatusInternalServerError, "STATUS_UPDATE_FAILED", err.Error())
 		return
@@ -441,7 +441,7 @@ func (s *Server) handleGetTaskPane(w http.ResponseWriter, _ *http.Request, taskI
 		}
 	}
 	if currentCommand == "" {
-		currentCommand = strings.TrimSpace(s.detectPaneCurrentCommand(binding.PaneTarget))
+		currentCommand = strings.TrimSpace(s.detectPaneCurrentCommand(projectID, binding.PaneTarget))
 	}
 	respondOK(w, map[string]any{
 		"project_id":      projectID,
@@ -500,7 +500,7 @@ func (s *Server) handleGetTaskPaneHistory(w http.ResponseWriter, r *http.Request
 		return
 	}
 	lines := parsePaneHistoryLines(r.URL.Query().Get("lines"))
-	snapshot, err := s.deps.PaneService.CaptureHistory(target, lines)
+	snapshot, err := s.paneServiceFor(projectID).CaptureHistory(target, lines)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "PANE_HISTORY_CAPTURE_FAILED", err.Error())
 		return
@@ -528,11 +528,16 @@ func (s *Server) handlePaneManualLaunch(w http.ResponseWriter, _ *http.Request,
 		respondError(w, http.StatusInternalServerError, "PANE_SERVICE_UNAVAILABLE", "pane service is not configured")
 		return
 	}
-	projectID, store, _, err := s.findTask(taskID)
+	projectID, store, entry, err := s.findTask(taskID)
 	if err != nil {
 		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
 		return
 	}
+	// A manual launch opens a login shell without a run, so it is a plain update.
+	if err := projectstate.ValidateStatusTransition(entry.Status, projectstate.StatusRunning, projectstate.StatusTriggerUpdate); err != nil {
+		respondTaskStatusError(w, err, "STATUS_UPDATE_FAILED")
+		return
+	}
 	repoRoot, err := s.findProjectRepoRoot(projectID)
 	if err != nil {
 		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
@@ -548,10 +553,10 @@ func (s *Server) handlePaneManualLaunch(w http.ResponseWriter, _ *http.Request,
 
 	paneID := ""
 	if target != "" {
-		paneID, err = s.deps.PaneService.CreateSiblingPaneInDirLoginShell(target, repoRoot)
+		paneID, err = s.paneServiceFor(projectID).CreateSiblingPaneInDirLoginShell(target, repoRoot)
 	}
 	if paneID == "" || err != nil {
-		paneID, err = s.deps.PaneService.CreateRootPaneInDirLoginShell(repoRoot)
+		paneID, err = s.paneServiceFor(projectID).CreateRootPaneInDirLoginShell(repoRoot)
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, "PANE_CREATE_FAILED", err.Error())
 			return
@@ -571,8 +576,8 @@ func (s *Server) handlePaneManualLaunch(w http.ResponseWriter, _ *http.Request,
 		respondError(w, http.StatusInternalServerError, "PANES_SAVE_FAILED", err.Error())
 		return
 	}
-	if err := s.updateTaskStatusInternal(store, taskID, projectID, projectstate.StatusRunning); err != nil {
-		respondError(w, http.StatusInternalServerError, "STATUS_UPDATE_FAILED", err.Error())
+	if err := s.updateTaskStatusInternal(store, taskID, projectID, projectstate.StatusRunning, projectstate.StatusTriggerUpdate); err != nil {
+		respondTaskStatusError(w, err, "STATUS_UPDATE_FAILED")
 		return
 	}
 	if err := s.persistTaskCurrentCommand(store, taskID, projectID, paneID); err != nil {
@@ -612,32 +617,5 @@ func (s *Server) rollbackTaskCreation(projectID, taskID string) error {
 	if err := store.DeleteTask(taskID); err != nil {
 		return err
 	}
-	rows, err := store.ListTasksByProject(projectID)
-	if err != nil {
-		return err
-	}
-	parentTaskID := strings.TrimSpace(entry.ParentTaskID)
-	if parentTaskID != "" {
-		hasChildren := false
-		parentStatus := ""
-		for _, row := range rows {
-			if row.TaskID == parentTaskID {
-				parentStatus = row.Status
-			}
-			if strings.TrimSpace(row.ParentTaskID) == parentTaskID {
-				hasChildren = true
-			}
-		}
-		if !hasChildren && parentStatus == projectstate.StatusWaitingChildren {
-			nextStatus := projectstate.StatusPending
-			if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{
-				TaskID:    parentTaskID,
-				ProjectID: projectID,
-				Status:    &nextStatus,
-			}); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+	return s.syncParentWaitingChildren(store, projectID, entry.ParentTaskID)
 }
@@ -18,6 +18,12 @@ import (
 var projectsActiveWriteInflight atomic.Int64
 var projectsActiveWriteSeq atomic.Int64
 
+// projectTmuxSocketSetter is implemented by projects stores that can move a project to another tmux socket, including
+// back to the default one.
+type projectTmuxSocketSetter interface {
+	SetProjectTmuxSocket(projectID, socket string) error
+}
+
 func (s *Server) registerProjectsRoutes() {
 	s.mux.HandleFunc("/api/v1/projects/active", s.handleProjectsActive)
 	s.mux.HandleFunc("/api/v1/projects/active/", s.handleProjectsActiveByID)
@@ -38,6 +44,7 @@ func (s *Server) handleProjectsActive(w http.ResponseWriter, r *http.Request) {
 			IsGitRepo   bool   `json:"is_git_repo"`
 			SortOrder   int64  `json:"sort_order"`
 			Collapsed   bool   `json:"collapsed"`
+			TmuxSocket  string `json:"tmux_socket,omitempty"`
 		}
 		payload := make([]projectPayload, 0, len(projects))
 		for _, p := range projects {
@@ -52,6 +59,7 @@ func (s *Server) handleProjectsActive(w http.ResponseWriter, r *http.Request) {
 				IsGitRepo:   isGitWorkTree(p.RepoRoot),
 				SortOrder:   p.SortOrder,
 				Collapsed:   p.Collapsed,
+				TmuxSocket:  p.TmuxSocket,
 			})
 		}
 		respondOK(w, payload)
@@ -69,6 +77,10 @@ func (s *Server) handleProjectsActive(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "INVALID_PROJECT_ROOT", err.Error())
 			return
 		}
+		if !validateTmuxSocketName(req.TmuxSocket) {
+			respondError(w, http.StatusBadRequest, "INVALID_TMUX_SOCKET", "tmux_socket must be letters, digits, '.', '-' or '_'")
+			return
+		}
 		source := strings.TrimSpace(r.Header.Get("X-Shellman-Gateway-Source"))
 		sortOrder := optionalSortOrder(req.SortOrder)
 		seq, startedAt := logProjectsActiveWriteStart(http.MethodPost, req.ProjectID, source, sortOrder, &req.Collapsed)
@@ -110,6 +122,7 @@ func (s *Server) handleProjectsActive(w http.ResponseWriter, r *http.Request) {
 			"is_git_repo":  isGitWorkTree(req.RepoRoot),
 			"sort_order":   saved.SortOrder,
 			"collapsed":    saved.Collapsed,
+			"tmux_socket":  saved.TmuxSocket,
 		})
 	default:
 		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
@@ -153,12 +166,13 @@ func (s *Server) handleProjectsActiveByID(w http.ResponseWriter, r *http.Request
 			DisplayName *string `json:"display_name"`
 			SortOrder   *int64  `json:"sort_order"`
 			Collapsed   *bool   `json:"collapsed"`
+			TmuxSocket  *string `json:"tmux_socket"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
 			return
 		}
-		if req.DisplayName == nil && req.SortOrder == nil && req.Collapsed == nil {
+		if req.DisplayName == nil && req.SortOrder == nil && req.Collapsed == nil && req.TmuxSocket == nil {
 			respondError(w, http.StatusBadRequest, "INVALID_PROJECT_PATCH", "at least one field is required")
 			return
 		}
@@ -197,6 +211,21 @@ func (s *Server) handleProjectsActiveByID(w http.ResponseWriter, r *http.Request
 		if req.Collapsed != nil {
 			next.Collapsed = *req.Collapsed
 		}
+		var socketSetter projectTmuxSocketSetter
+		if req.TmuxSocket != nil {
+			socket := strings.TrimSpace(*req.TmuxSocket)
+			if !validateTmuxSocketName(socket) {
+				respondError(w, http.StatusBadRequest, "INVALID_TMUX_SOCKET", "tmux_socket must be letters, digits, '.', '-' or '_'")
+				return
+			}
+			setter, ok := s.deps.ProjectsStore.(projectTmuxSocketSetter)
+			if !ok {
+				respondError(w, http.StatusInternalServerError, "PROJECT_TMUX_SOCKET_UNSUPPORTED", "projects store cannot change tmux sockets")
+				return
+			}
+			socketSetter = setter
+			next.TmuxSocket = socket
+		}
 
 		source := strings.TrimSpace(r.Header.Get("X-Shellman-Gateway-Source"))
 		seq, startedAt := logProjectsActiveWriteStart(http.MethodPatch, projectID, source, req.SortOrder, req.Collapsed)
@@ -216,11 +245,19 @@ func (s *Server) handleProjectsActiveByID(w http.ResponseWriter, r *http.Request
 			respondError(w, http.StatusInternalServerError, "PROJECT_RENAME_FAILED", err.Error())
 			return
 		}
+		if socketSetter != nil {
+			if err := socketSetter.SetProjectTmuxSocket(next.ProjectID, next.TmuxSocket); err != nil {
+				opErr = err
+				respondError(w, http.StatusInternalServerError, "PROJECT_TMUX_SOCKET_FAILED", err.Error())
+				return
+			}
+		}
 		respondOK(w, map[string]any{
 			"project_id":   next.ProjectID,
 			"display_name": next.DisplayName,
 			"sort_order":   next.SortOrder,
 			"collapsed":    next.Collapsed,
+			"tmux_socket":  next.TmuxSocket,
 		})
 	default:
 		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/google/uuid"
 
+	"shellman/cli/internal/global"
 	"shellman/cli/internal/projectstate"
 )
 
@@ -75,6 +77,14 @@ func (s *Server) handleRunActions(w http.ResponseWriter, r *http.Request) {
 	}
 	runID := strings.TrimSpace(parts[0])
 
+	if len(parts) == 1 && runID == "compare" {
+		if r.Method != http.MethodGet {
+			respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+		s.handleCompareRuns(w, r)
+		return
+	}
 	if len(parts) == 1 && r.Method == http.MethodGet {
 		respondOK(w, map[string]any{
 			"run_id": runID,
@@ -82,12 +92,31 @@ func (s *Server) handleRunActions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if len(parts) == 2 && parts[1] == "events" && r.Method == http.MethodGet {
-		respondOK(w, map[string]any{
-			"run_id": runID,
-			"items":  []any{},
-		})
+		s.handleListRunEvents(w, r, runID)
 		return
 	}
+	if (len(parts) == 2 || len(parts) == 3) && parts[1] == "artifacts" && r.Method == http.MethodGet {
+		name := ""
+		if len(parts) == 3 {
+			name = strings.TrimSpace(parts[2])
+		}
+		s.handleGetRunArtifacts(w, runID, name)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "recording.cast" && r.Method == http.MethodGet {
+		s.handleGetRunRecording(w, runID)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "actions" {
+		switch r.Method {
+		case http.MethodGet:
+			s.handleRunActionList(w, runID)
+			return
+		case http.MethodPost:
+			s.handleRunActionRetry(w, r, runID)
+			return
+		}
+	}
 	if len(parts) == 2 && r.Method == http.MethodPost {
 		switch parts[1] {
 		case "bind-pane":
@@ -101,6 +130,53 @@ func (s *Server) handleRunActions(w http.ResponseWriter, r *http.Request) {
 	respondError(w, http.StatusNotFound, "NOT_FOUND", "route not found")
 }
 
+func (s *Server) handleRunActionList(w http.ResponseWriter, runID string) {
+	if _, _, _, err := s.findRun(runID); err != nil {
+		respondError(w, http.StatusNotFound, "RUN_NOT_FOUND", err.Error())
+		return
+	}
+	items, err := runActionStore().ListRunActions(runID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "RUN_ACTIONS_LOAD_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{
+		"run_id": runID,
+		"items":  items,
+	})
+}
+
+func (s *Server) handleRunActionRetry(w http.ResponseWriter, r *http.Request, runID string) {
+	var req struct {
+		ActionIDs []int64 `json:"action_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err.Error() != "EOF" {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	projectID, _, run, err := s.findRun(runID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "RUN_NOT_FOUND", err.Error())
+		return
+	}
+	retried, err := runActionStore().RetryRunActions(runID, req.ActionIDs)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "RUN_ACTIONS_RETRY_FAILED", err.Error())
+		return
+	}
+	if retried > 0 {
+		s.kickRunActionDispatcher()
+		s.publishEvent("run.actions.updated", projectID, run.TaskID, map[string]any{
+			"run_id":  runID,
+			"retried": retried,
+		})
+	}
+	respondOK(w, map[string]any{
+		"run_id":  runID,
+		"retried": retried,
+	})
+}
+
 func (s *Server) handleRunBindPane(w http.ResponseWriter, r *http.Request, runID string) {
 	var req struct {
 		PaneID     string `json:"pane_id"`
@@ -325,13 +401,13 @@ func (s *Server) findLiveRunningRunByPaneTargetForTask(store *projectstate.Store
 		if strings.TrimSpace(item.TaskID) == strings.TrimSpace(taskID) {
 			diag.CandidateTaskMatch++
 		}
-		if item.RunStatus == projectstate.RunStatusRunning {
+		if projectstate.IsRunActive(item.RunStatus) {
 			diag.CandidateRunning++
 		}
 		if item.BindingStatus == projectstate.BindingStatusLive {
 			diag.CandidateLive++
 		}
-		if item.RunStatus == projectstate.RunStatusRunning && item.BindingStatus == projectstate.BindingStatusLive {
+		if projectstate.IsRunActive(item.RunStatus) && item.BindingStatus == projectstate.BindingStatusLive {
 			diag.CandidateLiveRunning++
 		}
 		if strings.TrimSpace(item.ServerInstanceID) != "" && strings.TrimSpace(currentServerInstanceID) != "" {
@@ -356,7 +432,7 @@ func (s *Server) findLiveRunningRunByPaneTargetForTask(store *projectstate.Store
 		if strings.TrimSpace(item.TaskID) != strings.TrimSpace(taskID) {
 			continue
 		}
-		if item.RunStatus != projectstate.RunStatusRunning || item.BindingStatus != projectstate.BindingStatusLive {
+		if !projectstate.IsRunActive(item.RunStatus) || item.BindingStatus != projectstate.BindingStatusLive {
 			continue
 		}
 		run, err := store.GetRun(item.RunID)
@@ -383,17 +459,35 @@ func (s *Server) invalidateBindingIfServerChanged(store *projectstate.Store, run
 	if strings.TrimSpace(currentServer) == "" || binding.ServerInstanceID == currentServer {
 		return false, nil
 	}
-	if err := store.MarkBindingsStaleByServer(binding.ServerInstanceID, "tmux_restarted"); err != nil {
-		return false, err
-	}
-	if err := store.SetRunStatus(runID, projectstate.RunStatusNeedsRebind); err != nil {
+	runIDs, err := store.MarkBindingsStaleByServer(binding.ServerInstanceID, "tmux_restarted")
+	if err != nil {
 		return false, err
 	}
-	if err := store.AppendRunEvent(runID, "tmux_restarted", map[string]any{
-		"expected_server_instance_id": binding.ServerInstanceID,
-		"current_server_instance_id":  currentServer,
-	}); err != nil {
-		return false, err
+	if !slices.Contains(runIDs, runID) {
+		// The run looked up is not active, but its binding is gone all the same.
+		if err := store.SetRunStatus(runID, projectstate.RunStatusNeedsRebind); err != nil {
+			return false, err
+		}
+		runIDs = append(runIDs, runID)
+	}
+	// Every run of the old server lost its pane, not only the one looked up; tell subscribers about each.
+	for _, id := range runIDs {
+		if err := store.AppendRunEvent(id, "tmux_restarted", map[string]any{
+			"expected_server_instance_id": binding.ServerInstanceID,
+			"current_server_instance_id":  currentServer,
+		}); err != nil {
+			return false, err
+		}
+		if projectID, _, run, err := s.findRun(id); err == nil {
+			s.notifyWebhooks(webhookPayload{
+				Event:     global.WebhookEventRunRebind,
+				ProjectID: projectID,
+				TaskID:    run.TaskID,
+				RunID:     id,
+				Status:    projectstate.RunStatusNeedsRebind,
+				Source:    "tmux_restarted",
+			})
+		}
 	}
 	return true, nil
 }
@@ -16,7 +16,10 @@ import (
 func TestRunBinding_BecomesStaleWhenServerInstanceChanges(t *testing.T) {
 	repo := t.TempDir()
 	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
-	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	cfgStore := &mutableConfigStore{cfg: global.GlobalConfig{LocalPort: 4621, Webhooks: []global.WebhookConfig{
+		{Name: "rebind", URL: "http://127.0.0.1:1/rebind", Events: []string{global.WebhookEventRunRebind}, TimeoutSeconds: 1, MaxAttempts: 1},
+	}}}
+	srv := NewServer(Deps{ConfigStore: cfgStore, ProjectsStore: projects})
 	ts := httptest.NewServer(srv.Handler())
 	defer ts.Close()
 
@@ -48,6 +51,21 @@ func TestRunBinding_BecomesStaleWhenServerInstanceChanges(t *testing.T) {
 		t.Fatalf("UpsertRunBinding failed: %v", err)
 	}
 
+	// Another run on the same old server loses its pane too.
+	otherRunID := "r_srv_mismatch_other"
+	if err := store.InsertRun(projectstate.RunRecord{RunID: otherRunID, TaskID: createOut.Data.TaskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{
+		RunID:            otherRunID,
+		ServerInstanceID: "srv_old",
+		PaneID:           "e2e:0.2",
+		PaneTarget:       "e2e:0.2",
+		BindingStatus:    projectstate.BindingStatusLive,
+	}); err != nil {
+		t.Fatalf("UpsertRunBinding failed: %v", err)
+	}
+
 	old := os.Getenv("SHELLMAN_SERVER_INSTANCE_ID")
 	defer func() { _ = os.Setenv("SHELLMAN_SERVER_INSTANCE_ID", old) }()
 	if err := os.Setenv("SHELLMAN_SERVER_INSTANCE_ID", "srv_new"); err != nil {
@@ -92,11 +110,27 @@ func TestRunBinding_BecomesStaleWhenServerInstanceChanges(t *testing.T) {
 		t.Fatalf("expected run status needs_rebind, got %q", run.RunStatus)
 	}
 
-	eventCount, err := store.CountRunEventsByType(runID, "tmux_restarted")
-	if err != nil {
-		t.Fatalf("CountRunEventsByType failed: %v", err)
-	}
-	if eventCount == 0 {
-		t.Fatal("expected tmux_restarted run event")
+	for _, id := range []string{runID, otherRunID} {
+		run, err := store.GetRun(id)
+		if err != nil {
+			t.Fatalf("GetRun failed: %v", err)
+		}
+		if run.RunStatus != projectstate.RunStatusNeedsRebind {
+			t.Fatalf("expected run %s needs_rebind, got %q", id, run.RunStatus)
+		}
+		eventCount, err := store.CountRunEventsByType(id, "tmux_restarted")
+		if err != nil {
+			t.Fatalf("CountRunEventsByType failed: %v", err)
+		}
+		if eventCount != 1 {
+			t.Fatalf("expected one tmux_restarted event for %s, got %d", id, eventCount)
+		}
+		actions, err := store.ListRunActions(id)
+		if err != nil {
+			t.Fatalf("ListRunActions failed: %v", err)
+		}
+		if len(actions) != 1 || actions[0].ActionType != runActionWebhookDelivery {
+			t.Fatalf("expected a queued run.rebind webhook for %s, got %#v", id, actions)
+		}
 	}
 }
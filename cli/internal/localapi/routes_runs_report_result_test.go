@@ -93,12 +93,8 @@ func TestRunAutoCompleteByPane_FirstAndSecondBothTrigger(t *testing.T) {
 	if run.RunStatus != projectstate.RunStatusCompleted {
 		t.Fatalf("expected completed run, got %q", run.RunStatus)
 	}
-	count, err := store.CountOutboxByRunID(runID)
-	if err != nil {
-		t.Fatalf("CountOutboxByRunID failed: %v", err)
-	}
-	if count != 1 {
-		t.Fatalf("expected one outbox row, got %d", count)
+	if items := runActionsOfType(t, store, runID, runActionCompletionDispatch); len(items) != 1 {
+		t.Fatalf("expected one completion outbox row, got %d", len(items))
 	}
 }
 
@@ -153,6 +153,8 @@ func TestRunRoutes_AreRegistered(t *testing.T) {
 		{method: http.MethodPost, path: "/api/v1/runs/" + runID + "/resume", body: `{}`},
 		{method: http.MethodGet, path: "/api/v1/runs/" + runID, body: ""},
 		{method: http.MethodGet, path: "/api/v1/runs/" + runID + "/events", body: ""},
+		{method: http.MethodGet, path: "/api/v1/tasks/" + created.Data.TaskID + "/runs", body: ""},
+		{method: http.MethodGet, path: "/api/v1/runs/compare?a=" + runID + "&b=" + runID, body: ""},
 	}
 
 	for _, tc := range cases {
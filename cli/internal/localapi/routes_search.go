@@ -0,0 +1,97 @@
+package localapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"shellman/cli/internal/projectstate"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type searchResultHit struct {
+	projectstate.SearchHit
+	Link string `json:"link,omitempty"`
+}
+
+func (s *Server) registerSearchRoutes() {
+	s.mux.HandleFunc("/api/v1/search", s.handleSearch)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_QUERY", "q is required")
+		return
+	}
+	kinds := make([]string, 0, 4)
+	for _, raw := range strings.Split(r.URL.Query().Get("kind"), ",") {
+		kind := strings.TrimSpace(raw)
+		if kind == "" {
+			continue
+		}
+		if !projectstate.IsSearchKind(kind) {
+			respondError(w, http.StatusBadRequest, "INVALID_KIND", "unsupported kind: "+kind)
+			return
+		}
+		kinds = append(kinds, kind)
+	}
+	limit := defaultSearchLimit
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			respondError(w, http.StatusBadRequest, "INVALID_LIMIT", "limit must be a positive integer")
+			return
+		}
+		limit = min(parsed, maxSearchLimit)
+	}
+
+	projectID := strings.TrimSpace(r.URL.Query().Get("project_id"))
+	projects, err := s.deps.ProjectsStore.ListProjects()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "PROJECTS_LOAD_FAILED", err.Error())
+		return
+	}
+	hits := make([]searchResultHit, 0)
+	matchedProject := false
+	for _, project := range projects {
+		if projectID != "" && project.ProjectID != projectID {
+			continue
+		}
+		matchedProject = true
+		store := projectstate.NewStore(project.RepoRoot)
+		items, err := store.Search(project.ProjectID, query, kinds, limit)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "SEARCH_FAILED", err.Error())
+			return
+		}
+		for _, item := range items {
+			hit := searchResultHit{SearchHit: item}
+			if item.TaskID != "" {
+				hit.Link = "/sess/" + item.TaskID
+			}
+			hits = append(hits, hit)
+		}
+	}
+	if projectID != "" && !matchedProject {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", "project not found")
+		return
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	respondOK(w, map[string]any{
+		"query": query,
+		"hits":  hits,
+	})
+}
@@ -0,0 +1,71 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"shellman/cli/internal/global"
+)
+
+func TestSearchRoute_ReturnsRankedHitsWithTaskLinks(t *testing.T) {
+	projects := &memProjectsStore{projects: []global.ActiveProject{
+		{ProjectID: "p1", RepoRoot: filepath.Clean(t.TempDir())},
+		{ProjectID: "p2", RepoRoot: filepath.Clean(t.TempDir())},
+	}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	for _, body := range []string{
+		`{"project_id":"p1","title":"fix the migration bug"}`,
+		`{"project_id":"p2","title":"write migration docs"}`,
+	} {
+		resp, err := http.Post(ts.URL+"/api/v1/tasks", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("POST tasks failed: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	var out struct {
+		Data struct {
+			Hits []searchResultHit `json:"hits"`
+		} `json:"data"`
+	}
+	resp, err := http.Get(ts.URL + "/api/v1/search?q=migr&project_id=p1&kind=task")
+	if err != nil {
+		t.Fatalf("GET search failed: %v", err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode search failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if len(out.Data.Hits) != 1 || out.Data.Hits[0].ProjectID != "p1" || out.Data.Hits[0].Link != "/sess/"+out.Data.Hits[0].TaskID {
+		t.Fatalf("unexpected scoped hits: %#v", out.Data.Hits)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/search?q=migration")
+	if err != nil {
+		t.Fatalf("GET search failed: %v", err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode search failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if len(out.Data.Hits) != 2 {
+		t.Fatalf("expected hits across projects, got %#v", out.Data.Hits)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/search?q=migration&kind=runs")
+	if err != nil {
+		t.Fatalf("GET search failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown kind, got %d", resp.StatusCode)
+	}
+}
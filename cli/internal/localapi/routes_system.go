@@ -17,6 +17,7 @@ func (s *Server) registerSystemRoutes() {
 	s.mux.HandleFunc("/api/v1/system/app-programs", s.handleSystemAppPrograms)
 	s.mux.HandleFunc("/api/v1/system/select-directory", s.handleSelectDirectory)
 	s.mux.HandleFunc("/api/v1/system/uploads/image", s.handleSystemImageUpload)
+	s.mux.HandleFunc("/api/v1/system/tmux-servers", s.handleSystemTmuxServers)
 }
 
 func (s *Server) handleSystemCapabilities(w http.ResponseWriter, r *http.Request) {
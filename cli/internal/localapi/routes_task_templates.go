@@ -0,0 +1,405 @@
+package localapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"shellman/cli/internal/projectstate"
+)
+
+type instantiatedTemplateTask struct {
+	TaskID       string `json:"task_id"`
+	ParentTaskID string `json:"parent_task_id,omitempty"`
+	Title        string `json:"title"`
+	TaskRole     string `json:"task_role"`
+	SidecarMode  string `json:"sidecar_mode"`
+	Command      string `json:"command,omitempty"`
+	PaneTarget   string `json:"pane_target,omitempty"`
+	RunID        string `json:"run_id,omitempty"`
+}
+
+func (s *Server) handleProjectTemplateRoutes(w http.ResponseWriter, r *http.Request, projectID string, parts []string) bool {
+	if len(parts) < 2 || strings.TrimSpace(parts[1]) != "templates" {
+		return false
+	}
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.handleListTaskTemplates(w, projectID)
+	case len(parts) == 3 && r.Method == http.MethodGet:
+		s.handleGetTaskTemplate(w, projectID, parts[2])
+	case len(parts) == 4 && parts[3] == "instantiate" && r.Method == http.MethodPost:
+		s.handleInstantiateTaskTemplate(w, r, projectID, parts[2])
+	case len(parts) <= 4:
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+	default:
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "route not found")
+	}
+	return true
+}
+
+func (s *Server) handleListTaskTemplates(w http.ResponseWriter, projectID string) {
+	if _, err := s.findProjectRepoRoot(projectID); err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	templates, err := s.loadTaskTemplates(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TEMPLATE_LOAD_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{"project_id": projectID, "templates": templates})
+}
+
+func (s *Server) handleGetTaskTemplate(w http.ResponseWriter, projectID, name string) {
+	tpl, ok := s.findTaskTemplateOrRespond(w, projectID, name)
+	if !ok {
+		return
+	}
+	respondOK(w, tpl)
+}
+
+func (s *Server) findTaskTemplateOrRespond(w http.ResponseWriter, projectID, name string) (TaskTemplate, bool) {
+	if _, err := s.findProjectRepoRoot(projectID); err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return TaskTemplate{}, false
+	}
+	templates, err := s.loadTaskTemplates(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TEMPLATE_LOAD_FAILED", err.Error())
+		return TaskTemplate{}, false
+	}
+	name = strings.TrimSpace(name)
+	for _, tpl := range templates {
+		if tpl.Name == name {
+			return tpl, true
+		}
+	}
+	respondError(w, http.StatusNotFound, "TEMPLATE_NOT_FOUND", "template not found: "+name)
+	return TaskTemplate{}, false
+}
+
+func (s *Server) handleInstantiateTaskTemplate(w http.ResponseWriter, r *http.Request, projectID, name string) {
+	var req struct {
+		Variables    map[string]string `json:"variables"`
+		ParentTaskID string            `json:"parent_task_id"`
+		Launch       bool              `json:"launch"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	tpl, ok := s.findTaskTemplateOrRespond(w, projectID, name)
+	if !ok {
+		return
+	}
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	store := projectstate.NewStore(repoRoot)
+
+	parentTaskID := strings.TrimSpace(req.ParentTaskID)
+	parentRole := ""
+	if parentTaskID != "" {
+		parent, found, err := findTaskEntryInProject(store, projectID, parentTaskID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "TASK_LOAD_FAILED", err.Error())
+			return
+		}
+		if !found {
+			respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", "parent task not found in project")
+			return
+		}
+		parentRole = normalizeTaskRole(parent.TaskRole)
+	}
+
+	variables := map[string]string{"project_id": projectID, "parent_task_id": parentTaskID}
+	for key, value := range req.Variables {
+		variables[key] = value
+	}
+	nodes, err := renderTaskTemplate(tpl, variables)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "TEMPLATE_VARIABLES_INVALID", err.Error())
+		return
+	}
+	if err := normalizeTemplateNodes(nodes, parentRole, "tasks"); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_TEMPLATE", err.Error())
+		return
+	}
+	if req.Launch && templateNodesHaveCommand(nodes) && s.deps.PaneService == nil {
+		respondError(w, http.StatusInternalServerError, "PANE_SERVICE_UNAVAILABLE", "pane service is not configured")
+		return
+	}
+
+	inst := &templateInstantiation{
+		server:    s,
+		projectID: projectID,
+		repoRoot:  repoRoot,
+		store:     store,
+		launch:    req.Launch,
+	}
+	parentTarget := ""
+	if parentTaskID != "" && req.Launch {
+		if panes, err := store.LoadPanes(); err == nil {
+			parentTarget = strings.TrimSpace(panes[parentTaskID].PaneTarget)
+		}
+	}
+	if err := inst.createNodes(nodes, parentTaskID, parentTarget); err != nil {
+		inst.rollback()
+		switch {
+		case errors.Is(err, errExecutorCannotDelegate):
+			respondError(w, http.StatusBadRequest, "EXECUTOR_CANNOT_DELEGATE", err.Error())
+		case errors.Is(err, errPlannerOnlySpawnExecutor):
+			respondError(w, http.StatusBadRequest, "PLANNER_ONLY_SPAWN_EXECUTOR", err.Error())
+		default:
+			respondError(w, http.StatusInternalServerError, "TEMPLATE_INSTANTIATE_FAILED", err.Error())
+		}
+		return
+	}
+
+	rootTaskIDs := make([]string, 0, len(nodes))
+	for _, item := range inst.created {
+		s.publishEvent("task.created", projectID, item.TaskID, map[string]any{"template": tpl.Name})
+		if item.ParentTaskID == parentTaskID {
+			rootTaskIDs = append(rootTaskIDs, item.TaskID)
+		}
+	}
+	s.publishEvent("task.template.instantiated", projectID, rootTaskIDs[0], map[string]any{
+		"template":      tpl.Name,
+		"root_task_ids": rootTaskIDs,
+		"task_count":    len(inst.created),
+	})
+	s.publishEvent("task.tree.updated", projectID, rootTaskIDs[0], map[string]any{})
+	respondOK(w, map[string]any{
+		"project_id":    projectID,
+		"template":      tpl.Name,
+		"root_task_ids": rootTaskIDs,
+		"tasks":         inst.created,
+	})
+}
+
+// normalizeTemplateNodes validates rendered nodes in place, defaulting roles so children of planners are executors.
+func normalizeTemplateNodes(nodes []TaskTemplateNode, parentRole, path string) error {
+	for idx := range nodes {
+		node := &nodes[idx]
+		itemPath := fmt.Sprintf("%s[%d]", path, idx)
+		if node.Title == "" {
+			return fmt.Errorf("%s: title is empty after substitution", itemPath)
+		}
+		if len(node.Title) > 256 {
+			return fmt.Errorf("%s: title is too long", itemPath)
+		}
+		if strings.TrimSpace(node.Role) == "" && parentRole == projectstate.TaskRolePlanner {
+			node.Role = projectstate.TaskRoleExecutor
+		}
+		role := normalizeTaskRole(node.Role)
+		if role == "" {
+			return fmt.Errorf("%s: %w", itemPath, errInvalidTaskRole)
+		}
+		node.Role = role
+		if parentRole != "" {
+			if err := validateTaskParentRole(parentRole, role); err != nil {
+				return fmt.Errorf("%s: %w", itemPath, err)
+			}
+		}
+		if strings.TrimSpace(node.SidecarMode) != "" {
+			if !validSidecarMode(node.SidecarMode) {
+				return fmt.Errorf("%s: invalid sidecar_mode %q", itemPath, node.SidecarMode)
+			}
+			node.SidecarMode = normalizeSidecarMode(node.SidecarMode)
+		}
+		if err := normalizeTemplateNodes(node.Children, role, itemPath+".children"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func templateNodesHaveCommand(nodes []TaskTemplateNode) bool {
+	for _, node := range nodes {
+		if node.Command != "" || templateNodesHaveCommand(node.Children) {
+			return true
+		}
+	}
+	return false
+}
+
+type templateInstantiation struct {
+	server    *Server
+	projectID string
+	repoRoot  string
+	store     *projectstate.Store
+	launch    bool
+	// spawnAll gives every node a pane when launching, not only nodes with a command.
+	spawnAll bool
+	// relation is reported in pane.created events; it defaults to "template".
+	relation string
+	created  []instantiatedTemplateTask
+	panes    []string
+}
+
+func (inst *templateInstantiation) createNodes(nodes []TaskTemplateNode, parentTaskID, parentTarget string) error {
+	for _, node := range nodes {
+		taskID, err := inst.server.createTaskWithRole(inst.projectID, parentTaskID, node.Title, node.Role)
+		if err != nil {
+			return err
+		}
+		item := instantiatedTemplateTask{
+			TaskID:       taskID,
+			ParentTaskID: parentTaskID,
+			Title:        node.Title,
+			TaskRole:     node.Role,
+			Command:      node.Command,
+		}
+		inst.created = append(inst.created, item)
+		meta := projectstate.TaskMetaUpsert{TaskID: taskID, ProjectID: inst.projectID}
+		if node.Description != "" {
+			description := node.Description
+			meta.Description = &description
+		}
+		if node.SidecarMode != "" {
+			mode := node.SidecarMode
+			meta.SidecarMode = &mode
+		}
+		if meta.Description != nil || meta.SidecarMode != nil {
+			if err := inst.store.UpsertTaskMeta(meta); err != nil {
+				return err
+			}
+		}
+		entry, _, err := findTaskEntryInProject(inst.store, inst.projectID, taskID)
+		if err != nil {
+			return err
+		}
+		item.SidecarMode = normalizeSidecarMode(entry.SidecarMode)
+
+		paneTarget := ""
+		if inst.launch && (node.Command != "" || inst.spawnAll) {
+			paneTarget, item.RunID, err = inst.spawnPane(taskID, parentTarget, node.Command)
+			if err != nil {
+				return err
+			}
+			item.PaneTarget = paneTarget
+		}
+		inst.created[len(inst.created)-1] = item
+		if err := inst.createNodes(node.Children, taskID, paneTarget); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (inst *templateInstantiation) spawnPane(taskID, parentTarget, command string) (string, string, error) {
+	s := inst.server
+	var paneID string
+	var err error
+	if parentTarget != "" {
+		paneID, err = s.paneServiceFor(inst.projectID).CreateChildPaneInDir(parentTarget, inst.repoRoot)
+	} else {
+		paneID, err = s.paneServiceFor(inst.projectID).CreateRootPaneInDir(inst.repoRoot)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	inst.panes = append(inst.panes, paneID)
+	panes, err := inst.store.LoadPanes()
+	if err != nil {
+		return "", "", err
+	}
+	paneUUID := uuid.NewString()
+	panes[taskID] = projectstate.PaneBinding{
+		TaskID:             taskID,
+		PaneUUID:           paneUUID,
+		PaneID:             paneID,
+		PaneTarget:         paneID,
+		ShellReadyRequired: true,
+		ShellReadyAcked:    false,
+	}
+	if err := inst.store.SavePanes(panes); err != nil {
+		return "", "", err
+	}
+	if err := s.updateTaskStatusInternal(inst.store, taskID, inst.projectID, projectstate.StatusRunning, projectstate.StatusTriggerRun); err != nil {
+		return "", "", err
+	}
+	runID, err := s.createRunAndLiveBinding(inst.store, taskID, paneID, paneID)
+	if err != nil {
+		return "", "", err
+	}
+	s.publishEvent("task.status.updated", inst.projectID, taskID, map[string]any{"status": projectstate.StatusRunning})
+	relation := inst.relation
+	if relation == "" {
+		relation = "template"
+	}
+	s.publishEvent("pane.created", inst.projectID, taskID, map[string]any{
+		"relation":    relation,
+		"run_id":      runID,
+		"pane_uuid":   paneUUID,
+		"pane_id":     paneID,
+		"pane_target": paneID,
+	})
+	if command != "" {
+		go s.sendPaneLaunchCommand(inst.projectID, taskID, paneID, command, "template.launch")
+	}
+	return paneID, runID, nil
+}
+
+// rollback removes everything created so far, children first.
+func (inst *templateInstantiation) rollback() {
+	for idx := len(inst.panes) - 1; idx >= 0; idx-- {
+		_ = inst.server.paneServiceFor(inst.projectID).ClosePane(inst.panes[idx])
+	}
+	for idx := len(inst.created) - 1; idx >= 0; idx-- {
+		_ = inst.server.rollbackTaskCreation(inst.projectID, inst.created[idx].TaskID)
+	}
+}
+
+// sendPaneLaunchCommand types command into a freshly spawned pane once its shell reports ready, auditing the
+// outcome under stage.
+func (s *Server) sendPaneLaunchCommand(projectID, taskID, paneTarget, command, stage string) {
+	if s.deps.TaskPromptSender == nil {
+		s.writeTaskCompletionAuditLog(projectID, taskID, stage+".skipped", map[string]any{
+			"pane_target": paneTarget,
+			"reason":      "task prompt sender is unavailable",
+		})
+		return
+	}
+	ready, err := s.waitPaneShellReady(context.Background(), s.projectTmuxSocket(projectID), paneTarget)
+	if err != nil || !ready {
+		reason := "shell not ready"
+		if err != nil {
+			reason = err.Error()
+		}
+		s.writeTaskCompletionAuditLog(projectID, taskID, stage+".skipped", map[string]any{
+			"pane_target": paneTarget,
+			"reason":      reason,
+		})
+		return
+	}
+	if _, store, _, err := s.findTask(taskID); err == nil {
+		if panes, err := store.LoadPanes(); err == nil {
+			if binding, ok := panes[taskID]; ok && binding.PaneTarget == paneTarget {
+				binding.ShellReadyAcked = true
+				panes[taskID] = binding
+				_ = store.SavePanes(panes)
+			}
+		}
+	}
+	if err := s.promptSenderFor(projectID).SendInput(paneTarget, command+"\r"); err != nil {
+		s.writeTaskCompletionAuditLog(projectID, taskID, stage+".error", map[string]any{
+			"pane_target": paneTarget,
+			"error":       err.Error(),
+		})
+		return
+	}
+	s.writeTaskCompletionAuditLog(projectID, taskID, stage+".sent", map[string]any{
+		"pane_target": paneTarget,
+		"command":     command,
+	})
+}
@@ -0,0 +1,103 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func TestInstantiateTaskTemplate_CreatesSubtree(t *testing.T) {
+	t.Setenv("SHELLMAN_CONFIG_DIR", t.TempDir())
+	repo := t.TempDir()
+	templateDir := filepath.Join(repo, ".shellman", "templates")
+	if err := os.MkdirAll(templateDir, 0o755); err != nil {
+		t.Fatalf("mkdir templates failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "feature.yaml"), []byte(featureTemplateYAML), 0o644); err != nil {
+		t.Fatalf("write template failed: %v", err)
+	}
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/v1/projects/p1/templates")
+	if err != nil {
+		t.Fatalf("GET templates failed: %v", err)
+	}
+	var listed struct {
+		Data struct {
+			Templates []TaskTemplate `json:"templates"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode templates failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if len(listed.Data.Templates) != 1 || listed.Data.Templates[0].Name != "feature" {
+		t.Fatalf("unexpected template list: %#v", listed.Data.Templates)
+	}
+
+	resp, err = http.Post(ts.URL+"/api/v1/projects/p1/templates/feature/instantiate", "application/json", bytes.NewBufferString(`{}`))
+	if err != nil {
+		t.Fatalf("POST instantiate failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for missing variable, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post(ts.URL+"/api/v1/projects/p1/templates/feature/instantiate", "application/json", bytes.NewBufferString(`{"variables":{"feature":"login"}}`))
+	if err != nil {
+		t.Fatalf("POST instantiate failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from instantiate, got %d", resp.StatusCode)
+	}
+	var out struct {
+		Data struct {
+			RootTaskIDs []string                   `json:"root_task_ids"`
+			Tasks       []instantiatedTemplateTask `json:"tasks"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode instantiate failed: %v", err)
+	}
+	if len(out.Data.RootTaskIDs) != 1 || len(out.Data.Tasks) != 3 {
+		t.Fatalf("unexpected instantiate result: %#v", out.Data)
+	}
+
+	store := projectstate.NewStore(filepath.Clean(repo))
+	rows, err := store.ListTasksByProject("p1")
+	if err != nil {
+		t.Fatalf("ListTasksByProject failed: %v", err)
+	}
+	byTitle := map[string]projectstate.TaskRecordRow{}
+	for _, row := range rows {
+		byTitle[row.Title] = row
+	}
+	root, ok := byTitle["Plan login"]
+	if !ok || root.TaskRole != projectstate.TaskRolePlanner || root.SidecarMode != projectstate.SidecarModeAutopilot {
+		t.Fatalf("unexpected planner row: %#v", root)
+	}
+	if root.Description != "Split login into steps.\nBase branch: main" {
+		t.Fatalf("unexpected planner description: %q", root.Description)
+	}
+	for _, title := range []string{"Implement login", "Test login"} {
+		child, ok := byTitle[title]
+		if !ok || child.ParentTaskID != root.TaskID || child.TaskRole != projectstate.TaskRoleExecutor {
+			t.Fatalf("unexpected child %q: %#v", title, child)
+		}
+		if child.SidecarMode != projectstate.SidecarModeAutopilot {
+			t.Fatalf("expected child %q to inherit sidecar mode, got %q", title, child.SidecarMode)
+		}
+	}
+}
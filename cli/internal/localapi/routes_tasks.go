@@ -28,6 +28,7 @@ var validTaskStatus = map[string]struct{}{
 func (s *Server) registerTaskRoutes() {
 	s.mux.HandleFunc("/api/v1/projects/", s.handleProjectTree)
 	s.mux.HandleFunc("/api/v1/tasks", s.handleCreateTask)
+	s.mux.HandleFunc("/api/v1/tasks/batch", s.handleBatchTasks)
 	s.mux.HandleFunc("/api/v1/tasks/", s.handleTaskActions)
 }
 
@@ -37,6 +38,18 @@ func (s *Server) handleProjectTree(w http.ResponseWriter, r *http.Request) {
 		if s.handleProjectManagerRoutes(w, r, parts[0], parts) {
 			return
 		}
+		if s.handleProjectTemplateRoutes(w, r, parts[0], parts) {
+			return
+		}
+		if s.handleProjectArchiveRoutes(w, r, parts[0], parts) {
+			return
+		}
+		if s.handleProjectFlagRoutes(w, r, parts[0], parts) {
+			return
+		}
+		if s.handleProjectLayoutRoutes(w, r, parts[0], parts) {
+			return
+		}
 	}
 	if len(parts) == 3 && parts[0] != "" && parts[1] == "panes" && parts[2] == "root" {
 		if r.Method != http.MethodPost {
@@ -63,6 +76,11 @@ func (s *Server) handleProjectTree(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	projectID := parts[0]
+	filter, err := parseTaskTreeFilter(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_TREE_FILTER", err.Error())
+		return
+	}
 	includeArchived := false
 	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("include_archived"))) {
 	case "1", "true", "yes":
@@ -79,9 +97,23 @@ func (s *Server) handleProjectTree(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "TREE_LOAD_FAILED", err.Error())
 		return
 	}
+	edges, err := store.ListTaskDependenciesByProject(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TREE_LOAD_FAILED", err.Error())
+		return
+	}
+	labels, err := store.ListTaskLabelsByProject(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TREE_LOAD_FAILED", err.Error())
+		return
+	}
+	nodes := applyTaskDependenciesToNodes(buildTreeNodesFromTaskRows(rows), rows, edges)
+	for i := range nodes {
+		nodes[i].Labels = labels[nodes[i].TaskID]
+	}
 	tree := projectstate.TaskTree{
 		ProjectID: projectID,
-		Nodes:     buildTreeNodesFromTaskRows(rows),
+		Nodes:     filterTaskTreeNodes(nodes, rows, labels, filter),
 	}
 	respondOK(w, tree)
 }
@@ -162,11 +194,15 @@ func (s *Server) archiveCheckedTasksByProject(store *projectstate.Store, project
 	}
 
 	archiveTaskIDs := make([]string, 0, len(rows))
+	openBlockers := map[string]struct{}{}
 	for _, row := range rows {
 		if !row.Checked || row.Archived {
 			continue
 		}
 		archiveTaskIDs = append(archiveTaskIDs, strings.TrimSpace(row.TaskID))
+		if strings.TrimSpace(row.Status) != projectstate.StatusCompleted {
+			openBlockers[strings.TrimSpace(row.TaskID)] = struct{}{}
+		}
 		binding, ok := panes[row.TaskID]
 		if !ok {
 			continue
@@ -181,7 +217,7 @@ func (s *Server) archiveCheckedTasksByProject(store *projectstate.Store, project
 			return 0, errors.New("pane service is not configured")
 		}
 		slog.Info("archive.done.close_pane_attempt", "project_id", strings.TrimSpace(projectID), "task_id", strings.TrimSpace(row.TaskID), "pane_target", target)
-		if err := s.deps.PaneService.ClosePane(target); err != nil {
+		if err := s.paneServiceFor(projectID).ClosePane(target); err != nil {
 			if isArchiveIgnorablePaneCloseError(err) {
 				slog.Warn("archive.done.close_pane_ignored", "project_id", strings.TrimSpace(projectID), "task_id", strings.TrimSpace(row.TaskID), "pane_target", target, "err", err)
 				continue
@@ -198,6 +234,9 @@ func (s *Server) archiveCheckedTasksByProject(store *projectstate.Store, project
 			if _, ok := panes[taskID]; ok {
 				delete(panes, taskID)
 				dirty = true
+				if err := s.cancelTaskRuns(store, projectID, taskID, runPaneClosedReason, projectstate.TaskRevisionSourceUser); err != nil {
+					slog.Warn("archive.done.cancel_runs_failed", "project_id", strings.TrimSpace(projectID), "task_id", taskID, "err", err)
+				}
 			}
 		}
 		if dirty {
@@ -207,7 +246,32 @@ func (s *Server) archiveCheckedTasksByProject(store *projectstate.Store, project
 		}
 	}
 
-	return store.ArchiveCheckedTasksByProject(projectID)
+	affected, err := store.ArchiveCheckedTasksByProject(projectID)
+	if err != nil {
+		return 0, err
+	}
+	s.releaseArchivedBlockers(projectID, store, openBlockers)
+	return affected, nil
+}
+
+// releaseArchivedBlockers releases the dependents of freshly archived tasks that had not completed; dependents of
+// completed blockers were already released on completion.
+func (s *Server) releaseArchivedBlockers(projectID string, store *projectstate.Store, blockerIDs map[string]struct{}) {
+	if len(blockerIDs) == 0 {
+		return
+	}
+	edges, err := store.ListTaskDependenciesByProject(projectID)
+	if err != nil {
+		slog.Warn("task.archive.release_dependents_failed", "project_id", projectID, "err", err)
+		return
+	}
+	archived := make([]projectstate.TaskDependencyRecord, 0, len(edges))
+	for _, edge := range edges {
+		if _, ok := blockerIDs[edge.BlockerTaskID]; ok {
+			archived = append(archived, edge)
+		}
+	}
+	s.releaseDependentEdges(projectID, store, archived, "blocker_archived")
 }
 
 func isTaskTerminalStatus(status string) bool {
@@ -322,6 +386,10 @@ func (s *Server) handleTaskActions(w http.ResponseWriter, r *http.Request) {
 		s.handlePostTaskCommit(w, r, taskID)
 	case r.Method == http.MethodGet && action == "pane":
 		s.handleGetTaskPane(w, r, taskID)
+	case r.Method == http.MethodGet && action == "pane/resources":
+		s.handleGetTaskPaneResources(w, r, taskID)
+	case r.Method == http.MethodPost && action == "pane/signal":
+		s.handlePostTaskPaneSignal(w, r, taskID)
 	case r.Method == http.MethodGet && action == "pane-history":
 		s.handleGetTaskPaneHistory(w, r, taskID)
 	case r.Method == http.MethodPost && action == "derive":
@@ -340,6 +408,34 @@ func (s *Server) handleTaskActions(w http.ResponseWriter, r *http.Request) {
 		s.handleGetTaskNotes(w, r, taskID)
 	case r.Method == http.MethodGet && action == "messages":
 		s.handleGetTaskMessages(w, r, taskID)
+	case r.Method == http.MethodGet && action == "labels":
+		s.handleGetTaskLabels(w, r, taskID)
+	case r.Method == http.MethodPatch && action == "labels":
+		s.handlePatchTaskLabels(w, r, taskID)
+	case r.Method == http.MethodGet && action == "dependencies":
+		s.handleGetTaskDependencies(w, r, taskID)
+	case r.Method == http.MethodPost && action == "dependencies":
+		s.handleAddTaskDependency(w, r, taskID)
+	case r.Method == http.MethodDelete && action == "dependencies":
+		s.handleRemoveTaskDependency(w, r, taskID, "")
+	case r.Method == http.MethodDelete && strings.HasPrefix(action, "dependencies/"):
+		s.handleRemoveTaskDependency(w, r, taskID, strings.TrimPrefix(action, "dependencies/"))
+	case r.Method == http.MethodGet && action == "history":
+		s.handleGetTaskHistory(w, r, taskID)
+	case r.Method == http.MethodPost && strings.HasPrefix(action, "history/") && strings.HasSuffix(action, "/restore"):
+		s.handleRestoreTaskRevision(w, r, taskID, strings.TrimSuffix(strings.TrimPrefix(action, "history/"), "/restore"))
+	case r.Method == http.MethodPost && action == "unarchive":
+		s.handleUnarchiveTask(w, r, taskID)
+	case r.Method == http.MethodPost && action == "clone":
+		s.handleCloneTask(w, r, taskID)
+	case r.Method == http.MethodPost && action == "reopen":
+		s.handleReopenTask(w, r, taskID)
+	case r.Method == http.MethodPost && action == "move":
+		s.handleMoveTask(w, r, taskID)
+	case r.Method == http.MethodGet && action == "notifications":
+		s.handleGetTaskNotifications(w, r, taskID)
+	case r.Method == http.MethodDelete && action == "notifications":
+		s.handleCancelTaskNotifications(w, r, taskID)
 	case r.Method == http.MethodGet && action == "sidecar-mode":
 		s.handleGetTaskSidecarMode(w, r, taskID)
 	case r.Method == http.MethodPatch && action == "sidecar-mode":
@@ -348,6 +444,8 @@ func (s *Server) handleTaskActions(w http.ResponseWriter, r *http.Request) {
 		s.handlePostTaskMessage(w, r, taskID)
 	case r.Method == http.MethodPost && action == "messages/stop":
 		s.handleStopTaskMessage(w, r, taskID)
+	case r.Method == http.MethodGet && action == "runs":
+		s.handleListTaskRuns(w, r, taskID)
 	case r.Method == http.MethodPost && action == "runs":
 		s.handleCreateRun(w, r, taskID)
 	case r.Method == http.MethodPost && action == "panes/sibling":
@@ -391,6 +489,23 @@ func (s *Server) handleGetTaskMessages(w http.ResponseWriter, _ *http.Request, t
 	respondOK(w, map[string]any{"task_id": taskID, "messages": items})
 }
 
+func (s *Server) handleGetTaskNotifications(w http.ResponseWriter, _ *http.Request, taskID string) {
+	if _, _, _, err := s.findTask(taskID); err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{"task_id": taskID, "pending": s.listPendingCompletionNotifications(taskID)})
+}
+
+func (s *Server) handleCancelTaskNotifications(w http.ResponseWriter, _ *http.Request, taskID string) {
+	if _, _, _, err := s.findTask(taskID); err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	canceled := s.CancelPendingCompletionNotification(taskID, "user-canceled")
+	respondOK(w, map[string]any{"task_id": taskID, "canceled": canceled})
+}
+
 func (s *Server) handleStopTaskMessage(w http.ResponseWriter, _ *http.Request, taskID string) {
 	projectID, store, _, err := s.findTask(taskID)
 	if err != nil {
@@ -460,6 +575,7 @@ func (s *Server) handlePatchTaskSidecarMode(w http.ResponseWriter, r *http.Reque
 		TaskID:      strings.TrimSpace(taskID),
 		ProjectID:   strings.TrimSpace(projectID),
 		SidecarMode: &mode,
+		Source:      projectstate.TaskRevisionSourceUser,
 	}); err != nil {
 		respondError(w, http.StatusInternalServerError, "TASK_UPDATE_FAILED", err.Error())
 		return
@@ -558,8 +674,8 @@ func (s *Server) handlePostTaskMessage(w http.ResponseWriter, r *http.Request, t
 			respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", findErr.Error())
 			return
 		}
-		if err := s.setTaskFlagInternal(store, pid, taskID, flag, statusMessage); err != nil {
-			if strings.Contains(err.Error(), "unsupported task flag") {
+		if err := s.setTaskFlagInternal(store, pid, taskID, flag, statusMessage, projectstate.TaskRevisionSourceSidecarTool); err != nil {
+			if errors.Is(err, errUnsupportedTaskFlag) {
 				respondError(w, http.StatusBadRequest, "INVALID_FLAG_KEY", err.Error())
 				return
 			}
@@ -584,7 +700,7 @@ func (s *Server) handlePostTaskMessage(w http.ResponseWriter, r *http.Request, t
 			respondError(w, http.StatusInternalServerError, "TASK_PROMPT_SENDER_UNAVAILABLE", "task prompt sender is unavailable")
 			return
 		}
-		_, store, _, findErr := s.findTask(taskID)
+		projectID, store, _, findErr := s.findTask(taskID)
 		if findErr != nil {
 			respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", findErr.Error())
 			return
@@ -608,7 +724,7 @@ func (s *Server) handlePostTaskMessage(w http.ResponseWriter, r *http.Request, t
 			return
 		}
 		if binding.ShellReadyRequired && !binding.ShellReadyAcked {
-			ready, readyErr := s.waitPaneShellReady(r.Context(), target)
+			ready, readyErr := s.waitPaneShellReady(r.Context(), s.projectTmuxSocket(projectID), target)
 			if readyErr != nil {
 				respondError(w, http.StatusInternalServerError, "TASK_INPUT_READY_CHECK_FAILED", readyErr.Error())
 				return
@@ -624,7 +740,7 @@ func (s *Server) handlePostTaskMessage(w http.ResponseWriter, r *http.Request, t
 				return
 			}
 		}
-		if err := s.deps.TaskPromptSender.SendInput(target, input); err != nil {
+		if err := s.promptSenderFor(projectID).SendInput(target, input); err != nil {
 			respondError(w, http.StatusInternalServerError, "TASK_INPUT_SEND_FAILED", err.Error())
 			return
 		}
@@ -786,7 +902,7 @@ func (s *Server) handleAdoptPane(w http.ResponseWriter, r *http.Request, parentT
 		respondError(w, http.StatusInternalServerError, "PANES_SAVE_FAILED", err.Error())
 		return
 	}
-	if err := s.updateTaskStatusInternal(store, taskID, projectID, projectstate.StatusRunning); err != nil {
+	if err := s.updateTaskStatusInternal(store, taskID, projectID, projectstate.StatusRunning, projectstate.StatusTriggerRun); err != nil {
 		_ = s.rollbackTaskCreation(projectID, taskID)
 		respondError(w, http.StatusInternalServerError, "STATUS_UPDATE_FAILED", err.Error())
 		return
@@ -864,19 +980,14 @@ func (s *Server) handleUpdateTaskStatus(w http.ResponseWriter, r *http.Request,
 		return
 	}
 	wasCompleted := entry.Status == projectstate.StatusCompleted
-	nextStatus := statusReq.Status
-	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{
-		TaskID:    taskID,
-		ProjectID: projectID,
-		Status:    &nextStatus,
-	}); err != nil {
-		respondError(w, http.StatusInternalServerError, "TASK_UPDATE_FAILED", err.Error())
+	if _, err := s.transitionTaskStatus(store, projectID, taskID, statusReq.Status, projectstate.StatusTriggerUpdate, projectstate.TaskRevisionSourceUser); err != nil {
+		respondTaskStatusError(w, err, "TASK_UPDATE_FAILED")
 		return
 	}
 	s.publishEvent("task.status.updated", projectID, taskID, map[string]any{"status": statusReq.Status})
 	s.publishEvent("task.tree.updated", projectID, taskID, map[string]any{})
 	if statusReq.Status == projectstate.StatusCompleted && !wasCompleted {
-		s.enqueueTaskCompletionActions(projectID, taskID, "", "status.patch", buildTaskCompletionRequestMeta(r))
+		s.enqueueTaskCompletionActions(projectID, taskID, "", "status.patch", nil, buildTaskCompletionRequestMeta(r))
 	}
 	respondOK(w, map[string]any{"task_id": taskID, "status": statusReq.Status})
 }
@@ -899,6 +1010,7 @@ func (s *Server) handleUpdateTaskChecked(w http.ResponseWriter, r *http.Request,
 		TaskID:    taskID,
 		ProjectID: projectID,
 		Checked:   &nextChecked,
+		Source:    projectstate.TaskRevisionSourceUser,
 	}); err != nil {
 		respondError(w, http.StatusInternalServerError, "TASK_UPDATE_FAILED", err.Error())
 		return
@@ -934,6 +1046,7 @@ func (s *Server) handleUpdateTaskTitle(w http.ResponseWriter, r *http.Request, t
 		TaskID:    taskID,
 		ProjectID: projectID,
 		Title:     &nextTitle,
+		Source:    projectstate.TaskRevisionSourceUser,
 	}); err != nil {
 		respondError(w, http.StatusInternalServerError, "TASK_UPDATE_FAILED", err.Error())
 		return
@@ -966,6 +1079,7 @@ func (s *Server) handleUpdateTaskDescription(w http.ResponseWriter, r *http.Requ
 		TaskID:      taskID,
 		ProjectID:   projectID,
 		Description: &nextDescription,
+		Source:      projectstate.TaskRevisionSourceUser,
 	}); err != nil {
 		respondError(w, http.StatusInternalServerError, "TASK_UPDATE_FAILED", err.Error())
 		return
@@ -994,6 +1108,7 @@ func (s *Server) handleUpdateTaskFlagReaded(w http.ResponseWriter, r *http.Reque
 		TaskID:     taskID,
 		ProjectID:  projectID,
 		FlagReaded: &nextFlagReaded,
+		Source:     projectstate.TaskRevisionSourceUser,
 	}); err != nil {
 		respondError(w, http.StatusInternalServerError, "TASK_UPDATE_FAILED", err.Error())
 		return
@@ -1041,15 +1156,8 @@ func (s *Server) createTaskWithRole(projectID, parentTaskID, title, requestedRol
 		if ok && validSidecarMode(parent.SidecarMode) {
 			sidecarMode = normalizeSidecarMode(parent.SidecarMode)
 		}
-		parentRole := normalizeTaskRole(parent.TaskRole)
-		if parentRole == "" {
-			parentRole = projectstate.TaskRoleFull
-		}
-		if parentRole == projectstate.TaskRoleExecutor {
-			return "", errExecutorCannotDelegate
-		}
-		if parentRole == projectstate.TaskRolePlanner && taskRole != projectstate.TaskRoleExecutor {
-			return "", errPlannerOnlySpawnExecutor
+		if err := validateTaskParentRole(parent.TaskRole, taskRole); err != nil {
+			return "", err
 		}
 	}
 	entry := projectstate.TaskRecord{
@@ -1068,21 +1176,8 @@ func (s *Server) createTaskWithRole(projectID, parentTaskID, title, requestedRol
 	if err := store.InsertTask(entry); err != nil {
 		return "", err
 	}
-	if parentTaskID != "" {
-		parent, ok, err := findTaskEntryInProject(store, projectID, parentTaskID)
-		if err != nil {
-			return "", err
-		}
-		if ok && parent.Status != projectstate.StatusWaitingChildren {
-			nextStatus := projectstate.StatusWaitingChildren
-			if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{
-				TaskID:    parentTaskID,
-				ProjectID: projectID,
-				Status:    &nextStatus,
-			}); err != nil {
-				return "", err
-			}
-		}
+	if err := s.syncParentWaitingChildren(store, projectID, parentTaskID); err != nil {
+		return "", err
 	}
 	if s.taskAgentSupervisor != nil {
 		_ = s.taskAgentSupervisor.SetSidecarMode(taskID, sidecarMode)
@@ -1141,23 +1236,15 @@ func (s *Server) findTask(taskID string) (string, *projectstate.Store, projectst
 	return "", nil, projectstate.TaskIndexEntry{}, errors.New("task not found")
 }
 
-func (s *Server) updateTaskStatusInternal(store *projectstate.Store, taskID, projectID, status string) error {
-	_, ok, err := findTaskEntryInProject(store, projectID, taskID)
-	if err != nil {
-		return err
-	}
-	if !ok {
-		return errors.New("task not found")
-	}
-	nextStatus := strings.TrimSpace(status)
-	if nextStatus == "" {
-		return errors.New("status is required")
-	}
-	return store.UpsertTaskMeta(projectstate.TaskMetaUpsert{
-		TaskID:    taskID,
-		ProjectID: projectID,
-		Status:    &nextStatus,
-	})
+func (s *Server) updateTaskStatusInternal(store *projectstate.Store, taskID, projectID, status, trigger string) error {
+	return s.updateTaskStatusInternalWithSource(store, taskID, projectID, status, trigger, projectstate.TaskRevisionSourceSystem)
+}
+
+// updateTaskStatusInternalWithSource applies a status change made by the server itself. Callers pass
+// StatusTriggerRun only when the change comes with a new run; anything else is a plain update.
+func (s *Server) updateTaskStatusInternalWithSource(store *projectstate.Store, taskID, projectID, status, trigger, source string) error {
+	_, err := s.transitionTaskStatus(store, projectID, taskID, status, trigger, source)
+	return err
 }
 
 func findTaskEntryInProject(store *projectstate.Store, projectID, taskID string) (projectstate.TaskIndexEntry, bool, error) {
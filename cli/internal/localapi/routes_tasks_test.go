@@ -213,7 +213,7 @@ func postRunReportResult(t *testing.T, srv *Server, ts *httptest.Server, repo, t
 	}
 	_ = bindResp.Body.Close()
 
-	return srv.AutoCompleteByPane(AutoCompleteByPaneInput{
+	result, autoErr := srv.AutoCompleteByPane(AutoCompleteByPaneInput{
 		PaneTarget: paneTarget,
 		Summary:    summary,
 		RequestMeta: map[string]any{
@@ -227,6 +227,8 @@ func postRunReportResult(t *testing.T, srv *Server, ts *httptest.Server, repo, t
 		CallerPath:       "internal:auto-progress",
 		CallerActivePane: paneTarget,
 	})
+	drainRunActions(t, srv)
+	return result, autoErr
 }
 
 func TestProjectTree_ReadsFromTasksTableOnly(t *testing.T) {
@@ -1706,7 +1708,7 @@ func TestTaskMutations_DoNotDependOnTaskTreeJSON(t *testing.T) {
 		}
 	}
 
-	if err := srv.setTaskFlagInternal(store, "p1", tChild, "notify", "need-check"); err != nil {
+	if err := srv.setTaskFlagInternal(store, "p1", tChild, "notify", "need-check", projectstate.TaskRevisionSourceSidecarTool); err != nil {
 		t.Fatalf("setTaskFlagInternal failed: %v", err)
 	}
 
@@ -1749,7 +1751,7 @@ func TestSetTaskFlagInternal_UpdatesTaskMetaInSQL(t *testing.T) {
 	}
 
 	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: &memProjectsStore{}})
-	if err := srv.setTaskFlagInternal(store, "p1", tid, "notify", "need-check"); err != nil {
+	if err := srv.setTaskFlagInternal(store, "p1", tid, "notify", "need-check", projectstate.TaskRevisionSourceSidecarTool); err != nil {
 		t.Fatalf("setTaskFlagInternal failed: %v", err)
 	}
 
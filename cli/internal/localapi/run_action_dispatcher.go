@@ -0,0 +1,272 @@
+package localapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/projectstate"
+)
+
+const (
+	runActionCompletionDispatch     = "run_completion_dispatch"
+	runActionTaskCompletionDispatch = "task_completion_dispatch"
+	runActionWebhookDelivery        = "webhook_delivery"
+	runActionCaptureArtifacts       = "run_capture_artifacts"
+
+	runActionPollInterval = 2 * time.Second
+	runActionClaimBatch   = 16
+	runActionMaxAttempts  = 5
+	runActionBaseBackoff  = 5 * time.Second
+	runActionMaxBackoff   = 10 * time.Minute
+)
+
+var runActionNow = time.Now
+
+// RunActionHandler executes one claimed action_outbox row. A returned error schedules a retry.
+type RunActionHandler func(ctx context.Context, action projectstate.RunActionRecord) error
+
+func (s *Server) RegisterRunActionHandler(actionType string, handler RunActionHandler) {
+	if s == nil {
+		return
+	}
+	actionType = strings.TrimSpace(actionType)
+	if actionType == "" || handler == nil {
+		return
+	}
+	s.runActionMu.Lock()
+	defer s.runActionMu.Unlock()
+	if s.runActionHandlers == nil {
+		s.runActionHandlers = map[string]RunActionHandler{}
+	}
+	s.runActionHandlers[actionType] = handler
+}
+
+func (s *Server) lookupRunActionHandler(actionType string) (RunActionHandler, bool) {
+	s.runActionMu.Lock()
+	defer s.runActionMu.Unlock()
+	handler, ok := s.runActionHandlers[strings.TrimSpace(actionType)]
+	return handler, ok
+}
+
+// RunActionDispatcher drains action_outbox until ctx is canceled. It is meant to be registered with lifecycle.Manager.
+func (s *Server) RunActionDispatcher(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	store := runActionStore()
+	if requeued, err := store.RequeueRunningRunActions(); err != nil {
+		slog.Warn("run action dispatcher requeue failed", "err", err)
+	} else if requeued > 0 {
+		slog.Info("run action dispatcher requeued interrupted actions", "count", requeued)
+	}
+	ticker := time.NewTicker(runActionPollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.DispatchDueRunActions(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Warn("run action dispatch pass failed", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-s.runActionWake:
+		}
+	}
+}
+
+// DispatchDueRunActions claims and executes every action whose retry time has passed. It returns the number of actions executed.
+func (s *Server) DispatchDueRunActions(ctx context.Context) (int, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	store := runActionStore()
+	total := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		actions, err := store.ClaimDueRunActions(runActionNow().UTC().Unix(), runActionClaimBatch)
+		if err != nil {
+			return total, err
+		}
+		if len(actions) == 0 {
+			return total, nil
+		}
+		for _, action := range actions {
+			s.executeRunAction(ctx, store, action)
+			total++
+		}
+	}
+}
+
+// runActionDeferral is returned by a handler whose action is not finished yet. The row goes back to pending until
+// Until without counting as a failed attempt; whoever finishes the action later settles it with settleRunAction.
+type runActionDeferral struct {
+	Until time.Time
+}
+
+func (d runActionDeferral) Error() string {
+	return "action deferred until " + d.Until.UTC().Format(time.RFC3339)
+}
+
+func (s *Server) executeRunAction(ctx context.Context, store *projectstate.Store, action projectstate.RunActionRecord) {
+	handler, ok := s.lookupRunActionHandler(action.ActionType)
+	var err error
+	if !ok {
+		err = fmt.Errorf("no handler registered for action type %q", action.ActionType)
+	} else {
+		err = handler(ctx, action)
+	}
+	var deferral runActionDeferral
+	if errors.As(err, &deferral) {
+		// Never hand the row back as due right away, or the same pass would claim it again.
+		until := deferral.Until
+		if earliest := runActionNow().Add(runActionPollInterval); until.Before(earliest) {
+			until = earliest
+		}
+		if deferErr := store.DeferRunAction(action.ID, until.UTC().Unix()); deferErr != nil {
+			slog.Error("run action defer failed", "action_id", action.ID, "run_id", action.RunID, "err", deferErr)
+		}
+		return
+	}
+	s.settleRunAction(store, action, err)
+}
+
+// settleRunAction marks action done, or records the failed attempt and schedules its retry.
+func (s *Server) settleRunAction(store *projectstate.Store, action projectstate.RunActionRecord, err error) {
+	if err == nil {
+		if markErr := store.MarkRunActionDone(action.ID); markErr != nil {
+			slog.Error("run action mark done failed", "action_id", action.ID, "run_id", action.RunID, "err", markErr)
+		}
+		return
+	}
+
+	attempts := action.RetryCount + 1
+	dead := attempts >= runActionMaxAttempts
+	nextRetryAt := runActionNow().UTC().Add(runActionBackoff(attempts)).Unix()
+	if markErr := store.MarkRunActionFailed(action.ID, err.Error(), nextRetryAt, dead); markErr != nil {
+		slog.Error("run action mark failed failed", "action_id", action.ID, "run_id", action.RunID, "err", markErr)
+		return
+	}
+	if !dead {
+		slog.Warn("run action failed, retry scheduled", "action_id", action.ID, "run_id", action.RunID, "action_type", action.ActionType, "attempts", attempts, "next_retry_at", nextRetryAt, "err", err)
+		return
+	}
+	slog.Error("run action dead-lettered", "action_id", action.ID, "run_id", action.RunID, "action_type", action.ActionType, "attempts", attempts, "err", err)
+	if strings.TrimSpace(action.RunID) == "" {
+		return
+	}
+	_ = store.AppendRunEvent(action.RunID, "action.dead_lettered", map[string]any{
+		"action_id":   action.ID,
+		"action_type": action.ActionType,
+		"attempts":    attempts,
+		"error":       err.Error(),
+	})
+	if projectID, _, run, findErr := s.findRun(action.RunID); findErr == nil {
+		s.publishEvent("run.actions.updated", projectID, run.TaskID, map[string]any{
+			"run_id":    action.RunID,
+			"action_id": action.ID,
+			"status":    projectstate.OutboxStatusDead,
+		})
+	}
+}
+
+func (s *Server) kickRunActionDispatcher() {
+	if s == nil || s.runActionWake == nil {
+		return
+	}
+	select {
+	case s.runActionWake <- struct{}{}:
+	default:
+	}
+}
+
+func runActionBackoff(attempts int) time.Duration {
+	if attempts <= 1 {
+		return runActionBaseBackoff
+	}
+	delay := runActionBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= runActionMaxBackoff {
+			return runActionMaxBackoff
+		}
+	}
+	return delay
+}
+
+// runActionStore returns a store for action_outbox access; outbox rows are not scoped by repo root.
+func runActionStore() *projectstate.Store {
+	return projectstate.NewStore("")
+}
+
+func decodeRunActionPayload(action projectstate.RunActionRecord) map[string]any {
+	out := map[string]any{}
+	raw := strings.TrimSpace(action.PayloadJSON)
+	if raw == "" {
+		return out
+	}
+	if err := json.Unmarshal([]byte(raw), &out); err != nil || out == nil {
+		return map[string]any{}
+	}
+	return out
+}
+
+func (s *Server) handleRunCompletionDispatchAction(ctx context.Context, action projectstate.RunActionRecord) error {
+	projectID, _, run, err := s.findRun(action.RunID)
+	if err != nil {
+		return err
+	}
+	payload := decodeRunActionPayload(action)
+	taskID, _ := payload["task_id"].(string)
+	if strings.TrimSpace(taskID) == "" {
+		taskID = run.TaskID
+	}
+	return s.dispatchCompletionAction(ctx, action, projectID, taskID, payload)
+}
+
+// handleTaskCompletionDispatchAction runs the completion actions of a task that finished without a run.
+func (s *Server) handleTaskCompletionDispatchAction(ctx context.Context, action projectstate.RunActionRecord) error {
+	payload := decodeRunActionPayload(action)
+	taskID, _ := payload["task_id"].(string)
+	projectID, _ := payload["project_id"].(string)
+	if strings.TrimSpace(taskID) == "" {
+		return errors.New("task completion action has no task_id")
+	}
+	if strings.TrimSpace(projectID) == "" {
+		resolved, _, _, err := s.findTask(taskID)
+		if err != nil {
+			return err
+		}
+		projectID = resolved
+	}
+	return s.dispatchCompletionAction(ctx, action, projectID, taskID, payload)
+}
+
+func (s *Server) dispatchCompletionAction(ctx context.Context, action projectstate.RunActionRecord, projectID, taskID string, payload map[string]any) error {
+	summary, _ := payload["summary"].(string)
+	source, _ := payload["source"].(string)
+	if strings.EqualFold(strings.TrimSpace(source), "pane-idle") {
+		reqMeta, _ := payload["request_meta"].(map[string]any)
+		return s.sendCompletionToTaskAgentLoop(ctx, action.RunID, projectID, taskID, summary, payloadExitCode(payload), reqMeta)
+	}
+	if !s.evaluateTaskCompletionDispatch().Dispatch {
+		return nil
+	}
+	return s.dispatchRunCompletionActions(ctx, action, projectID, taskID, summary, payloadCompletionOutcome(payload))
+}
+
+// payloadExitCode reads the exit_code of a decoded action payload, where JSON numbers arrive as float64.
+func payloadExitCode(payload map[string]any) *int {
+	value, ok := payload["exit_code"].(float64)
+	if !ok {
+		return nil
+	}
+	code := int(value)
+	return &code
+}
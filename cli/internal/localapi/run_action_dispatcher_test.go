@@ -0,0 +1,250 @@
+package localapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+// drainRunActions runs every due outbox action, standing in for the dispatcher loop the tests do not start.
+func drainRunActions(t *testing.T, srv *Server) {
+	t.Helper()
+	if _, err := srv.DispatchDueRunActions(context.Background()); err != nil {
+		t.Fatalf("DispatchDueRunActions failed: %v", err)
+	}
+}
+
+// runActionsOfType lists the outbox rows of runID with the given action type.
+func runActionsOfType(t *testing.T, store *projectstate.Store, runID, actionType string) []projectstate.RunActionRecord {
+	t.Helper()
+	items, err := store.ListRunActions(runID)
+	if err != nil {
+		t.Fatalf("ListRunActions failed: %v", err)
+	}
+	out := make([]projectstate.RunActionRecord, 0, len(items))
+	for _, item := range items {
+		if item.ActionType == actionType {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func TestRunActionDispatcher_RetriesWithBackoffThenDeadLetters(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: repo}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+
+	store := projectstate.NewStore(repo)
+	if err := store.InsertTask(projectstate.TaskRecord{TaskID: "t_outbox_dead", ProjectID: "p1", Title: "root"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_outbox_dead", TaskID: "t_outbox_dead", RunStatus: projectstate.RunStatusCompleted}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.EnqueueRunAction("r_outbox_dead", "test_always_fail", map[string]any{"k": "v"}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	srv.RegisterRunActionHandler("test_always_fail", func(_ context.Context, action projectstate.RunActionRecord) error {
+		if action.RunID == "r_outbox_dead" {
+			calls++
+		}
+		return errors.New("receiver unavailable")
+	})
+
+	clock := time.Now()
+	origNow := runActionNow
+	runActionNow = func() time.Time { return clock }
+	defer func() { runActionNow = origNow }()
+
+	for attempt := 1; attempt <= runActionMaxAttempts; attempt++ {
+		if _, err := srv.DispatchDueRunActions(context.Background()); err != nil {
+			t.Fatalf("dispatch failed: %v", err)
+		}
+		if calls != attempt {
+			t.Fatalf("expected %d handler calls, got %d", attempt, calls)
+		}
+		items, err := store.ListRunActions("r_outbox_dead")
+		if err != nil || len(items) != 1 {
+			t.Fatalf("expected one action, got %d err=%v", len(items), err)
+		}
+		item := items[0]
+		if item.RetryCount != attempt || item.LastError != "receiver unavailable" {
+			t.Fatalf("unexpected action after attempt %d: %#v", attempt, item)
+		}
+		if attempt == runActionMaxAttempts {
+			if item.Status != projectstate.OutboxStatusDead {
+				t.Fatalf("expected dead-lettered action, got %q", item.Status)
+			}
+			break
+		}
+		if item.Status != projectstate.OutboxStatusPending {
+			t.Fatalf("expected pending action, got %q", item.Status)
+		}
+		if want := clock.UTC().Add(runActionBackoff(attempt)).Unix(); item.NextRetryAt != want {
+			t.Fatalf("expected next_retry_at %d, got %d", want, item.NextRetryAt)
+		}
+		// Nothing is due until the backoff elapses.
+		if _, err := srv.DispatchDueRunActions(context.Background()); err != nil {
+			t.Fatalf("dispatch failed: %v", err)
+		}
+		if calls != attempt {
+			t.Fatalf("expected backoff to defer attempt, got %d calls", calls)
+		}
+		clock = clock.Add(runActionBackoff(attempt))
+	}
+
+	deadEvents, err := store.CountRunEventsByType("r_outbox_dead", "action.dead_lettered")
+	if err != nil {
+		t.Fatalf("CountRunEventsByType failed: %v", err)
+	}
+	if deadEvents != 1 {
+		t.Fatalf("expected one action.dead_lettered run event, got %d", deadEvents)
+	}
+}
+
+func TestRunActionBackoff_GrowsAndCaps(t *testing.T) {
+	if got := runActionBackoff(1); got != runActionBaseBackoff {
+		t.Fatalf("expected base backoff, got %s", got)
+	}
+	if got := runActionBackoff(3); got != 4*runActionBaseBackoff {
+		t.Fatalf("expected 4x base backoff, got %s", got)
+	}
+	if got := runActionBackoff(50); got != runActionMaxBackoff {
+		t.Fatalf("expected capped backoff, got %s", got)
+	}
+}
+
+func TestRunActionsRoute_ListAndRetry(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: repo}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	store := projectstate.NewStore(repo)
+	if err := store.InsertTask(projectstate.TaskRecord{TaskID: "t_outbox_route", ProjectID: "p1", Title: "root"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_outbox_route", TaskID: "t_outbox_route", RunStatus: projectstate.RunStatusCompleted}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.EnqueueRunAction("r_outbox_route", "test_route_action", nil); err != nil {
+		t.Fatal(err)
+	}
+	items, _ := store.ListRunActions("r_outbox_route")
+	if len(items) != 1 {
+		t.Fatalf("expected one action, got %d", len(items))
+	}
+	if err := store.MarkRunActionFailed(items[0].ID, "boom", 0, true); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/runs/r_outbox_route/actions")
+	if err != nil {
+		t.Fatalf("list actions failed: %v", err)
+	}
+	var listed struct {
+		Data struct {
+			RunID string                         `json:"run_id"`
+			Items []projectstate.RunActionRecord `json:"items"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode list failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || listed.Data.RunID != "r_outbox_route" || len(listed.Data.Items) != 1 {
+		t.Fatalf("unexpected list response: status=%d body=%#v", resp.StatusCode, listed)
+	}
+	if listed.Data.Items[0].Status != projectstate.OutboxStatusDead || listed.Data.Items[0].LastError != "boom" {
+		t.Fatalf("expected dead action in listing, got %#v", listed.Data.Items[0])
+	}
+
+	retryResp, err := http.Post(ts.URL+"/api/v1/runs/r_outbox_route/actions", "application/json", bytes.NewBufferString(`{}`))
+	if err != nil {
+		t.Fatalf("retry actions failed: %v", err)
+	}
+	var retried struct {
+		Data struct {
+			Retried int64 `json:"retried"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(retryResp.Body).Decode(&retried); err != nil {
+		t.Fatalf("decode retry failed: %v", err)
+	}
+	_ = retryResp.Body.Close()
+	if retryResp.StatusCode != http.StatusOK || retried.Data.Retried != 1 {
+		t.Fatalf("unexpected retry response: status=%d body=%#v", retryResp.StatusCode, retried)
+	}
+	item, _, _ := store.GetRunAction(items[0].ID)
+	if item.Status != projectstate.OutboxStatusPending || item.RetryCount != 0 {
+		t.Fatalf("expected action reset to pending, got %#v", item)
+	}
+
+	missing, err := http.Get(ts.URL + "/api/v1/runs/r_missing/actions")
+	if err != nil {
+		t.Fatalf("missing run request failed: %v", err)
+	}
+	_ = missing.Body.Close()
+	if missing.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown run, got %d", missing.StatusCode)
+	}
+}
+
+func TestRunActionDispatcher_PaneIdleCompletionRetriesWhenAgentLoopUnavailable(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: repo}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	store := projectstate.NewStore(repo)
+	taskID := uniqueTaskID(t, "t_outbox_idle")
+	if err := store.InsertTask(projectstate.TaskRecord{TaskID: taskID, ProjectID: "p1", Title: "root", Status: projectstate.StatusRunning}); err != nil {
+		t.Fatal(err)
+	}
+	result, autoErr := postRunReportResult(t, srv, ts, repo, taskID, "done", nil)
+	if autoErr != nil || !result.Triggered || result.RunID == "" {
+		t.Fatalf("expected triggered run completion, got %#v err=%v", result, autoErr)
+	}
+
+	items := runActionsOfType(t, store, result.RunID, runActionCompletionDispatch)
+	if len(items) != 1 {
+		t.Fatalf("expected one completion outbox action, got %d", len(items))
+	}
+	item := items[0]
+	if item.ActionType != runActionCompletionDispatch || item.Status != projectstate.OutboxStatusPending || item.RetryCount != 1 {
+		t.Fatalf("expected pane-idle completion kept pending for retry, got %#v", item)
+	}
+	if !strings.Contains(item.LastError, ErrTaskAgentLoopUnavailable.Error()) {
+		t.Fatalf("expected agent loop error recorded, got %q", item.LastError)
+	}
+}
+
+func TestServer_BackgroundLoopsIncludeEveryFollowUpLoop(t *testing.T) {
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}})
+	var names []string
+	for _, loop := range srv.BackgroundLoops() {
+		if loop.Run == nil {
+			t.Fatalf("loop %q has no run func", loop.Name)
+		}
+		names = append(names, loop.Name)
+	}
+	want := []string{"run-action-dispatcher", "archive-retention", "flag-escalation", "run-watchdog", "pane-recovery", "run-recording"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("unexpected background loops: %v", names)
+	}
+}
@@ -0,0 +1,291 @@
+package localapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+const (
+	runArtifactHistoryLines = 10000
+	runArtifactGitTimeout   = 15 * time.Second
+
+	// Bundles older than runArtifactRetention, and beyond the newest runArtifactMaxBundles, are removed.
+	runArtifactRetention     = 30 * 24 * time.Hour
+	runArtifactMaxBundles    = 500
+	runArtifactPruneInterval = time.Hour
+
+	runArtifactHistoryFile  = "history.txt"
+	runArtifactStatusFile   = "git-status.txt"
+	runArtifactDiffStatFile = "git-diff-stat.txt"
+	runArtifactRuntimeFile  = "runtime.json"
+	runArtifactManifestFile = "manifest.json"
+)
+
+type runArtifactFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+type runArtifactManifest struct {
+	RunID      string            `json:"run_id"`
+	TaskID     string            `json:"task_id"`
+	ProjectID  string            `json:"project_id"`
+	PaneTarget string            `json:"pane_target,omitempty"`
+	CapturedAt int64             `json:"captured_at"`
+	Files      []runArtifactFile `json:"files"`
+	Errors     map[string]string `json:"errors,omitempty"`
+}
+
+func runArtifactsBaseDir() (string, error) {
+	configDir, err := global.DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "run-artifacts"), nil
+}
+
+// handleCaptureRunArtifactsAction captures the artifact bundle of a finished run from the action outbox.
+func (s *Server) handleCaptureRunArtifactsAction(ctx context.Context, action projectstate.RunActionRecord) error {
+	projectID, store, run, err := s.findRun(action.RunID)
+	if err != nil {
+		return err
+	}
+	s.captureRunArtifacts(ctx, store, projectID, run)
+	return nil
+}
+
+// captureRunArtifacts writes what the run's pane and working tree looked like when it finished — the full pane
+// scrollback, git status, the diff stat and the task's last runtime record — to a bundle under the config dir and
+// points the run at it. Every part is best effort; what could not be captured is listed in the manifest.
+func (s *Server) captureRunArtifacts(ctx context.Context, store *projectstate.Store, projectID string, run projectstate.RunRecord) {
+	base, err := runArtifactsBaseDir()
+	if err != nil {
+		slog.Warn("run artifacts dir unavailable", "run_id", run.RunID, "err", err)
+		return
+	}
+	dir := filepath.Join(base, run.RunID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		slog.Warn("run artifacts dir create failed", "run_id", run.RunID, "err", err)
+		return
+	}
+	manifest := runArtifactManifest{
+		RunID:      run.RunID,
+		TaskID:     run.TaskID,
+		ProjectID:  projectID,
+		CapturedAt: time.Now().UTC().Unix(),
+		Files:      []runArtifactFile{},
+		Errors:     map[string]string{},
+	}
+	write := func(name string, data []byte, err error) {
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, name), data, 0o644)
+		}
+		if err != nil {
+			manifest.Errors[name] = err.Error()
+			return
+		}
+		manifest.Files = append(manifest.Files, runArtifactFile{Name: name, Size: int64(len(data))})
+	}
+
+	if binding, ok, err := store.GetBindingByRunID(run.RunID); err == nil && ok {
+		manifest.PaneTarget = strings.TrimSpace(binding.PaneTarget)
+		if manifest.PaneTarget == "" {
+			manifest.PaneTarget = strings.TrimSpace(binding.PaneID)
+		}
+	}
+	switch {
+	case s.deps.PaneService == nil:
+		manifest.Errors[runArtifactHistoryFile] = "pane service is not configured"
+	case manifest.PaneTarget == "":
+		manifest.Errors[runArtifactHistoryFile] = "run has no pane binding"
+	default:
+		history, err := s.paneServiceFor(projectID).CaptureHistory(manifest.PaneTarget, runArtifactHistoryLines)
+		write(runArtifactHistoryFile, []byte(history), err)
+	}
+
+	if repoRoot, err := s.findProjectRepoRoot(projectID); err != nil {
+		manifest.Errors[runArtifactStatusFile] = err.Error()
+		manifest.Errors[runArtifactDiffStatFile] = err.Error()
+	} else {
+		git := func(args ...string) (string, error) {
+			gitCtx, cancel := context.WithTimeout(ctx, runArtifactGitTimeout)
+			defer cancel()
+			return runGitOutputContext(gitCtx, repoRoot, args...)
+		}
+		status, err := git("status", "--porcelain")
+		write(runArtifactStatusFile, []byte(status), err)
+		diffStat, err := git("diff", "--stat", "HEAD")
+		if err != nil && ctx.Err() == nil {
+			// A repository without commits has no HEAD to diff against; the unstaged stat is all there is.
+			diffStat, err = git("diff", "--stat")
+		}
+		write(runArtifactDiffStatFile, []byte(diffStat), err)
+	}
+
+	if runtime, ok, err := store.GetTaskRuntimeByTaskID(run.TaskID); err != nil {
+		manifest.Errors[runArtifactRuntimeFile] = err.Error()
+	} else if !ok {
+		manifest.Errors[runArtifactRuntimeFile] = "task runtime not found"
+	} else {
+		raw, err := json.MarshalIndent(runtime, "", "  ")
+		write(runArtifactRuntimeFile, raw, err)
+	}
+
+	if len(manifest.Errors) == 0 {
+		manifest.Errors = nil
+	}
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, runArtifactManifestFile), raw, 0o644)
+	}
+	if err != nil {
+		slog.Warn("run artifacts manifest write failed", "run_id", run.RunID, "err", err)
+		return
+	}
+	if err := store.SetRunArtifactDir(run.RunID, dir); err != nil {
+		slog.Warn("run artifacts dir record failed", "run_id", run.RunID, "err", err)
+	}
+
+	s.runArtifactsMu.Lock()
+	due := time.Since(s.runArtifactsPrunedAt) >= runArtifactPruneInterval
+	if due {
+		s.runArtifactsPrunedAt = time.Now()
+	}
+	s.runArtifactsMu.Unlock()
+	if due {
+		pruneRunArtifacts(base, time.Now())
+	}
+}
+
+// pruneRunArtifacts removes bundles under base older than runArtifactRetention and, beyond runArtifactMaxBundles,
+// the oldest ones.
+func pruneRunArtifacts(base string, now time.Time) {
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("run artifacts list failed", "dir", base, "err", err)
+		}
+		return
+	}
+	type bundle struct {
+		runID   string
+		modTime time.Time
+	}
+	bundles := make([]bundle, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		bundles = append(bundles, bundle{runID: entry.Name(), modTime: info.ModTime()})
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].modTime.After(bundles[j].modTime)
+	})
+	cutoff := now.Add(-runArtifactRetention)
+	for i, item := range bundles {
+		if i < runArtifactMaxBundles && !item.modTime.Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(base, item.runID)); err != nil {
+			slog.Warn("run artifacts remove failed", "run_id", item.runID, "err", err)
+		}
+	}
+}
+
+// removeRunArtifacts deletes the bundles in dirs, e.g. of runs purged with their archived task. Only directories
+// directly under the artifacts base dir are touched.
+func removeRunArtifacts(dirs []string) {
+	if len(dirs) == 0 {
+		return
+	}
+	base, err := runArtifactsBaseDir()
+	if err != nil {
+		slog.Warn("run artifacts dir unavailable", "err", err)
+		return
+	}
+	base = filepath.Clean(base)
+	for _, dir := range dirs {
+		dir = filepath.Clean(strings.TrimSpace(dir))
+		if filepath.Dir(dir) != base {
+			slog.Warn("run artifacts remove skipped, dir outside the artifacts dir", "dir", dir)
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			slog.Warn("run artifacts remove failed", "dir", dir, "err", err)
+		}
+	}
+}
+
+// handleGetRunArtifacts returns the manifest of a run's artifact bundle, or with name one file of it.
+func (s *Server) handleGetRunArtifacts(w http.ResponseWriter, runID, name string) {
+	_, _, run, err := s.findRun(runID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "RUN_NOT_FOUND", err.Error())
+		return
+	}
+	dir := strings.TrimSpace(run.ArtifactDir)
+	if dir == "" {
+		respondError(w, http.StatusNotFound, "RUN_ARTIFACTS_NOT_FOUND", "run has no artifacts")
+		return
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, runArtifactManifestFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			respondError(w, http.StatusNotFound, "RUN_ARTIFACTS_NOT_FOUND", "run artifacts were removed")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "RUN_ARTIFACTS_LOAD_FAILED", err.Error())
+		return
+	}
+	var manifest runArtifactManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		respondError(w, http.StatusInternalServerError, "RUN_ARTIFACTS_LOAD_FAILED", err.Error())
+		return
+	}
+	if name == "" {
+		respondOK(w, map[string]any{
+			"run_id":   run.RunID,
+			"dir":      dir,
+			"manifest": manifest,
+		})
+		return
+	}
+	// Only files the manifest lists can be downloaded, which also keeps name from escaping the bundle.
+	found := name == runArtifactManifestFile
+	for _, file := range manifest.Files {
+		found = found || file.Name == name
+	}
+	if !found {
+		respondError(w, http.StatusNotFound, "RUN_ARTIFACT_NOT_FOUND", "artifact not found")
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "RUN_ARTIFACTS_LOAD_FAILED", err.Error())
+		return
+	}
+	contentType := "text/plain; charset=utf-8"
+	if strings.HasSuffix(name, ".json") {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(run.RunID+"-"+name))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
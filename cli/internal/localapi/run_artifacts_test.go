@@ -0,0 +1,153 @@
+package localapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func TestRunArtifacts_CapturedOnCompletionAndDownloadable(t *testing.T) {
+	t.Setenv("SHELLMAN_CONFIG_DIR", t.TempDir())
+	repo := t.TempDir()
+	mustRunGit(t, repo, "init")
+	if err := os.WriteFile(filepath.Join(repo, "new.txt"), []byte("new"), 0o644); err != nil {
+		t.Fatalf("write new.txt failed: %v", err)
+	}
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	panes := &fakePaneService{history: "line 1\nline 2\n"}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "build", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_artifacts_1", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{RunID: "r_artifacts_1", ServerInstanceID: "srv", PaneID: "art:1.0", PaneTarget: "art:1.0"}); err != nil {
+		t.Fatalf("UpsertRunBinding failed: %v", err)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/runs/r_artifacts_1/artifacts")
+	if err != nil {
+		t.Fatalf("GET artifacts failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 before completion, got %d", resp.StatusCode)
+	}
+
+	if err := srv.completeRunAndEnqueueActions("r_artifacts_1", "done", "test", nil, nil); err != nil {
+		t.Fatalf("completeRunAndEnqueueActions failed: %v", err)
+	}
+	if panes.historyTarget != "" {
+		t.Fatal("expected the capture left to the action outbox, not done on the completion path")
+	}
+	drainRunActions(t, srv)
+	if panes.historyTarget != "art:1.0" || panes.historyLines != runArtifactHistoryLines {
+		t.Fatalf("expected full history capture of art:1.0, got target=%q lines=%d", panes.historyTarget, panes.historyLines)
+	}
+	run, err := store.GetRun("r_artifacts_1")
+	if err != nil {
+		t.Fatalf("GetRun failed: %v", err)
+	}
+	if run.ArtifactDir == "" {
+		t.Fatal("expected run to reference its artifact dir")
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/runs/r_artifacts_1/artifacts")
+	if err != nil {
+		t.Fatalf("GET artifacts failed: %v", err)
+	}
+	var listed struct {
+		Data struct {
+			Manifest runArtifactManifest `json:"manifest"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode artifacts failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	names := map[string]bool{}
+	for _, file := range listed.Data.Manifest.Files {
+		names[file.Name] = true
+	}
+	for _, name := range []string{runArtifactHistoryFile, runArtifactStatusFile, runArtifactDiffStatFile} {
+		if !names[name] {
+			t.Fatalf("expected %s in manifest, got %#v", name, listed.Data.Manifest)
+		}
+	}
+
+	download := func(name string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/api/v1/runs/r_artifacts_1/artifacts/" + name)
+		if err != nil {
+			t.Fatalf("GET artifact %s failed: %v", name, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	if code, body := download(runArtifactHistoryFile); code != http.StatusOK || body != "line 1\nline 2\n" {
+		t.Fatalf("unexpected history download: %d %q", code, body)
+	}
+	if code, body := download(runArtifactStatusFile); code != http.StatusOK || body != "?? new.txt\n" {
+		t.Fatalf("unexpected git status download: %d %q", code, body)
+	}
+	if code, _ := download("..%2Fshellman.db"); code != http.StatusNotFound {
+		t.Fatalf("expected unknown artifact 404, got %d", code)
+	}
+
+	// Purging the archived task takes the bundle of its run with it.
+	archived := true
+	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{TaskID: taskID, ProjectID: "p1", Archived: &archived, LastModified: 100}); err != nil {
+		t.Fatalf("archive task failed: %v", err)
+	}
+	purged, err := srv.purgeArchivedTasks(store, "p1", 1)
+	if err != nil || len(purged) != 1 {
+		t.Fatalf("expected the task purged, got %#v err=%v", purged, err)
+	}
+	if _, err := os.Stat(run.ArtifactDir); !os.IsNotExist(err) {
+		t.Fatalf("expected artifact dir removed with the purged run, got err=%v", err)
+	}
+}
+
+func TestPruneRunArtifacts_DropsExpiredAndExcessBundles(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	mkBundle := func(runID string, age time.Duration) {
+		t.Helper()
+		dir := filepath.Join(base, runID)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir %s failed: %v", runID, err)
+		}
+		if err := os.Chtimes(dir, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatalf("chtimes %s failed: %v", runID, err)
+		}
+	}
+	mkBundle("r_expired", runArtifactRetention+time.Hour)
+	for i := 0; i < runArtifactMaxBundles+1; i++ {
+		mkBundle(fmt.Sprintf("r_%04d", i), time.Duration(i)*time.Minute)
+	}
+
+	pruneRunArtifacts(base, now)
+	exists := func(runID string) bool {
+		_, err := os.Stat(filepath.Join(base, runID))
+		return err == nil
+	}
+	if exists("r_expired") {
+		t.Fatal("expected the expired bundle removed")
+	}
+	if !exists("r_0000") || exists(fmt.Sprintf("r_%04d", runArtifactMaxBundles)) {
+		t.Fatal("expected only the oldest bundle beyond the limit removed")
+	}
+}
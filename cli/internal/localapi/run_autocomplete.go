@@ -1,6 +1,7 @@
 package localapi
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -26,6 +27,8 @@ type AutoCompleteByPaneResult struct {
 	TaskID      string
 	Status      string
 	SummaryUsed string
+	// ExitCode is the pane command's exit status when the pane could report it.
+	ExitCode *int
 }
 
 type AutoCompleteByPaneError struct {
@@ -204,6 +207,7 @@ func (s *Server) AutoCompleteByPane(input AutoCompleteByPaneInput) (AutoComplete
 		summary = "auto-complete: pane idle and output stable"
 	}
 	reqMeta := copyTaskCompletionRequestMeta(input.RequestMeta)
+	exitCode := s.paneExitCode(projectID, paneTarget)
 	if foundRun {
 		slog.Info(
 			"run auto-complete proceeding with live running run",
@@ -240,31 +244,48 @@ func (s *Server) AutoCompleteByPane(input AutoCompleteByPaneInput) (AutoComplete
 			"pane_target": paneTarget,
 			"trigger":     "status.ready",
 		})
-		if err := s.completeRunAndEnqueueActions(run.RunID, summary, "pane-idle", reqMeta); err != nil {
+		if err := s.completeRunAndEnqueueActions(run.RunID, summary, "pane-idle", exitCode, reqMeta); err != nil {
 			return AutoCompleteByPaneResult{}, &AutoCompleteByPaneError{
 				HTTPStatus: http.StatusInternalServerError,
 				Code:       "RUN_COMPLETE_FAILED",
 				Message:    err.Error(),
 			}
 		}
+		runStatus := projectstate.RunStatusCompleted
+		if runOutcomeStatus(exitCode) == projectstate.StatusFailed {
+			runStatus = projectstate.RunStatusFailed
+		}
 		return AutoCompleteByPaneResult{
 			Triggered:   true,
 			PaneTarget:  paneTarget,
 			RunID:       run.RunID,
 			TaskID:      run.TaskID,
-			Status:      projectstate.RunStatusCompleted,
+			Status:      runStatus,
 			SummaryUsed: summary,
+			ExitCode:    exitCode,
 		}, nil
 	}
-	if err := s.updateTaskStatusInternal(store, taskID, projectID, projectstate.StatusCompleted); err != nil {
+	taskStatus := runOutcomeStatus(exitCode)
+	if err := s.updateTaskStatusInternalWithSource(store, taskID, projectID, taskStatus, projectstate.StatusTriggerUpdate, projectstate.TaskRevisionSourceAutoProgress); err != nil {
+		if errors.Is(err, projectstate.ErrInvalidStatusTransition) {
+			return AutoCompleteByPaneResult{}, &AutoCompleteByPaneError{
+				HTTPStatus: http.StatusConflict,
+				Code:       "INVALID_STATUS_TRANSITION",
+				Message:    err.Error(),
+			}
+		}
 		return AutoCompleteByPaneResult{}, &AutoCompleteByPaneError{
 			HTTPStatus: http.StatusInternalServerError,
 			Code:       "TASK_COMPLETE_FAILED",
 			Message:    err.Error(),
 		}
 	}
-	s.enqueueTaskCompletionActions(projectID, taskID, summary, "pane-idle", reqMeta)
-	s.publishEvent("task.status.updated", projectID, taskID, map[string]any{"status": projectstate.StatusCompleted})
+	s.enqueueTaskCompletionActions(projectID, taskID, summary, "pane-idle", exitCode, reqMeta)
+	statusPayload := map[string]any{"status": taskStatus}
+	if exitCode != nil {
+		statusPayload["exit_code"] = *exitCode
+	}
+	s.publishEvent("task.status.updated", projectID, taskID, statusPayload)
 	s.publishEvent("task.return.reported", projectID, taskID, map[string]any{"summary": summary, "run_id": ""})
 	s.publishEvent("task.tree.updated", projectID, taskID, map[string]any{})
 	return AutoCompleteByPaneResult{
@@ -272,8 +293,9 @@ func (s *Server) AutoCompleteByPane(input AutoCompleteByPaneInput) (AutoComplete
 		PaneTarget:  paneTarget,
 		RunID:       "",
 		TaskID:      taskID,
-		Status:      projectstate.StatusCompleted,
+		Status:      taskStatus,
 		SummaryUsed: summary,
+		ExitCode:    exitCode,
 	}, nil
 }
 
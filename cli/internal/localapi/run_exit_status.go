@@ -0,0 +1,51 @@
+package localapi
+
+import (
+	"fmt"
+	"log/slog"
+
+	"shellman/cli/internal/projectstate"
+	"shellman/cli/internal/tmux"
+)
+
+// paneExitStatusProvider is implemented by pane services that can report how a pane's last command exited.
+type paneExitStatusProvider interface {
+	PaneExitStatus(target string) (tmux.PaneExitStatus, error)
+}
+
+// paneExitCode returns the exit status of the command that just finished in paneTarget, or nil when it is unknown.
+// Dead panes report tmux's pane_dead_status; live panes only count the bootstrap shell's record once the shell is
+// back in the foreground, since a still-running agent leaves the previous command's status behind.
+func (s *Server) paneExitCode(projectID, paneTarget string) *int {
+	provider, ok := s.paneServiceFor(projectID).(paneExitStatusProvider)
+	if !ok || paneTarget == "" {
+		return nil
+	}
+	status, err := provider.PaneExitStatus(paneTarget)
+	if err != nil {
+		slog.Warn("pane exit status lookup failed", "pane_target", paneTarget, "err", err)
+		return nil
+	}
+	switch {
+	case status.Dead && status.HasDeadStatus:
+		code := status.DeadStatus
+		return &code
+	case !status.Dead && status.HasLastExit && resolveTaskAgentToolModeFromCommand(status.CurrentCommand) == taskAgentToolModeShell:
+		code := status.LastExit
+		return &code
+	default:
+		return nil
+	}
+}
+
+// runOutcomeStatus is the task status a finished command leads to: failed for a non-zero exit, completed otherwise.
+func runOutcomeStatus(exitCode *int) string {
+	if exitCode != nil && *exitCode != 0 {
+		return projectstate.StatusFailed
+	}
+	return projectstate.StatusCompleted
+}
+
+func runExitError(exitCode int) string {
+	return fmt.Sprintf("exit status %d", exitCode)
+}
@@ -0,0 +1,149 @@
+package localapi
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+	"shellman/cli/internal/tmux"
+)
+
+type exitStatusPaneService struct {
+	fakePaneService
+	status tmux.PaneExitStatus
+}
+
+func (f *exitStatusPaneService) PaneExitStatus(string) (tmux.PaneExitStatus, error) {
+	return f.status, nil
+}
+
+func TestAutoCompleteByPane_NonZeroExitFailsRunAndTask(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	panes := &exitStatusPaneService{status: tmux.PaneExitStatus{CurrentCommand: "bash", LastExit: 3, HasLastExit: true}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "build", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_exit_1", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{
+		RunID:            "r_exit_1",
+		ServerInstanceID: detectServerInstanceID(),
+		PaneID:           "e2e:1.1",
+		PaneTarget:       "e2e:1.1",
+		BindingStatus:    projectstate.BindingStatusLive,
+	}); err != nil {
+		t.Fatalf("UpsertRunBinding failed: %v", err)
+	}
+	bindings, err := store.LoadPanes()
+	if err != nil {
+		t.Fatalf("LoadPanes failed: %v", err)
+	}
+	bindings[taskID] = projectstate.PaneBinding{TaskID: taskID, PaneUUID: "pane-uuid-1", PaneID: "e2e:1.1", PaneTarget: "e2e:1.1"}
+	if err := store.SavePanes(bindings); err != nil {
+		t.Fatalf("SavePanes failed: %v", err)
+	}
+
+	out, runErr := srv.AutoCompleteByPane(AutoCompleteByPaneInput{PaneTarget: "e2e:1.1", Summary: "done"})
+	if runErr != nil {
+		t.Fatalf("AutoCompleteByPane failed: %v", runErr)
+	}
+	if out.Status != projectstate.RunStatusFailed || out.ExitCode == nil || *out.ExitCode != 3 {
+		t.Fatalf("expected failed run with exit code 3, got %#v", out)
+	}
+	run, err := store.GetRun("r_exit_1")
+	if err != nil {
+		t.Fatalf("GetRun failed: %v", err)
+	}
+	if run.RunStatus != projectstate.RunStatusFailed || run.LastError != "exit status 3" {
+		t.Fatalf("unexpected run: %#v", run)
+	}
+	if got := loadDependencyTestNode(t, ts.URL, taskID).Status; got != projectstate.StatusFailed {
+		t.Fatalf("expected task failed, got %q", got)
+	}
+
+	// Canceling a task cancels the run a new launch attached to it.
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_exit_2", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	resp, err := http.Post(ts.URL+"/api/v1/tasks/"+taskID+"/reopen", "application/json", bytes.NewBufferString(`{}`))
+	if err != nil {
+		t.Fatalf("POST reopen failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if code, _ := patchTaskStatus(t, ts.URL, taskID, projectstate.StatusCanceled); code != http.StatusOK {
+		t.Fatalf("expected cancel to succeed, got %d", code)
+	}
+	run, err = store.GetRun("r_exit_2")
+	if err != nil {
+		t.Fatalf("GetRun failed: %v", err)
+	}
+	if run.RunStatus != projectstate.RunStatusCanceled {
+		t.Fatalf("expected canceled run, got %#v", run)
+	}
+}
+
+func TestPaneExitCode_IgnoresHookStatusWhileCommandRuns(t *testing.T) {
+	panes := &exitStatusPaneService{status: tmux.PaneExitStatus{CurrentCommand: "codex", LastExit: 1, HasLastExit: true}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, PaneService: panes})
+	if code := srv.paneExitCode("", "e2e:1.1"); code != nil {
+		t.Fatalf("expected unknown exit code while codex is in the foreground, got %d", *code)
+	}
+	panes.status = tmux.PaneExitStatus{Dead: true, DeadStatus: 0, HasDeadStatus: true, CurrentCommand: "codex"}
+	if code := srv.paneExitCode("", "e2e:1.1"); code == nil || *code != 0 {
+		t.Fatalf("expected dead pane status 0, got %v", code)
+	}
+}
+
+func TestTaskBatch_CancelEndsRunningRuns(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: &fakePaneService{}})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "build", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_batch_cancel_1", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	code, out := postTaskBatch(t, ts.URL, map[string]any{"project_id": "p1", "op": "set_status", "status": projectstate.StatusCanceled, "task_ids": []string{taskID}})
+	if code != http.StatusOK || out.Succeeded != 1 {
+		t.Fatalf("expected batch cancel to succeed, got %d %#v", code, out)
+	}
+	run, err := store.GetRun("r_batch_cancel_1")
+	if err != nil {
+		t.Fatalf("GetRun failed: %v", err)
+	}
+	if run.RunStatus != projectstate.RunStatusCanceled {
+		t.Fatalf("expected batch cancel to cancel the run, got %#v", run)
+	}
+}
+
+func TestCompletionNotifyCommand_ReceivesStatusAndExitCode(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "notify.env")
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}})
+	command := `printf '%s %s' "$SHELLMAN_TASK_STATUS" "$SHELLMAN_TASK_EXIT_CODE" > ` + outFile
+	exitCode := 3
+	outcome := completionOutcome{Status: projectstate.StatusFailed, ExitCode: &exitCode}
+	if err := srv.runCompletionNotifyCommand(context.Background(), "p1", "t_notify", "done", outcome, command, 0); err != nil {
+		t.Fatalf("runCompletionNotifyCommand failed: %v", err)
+	}
+	raw, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("read notify output failed: %v", err)
+	}
+	if got := string(raw); got != "failed 3" {
+		t.Fatalf("expected notify command to see the failed status and exit code, got %q", got)
+	}
+}
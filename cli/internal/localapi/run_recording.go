@@ -0,0 +1,428 @@
+package localapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+const (
+	runRecordingInterval      = 2 * time.Second
+	runRecordingPruneInterval = 10 * time.Minute
+	runRecordingDefaultCols   = 80
+	runRecordingDefaultRows   = 24
+	runRecordingFileExt       = ".cast"
+)
+
+// paneSizeService is implemented by pane services that can report the size of a pane, which recordings need for
+// their header and resize events.
+type paneSizeService interface {
+	PaneSize(target string) (int, int, error)
+}
+
+// socketPaneOutputSource is implemented by output sources that can follow panes on several tmux servers.
+type socketPaneOutputSource interface {
+	SubscribeOnSocket(socket, target string) (<-chan string, func(), error)
+}
+
+// runRecordingPane is the pane a run is recorded from and the tmux socket it lives on, "" for the default server.
+type runRecordingPane struct {
+	socket string
+	target string
+}
+
+// asciicastHeader is the first line of an asciicast v2 file.
+type asciicastHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// runRecorder appends what one run's pane prints to the run's asciicast file. A recording that is picked up again,
+// after a rebind or a restart of shellman, keeps its original header and timeline.
+type runRecorder struct {
+	runID string
+	pane  runRecordingPane
+	stop  func()
+	quit  chan struct{}
+	done  chan struct{}
+
+	mu      sync.Mutex
+	file    *os.File
+	started time.Time
+	cols    int
+	rows    int
+}
+
+func runRecordingsDir() (string, error) {
+	configDir, err := global.DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "run-recordings"), nil
+}
+
+func runRecordingPath(dir, runID string) string {
+	return filepath.Join(dir, runID+runRecordingFileExt)
+}
+
+// RunRecordingLoop records the panes of active runs in projects with recording enabled and enforces the recording
+// retention limits, until ctx is canceled.
+func (s *Server) RunRecordingLoop(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	defer s.syncRunRecorders("", nil)
+	ticker := time.NewTicker(runRecordingInterval)
+	defer ticker.Stop()
+	for {
+		s.runRecordingPass()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) runRecordingPass() {
+	if s.deps.PaneOutputSource == nil || s.deps.ConfigStore == nil || s.deps.ProjectsStore == nil {
+		return
+	}
+	cfg, err := s.deps.ConfigStore.LoadOrInit()
+	if err != nil {
+		slog.Warn("run recording config load failed", "err", err)
+		return
+	}
+	dir, err := runRecordingsDir()
+	if err != nil {
+		slog.Warn("run recordings dir unavailable", "err", err)
+		return
+	}
+	projects, err := s.deps.ProjectsStore.ListProjects()
+	if err != nil {
+		slog.Warn("run recording project list failed", "err", err)
+		return
+	}
+	wanted := map[string]runRecordingPane{}
+	for _, p := range projects {
+		if !cfg.RunRecording.EnabledFor(p.ProjectID) {
+			continue
+		}
+		runs, err := projectstate.NewStore(p.RepoRoot).ListActiveRunsByProject(p.ProjectID)
+		if err != nil {
+			slog.Warn("run recording run list failed", "project_id", p.ProjectID, "err", err)
+			continue
+		}
+		for _, run := range runs {
+			target := strings.TrimSpace(run.PaneTarget)
+			if target == "" {
+				target = strings.TrimSpace(run.PaneID)
+			}
+			if target == "" || run.BindingStatus != projectstate.BindingStatusLive {
+				continue
+			}
+			wanted[run.RunID] = runRecordingPane{socket: strings.TrimSpace(p.TmuxSocket), target: target}
+		}
+	}
+	stopped := s.syncRunRecorders(dir, wanted)
+
+	s.runRecordingMu.Lock()
+	due := stopped > 0 || time.Since(s.runRecordingPrunedAt) >= runRecordingPruneInterval
+	if due {
+		s.runRecordingPrunedAt = time.Now()
+	}
+	active := make(map[string]bool, len(s.runRecorders))
+	for runID := range s.runRecorders {
+		active[runID] = true
+	}
+	s.runRecordingMu.Unlock()
+	if due {
+		pruneRunRecordings(dir, cfg.RunRecording, active, time.Now())
+	}
+}
+
+// syncRunRecorders starts a recorder for every run in wanted (run id to pane) that lacks one and stops those no longer
+// wanted or whose run moved to another pane. It returns how many recorders were stopped.
+func (s *Server) syncRunRecorders(dir string, wanted map[string]runRecordingPane) int {
+	s.runRecordingMu.Lock()
+	stale := make([]*runRecorder, 0)
+	for runID, rec := range s.runRecorders {
+		if pane, ok := wanted[runID]; !ok || pane != rec.pane {
+			stale = append(stale, rec)
+			delete(s.runRecorders, runID)
+		}
+	}
+	s.runRecordingMu.Unlock()
+	for _, rec := range stale {
+		rec.close()
+	}
+
+	for runID, pane := range wanted {
+		sizes, _ := s.paneServiceOnSocket(pane.socket).(paneSizeService)
+		s.runRecordingMu.Lock()
+		rec := s.runRecorders[runID]
+		s.runRecordingMu.Unlock()
+		if rec != nil {
+			if sizes != nil {
+				if cols, rows, err := sizes.PaneSize(pane.target); err == nil {
+					rec.resize(cols, rows)
+				}
+			}
+			continue
+		}
+		rec, err := startRunRecorder(s.deps.PaneOutputSource, sizes, runRecordingPath(dir, runID), runID, pane)
+		if err != nil {
+			slog.Warn("run recording start failed", "run_id", runID, "pane_target", pane.target, "err", err)
+			continue
+		}
+		s.runRecordingMu.Lock()
+		s.runRecorders[runID] = rec
+		s.runRecordingMu.Unlock()
+	}
+	return len(stale)
+}
+
+func startRunRecorder(source PaneOutputSource, sizes paneSizeService, path, runID string, pane runRecordingPane) (*runRecorder, error) {
+	cols, rows := runRecordingDefaultCols, runRecordingDefaultRows
+	if sizes != nil {
+		if c, r, err := sizes.PaneSize(pane.target); err == nil && c > 0 && r > 0 {
+			cols, rows = c, r
+		}
+	}
+	var (
+		ch   <-chan string
+		stop func()
+		err  error
+	)
+	if multi, ok := source.(socketPaneOutputSource); ok && pane.socket != "" {
+		ch, stop, err = multi.SubscribeOnSocket(pane.socket, pane.target)
+	} else {
+		ch, stop, err = source.Subscribe(pane.target)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec := &runRecorder{
+		runID: runID,
+		pane:  pane,
+		stop:  stop,
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if header, ok := readAsciicastHeader(path); ok {
+		rec.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+		rec.started = time.Unix(header.Timestamp, 0)
+		rec.cols, rec.rows = header.Width, header.Height
+	} else {
+		rec.file, err = createAsciicastFile(path, asciicastHeader{
+			Version:   2,
+			Width:     cols,
+			Height:    rows,
+			Timestamp: time.Now().Unix(),
+			Title:     runID,
+		})
+		rec.started = time.Now()
+		rec.cols, rec.rows = cols, rows
+	}
+	if err != nil {
+		if stop != nil {
+			stop()
+		}
+		return nil, err
+	}
+	rec.resize(cols, rows)
+	go rec.loop(ch)
+	return rec, nil
+}
+
+func createAsciicastFile(path string, header asciicastHeader) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Write(append(raw, '\n')); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func readAsciicastHeader(path string) (asciicastHeader, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return asciicastHeader{}, false
+	}
+	defer func() { _ = file.Close() }()
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil {
+		return asciicastHeader{}, false
+	}
+	var header asciicastHeader
+	if err := json.Unmarshal([]byte(line), &header); err != nil || header.Version != 2 {
+		return asciicastHeader{}, false
+	}
+	return header, true
+}
+
+func (r *runRecorder) loop(ch <-chan string) {
+	defer close(r.done)
+	for {
+		select {
+		case <-r.quit:
+			return
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+			if data != "" {
+				r.writeEvent("o", data)
+			}
+		}
+	}
+}
+
+// resize records a resize event when the pane no longer has the size last recorded.
+func (r *runRecorder) resize(cols, rows int) {
+	if cols <= 0 || rows <= 0 {
+		return
+	}
+	r.mu.Lock()
+	changed := cols != r.cols || rows != r.rows
+	r.cols, r.rows = cols, rows
+	r.mu.Unlock()
+	if changed {
+		r.writeEvent("r", fmt.Sprintf("%dx%d", cols, rows))
+	}
+}
+
+func (r *runRecorder) writeEvent(kind, data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	elapsed := math.Round(time.Since(r.started).Seconds()*1e6) / 1e6
+	raw, err := json.Marshal([]any{elapsed, kind, data})
+	if err == nil {
+		_, err = r.file.Write(append(raw, '\n'))
+	}
+	if err != nil {
+		slog.Warn("run recording write failed", "run_id", r.runID, "err", err)
+	}
+}
+
+func (r *runRecorder) close() {
+	if r.stop != nil {
+		r.stop()
+	}
+	close(r.quit)
+	<-r.done
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		_ = r.file.Close()
+		r.file = nil
+	}
+}
+
+// pruneRunRecordings removes recordings older than the retention window and, beyond MaxRecordings, the oldest ones.
+// Recordings still being written are kept either way.
+func pruneRunRecordings(dir string, cfg global.RunRecordingConfig, active map[string]bool, now time.Time) {
+	if cfg.MaxRecordings <= 0 && cfg.RetentionDays <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("run recordings list failed", "dir", dir, "err", err)
+		}
+		return
+	}
+	type recording struct {
+		runID   string
+		modTime time.Time
+	}
+	recordings := make([]recording, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, runRecordingFileExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		recordings = append(recordings, recording{runID: strings.TrimSuffix(name, runRecordingFileExt), modTime: info.ModTime()})
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].modTime.After(recordings[j].modTime)
+	})
+	cutoff := now.Add(-time.Duration(cfg.RetentionDays) * 24 * time.Hour)
+	for i, item := range recordings {
+		if active[item.runID] {
+			continue
+		}
+		expired := cfg.RetentionDays > 0 && item.modTime.Before(cutoff)
+		overLimit := cfg.MaxRecordings > 0 && i >= cfg.MaxRecordings
+		if !expired && !overLimit {
+			continue
+		}
+		if err := os.Remove(runRecordingPath(dir, item.runID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("run recording remove failed", "run_id", item.runID, "err", err)
+		}
+	}
+}
+
+// handleGetRunRecording serves the asciicast v2 recording of a run, including while it is still being written.
+func (s *Server) handleGetRunRecording(w http.ResponseWriter, runID string) {
+	_, _, run, err := s.findRun(runID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "RUN_NOT_FOUND", err.Error())
+		return
+	}
+	dir, err := runRecordingsDir()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "RUN_RECORDING_LOAD_FAILED", err.Error())
+		return
+	}
+	file, err := os.Open(runRecordingPath(dir, run.RunID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			respondError(w, http.StatusNotFound, "RUN_RECORDING_NOT_FOUND", "run has no recording")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "RUN_RECORDING_LOAD_FAILED", err.Error())
+		return
+	}
+	defer func() { _ = file.Close() }()
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(run.RunID+runRecordingFileExt))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, file)
+}
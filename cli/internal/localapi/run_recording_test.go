@@ -0,0 +1,177 @@
+package localapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+type fakePaneOutputSource struct {
+	mu      sync.Mutex
+	streams map[string]chan string
+	stopped []string
+}
+
+func (f *fakePaneOutputSource) Subscribe(target string) (<-chan string, func(), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan string, 8)
+	f.streams[target] = ch
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.stopped = append(f.stopped, target)
+	}, nil
+}
+
+func (f *fakePaneOutputSource) stream(target string) chan string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.streams[target]
+}
+
+type sizedPaneService struct {
+	fakePaneService
+	cols, rows int
+}
+
+func (f *sizedPaneService) PaneSize(string) (int, int, error) {
+	return f.cols, f.rows, nil
+}
+
+func TestRunRecording_RecordsActiveRunPaneAsAsciicast(t *testing.T) {
+	t.Setenv("SHELLMAN_CONFIG_DIR", t.TempDir())
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	cfgStore := &mutableConfigStore{cfg: global.GlobalConfig{LocalPort: 4621}}
+	panes := &sizedPaneService{cols: 100, rows: 30}
+	source := &fakePaneOutputSource{streams: map[string]chan string{}}
+	srv := NewServer(Deps{ConfigStore: cfgStore, ProjectsStore: projects, PaneService: panes, PaneOutputSource: source})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "record me", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_recording_1", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{RunID: "r_recording_1", PaneID: "%9", PaneTarget: "%9", BindingStatus: projectstate.BindingStatusLive}); err != nil {
+		t.Fatalf("UpsertRunBinding failed: %v", err)
+	}
+
+	srv.runRecordingPass()
+	if source.stream("%9") != nil {
+		t.Fatal("expected no recording while recording is disabled")
+	}
+
+	cfgStore.cfg.RunRecording.Projects = map[string]bool{"p1": true}
+	srv.runRecordingPass()
+	stream := source.stream("%9")
+	if stream == nil {
+		t.Fatal("expected the run's pane to be recorded")
+	}
+	stream <- "hello\r\n"
+	dir, err := runRecordingsDir()
+	if err != nil {
+		t.Fatalf("runRecordingsDir failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		raw, _ := os.ReadFile(runRecordingPath(dir, "r_recording_1"))
+		if strings.Contains(string(raw), `"o","hello\r\n"`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected output event in recording, got %q", raw)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	panes.cols, panes.rows = 120, 40
+	srv.runRecordingPass()
+	if err := store.SetRunStatus("r_recording_1", projectstate.RunStatusCompleted); err != nil {
+		t.Fatalf("SetRunStatus failed: %v", err)
+	}
+	srv.runRecordingPass()
+	if len(source.stopped) != 1 {
+		t.Fatalf("expected recording stopped once the run finished, got %v", source.stopped)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/runs/r_recording_1/recording.cast")
+	if err != nil {
+		t.Fatalf("GET recording failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/x-asciicast" {
+		t.Fatalf("unexpected recording response: %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header, output and resize lines, got %q", body)
+	}
+	var header asciicastHeader
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil || header.Version != 2 || header.Width != 100 || header.Height != 30 {
+		t.Fatalf("unexpected header %q: %v", lines[0], err)
+	}
+	var resize []any
+	if err := json.Unmarshal([]byte(lines[2]), &resize); err != nil || len(resize) != 3 || resize[1] != "r" || resize[2] != "120x40" {
+		t.Fatalf("unexpected resize event %q: %v", lines[2], err)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/runs/r_recording_missing/recording.cast")
+	if err != nil {
+		t.Fatalf("GET recording failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown run, got %d", resp.StatusCode)
+	}
+}
+
+func TestPruneRunRecordings_EnforcesRetention(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"r_new":    time.Hour,
+		"r_mid":    2 * time.Hour,
+		"r_old":    3 * time.Hour,
+		"r_active": 10 * 24 * time.Hour,
+		"r_stale":  10 * 24 * time.Hour,
+	}
+	for runID, age := range ages {
+		path := runRecordingPath(dir, runID)
+		if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+			t.Fatalf("write recording failed: %v", err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatalf("chtimes failed: %v", err)
+		}
+	}
+
+	pruneRunRecordings(dir, global.RunRecordingConfig{RetentionDays: 7}, map[string]bool{"r_active": true}, now)
+	if _, err := os.Stat(runRecordingPath(dir, "r_stale")); !os.IsNotExist(err) {
+		t.Fatal("expected recording past the retention window removed")
+	}
+	if _, err := os.Stat(runRecordingPath(dir, "r_active")); err != nil {
+		t.Fatal("expected active recording kept")
+	}
+
+	pruneRunRecordings(dir, global.RunRecordingConfig{MaxRecordings: 2}, map[string]bool{"r_active": true}, now)
+	for runID, want := range map[string]bool{"r_new": true, "r_mid": true, "r_old": false, "r_active": true} {
+		_, err := os.Stat(runRecordingPath(dir, runID))
+		if got := err == nil; got != want {
+			t.Fatalf("recording %s kept=%v, want %v", runID, got, want)
+		}
+	}
+}
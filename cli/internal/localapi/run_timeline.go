@@ -0,0 +1,430 @@
+package localapi
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/projectstate"
+)
+
+const (
+	runEventFinished = "run.finished"
+
+	// runFinishedGitTimeout bounds the git status taken for run.finished; the run is recorded without it on timeout.
+	runFinishedGitTimeout = 5 * time.Second
+
+	// runCompareMaxLines bounds the output diff; the tail of each output is what differs in practice.
+	runCompareMaxLines = 400
+)
+
+type taskRunBindingItem struct {
+	EventType        string `json:"event_type"`
+	ServerInstanceID string `json:"server_instance_id,omitempty"`
+	PaneID           string `json:"pane_id,omitempty"`
+	PaneTarget       string `json:"pane_target,omitempty"`
+	BindingStatus    string `json:"binding_status,omitempty"`
+	StaleReason      string `json:"stale_reason,omitempty"`
+	At               int64  `json:"at"`
+}
+
+type taskRunItem struct {
+	RunID       string               `json:"run_id"`
+	Status      string               `json:"status"`
+	StartedAt   int64                `json:"started_at"`
+	CompletedAt int64                `json:"completed_at,omitempty"`
+	DurationSec int64                `json:"duration_sec"`
+	LastError   string               `json:"last_error,omitempty"`
+	ExitCode    *int                 `json:"exit_code,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Source      string               `json:"source,omitempty"`
+	Binding     *taskRunBindingItem  `json:"binding,omitempty"`
+	Bindings    []taskRunBindingItem `json:"bindings"`
+}
+
+// recordRunFinished keeps what a run left behind — the pane's final screen and the working tree's changed files —
+// as a run.finished event, so runs of the same task can be compared after their pane is gone.
+func (s *Server) recordRunFinished(store *projectstate.Store, projectID, runID, status, summary, source string, exitCode *int) {
+	payload := map[string]any{
+		"status":  status,
+		"summary": strings.TrimSpace(summary),
+		"source":  strings.TrimSpace(source),
+	}
+	if exitCode != nil {
+		payload["exit_code"] = *exitCode
+	}
+	if binding, ok, err := store.GetBindingByRunID(runID); err == nil && ok {
+		paneID := strings.TrimSpace(binding.PaneID)
+		if paneID == "" {
+			paneID = strings.TrimSpace(binding.PaneTarget)
+		}
+		if runtime, found, err := store.GetPaneRuntimeByPaneID(paneID); err == nil && found {
+			payload["output"] = runtime.Snapshot
+		}
+	}
+	if repoRoot, err := s.findProjectRepoRoot(projectID); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), runFinishedGitTimeout)
+		status, err := runGitOutputContext(ctx, repoRoot, "status", "--porcelain")
+		cancel()
+		if err == nil {
+			payload["changed_files"] = parseGitStatusPorcelain(status)
+		}
+	}
+	if err := store.AppendRunEvent(runID, runEventFinished, payload); err != nil {
+		slog.Warn("run finished record failed", "run_id", runID, "err", err)
+	}
+}
+
+func (s *Server) handleListTaskRuns(w http.ResponseWriter, r *http.Request, taskID string) {
+	_, store, _, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	limit := 50
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			respondError(w, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 500")
+			return
+		}
+		limit = parsed
+	}
+	var after *projectstate.RunPageCursor
+	if raw := strings.TrimSpace(r.URL.Query().Get("cursor")); raw != "" {
+		cursor, err := decodeRunPageCursor(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_CURSOR", err.Error())
+			return
+		}
+		after = &cursor
+	}
+	runs, err := store.ListTaskRuns(taskID, after, limit+1)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_RUNS_LOAD_FAILED", err.Error())
+		return
+	}
+	nextCursor := ""
+	if len(runs) > limit {
+		runs = runs[:limit]
+		last := runs[len(runs)-1]
+		nextCursor = encodeRunPageCursor(projectstate.RunPageCursor{StartedAt: last.StartedAt, RunID: last.RunID})
+	}
+	now := time.Now().UTC().Unix()
+	items := make([]taskRunItem, 0, len(runs))
+	for _, run := range runs {
+		item, err := buildTaskRunItem(store, run, now)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "TASK_RUNS_LOAD_FAILED", err.Error())
+			return
+		}
+		items = append(items, item)
+	}
+	respondOK(w, map[string]any{
+		"task_id":     taskID,
+		"items":       items,
+		"next_cursor": nextCursor,
+	})
+}
+
+func buildTaskRunItem(store *projectstate.Store, run projectstate.RunRecord, now int64) (taskRunItem, error) {
+	item := taskRunItem{
+		RunID:       run.RunID,
+		Status:      run.RunStatus,
+		StartedAt:   run.StartedAt,
+		CompletedAt: run.CompletedAt,
+		LastError:   run.LastError,
+		Bindings:    []taskRunBindingItem{},
+	}
+	end := now
+	if run.CompletedAt > 0 {
+		end = run.CompletedAt
+	}
+	if end > run.StartedAt {
+		item.DurationSec = end - run.StartedAt
+	}
+	binding, ok, err := store.GetBindingByRunID(run.RunID)
+	if err != nil {
+		return item, err
+	}
+	if ok {
+		item.Binding = &taskRunBindingItem{
+			ServerInstanceID: binding.ServerInstanceID,
+			PaneID:           binding.PaneID,
+			PaneTarget:       binding.PaneTarget,
+			BindingStatus:    binding.BindingStatus,
+			StaleReason:      binding.StaleReason,
+		}
+	}
+	events, err := store.ListRunEvents(run.RunID, 0, 0)
+	if err != nil {
+		return item, err
+	}
+	for _, event := range events {
+		switch event.EventType {
+		case projectstate.RunEventBindingUpdated, "tmux_restarted":
+			item.Bindings = append(item.Bindings, taskRunBindingItem{
+				EventType:        event.EventType,
+				ServerInstanceID: payloadString(event.Payload, "server_instance_id", "current_server_instance_id"),
+				PaneID:           payloadString(event.Payload, "pane_id"),
+				PaneTarget:       payloadString(event.Payload, "pane_target"),
+				BindingStatus:    payloadString(event.Payload, "binding_status"),
+				StaleReason:      payloadString(event.Payload, "stale_reason"),
+				At:               event.CreatedAt,
+			})
+		case runEventFinished:
+			item.Summary = payloadString(event.Payload, "summary")
+			item.Source = payloadString(event.Payload, "source")
+			item.ExitCode = payloadInt(event.Payload, "exit_code")
+		}
+	}
+	return item, nil
+}
+
+func (s *Server) handleListRunEvents(w http.ResponseWriter, r *http.Request, runID string) {
+	_, store, _, err := s.findRun(runID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "RUN_NOT_FOUND", err.Error())
+		return
+	}
+	var afterID int64
+	if raw := strings.TrimSpace(r.URL.Query().Get("after")); raw != "" {
+		afterID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || afterID < 0 {
+			respondError(w, http.StatusBadRequest, "INVALID_AFTER", "after must be a non-negative event id")
+			return
+		}
+	}
+	limit := 100
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			respondError(w, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 500")
+			return
+		}
+		limit = parsed
+	}
+	items, err := store.ListRunEvents(runID, afterID, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "RUN_EVENTS_LOAD_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{
+		"run_id": runID,
+		"items":  items,
+	})
+}
+
+// handleCompareRuns diffs what two runs of the same task finished with: the final pane output, the changed files
+// and the completion summaries.
+func (s *Server) handleCompareRuns(w http.ResponseWriter, r *http.Request) {
+	runA := strings.TrimSpace(r.URL.Query().Get("a"))
+	runB := strings.TrimSpace(r.URL.Query().Get("b"))
+	if runA == "" || runB == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_RUN_IDS", "a and b are required")
+		return
+	}
+	_, storeA, recA, err := s.findRun(runA)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "RUN_NOT_FOUND", err.Error())
+		return
+	}
+	_, storeB, recB, err := s.findRun(runB)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "RUN_NOT_FOUND", err.Error())
+		return
+	}
+	if recA.TaskID != recB.TaskID {
+		respondError(w, http.StatusConflict, "RUN_TASK_MISMATCH", "runs belong to different tasks")
+		return
+	}
+	finishedA, err := lastRunFinishedPayload(storeA, runA)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "RUN_EVENTS_LOAD_FAILED", err.Error())
+		return
+	}
+	finishedB, err := lastRunFinishedPayload(storeB, runB)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "RUN_EVENTS_LOAD_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{
+		"task_id":     recA.TaskID,
+		"a":           compareRunSide(recA, finishedA),
+		"b":           compareRunSide(recB, finishedB),
+		"output_diff": diffLines(payloadString(finishedA, "output"), payloadString(finishedB, "output"), runCompareMaxLines),
+		"files":       diffChangedFiles(payloadChangedFiles(finishedA), payloadChangedFiles(finishedB)),
+	})
+}
+
+func compareRunSide(run projectstate.RunRecord, finished map[string]any) map[string]any {
+	side := map[string]any{
+		"run_id":       run.RunID,
+		"status":       run.RunStatus,
+		"started_at":   run.StartedAt,
+		"completed_at": run.CompletedAt,
+		"summary":      payloadString(finished, "summary"),
+		"source":       payloadString(finished, "source"),
+		"recorded":     finished != nil,
+	}
+	if code := payloadInt(finished, "exit_code"); code != nil {
+		side["exit_code"] = *code
+	}
+	return side
+}
+
+func lastRunFinishedPayload(store *projectstate.Store, runID string) (map[string]any, error) {
+	events, err := store.ListRunEvents(runID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].EventType == runEventFinished {
+			return events[i].Payload, nil
+		}
+	}
+	return nil, nil
+}
+
+func payloadChangedFiles(payload map[string]any) map[string]string {
+	out := map[string]string{}
+	raw, _ := payload["changed_files"].([]any)
+	for _, item := range raw {
+		file, _ := item.(map[string]any)
+		path, _ := file["path"].(string)
+		if strings.TrimSpace(path) == "" {
+			continue
+		}
+		status, _ := file["status"].(string)
+		out[path] = status
+	}
+	return out
+}
+
+// diffChangedFiles splits the changed files of two runs into those only one run touched and those both touched
+// with a different git status.
+func diffChangedFiles(a, b map[string]string) map[string]any {
+	onlyA := make([]string, 0)
+	onlyB := make([]string, 0)
+	changed := make([]map[string]string, 0)
+	for path, statusA := range a {
+		statusB, ok := b[path]
+		switch {
+		case !ok:
+			onlyA = append(onlyA, path)
+		case statusA != statusB:
+			changed = append(changed, map[string]string{"path": path, "a": statusA, "b": statusB})
+		}
+	}
+	for path := range b {
+		if _, ok := a[path]; !ok {
+			onlyB = append(onlyB, path)
+		}
+	}
+	sort.Strings(onlyA)
+	sort.Strings(onlyB)
+	sort.Slice(changed, func(i, j int) bool { return changed[i]["path"] < changed[j]["path"] })
+	return map[string]any{"only_a": onlyA, "only_b": onlyB, "changed": changed}
+}
+
+// diffLines is a line diff of the last maxLines lines of a and b, one entry per line prefixed with " " (both),
+// "-" (only a) or "+" (only b).
+func diffLines(a, b string, maxLines int) []string {
+	la := tailLines(a, maxLines)
+	lb := tailLines(b, maxLines)
+	lcs := make([][]int, len(la)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(lb)+1)
+	}
+	for i := len(la) - 1; i >= 0; i-- {
+		for j := len(lb) - 1; j >= 0; j-- {
+			if la[i] == lb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	out := make([]string, 0, len(la)+len(lb))
+	i, j := 0, 0
+	for i < len(la) && j < len(lb) {
+		switch {
+		case la[i] == lb[j]:
+			out = append(out, " "+la[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "-"+la[i])
+			i++
+		default:
+			out = append(out, "+"+lb[j])
+			j++
+		}
+	}
+	for ; i < len(la); i++ {
+		out = append(out, "-"+la[i])
+	}
+	for ; j < len(lb); j++ {
+		out = append(out, "+"+lb[j])
+	}
+	return out
+}
+
+func tailLines(text string, maxLines int) []string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return lines
+}
+
+func encodeRunPageCursor(cursor projectstate.RunPageCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", cursor.StartedAt, cursor.RunID)))
+}
+
+func decodeRunPageCursor(raw string) (projectstate.RunPageCursor, error) {
+	errInvalid := errors.New("cursor is not a value returned as next_cursor")
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return projectstate.RunPageCursor{}, errInvalid
+	}
+	startedAt, runID, ok := strings.Cut(string(decoded), ":")
+	if !ok || runID == "" {
+		return projectstate.RunPageCursor{}, errInvalid
+	}
+	at, err := strconv.ParseInt(startedAt, 10, 64)
+	if err != nil {
+		return projectstate.RunPageCursor{}, errInvalid
+	}
+	return projectstate.RunPageCursor{StartedAt: at, RunID: runID}, nil
+}
+
+// payloadString returns the first non-empty string among keys of a decoded event payload.
+func payloadString(payload map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := payload[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func payloadInt(payload map[string]any, key string) *int {
+	value, ok := payload[key].(float64)
+	if !ok {
+		return nil
+	}
+	n := int(value)
+	return &n
+}
@@ -0,0 +1,173 @@
+package localapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func TestRunTimeline_ListTaskRunsAndCompare(t *testing.T) {
+	repo := t.TempDir()
+	mustRunGit(t, repo, "init")
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "build", nil)
+	store := projectstate.NewStore(repo)
+	finishRun := func(runID string, startedAt int64, pane, output string) {
+		t.Helper()
+		if err := store.InsertRun(projectstate.RunRecord{RunID: runID, TaskID: taskID, RunStatus: projectstate.RunStatusRunning, StartedAt: startedAt}); err != nil {
+			t.Fatalf("InsertRun failed: %v", err)
+		}
+		if err := store.UpsertRunBinding(projectstate.RunBinding{RunID: runID, ServerInstanceID: "srv", PaneID: pane, PaneTarget: pane}); err != nil {
+			t.Fatalf("UpsertRunBinding failed: %v", err)
+		}
+		if err := store.BatchUpsertRuntime(projectstate.RuntimeBatchUpdate{
+			Panes: []projectstate.PaneRuntimeRecord{{PaneID: pane, PaneTarget: pane, Snapshot: output, SnapshotHash: runID, UpdatedAt: time.Now().UTC().Unix()}},
+		}); err != nil {
+			t.Fatalf("BatchUpsertRuntime failed: %v", err)
+		}
+		if err := srv.completeRunAndEnqueueActions(runID, "summary of "+runID, "test", nil, nil); err != nil {
+			t.Fatalf("completeRunAndEnqueueActions failed: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatalf("write a.txt failed: %v", err)
+	}
+	finishRun("r_timeline_a", 100, "tl:1.0", "build\nok\n")
+	if err := os.WriteFile(filepath.Join(repo, "b.txt"), []byte("b"), 0o644); err != nil {
+		t.Fatalf("write b.txt failed: %v", err)
+	}
+	finishRun("r_timeline_b", 200, "tl:2.0", "build\nfailed\n")
+
+	var page struct {
+		Data struct {
+			Items      []taskRunItem `json:"items"`
+			NextCursor string        `json:"next_cursor"`
+		} `json:"data"`
+	}
+	getJSON := func(path string, out any) int {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if out != nil && resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				t.Fatalf("decode %s failed: %v", path, err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	if code := getJSON("/api/v1/tasks/"+taskID+"/runs?limit=1", &page); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(page.Data.Items) != 1 || page.Data.Items[0].RunID != "r_timeline_b" || page.Data.NextCursor == "" {
+		t.Fatalf("unexpected first page: %#v", page.Data)
+	}
+	newest := page.Data.Items[0]
+	if newest.Status != projectstate.RunStatusCompleted || newest.Summary != "summary of r_timeline_b" || len(newest.Bindings) != 1 || newest.Binding == nil {
+		t.Fatalf("unexpected run item: %#v", newest)
+	}
+	if code := getJSON("/api/v1/tasks/"+taskID+"/runs?limit=1&cursor="+page.Data.NextCursor, &page); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(page.Data.Items) != 1 || page.Data.Items[0].RunID != "r_timeline_a" || page.Data.NextCursor != "" {
+		t.Fatalf("unexpected second page: %#v", page.Data)
+	}
+	if code := getJSON("/api/v1/tasks/"+taskID+"/runs?cursor=bogus", nil); code != http.StatusBadRequest {
+		t.Fatalf("expected invalid cursor 400, got %d", code)
+	}
+
+	var compared struct {
+		Data struct {
+			OutputDiff []string `json:"output_diff"`
+			Files      struct {
+				OnlyA []string `json:"only_a"`
+				OnlyB []string `json:"only_b"`
+			} `json:"files"`
+			A map[string]any `json:"a"`
+			B map[string]any `json:"b"`
+		} `json:"data"`
+	}
+	if code := getJSON("/api/v1/runs/compare?a=r_timeline_a&b=r_timeline_b", &compared); code != http.StatusOK {
+		t.Fatalf("expected compare 200, got %d", code)
+	}
+	wantDiff := []string{" build", "-ok", "+failed"}
+	if len(compared.Data.OutputDiff) != len(wantDiff) {
+		t.Fatalf("unexpected output diff: %#v", compared.Data.OutputDiff)
+	}
+	for i, line := range wantDiff {
+		if compared.Data.OutputDiff[i] != line {
+			t.Fatalf("unexpected output diff: %#v", compared.Data.OutputDiff)
+		}
+	}
+	if len(compared.Data.Files.OnlyA) != 0 || len(compared.Data.Files.OnlyB) != 1 || compared.Data.Files.OnlyB[0] != "b.txt" {
+		t.Fatalf("unexpected files diff: %#v", compared.Data.Files)
+	}
+	if compared.Data.A["summary"] != "summary of r_timeline_a" || compared.Data.B["summary"] != "summary of r_timeline_b" {
+		t.Fatalf("unexpected summaries: a=%#v b=%#v", compared.Data.A, compared.Data.B)
+	}
+
+	otherTaskID := createTestTask(t, ts.URL, "other", nil)
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_timeline_other", TaskID: otherTaskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if code := getJSON("/api/v1/runs/compare?a=r_timeline_a&b=r_timeline_other", nil); code != http.StatusConflict {
+		t.Fatalf("expected cross-task compare 409, got %d", code)
+	}
+
+	var events struct {
+		Data struct {
+			Items []projectstate.RunEventRecord `json:"items"`
+		} `json:"data"`
+	}
+	if code := getJSON("/api/v1/runs/r_timeline_a/events", &events); code != http.StatusOK {
+		t.Fatalf("expected events 200, got %d", code)
+	}
+	if len(events.Data.Items) != 2 || events.Data.Items[1].EventType != runEventFinished {
+		t.Fatalf("unexpected run events: %#v", events.Data.Items)
+	}
+}
+
+func TestRunTimeline_RecordsFinishedWhenTaskCancelEndsRun(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "build", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_timeline_cancel", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if code, _ := patchTaskStatus(t, ts.URL, taskID, projectstate.StatusCanceled); code != http.StatusOK {
+		t.Fatalf("expected 200 canceling task, got %d", code)
+	}
+	events, err := store.ListRunEvents("r_timeline_cancel", 0, 100)
+	if err != nil {
+		t.Fatalf("ListRunEvents failed: %v", err)
+	}
+	var finished map[string]any
+	for _, event := range events {
+		if event.EventType == runEventFinished {
+			finished = event.Payload
+		}
+	}
+	if finished["status"] != projectstate.StatusCanceled {
+		t.Fatalf("expected run.finished recorded for the canceled run, got %#v", events)
+	}
+}
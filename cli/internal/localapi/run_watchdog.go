@@ -0,0 +1,231 @@
+package localapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/progdetector"
+	"shellman/cli/internal/programadapter"
+	"shellman/cli/internal/projectstate"
+)
+
+const runWatchdogInterval = 30 * time.Second
+
+var runWatchdogNow = time.Now
+
+// runWatchdogObservation remembers the last snapshot hash seen for a run's pane and when it last changed, so output
+// that changes without tmux noticing activity still counts.
+type runWatchdogObservation struct {
+	snapshotHash string
+	changedAt    time.Time
+}
+
+// RunWatchdogLoop marks running runs stalled once their pane has been silent longer than the configured window, and
+// returns them to running when output resumes, until ctx is canceled.
+func (s *Server) RunWatchdogLoop(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	ticker := time.NewTicker(runWatchdogInterval)
+	defer ticker.Stop()
+	for {
+		s.runWatchdogPass()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) runWatchdogPass() {
+	if s.deps.ConfigStore == nil || s.deps.ProjectsStore == nil {
+		return
+	}
+	cfg, err := s.deps.ConfigStore.LoadOrInit()
+	if err != nil {
+		slog.Warn("run watchdog config load failed", "err", err)
+		return
+	}
+	projects, err := s.deps.ProjectsStore.ListProjects()
+	if err != nil {
+		slog.Warn("run watchdog project list failed", "err", err)
+		return
+	}
+	active := map[string]struct{}{}
+	for _, p := range projects {
+		runIDs, err := s.watchProjectRuns(projectstate.NewStore(p.RepoRoot), p.ProjectID, cfg.RunWatchdog)
+		if err != nil {
+			slog.Warn("run watchdog failed", "project_id", p.ProjectID, "err", err)
+		}
+		for _, runID := range runIDs {
+			active[runID] = struct{}{}
+		}
+	}
+	s.runWatchdogMu.Lock()
+	for runID := range s.runWatchdogSeen {
+		if _, ok := active[runID]; !ok {
+			delete(s.runWatchdogSeen, runID)
+		}
+	}
+	s.runWatchdogMu.Unlock()
+}
+
+// watchProjectRuns checks every active run of projectID against its adapter's silence window and returns the ids
+// of the runs it looked at.
+func (s *Server) watchProjectRuns(store *projectstate.Store, projectID string, cfg global.RunWatchdogConfig) ([]string, error) {
+	runs, err := store.ListActiveRunsByProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	now := runWatchdogNow().UTC()
+	runIDs := make([]string, 0, len(runs))
+	for _, run := range runs {
+		runIDs = append(runIDs, run.RunID)
+		if run.BindingStatus != projectstate.BindingStatusLive {
+			continue
+		}
+		window := cfg.SilenceWindow(run.ActiveAdapter)
+		if window <= 0 {
+			continue
+		}
+		lastActive := s.runLastActivity(store, projectID, run, now)
+		silent := now.Sub(lastActive) >= window
+		switch {
+		case silent && run.RunStatus == projectstate.RunStatusRunning:
+			if err := s.stallRun(store, projectID, run, cfg, window, lastActive, now); err != nil {
+				return runIDs, err
+			}
+		case !silent && run.RunStatus == projectstate.RunStatusStalled:
+			resumed, err := store.TransitionRunStatus(run.RunID, projectstate.RunStatusStalled, projectstate.RunStatusRunning)
+			if err != nil {
+				return runIDs, err
+			}
+			if !resumed {
+				continue
+			}
+			_ = store.AppendRunEvent(run.RunID, "run.resumed", map[string]any{"last_active_at": lastActive.Unix()})
+			s.publishEvent("run.resumed", projectID, run.TaskID, map[string]any{
+				"run_id":         run.RunID,
+				"last_active_at": lastActive.Unix(),
+			})
+		}
+	}
+	return runIDs, nil
+}
+
+// runLastActivity is the latest sign of life of run's pane: the run start, the pane actor's recorded activity, a
+// snapshot hash change, or tmux's pane_activity.
+func (s *Server) runLastActivity(store *projectstate.Store, projectID string, run projectstate.ActiveRunRecord, now time.Time) time.Time {
+	lastActive := time.Unix(run.StartedAt, 0).UTC()
+	later := func(at time.Time) {
+		if at.After(lastActive) {
+			lastActive = at
+		}
+	}
+	paneID := strings.TrimSpace(run.PaneID)
+	if paneID == "" {
+		paneID = strings.TrimSpace(run.PaneTarget)
+	}
+	if runtime, ok, err := store.GetPaneRuntimeByPaneID(paneID); err == nil && ok {
+		later(time.Unix(runtime.UpdatedAt, 0).UTC())
+		later(s.observeRunSnapshot(run.RunID, runtime.SnapshotHash, now))
+	}
+	if provider, ok := s.paneServiceForBinding(projectID, run.TmuxServerID).(paneActivityProvider); ok && run.PaneTarget != "" {
+		if at, err := provider.PaneLastActiveAt(run.PaneTarget); err == nil {
+			later(at.UTC())
+		}
+	}
+	return lastActive
+}
+
+// observeRunSnapshot records hash for runID and returns when it last changed. The first observation only starts the
+// clock, so a restart never counts as activity or silence on its own.
+func (s *Server) observeRunSnapshot(runID, hash string, now time.Time) time.Time {
+	s.runWatchdogMu.Lock()
+	defer s.runWatchdogMu.Unlock()
+	seen, ok := s.runWatchdogSeen[runID]
+	if !ok {
+		s.runWatchdogSeen[runID] = runWatchdogObservation{snapshotHash: hash}
+		return time.Time{}
+	}
+	if seen.snapshotHash != hash {
+		seen = runWatchdogObservation{snapshotHash: hash, changedAt: now}
+		s.runWatchdogSeen[runID] = seen
+	}
+	return seen.changedAt
+}
+
+func (s *Server) stallRun(store *projectstate.Store, projectID string, run projectstate.ActiveRunRecord, cfg global.RunWatchdogConfig, window time.Duration, lastActive, now time.Time) error {
+	// The run may have finished since it was listed; only the pass that actually stalls it reports the stall.
+	stalled, err := store.TransitionRunStatus(run.RunID, projectstate.RunStatusRunning, projectstate.RunStatusStalled)
+	if err != nil || !stalled {
+		return err
+	}
+	silentSec := int64(now.Sub(lastActive) / time.Second)
+	interrupted := false
+	if cfg.Interrupt && s.deps.TaskPromptSender != nil && run.PaneTarget != "" {
+		input := programadapter.DefaultInterruptInput
+		if detector, ok := progdetector.ProgramDetectorRegistry.Get(run.ActiveAdapter); ok {
+			input = programadapter.InterruptInput(detector)
+		}
+		if err := s.promptSenderFor(projectID).SendInput(run.PaneTarget, input); err != nil {
+			slog.Warn("run watchdog interrupt failed", "run_id", run.RunID, "pane_target", run.PaneTarget, "err", err)
+		} else {
+			interrupted = true
+		}
+	}
+	flag := s.runWatchdogFlag(store, projectID, run, cfg.Flag)
+	payload := map[string]any{
+		"run_id":         run.RunID,
+		"adapter":        run.ActiveAdapter,
+		"flag":           flag,
+		"pane_target":    run.PaneTarget,
+		"last_active_at": lastActive.Unix(),
+		"silent_seconds": silentSec,
+		"window_seconds": int64(window / time.Second),
+		"interrupted":    interrupted,
+	}
+	_ = store.AppendRunEvent(run.RunID, "run.stalled", payload)
+	slog.Info("run.stalled", "project_id", projectID, "task_id", run.TaskID, "run_id", run.RunID, "silent_seconds", silentSec)
+
+	flagDesc := fmt.Sprintf("run %s silent for %s", run.RunID, (time.Duration(silentSec) * time.Second).String())
+	if flag != "" {
+		if err := s.setTaskFlagInternal(store, projectID, run.TaskID, flag, flagDesc, projectstate.TaskRevisionSourceSystem); err != nil {
+			slog.Warn("run watchdog flag failed", "run_id", run.RunID, "task_id", run.TaskID, "flag", flag, "err", err)
+		}
+	}
+	s.publishEvent("run.stalled", projectID, run.TaskID, payload)
+	s.notifyWebhooks(webhookPayload{
+		Event:     global.WebhookEventRunStalled,
+		ProjectID: projectID,
+		TaskID:    run.TaskID,
+		RunID:     run.RunID,
+		Status:    projectstate.RunStatusStalled,
+		Summary:   flagDesc,
+	})
+	return nil
+}
+
+// runWatchdogFlag returns the configured stall flag when the project's flag registry has it, or "" when the task
+// should be left unflagged. Projects may drop or rename the default flags, so an unregistered flag is skipped.
+func (s *Server) runWatchdogFlag(store *projectstate.Store, projectID string, run projectstate.ActiveRunRecord, flag string) string {
+	flag = strings.TrimSpace(flag)
+	if flag == "" {
+		return ""
+	}
+	_, found, err := store.GetProjectFlag(projectID, flag)
+	if err != nil {
+		slog.Warn("run watchdog flag lookup failed", "project_id", projectID, "run_id", run.RunID, "flag", flag, "err", err)
+		return ""
+	}
+	if !found {
+		slog.Warn("run watchdog flag is not registered for the project, leaving the task unflagged", "project_id", projectID, "run_id", run.RunID, "task_id", run.TaskID, "flag", flag)
+		return ""
+	}
+	return flag
+}
@@ -0,0 +1,175 @@
+package localapi
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func TestRunWatchdog_StallsSilentRunAndResumesOnActivity(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	cfgStore := &mutableConfigStore{cfg: global.GlobalConfig{LocalPort: 4621, RunWatchdog: global.RunWatchdogConfig{
+		SilenceSeconds:        600,
+		AdapterSilenceSeconds: map[string]int{"shell": 0},
+		Flag:                  "error",
+		Interrupt:             true,
+	}}}
+	sender := &fakeTaskPromptSender{}
+	srv := NewServer(Deps{ConfigStore: cfgStore, ProjectsStore: projects, TaskPromptSender: sender})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "agent work", nil)
+	store := projectstate.NewStore(repo)
+	adapter := "codex"
+	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{TaskID: taskID, ProjectID: "p1", ActiveAdapter: &adapter}); err != nil {
+		t.Fatalf("seed adapter failed: %v", err)
+	}
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_watchdog_1", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{RunID: "r_watchdog_1", PaneID: "wd:1.0", PaneTarget: "wd:1.0", BindingStatus: projectstate.BindingStatusLive}); err != nil {
+		t.Fatalf("UpsertRunBinding failed: %v", err)
+	}
+
+	prevNow := runWatchdogNow
+	t.Cleanup(func() { runWatchdogNow = prevNow })
+	now := time.Now().Add(5 * time.Minute)
+	runWatchdogNow = func() time.Time { return now }
+	srv.runWatchdogPass()
+	if run, _ := store.GetRun("r_watchdog_1"); run.RunStatus != projectstate.RunStatusRunning {
+		t.Fatalf("expected run inside its window to keep running, got %q", run.RunStatus)
+	}
+
+	now = time.Now().Add(15 * time.Minute)
+	srv.runWatchdogPass()
+	srv.runWatchdogPass()
+	if run, _ := store.GetRun("r_watchdog_1"); run.RunStatus != projectstate.RunStatusStalled {
+		t.Fatalf("expected stalled run, got %q", run.RunStatus)
+	}
+	if count, _ := store.CountRunEventsByType("r_watchdog_1", "run.stalled"); count != 1 {
+		t.Fatalf("expected one run.stalled event, got %d", count)
+	}
+	if len(sender.calls) != 1 || sender.calls[0].target != "wd:1.0" || sender.calls[0].text != "\x1b" {
+		t.Fatalf("expected one codex interrupt, got %#v", sender.calls)
+	}
+	entry, _, err := findTaskEntryInProject(store, "p1", taskID)
+	if err != nil || entry.Flag != "error" {
+		t.Fatalf("expected error flag on task, got %#v err=%v", entry, err)
+	}
+
+	if err := store.BatchUpsertRuntime(projectstate.RuntimeBatchUpdate{Panes: []projectstate.PaneRuntimeRecord{{
+		PaneID:       "wd:1.0",
+		PaneTarget:   "wd:1.0",
+		SnapshotHash: "h1",
+		UpdatedAt:    now.Add(-time.Minute).Unix(),
+	}}}); err != nil {
+		t.Fatalf("BatchUpsertRuntime failed: %v", err)
+	}
+	srv.runWatchdogPass()
+	if run, _ := store.GetRun("r_watchdog_1"); run.RunStatus != projectstate.RunStatusRunning {
+		t.Fatalf("expected run resumed after pane activity, got %q", run.RunStatus)
+	}
+	if count, _ := store.CountRunEventsByType("r_watchdog_1", "run.resumed"); count != 1 {
+		t.Fatalf("expected one run.resumed event, got %d", count)
+	}
+}
+
+func TestRunWatchdog_FlagFollowsProjectRegistry(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	cfgStore := &mutableConfigStore{cfg: global.GlobalConfig{LocalPort: 4621, RunWatchdog: global.RunWatchdogConfig{
+		SilenceSeconds: 600,
+		Flag:           "error",
+	}}}
+	srv := NewServer(Deps{ConfigStore: cfgStore, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	store := projectstate.NewStore(repo)
+	// The project swaps the default error flag for its own stuck flag.
+	if _, err := store.UpsertProjectFlag("p1", projectstate.ProjectFlagRecord{Name: "stuck", Severity: projectstate.FlagSeverityHigh, Color: "#aa0000"}); err != nil {
+		t.Fatalf("UpsertProjectFlag failed: %v", err)
+	}
+	if err := store.DeleteProjectFlag("p1", "error"); err != nil {
+		t.Fatalf("DeleteProjectFlag failed: %v", err)
+	}
+	seedRun := func(runID string) string {
+		taskID := createTestTask(t, ts.URL, runID, nil)
+		if err := store.InsertRun(projectstate.RunRecord{RunID: runID, TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+			t.Fatalf("InsertRun failed: %v", err)
+		}
+		if err := store.UpsertRunBinding(projectstate.RunBinding{RunID: runID, PaneID: runID, PaneTarget: runID, BindingStatus: projectstate.BindingStatusLive}); err != nil {
+			t.Fatalf("UpsertRunBinding failed: %v", err)
+		}
+		return taskID
+	}
+
+	prevNow := runWatchdogNow
+	t.Cleanup(func() { runWatchdogNow = prevNow })
+	runWatchdogNow = func() time.Time { return time.Now().Add(15 * time.Minute) }
+
+	unregistered := seedRun("r_watchdog_flag_1")
+	srv.runWatchdogPass()
+	if run, _ := store.GetRun("r_watchdog_flag_1"); run.RunStatus != projectstate.RunStatusStalled {
+		t.Fatalf("expected the run stalled even though its flag is unregistered, got %q", run.RunStatus)
+	}
+	if entry, _, err := findTaskEntryInProject(store, "p1", unregistered); err != nil || entry.Flag != "" {
+		t.Fatalf("expected an unregistered flag to be skipped, got %#v err=%v", entry, err)
+	}
+
+	cfgStore.cfg.RunWatchdog.Flag = "stuck"
+	registered := seedRun("r_watchdog_flag_2")
+	srv.runWatchdogPass()
+	if entry, _, err := findTaskEntryInProject(store, "p1", registered); err != nil || entry.Flag != "stuck" {
+		t.Fatalf("expected the registered custom flag on the task, got %#v err=%v", entry, err)
+	}
+}
+
+func TestRunWatchdog_SkipsStallWhenRunFinishedSinceListing(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	sender := &fakeTaskPromptSender{}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, TaskPromptSender: sender})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "agent work", nil)
+	store := projectstate.NewStore(repo)
+	if err := store.InsertRun(projectstate.RunRecord{RunID: "r_watchdog_race", TaskID: taskID, RunStatus: projectstate.RunStatusRunning}); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+	if err := store.UpsertRunBinding(projectstate.RunBinding{RunID: "r_watchdog_race", PaneID: "wd:2.0", PaneTarget: "wd:2.0", BindingStatus: projectstate.BindingStatusLive}); err != nil {
+		t.Fatalf("UpsertRunBinding failed: %v", err)
+	}
+	runs, err := store.ListActiveRunsByProject("p1")
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected one active run, got %d err=%v", len(runs), err)
+	}
+	if err := store.MarkRunCompleted("r_watchdog_race"); err != nil {
+		t.Fatalf("MarkRunCompleted failed: %v", err)
+	}
+
+	now := time.Now().UTC()
+	cfg := global.RunWatchdogConfig{Flag: "error", Interrupt: true}
+	if err := srv.stallRun(store, "p1", runs[0], cfg, time.Minute, now.Add(-time.Hour), now); err != nil {
+		t.Fatalf("stallRun failed: %v", err)
+	}
+	if run, _ := store.GetRun("r_watchdog_race"); run.RunStatus != projectstate.RunStatusCompleted {
+		t.Fatalf("expected completed run left alone, got %q", run.RunStatus)
+	}
+	if count, _ := store.CountRunEventsByType("r_watchdog_race", "run.stalled"); count != 0 {
+		t.Fatalf("expected no run.stalled event, got %d", count)
+	}
+	if len(sender.calls) != 0 {
+		t.Fatalf("expected no interrupt, got %#v", sender.calls)
+	}
+	if actions, _ := store.ListRunActions("r_watchdog_race"); len(actions) != 0 {
+		t.Fatalf("expected no webhook queued, got %#v", actions)
+	}
+}
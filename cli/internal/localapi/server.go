@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/flaboy/agentloop"
 	"shellman/cli/internal/fsbrowser"
 	"shellman/cli/internal/global"
 	"shellman/cli/internal/helperconfig"
 	"shellman/cli/internal/historydb"
+	"shellman/cli/internal/panestats"
 )
 
 type ConfigStore interface {
@@ -44,6 +46,17 @@ type PaneService interface {
 	CaptureHistory(target string, lines int) (string, error)
 }
 
+// PaneOutputSource streams the raw output of a pane as it is written, e.g. from a tmux control-mode client.
+type PaneOutputSource interface {
+	Subscribe(target string) (<-chan string, func(), error)
+}
+
+// PaneResourceSampler samples the processes running in panes, pane id to pane shell pid, and keeps recent samples.
+type PaneResourceSampler interface {
+	Sample(now time.Time, panes map[string]int) []panestats.Sample
+	Recent(paneID string) []panestats.Sample
+}
+
 type FSBrowser interface {
 	Roots() ([]string, error)
 	List(path string) (fsbrowser.ListResult, error)
@@ -94,6 +107,8 @@ type Deps struct {
 	HelperConfigStore   HelperConfigStore
 	ProjectsStore       ProjectsStore
 	PaneService         PaneService
+	PaneOutputSource    PaneOutputSource
+	PaneResources       PaneResourceSampler
 	TaskPromptSender    TaskPromptSender
 	ExecuteCommand      CommandRunner
 	PickDirectory       func() (string, error)
@@ -123,9 +138,31 @@ type Server struct {
 
 	skillIndexMu    sync.Mutex
 	skillIndexCache map[string]skillIndexCacheEntry
+
+	runActionMu       sync.Mutex
+	runActionHandlers map[string]RunActionHandler
+	runActionWake     chan struct{}
+
+	idleNotifyMu       sync.Mutex
+	idleNotifications  map[string]*PendingCompletionNotification
+	idleNotifyInterval time.Duration
+	idleNotifyNow      func() time.Time
+
+	runWatchdogMu   sync.Mutex
+	runWatchdogSeen map[string]runWatchdogObservation
+
+	runRecordingMu       sync.Mutex
+	runRecorders         map[string]*runRecorder
+	runRecordingPrunedAt time.Time
+
+	runArtifactsMu       sync.Mutex
+	runArtifactsPrunedAt time.Time
 }
 
 func NewServer(deps Deps) *Server {
+	if deps.PaneResources == nil {
+		deps.PaneResources = panestats.NewSampler(panestats.Options{})
+	}
 	s := &Server{deps: deps, mux: http.NewServeMux(), hub: NewWSHub()}
 	s.taskAgentSupervisor = newTaskAgentLoopSupervisor(nil, s.handleTaskAgentLoopEvent)
 	s.pmAgentSupervisor = newProjectManagerLoopSupervisor(s.handleProjectManagerLoopEvent)
@@ -133,11 +170,22 @@ func NewServer(deps Deps) *Server {
 	s.taskAgentDetectorByTask = map[string]string{}
 	s.taskMessageRunByTask = map[string]taskMessageRunState{}
 	s.skillIndexCache = map[string]skillIndexCacheEntry{}
+	s.runActionHandlers = map[string]RunActionHandler{}
+	s.runActionWake = make(chan struct{}, 1)
+	s.idleNotifications = map[string]*PendingCompletionNotification{}
+	s.idleNotifyInterval = idleNotificationCheckInterval
+	s.runWatchdogSeen = map[string]runWatchdogObservation{}
+	s.runRecorders = map[string]*runRecorder{}
+	s.RegisterRunActionHandler(runActionCompletionDispatch, s.handleRunCompletionDispatchAction)
+	s.RegisterRunActionHandler(runActionTaskCompletionDispatch, s.handleTaskCompletionDispatchAction)
+	s.RegisterRunActionHandler(runActionWebhookDelivery, s.handleWebhookDeliveryAction)
+	s.RegisterRunActionHandler(runActionCaptureArtifacts, s.handleCaptureRunArtifactsAction)
 	s.registerConfigRoutes()
 	s.registerProjectsRoutes()
 	s.registerSystemRoutes()
 	s.registerFSRoutes()
 	s.registerTaskRoutes()
+	s.registerSearchRoutes()
 	s.registerRunRoutes()
 	s.registerPaneRoutes()
 	s.mux.HandleFunc("/healthz", s.handleHealth)
@@ -149,6 +197,26 @@ func (s *Server) Handler() http.Handler {
 	return s.mux
 }
 
+// BackgroundLoop is a loop a Server needs running next to its handler; Run blocks until ctx is canceled.
+type BackgroundLoop struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// BackgroundLoops returns the loops that follow up on what the server accepts: queued run actions such as completion
+// notifications and artifact capture, archive retention, flag escalation, the run watchdog, pane recovery and run
+// recording. Every process serving the task API must run all of them, e.g. by registering them with lifecycle.Manager.
+func (s *Server) BackgroundLoops() []BackgroundLoop {
+	return []BackgroundLoop{
+		{Name: "run-action-dispatcher", Run: s.RunActionDispatcher},
+		{Name: "archive-retention", Run: s.ArchiveRetentionLoop},
+		{Name: "flag-escalation", Run: s.FlagEscalationLoop},
+		{Name: "run-watchdog", Run: s.RunWatchdogLoop},
+		{Name: "pane-recovery", Run: s.PaneRecoveryLoop},
+		{Name: "run-recording", Run: s.RunRecordingLoop},
+	}
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	respondOK(w, map[string]any{"status": "ok"})
 }
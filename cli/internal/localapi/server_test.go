@@ -112,6 +112,31 @@ func (fakeAgentLoopRunner) Run(_ context.Context, userPrompt string) (string, er
 	return "echo:" + userPrompt, nil
 }
 
+// createTestTask creates a task in project p1 through the API and returns its id. fields adds to the request body,
+// e.g. parent_task_id or task_role.
+func createTestTask(t *testing.T, baseURL, title string, fields map[string]any) string {
+	t.Helper()
+	body := map[string]any{"project_id": "p1", "title": title}
+	for k, v := range fields {
+		body[k] = v
+	}
+	raw, _ := json.Marshal(body)
+	resp, err := http.Post(baseURL+"/api/v1/tasks", "application/json", bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("POST tasks failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var out struct {
+		Data struct {
+			TaskID string `json:"task_id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.Data.TaskID == "" {
+		t.Fatalf("create task %q failed: status=%d err=%v", title, resp.StatusCode, err)
+	}
+	return out.Data.TaskID
+}
+
 // uniqueTaskID returns a task id unique to this test so shared global DB does not conflict.
 func uniqueTaskID(t *testing.T, base string) string {
 	t.Helper()
@@ -2,7 +2,6 @@ package localapi
 
 import (
 	"context"
-	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -18,7 +17,7 @@ var (
 	shellReadyPollInterval = 120 * time.Millisecond
 )
 
-func (s *Server) waitPaneShellReady(ctx context.Context, paneTarget string) (bool, error) {
+func (s *Server) waitPaneShellReady(ctx context.Context, socket, paneTarget string) (bool, error) {
 	target := strings.TrimSpace(paneTarget)
 	if target == "" {
 		return false, nil
@@ -34,7 +33,7 @@ func (s *Server) waitPaneShellReady(ctx context.Context, paneTarget string) (boo
 
 	deadline := time.Now().Add(timeout)
 	for {
-		readyValue, err := s.readPaneOptionValue(ctx, target, shellReadyPaneOptionKey)
+		readyValue, err := s.readPaneOptionValue(ctx, socket, target, shellReadyPaneOptionKey)
 		if err != nil {
 			return false, err
 		}
@@ -52,12 +51,20 @@ func (s *Server) waitPaneShellReady(ctx context.Context, paneTarget string) (boo
 	}
 }
 
-func (s *Server) readPaneOptionValue(ctx context.Context, paneTarget, key string) (string, error) {
+// paneOptionProvider is implemented by pane backends that keep pane options themselves instead of in a tmux server.
+type paneOptionProvider interface {
+	PaneOption(target, key string) (string, error)
+}
+
+func (s *Server) readPaneOptionValue(ctx context.Context, socket, paneTarget, key string) (string, error) {
 	target := strings.TrimSpace(paneTarget)
 	key = strings.TrimSpace(key)
 	if target == "" || key == "" {
 		return "", nil
 	}
+	if provider, ok := s.paneServiceOnSocket(socket).(paneOptionProvider); ok {
+		return provider.PaneOption(target, key)
+	}
 	execute := s.deps.ExecuteCommand
 	if execute == nil {
 		execute = func(ctx context.Context, name string, args ...string) ([]byte, error) {
@@ -67,10 +74,7 @@ func (s *Server) readPaneOptionValue(ctx context.Context, paneTarget, key string
 	execCtx, cancel := context.WithTimeout(ctx, 1500*time.Millisecond)
 	defer cancel()
 
-	args := []string{}
-	if socket := strings.TrimSpace(os.Getenv("SHELLMAN_TMUX_SOCKET")); socket != "" {
-		args = append(args, "-L", socket)
-	}
+	args := tmuxSocketArgs(socket)
 	format := "#{@" + strings.TrimPrefix(key, "@") + "}"
 	args = append(args, "display-message", "-p", "-t", target, format)
 	out, err := execute(execCtx, "tmux", args...)
@@ -991,6 +991,7 @@ func resolveTaskAgentToolModeAndNamesFromInputsForSource(currentCommand, sidecar
 	default:
 		fullTools = append(fullTools, "readfile", "write_stdin")
 	}
+	fullTools = append(fullTools, "task.current.signal")
 	fullTools = applyTaskRoleToolScope(taskRole, fullTools)
 
 	if !isAutoProcessTurnSource(source) {
@@ -1015,7 +1016,7 @@ func isAutoProcessTurnSource(source string) bool {
 
 func (s *Server) resolveTaskAgentToolModeAndNamesRealtime(store *projectstate.Store, projectID, taskID, source string) (string, string, []string) {
 	storedCommand, sidecarMode, taskRole, activeAdapter := resolveTaskAgentModeInputs(store, projectID, taskID)
-	runtimeState := s.detectTaskPaneRuntimeState(store, taskID)
+	runtimeState := s.detectTaskPaneRuntimeState(store, projectID, taskID)
 	currentCommand := strings.TrimSpace(runtimeState.CurrentCommand)
 	if currentCommand == "" {
 		currentCommand = strings.TrimSpace(storedCommand)
@@ -1067,6 +1068,7 @@ func buildTaskAgentToolsForResolvedMode(mode taskAgentToolMode, sidecarMode, tas
 	default:
 		fullTools = append(fullTools, "readfile", "write_stdin")
 	}
+	fullTools = append(fullTools, "task.current.signal")
 	fullTools = applyTaskRoleToolScope(taskRole, fullTools)
 
 	if !isAutoProcessTurnSource(source) {
@@ -1104,6 +1106,7 @@ func applyTaskRoleToolScope(taskRole string, tools []string) []string {
 			"write_stdin",
 			"exec_command",
 			"task.input_prompt",
+			"task.current.signal",
 		)
 	default:
 		return tools
@@ -1148,7 +1151,7 @@ func (s *Server) setTaskAgentMode(taskID string, mode taskAgentToolMode) {
 	s.taskAgentModeMu.Unlock()
 }
 
-func (s *Server) detectTaskPaneRuntimeState(store *projectstate.Store, taskID string) progdetector.RuntimeState {
+func (s *Server) detectTaskPaneRuntimeState(store *projectstate.Store, projectID, taskID string) progdetector.RuntimeState {
 	if s == nil || store == nil {
 		return progdetector.RuntimeState{}
 	}
@@ -1167,7 +1170,7 @@ func (s *Server) detectTaskPaneRuntimeState(store *projectstate.Store, taskID st
 	if target == "" {
 		return progdetector.RuntimeState{}
 	}
-	return s.detectPaneRuntimeState(target)
+	return s.detectPaneRuntimeState(projectID, target)
 }
 
 func resolveTaskAgentToolModeFromCommand(command string) taskAgentToolMode {
@@ -67,6 +67,7 @@ func TestResolveTaskAgentToolModeAndNames_AutopilotAIAgentIncludesWriteStdin(t *
 		"task.input_prompt",
 		"readfile",
 		"write_stdin",
+		"task.current.signal",
 	}
 	if !reflect.DeepEqual(gotTools, wantTools) {
 		t.Fatalf("unexpected tools: got=%#v want=%#v", gotTools, wantTools)
@@ -85,6 +86,7 @@ func TestResolveTaskAgentToolModeAndNames_UserTurn_IgnoresSidecarMode(t *testing
 		"task.input_prompt",
 		"readfile",
 		"write_stdin",
+		"task.current.signal",
 	}
 	for _, mode := range []string{
 		projectstate.SidecarModeAdvisor,
@@ -156,6 +158,7 @@ func TestResolveTaskAgentToolModeAndNamesRealtime_UsesLivePaneCommandInAutopilot
 		"task.input_prompt",
 		"readfile",
 		"write_stdin",
+		"task.current.signal",
 	}) {
 		t.Fatalf("unexpected tools: %#v", gotTools)
 	}
@@ -209,6 +212,7 @@ func TestResolveTaskAgentToolModeAndNamesRealtime_DetectMissFallsBackToStoredCom
 		"task.input_prompt",
 		"readfile",
 		"write_stdin",
+		"task.current.signal",
 	}) {
 		t.Fatalf("unexpected tools: %#v", gotTools)
 	}
@@ -415,6 +419,7 @@ func TestResolveTaskAgentToolModeAndNames_AutoTurnDiffersBySidecarMode(t *testin
 		"task.input_prompt",
 		"readfile",
 		"write_stdin",
+		"task.current.signal",
 	}
 
 	mode, gotCommand, tools := resolveTaskAgentToolModeAndNamesFromInputsForSource(currentCommand, projectstate.SidecarModeAdvisor, projectstate.TaskRoleFull, "tty_output")
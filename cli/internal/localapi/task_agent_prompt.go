@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -42,6 +43,7 @@ type TaskAgentAutoProgressPromptInput struct {
 	Name              string
 	Description       string
 	Summary           string
+	ExitCode          *int
 	HistoryBlock      string
 	PrevFlag          string
 	PrevStatusMessage string
@@ -86,7 +88,13 @@ func buildTaskAgentAutoProgressPrompt(input TaskAgentAutoProgressPromptInput) st
 	b.WriteString("\n")
 	b.WriteString("summary: ")
 	b.WriteString(input.Summary)
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	if input.ExitCode != nil {
+		b.WriteString("exit_code: ")
+		b.WriteString(strconv.Itoa(*input.ExitCode))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 	b.WriteString("system_context_json:\n")
 	b.WriteString(systemContextJSON)
 	b.WriteString("\n\n")
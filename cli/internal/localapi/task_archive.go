@@ -0,0 +1,278 @@
+package localapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/projectstate"
+)
+
+const archiveRetentionInterval = time.Hour
+
+var archiveRetentionNow = time.Now
+
+type archivedTaskItem struct {
+	TaskID       string `json:"task_id"`
+	ParentTaskID string `json:"parent_task_id,omitempty"`
+	Title        string `json:"title"`
+	Status       string `json:"status"`
+	Flag         string `json:"flag,omitempty"`
+	TaskRole     string `json:"task_role,omitempty"`
+	CreatedAt    int64  `json:"created_at"`
+	LastModified int64  `json:"last_modified"`
+}
+
+func (s *Server) handleProjectArchiveRoutes(w http.ResponseWriter, r *http.Request, projectID string, parts []string) bool {
+	if len(parts) < 2 || parts[1] != "archive" {
+		return false
+	}
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.handleListArchivedTasks(w, r, projectID)
+	case len(parts) == 3 && parts[2] == "purge" && r.Method == http.MethodPost:
+		s.handlePurgeArchivedTasks(w, r, projectID)
+	case len(parts) == 2 || (len(parts) == 3 && parts[2] == "purge"):
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+	default:
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "route not found")
+	}
+	return true
+}
+
+func (s *Server) handleListArchivedTasks(w http.ResponseWriter, r *http.Request, projectID string) {
+	limit, err := parseArchivePageParam(r, "limit", 50, 1, 200)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_LIMIT", err.Error())
+		return
+	}
+	offset, err := parseArchivePageParam(r, "offset", 0, 0, -1)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_OFFSET", err.Error())
+		return
+	}
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	page, err := projectstate.NewStore(repoRoot).ListArchivedTasks(projectID, limit, offset)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "ARCHIVE_LOAD_FAILED", err.Error())
+		return
+	}
+	items := make([]archivedTaskItem, 0, len(page.Tasks))
+	for _, row := range page.Tasks {
+		items = append(items, archivedTaskItem{
+			TaskID:       row.TaskID,
+			ParentTaskID: row.ParentTaskID,
+			Title:        row.Title,
+			Status:       row.Status,
+			Flag:         row.Flag,
+			TaskRole:     row.TaskRole,
+			CreatedAt:    row.CreatedAt,
+			LastModified: row.LastModified,
+		})
+	}
+	respondOK(w, map[string]any{
+		"project_id": projectID,
+		"tasks":      items,
+		"total":      page.Total,
+		"limit":      page.Limit,
+		"offset":     page.Offset,
+	})
+}
+
+func parseArchivePageParam(r *http.Request, name string, fallback, minValue, maxValue int) (int, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get(name))
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < minValue || (maxValue >= 0 && value > maxValue) {
+		return 0, errors.New(name + " is out of range")
+	}
+	return value, nil
+}
+
+// handlePurgeArchivedTasks runs the retention purge on demand. older_than_days defaults to the configured retention.
+func (s *Server) handlePurgeArchivedTasks(w http.ResponseWriter, r *http.Request, projectID string) {
+	var req struct {
+		OlderThanDays *int `json:"older_than_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	days := 0
+	if req.OlderThanDays != nil {
+		days = *req.OlderThanDays
+	} else if cfg, err := s.deps.ConfigStore.LoadOrInit(); err == nil {
+		days = cfg.TaskArchive.RetentionDays
+	} else {
+		respondError(w, http.StatusInternalServerError, "CONFIG_LOAD_FAILED", err.Error())
+		return
+	}
+	if days <= 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_RETENTION_DAYS", "older_than_days must be > 0 when no retention is configured")
+		return
+	}
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	purged, err := s.purgeArchivedTasks(projectstate.NewStore(repoRoot), projectID, days)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "ARCHIVE_PURGE_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{"project_id": projectID, "purged_task_ids": purged})
+}
+
+func (s *Server) purgeArchivedTasks(store *projectstate.Store, projectID string, days int) ([]string, error) {
+	cutoff := archiveRetentionNow().UTC().Add(-time.Duration(days) * 24 * time.Hour).Unix()
+	// The purge deletes dependency rows with the tasks, so collect the edges first.
+	edges, err := store.ListTaskDependenciesByProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := store.PurgeArchivedTasks(projectID, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	removeRunArtifacts(result.RunArtifactDirs)
+	purged := result.TaskIDs
+	if len(purged) > 0 {
+		slog.Info("archive.purge.succeeded", "project_id", projectID, "purged_count", len(purged), "retention_days", days)
+		s.publishEvent("task.archive.purged", projectID, "", map[string]any{"task_ids": purged})
+		purgedIDs := make(map[string]struct{}, len(purged))
+		for _, taskID := range purged {
+			purgedIDs[taskID] = struct{}{}
+		}
+		released := make([]projectstate.TaskDependencyRecord, 0, len(edges))
+		for _, edge := range edges {
+			if _, ok := purgedIDs[edge.BlockerTaskID]; ok {
+				released = append(released, edge)
+			}
+		}
+		s.releaseDependentEdges(projectID, store, released, "blocker_purged")
+	}
+	return purged, nil
+}
+
+// ArchiveRetentionLoop purges expired archived tasks of every project until ctx is canceled. It is meant to be
+// registered with lifecycle.Manager next to RunActionDispatcher.
+func (s *Server) ArchiveRetentionLoop(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	ticker := time.NewTicker(archiveRetentionInterval)
+	defer ticker.Stop()
+	for {
+		s.runArchiveRetentionPass()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) runArchiveRetentionPass() {
+	if s.deps.ConfigStore == nil || s.deps.ProjectsStore == nil {
+		return
+	}
+	cfg, err := s.deps.ConfigStore.LoadOrInit()
+	if err != nil {
+		slog.Warn("archive retention config load failed", "err", err)
+		return
+	}
+	if cfg.TaskArchive.RetentionDays <= 0 {
+		return
+	}
+	projects, err := s.deps.ProjectsStore.ListProjects()
+	if err != nil {
+		slog.Warn("archive retention project list failed", "err", err)
+		return
+	}
+	for _, p := range projects {
+		if _, err := s.purgeArchivedTasks(projectstate.NewStore(p.RepoRoot), p.ProjectID, cfg.TaskArchive.RetentionDays); err != nil {
+			slog.Warn("archive retention purge failed", "project_id", p.ProjectID, "err", err)
+		}
+	}
+}
+
+// handleUnarchiveTask restores an archived task and any archived ancestors so it reappears in the tree. The task
+// is also unchecked, otherwise the next archive-done would archive it again.
+func (s *Server) handleUnarchiveTask(w http.ResponseWriter, _ *http.Request, taskID string) {
+	projectID, store, rows, err := s.findTaskIncludingArchived(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	byID := make(map[string]projectstate.TaskRecordRow, len(rows))
+	for _, row := range rows {
+		byID[row.TaskID] = row
+	}
+	if !byID[taskID].Archived {
+		respondError(w, http.StatusConflict, "TASK_NOT_ARCHIVED", "task is not archived")
+		return
+	}
+	notArchived := false
+	unchecked := false
+	restored := make([]string, 0)
+	inputs := make([]projectstate.TaskMetaUpsert, 0)
+	seen := map[string]struct{}{}
+	for current := taskID; current != ""; current = strings.TrimSpace(byID[current].ParentTaskID) {
+		row, ok := byID[current]
+		if _, loop := seen[current]; loop || !ok || !row.Archived {
+			break
+		}
+		seen[current] = struct{}{}
+		input := projectstate.TaskMetaUpsert{
+			TaskID:    current,
+			ProjectID: projectID,
+			Archived:  &notArchived,
+			Source:    projectstate.TaskRevisionSourceUser,
+		}
+		if current == taskID {
+			input.Checked = &unchecked
+		}
+		inputs = append(inputs, input)
+		restored = append(restored, current)
+	}
+	if err := store.BatchUpsertTaskMeta(inputs); err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_UNARCHIVE_FAILED", err.Error())
+		return
+	}
+	s.publishEvent("task.unarchived", projectID, taskID, map[string]any{"task_ids": restored})
+	s.publishEvent("task.tree.updated", projectID, taskID, map[string]any{})
+	respondOK(w, map[string]any{"task_id": taskID, "unarchived_task_ids": restored})
+}
+
+func (s *Server) findTaskIncludingArchived(taskID string) (string, *projectstate.Store, []projectstate.TaskRecordRow, error) {
+	projects, err := s.deps.ProjectsStore.ListProjects()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	for _, p := range projects {
+		store := projectstate.NewStore(p.RepoRoot)
+		rows, err := store.ListTasksByProjectWithArchived(p.ProjectID, true)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		for _, row := range rows {
+			if row.TaskID == taskID {
+				return p.ProjectID, store, rows, nil
+			}
+		}
+	}
+	return "", nil, nil, errors.New("task not found")
+}
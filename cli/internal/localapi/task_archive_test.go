@@ -0,0 +1,87 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+)
+
+func TestTaskArchive_ListUnarchiveAndPurge(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	keep := createTestTask(t, ts.URL, "keep", nil)
+	drop := createTestTask(t, ts.URL, "drop", nil)
+	if code, _ := postTaskBatch(t, ts.URL, map[string]any{"project_id": "p1", "op": "archive", "task_ids": []string{keep, drop}}); code != http.StatusOK {
+		t.Fatalf("expected archive batch 200, got %d", code)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/projects/p1/archive?limit=1")
+	if err != nil {
+		t.Fatalf("GET archive failed: %v", err)
+	}
+	var listOut struct {
+		Data struct {
+			Tasks []archivedTaskItem `json:"tasks"`
+			Total int64              `json:"total"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listOut); err != nil {
+		t.Fatalf("decode archive list failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if listOut.Data.Total != 2 || len(listOut.Data.Tasks) != 1 || listOut.Data.Tasks[0].TaskID == "" {
+		t.Fatalf("unexpected archive page: %#v", listOut.Data)
+	}
+
+	resp, err = http.Post(ts.URL+"/api/v1/tasks/"+keep+"/unarchive", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST unarchive failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from unarchive, got %d", resp.StatusCode)
+	}
+	if node := loadDependencyTestNode(t, ts.URL, keep); node.Archived || node.Checked {
+		t.Fatalf("expected restored task visible and unchecked, got %#v", node)
+	}
+
+	prevNow := archiveRetentionNow
+	archiveRetentionNow = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	t.Cleanup(func() { archiveRetentionNow = prevNow })
+	resp, err = http.Post(ts.URL+"/api/v1/projects/p1/archive/purge", "application/json", bytes.NewBufferString(`{"older_than_days":1}`))
+	if err != nil {
+		t.Fatalf("POST purge failed: %v", err)
+	}
+	var purgeOut struct {
+		Data struct {
+			PurgedTaskIDs []string `json:"purged_task_ids"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&purgeOut); err != nil {
+		t.Fatalf("decode purge failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if !reflect.DeepEqual(purgeOut.Data.PurgedTaskIDs, []string{drop}) {
+		t.Fatalf("expected only the archived task purged, got %#v", purgeOut.Data.PurgedTaskIDs)
+	}
+
+	resp, err = http.Post(ts.URL+"/api/v1/tasks/"+drop+"/unarchive", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST unarchive failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for purged task, got %d", resp.StatusCode)
+	}
+}
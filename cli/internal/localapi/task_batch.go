@@ -0,0 +1,285 @@
+package localapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"shellman/cli/internal/projectstate"
+)
+
+const (
+	taskBatchOpSetStatus      = "set_status"
+	taskBatchOpSetSidecarMode = "set_sidecar_mode"
+	taskBatchOpMarkFlagRead   = "mark_flag_read"
+	taskBatchOpCheck          = "check"
+	taskBatchOpUncheck        = "uncheck"
+	taskBatchOpArchive        = "archive"
+	taskBatchOpClosePanes     = "close_panes"
+
+	maxTaskBatchSize = 500
+)
+
+type taskBatchItemResult struct {
+	TaskID  string `json:"task_id"`
+	OK      bool   `json:"ok"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// handleBatchTasks applies one operation to many tasks of a project and reports per-task problems (unknown task,
+// rejected transition, pane close failure) in results. Field updates commit in a single transaction before any pane
+// is closed; set_status goes through transitionTaskStatus task by task, so it is not atomic across the batch. The
+// tree is published once.
+func (s *Server) handleBatchTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+		return
+	}
+	var req struct {
+		ProjectID   string   `json:"project_id"`
+		TaskIDs     []string `json:"task_ids"`
+		Op          string   `json:"op"`
+		Status      string   `json:"status"`
+		SidecarMode string   `json:"sidecar_mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	projectID := strings.TrimSpace(req.ProjectID)
+	if projectID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_PROJECT_ID", "project_id is required")
+		return
+	}
+	taskIDs := make([]string, 0, len(req.TaskIDs))
+	seen := map[string]struct{}{}
+	for _, raw := range req.TaskIDs {
+		taskID := strings.TrimSpace(raw)
+		if taskID == "" {
+			continue
+		}
+		if _, ok := seen[taskID]; ok {
+			continue
+		}
+		seen[taskID] = struct{}{}
+		taskIDs = append(taskIDs, taskID)
+	}
+	if len(taskIDs) == 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_TASK_IDS", "task_ids is required")
+		return
+	}
+	if len(taskIDs) > maxTaskBatchSize {
+		respondError(w, http.StatusBadRequest, "INVALID_TASK_IDS", "too many task_ids")
+		return
+	}
+	op := strings.TrimSpace(req.Op)
+	status := strings.TrimSpace(req.Status)
+	sidecarMode := normalizeSidecarMode(req.SidecarMode)
+	switch op {
+	case taskBatchOpSetStatus:
+		if _, ok := validTaskStatus[status]; !ok {
+			respondError(w, http.StatusBadRequest, "INVALID_STATUS", "unsupported status")
+			return
+		}
+	case taskBatchOpSetSidecarMode:
+		if strings.TrimSpace(req.SidecarMode) == "" || sidecarMode == "" {
+			respondError(w, http.StatusBadRequest, "INVALID_SIDECAR_MODE", errInvalidSidecarMode.Error())
+			return
+		}
+	case taskBatchOpMarkFlagRead, taskBatchOpCheck, taskBatchOpUncheck, taskBatchOpArchive, taskBatchOpClosePanes:
+	default:
+		respondError(w, http.StatusBadRequest, "INVALID_BATCH_OP", "unsupported op")
+		return
+	}
+
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	store := projectstate.NewStore(repoRoot)
+	rows, err := store.ListTasksByProject(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_BATCH_FAILED", err.Error())
+		return
+	}
+	byID := make(map[string]projectstate.TaskRecordRow, len(rows))
+	for _, row := range rows {
+		byID[row.TaskID] = row
+	}
+
+	results := make([]taskBatchItemResult, 0, len(taskIDs))
+	accepted := make([]string, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		if _, ok := byID[taskID]; !ok {
+			results = append(results, taskBatchItemResult{TaskID: taskID, Code: "TASK_NOT_FOUND", Message: "task not found"})
+			continue
+		}
+		results = append(results, taskBatchItemResult{TaskID: taskID, OK: true})
+		accepted = append(accepted, taskID)
+	}
+
+	failed := map[string]taskBatchItemResult{}
+	switch op {
+	case taskBatchOpSetStatus:
+		for _, taskID := range accepted {
+			changed, err := s.transitionTaskStatus(store, projectID, taskID, status, projectstate.StatusTriggerUpdate, projectstate.TaskRevisionSourceUser)
+			if err != nil {
+				code := "TASK_UPDATE_FAILED"
+				if errors.Is(err, projectstate.ErrInvalidStatusTransition) {
+					code = "INVALID_STATUS_TRANSITION"
+				}
+				failed[taskID] = taskBatchItemResult{TaskID: taskID, Code: code, Message: err.Error()}
+				continue
+			}
+			if !changed {
+				continue
+			}
+			s.publishEvent("task.status.updated", projectID, taskID, map[string]any{"status": status})
+			if status == projectstate.StatusCompleted {
+				s.enqueueTaskCompletionActions(projectID, taskID, "", "batch", nil, buildTaskCompletionRequestMeta(r))
+			}
+		}
+	case taskBatchOpClosePanes:
+		closeFailed, err := s.closeTaskBatchPanes(store, projectID, accepted)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "TASK_BATCH_FAILED", err.Error())
+			return
+		}
+		for taskID, msg := range closeFailed {
+			failed[taskID] = taskBatchItemResult{TaskID: taskID, Code: "PANE_CLOSE_FAILED", Message: msg}
+		}
+	default:
+		inputs := make([]projectstate.TaskMetaUpsert, 0, len(accepted))
+		for _, taskID := range accepted {
+			input := projectstate.TaskMetaUpsert{TaskID: taskID, ProjectID: projectID, Source: projectstate.TaskRevisionSourceUser}
+			switch op {
+			case taskBatchOpSetSidecarMode:
+				next := sidecarMode
+				input.SidecarMode = &next
+			case taskBatchOpMarkFlagRead:
+				next := true
+				input.FlagReaded = &next
+			case taskBatchOpCheck, taskBatchOpUncheck:
+				next := op == taskBatchOpCheck
+				input.Checked = &next
+			case taskBatchOpArchive:
+				next := true
+				input.Archived = &next
+			}
+			inputs = append(inputs, input)
+		}
+		if err := store.BatchUpsertTaskMeta(inputs); err != nil {
+			respondError(w, http.StatusInternalServerError, "TASK_BATCH_FAILED", err.Error())
+			return
+		}
+		switch op {
+		case taskBatchOpSetSidecarMode:
+			if s.taskAgentSupervisor != nil {
+				for _, taskID := range accepted {
+					_ = s.taskAgentSupervisor.SetSidecarMode(taskID, sidecarMode)
+				}
+			}
+		case taskBatchOpArchive:
+			parents := map[string]struct{}{}
+			openBlockers := map[string]struct{}{}
+			for _, taskID := range accepted {
+				if strings.TrimSpace(byID[taskID].Status) != projectstate.StatusCompleted {
+					openBlockers[taskID] = struct{}{}
+				}
+				parentID := strings.TrimSpace(byID[taskID].ParentTaskID)
+				if _, done := parents[parentID]; done || parentID == "" {
+					continue
+				}
+				parents[parentID] = struct{}{}
+				if err := s.syncParentWaitingChildren(store, projectID, parentID); err != nil {
+					slog.Warn("task.batch.sync_parent_failed", "project_id", projectID, "task_id", parentID, "err", err)
+				}
+			}
+			s.releaseArchivedBlockers(projectID, store, openBlockers)
+			// The tasks are archived at this point; a pane that cannot be closed is reported, not rolled back.
+			closeFailed, err := s.closeTaskBatchPanes(store, projectID, accepted)
+			if err != nil {
+				closeFailed = make(map[string]string, len(accepted))
+				for _, taskID := range accepted {
+					closeFailed[taskID] = err.Error()
+				}
+			}
+			for taskID, msg := range closeFailed {
+				failed[taskID] = taskBatchItemResult{TaskID: taskID, Code: "PANE_CLOSE_FAILED", Message: "archived, but the pane was not closed: " + msg}
+			}
+		}
+	}
+
+	// Field updates committed for every accepted task, even those whose pane then failed to close; a failed status
+	// transition changed nothing.
+	updated := make([]string, 0, len(accepted))
+	for _, taskID := range accepted {
+		if _, ok := failed[taskID]; ok && op == taskBatchOpSetStatus {
+			continue
+		}
+		updated = append(updated, taskID)
+	}
+	succeeded := 0
+	for i := range results {
+		if item, ok := failed[results[i].TaskID]; ok {
+			results[i] = item
+		}
+		if results[i].OK {
+			succeeded++
+		}
+	}
+	if len(updated) > 0 {
+		s.publishEvent("task.tree.updated", projectID, "", map[string]any{"op": op, "task_ids": updated})
+	}
+	respondOK(w, map[string]any{
+		"project_id": projectID,
+		"op":         op,
+		"results":    results,
+		"succeeded":  succeeded,
+		"failed":     len(results) - succeeded,
+	})
+}
+
+// closeTaskBatchPanes closes the panes bound to taskIDs and drops their bindings. It returns the tasks whose pane
+// could not be closed; panes that are already gone count as closed, as in archive-done.
+func (s *Server) closeTaskBatchPanes(store *projectstate.Store, projectID string, taskIDs []string) (map[string]string, error) {
+	panes, err := store.LoadPanes()
+	if err != nil {
+		return nil, err
+	}
+	failed := map[string]string{}
+	dirty := false
+	for _, taskID := range taskIDs {
+		binding, ok := panes[taskID]
+		if !ok {
+			continue
+		}
+		target := strings.TrimSpace(binding.PaneTarget)
+		if target != "" {
+			if s.deps.PaneService == nil {
+				failed[taskID] = "pane service is not configured"
+				continue
+			}
+			if err := s.paneServiceFor(projectID).ClosePane(target); err != nil && !isArchiveIgnorablePaneCloseError(err) {
+				slog.Error("task.batch.close_pane_failed", "project_id", projectID, "task_id", taskID, "pane_target", target, "err", err)
+				failed[taskID] = err.Error()
+				continue
+			}
+		}
+		delete(panes, taskID)
+		dirty = true
+		if err := s.cancelTaskRuns(store, projectID, taskID, runPaneClosedReason, projectstate.TaskRevisionSourceUser); err != nil {
+			slog.Warn("task.batch.cancel_runs_failed", "project_id", projectID, "task_id", taskID, "err", err)
+		}
+	}
+	if dirty {
+		if err := store.SavePanes(panes); err != nil {
+			return nil, err
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,138 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+type taskBatchTestResponse struct {
+	Results   []taskBatchItemResult `json:"results"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+}
+
+func postTaskBatch(t *testing.T, baseURL string, body map[string]any) (int, taskBatchTestResponse) {
+	t.Helper()
+	raw, _ := json.Marshal(body)
+	resp, err := http.Post(baseURL+"/api/v1/tasks/batch", "application/json", bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("POST batch failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var out struct {
+		Data taskBatchTestResponse `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out.Data
+}
+
+func TestTaskBatch_AppliesOperationWithPerItemResults(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	panes := &fakePaneService{}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	first := createTestTask(t, ts.URL, "first", nil)
+	second := createTestTask(t, ts.URL, "second", nil)
+
+	code, out := postTaskBatch(t, ts.URL, map[string]any{"project_id": "p1", "op": "set_status", "status": "bogus", "task_ids": []string{first}})
+	if code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid status, got %d", code)
+	}
+
+	code, out = postTaskBatch(t, ts.URL, map[string]any{"project_id": "p1", "op": "set_status", "status": projectstate.StatusWaitingUser, "task_ids": []string{first, "t_missing", second}})
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if out.Succeeded != 2 || out.Failed != 1 || out.Results[1].Code != "TASK_NOT_FOUND" {
+		t.Fatalf("unexpected batch results: %#v", out)
+	}
+	for _, taskID := range []string{first, second} {
+		if node := loadDependencyTestNode(t, ts.URL, taskID); node.Status != projectstate.StatusWaitingUser {
+			t.Fatalf("expected %s waiting_user, got %q", taskID, node.Status)
+		}
+	}
+
+	_, store, _, err := srv.findTask(first)
+	if err != nil {
+		t.Fatalf("findTask failed: %v", err)
+	}
+	if err := store.SavePanes(map[string]projectstate.PaneBinding{first: {TaskID: first, PaneID: "e2e:0.1", PaneTarget: "e2e:0.1"}}); err != nil {
+		t.Fatalf("SavePanes failed: %v", err)
+	}
+	code, out = postTaskBatch(t, ts.URL, map[string]any{"project_id": "p1", "op": "archive", "task_ids": []string{first, second}})
+	if code != http.StatusOK || out.Succeeded != 2 {
+		t.Fatalf("expected archive of both tasks, got %d %#v", code, out)
+	}
+	if !reflect.DeepEqual(panes.closedTargets, []string{"e2e:0.1"}) {
+		t.Fatalf("expected bound pane closed, got %#v", panes.closedTargets)
+	}
+	bindings, err := store.LoadPanes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bindings[first]; ok {
+		t.Fatalf("expected binding removed after archive")
+	}
+	rows, err := store.ListTasksByProject("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 0 {
+		t.Fatalf("expected archived tasks hidden, got %#v", rows)
+	}
+}
+
+func TestTaskBatch_ArchiveCommitsBeforePaneCloseAndSyncsParent(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	panes := &fakePaneService{closeErr: errors.New("tmux unavailable")}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	parent := createTestTask(t, ts.URL, "parent", nil)
+	child := createTestTask(t, ts.URL, "child", map[string]any{"parent_task_id": parent})
+	if node := loadDependencyTestNode(t, ts.URL, parent); node.Status != projectstate.StatusWaitingChildren {
+		t.Fatalf("expected parent waiting on its child, got %q", node.Status)
+	}
+
+	done := createTestTask(t, ts.URL, "done", nil)
+	if code, _ := postTaskBatch(t, ts.URL, map[string]any{"project_id": "p1", "op": "set_status", "status": projectstate.StatusCompleted, "task_ids": []string{done}}); code != http.StatusOK {
+		t.Fatalf("expected 200 completing task, got %d", code)
+	}
+	code, out := postTaskBatch(t, ts.URL, map[string]any{"project_id": "p1", "op": "set_status", "status": projectstate.StatusPending, "task_ids": []string{done}})
+	if code != http.StatusOK || out.Failed != 1 || out.Results[0].Code != "INVALID_STATUS_TRANSITION" {
+		t.Fatalf("expected rejected transition reported per item, got %d %#v", code, out)
+	}
+
+	store := projectstate.NewStore(filepath.Clean(repo))
+	if err := store.SavePanes(map[string]projectstate.PaneBinding{child: {TaskID: child, PaneID: "e2e:0.1", PaneTarget: "e2e:0.1"}}); err != nil {
+		t.Fatalf("SavePanes failed: %v", err)
+	}
+	code, out = postTaskBatch(t, ts.URL, map[string]any{"project_id": "p1", "op": "archive", "task_ids": []string{child}})
+	if code != http.StatusOK || out.Failed != 1 || out.Results[0].Code != "PANE_CLOSE_FAILED" {
+		t.Fatalf("expected pane close failure reported per item, got %d %#v", code, out)
+	}
+	rows, err := store.ListTasksByProject("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected child archived despite the pane failure, got %#v", rows)
+	}
+	if node := loadDependencyTestNode(t, ts.URL, parent); node.Status != projectstate.StatusPending {
+		t.Fatalf("expected parent back to pending once its only child is archived, got %q", node.Status)
+	}
+}
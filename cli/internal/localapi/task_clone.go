@@ -0,0 +1,164 @@
+package localapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"shellman/cli/internal/projectstate"
+)
+
+// handleCloneTask deep-copies a task and its non-archived descendants. The copy lands under parent_task_id, at
+// the root of project_id, or next to the source when neither is given. With launch every copy gets a fresh pane
+// and non-shell commands recorded on the source tasks are typed into them again.
+func (s *Server) handleCloneTask(w http.ResponseWriter, r *http.Request, taskID string) {
+	var req struct {
+		ProjectID    string  `json:"project_id"`
+		ParentTaskID *string `json:"parent_task_id"`
+		Launch       bool    `json:"launch"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	sourceProjectID, sourceStore, source, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	rows, err := sourceStore.ListTasksByProject(sourceProjectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_LOAD_FAILED", err.Error())
+		return
+	}
+	nodes := []TaskTemplateNode{buildCloneTemplateNode(rows, taskID)}
+
+	projectID := strings.TrimSpace(req.ProjectID)
+	parentTaskID := ""
+	switch {
+	case req.ParentTaskID != nil && strings.TrimSpace(*req.ParentTaskID) != "":
+		parentTaskID = strings.TrimSpace(*req.ParentTaskID)
+		parentProjectID, _, _, err := s.findTask(parentTaskID)
+		if err != nil {
+			respondError(w, http.StatusNotFound, "PARENT_NOT_FOUND", "parent task not found")
+			return
+		}
+		if projectID != "" && projectID != parentProjectID {
+			respondError(w, http.StatusBadRequest, "INVALID_CLONE_TARGET", "parent task belongs to another project")
+			return
+		}
+		projectID = parentProjectID
+	case projectID != "" || req.ParentTaskID != nil:
+		if projectID == "" {
+			projectID = sourceProjectID
+		}
+	default:
+		projectID = sourceProjectID
+		parentTaskID = strings.TrimSpace(source.ParentTaskID)
+	}
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	store := projectstate.NewStore(repoRoot)
+	parentRole := ""
+	parentTarget := ""
+	if parentTaskID != "" {
+		parent, found, err := findTaskEntryInProject(store, projectID, parentTaskID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "TASK_LOAD_FAILED", err.Error())
+			return
+		}
+		if !found {
+			respondError(w, http.StatusNotFound, "PARENT_NOT_FOUND", "parent task not found")
+			return
+		}
+		parentRole = normalizeTaskRole(parent.TaskRole)
+		if req.Launch {
+			if panes, err := store.LoadPanes(); err == nil {
+				parentTarget = strings.TrimSpace(panes[parentTaskID].PaneTarget)
+			}
+		}
+	}
+	if err := normalizeTemplateNodes(nodes, parentRole, "clone"); err != nil {
+		switch {
+		case errors.Is(err, errExecutorCannotDelegate):
+			respondError(w, http.StatusBadRequest, "EXECUTOR_CANNOT_DELEGATE", err.Error())
+		case errors.Is(err, errPlannerOnlySpawnExecutor):
+			respondError(w, http.StatusBadRequest, "PLANNER_ONLY_SPAWN_EXECUTOR", err.Error())
+		default:
+			respondError(w, http.StatusBadRequest, "INVALID_CLONE_TARGET", err.Error())
+		}
+		return
+	}
+	if req.Launch && s.deps.PaneService == nil {
+		respondError(w, http.StatusInternalServerError, "PANE_SERVICE_UNAVAILABLE", "pane service is not configured")
+		return
+	}
+
+	inst := &templateInstantiation{
+		server:    s,
+		projectID: projectID,
+		repoRoot:  repoRoot,
+		store:     store,
+		launch:    req.Launch,
+		spawnAll:  true,
+		relation:  "clone",
+	}
+	if err := inst.createNodes(nodes, parentTaskID, parentTarget); err != nil {
+		inst.rollback()
+		respondError(w, http.StatusInternalServerError, "TASK_CLONE_FAILED", err.Error())
+		return
+	}
+
+	cloneRootID := inst.created[0].TaskID
+	for _, item := range inst.created {
+		s.publishEvent("task.created", projectID, item.TaskID, map[string]any{"cloned_from": taskID})
+	}
+	s.publishEvent("task.cloned", projectID, cloneRootID, map[string]any{
+		"source_task_id": taskID,
+		"task_count":     len(inst.created),
+	})
+	s.publishEvent("task.tree.updated", projectID, cloneRootID, map[string]any{})
+	respondOK(w, map[string]any{
+		"project_id":     projectID,
+		"source_task_id": taskID,
+		"task_id":        cloneRootID,
+		"tasks":          inst.created,
+	})
+}
+
+// buildCloneTemplateNode turns the subtree rooted at taskID into template nodes. Shell commands are not recorded
+// as launch commands since a fresh pane already starts a shell.
+func buildCloneTemplateNode(rows []projectstate.TaskRecordRow, taskID string) TaskTemplateNode {
+	childrenByParent := map[string][]projectstate.TaskRecordRow{}
+	byID := map[string]projectstate.TaskRecordRow{}
+	for _, row := range rows {
+		byID[row.TaskID] = row
+		parentID := strings.TrimSpace(row.ParentTaskID)
+		childrenByParent[parentID] = append(childrenByParent[parentID], row)
+	}
+	var build func(row projectstate.TaskRecordRow, depth int) TaskTemplateNode
+	build = func(row projectstate.TaskRecordRow, depth int) TaskTemplateNode {
+		node := TaskTemplateNode{
+			Title:       row.Title,
+			Description: row.Description,
+			Role:        row.TaskRole,
+			SidecarMode: row.SidecarMode,
+		}
+		if command := strings.TrimSpace(row.CurrentCommand); resolveTaskAgentToolModeFromCommand(command) != taskAgentToolModeShell {
+			node.Command = command
+		}
+		if depth > len(rows) {
+			return node
+		}
+		for _, child := range childrenByParent[row.TaskID] {
+			node.Children = append(node.Children, build(child, depth+1))
+		}
+		return node
+	}
+	return build(byID[taskID], 0)
+}
@@ -0,0 +1,94 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func TestTaskClone_CopiesSubtreeAndLaunchesPanes(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	panes := &fakePaneService{}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	root := createTestTask(t, ts.URL, "feature", nil)
+	child := createTestTask(t, ts.URL, "implement", map[string]any{"parent_task_id": root})
+	createTestTask(t, ts.URL, "write tests", map[string]any{"parent_task_id": child})
+	store := projectstate.NewStore(filepath.Clean(repo))
+	description := "build the feature"
+	agentCommand := "codex --full-auto"
+	shellCommand := "zsh"
+	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{TaskID: root, ProjectID: "p1", Description: &description, CurrentCommand: &shellCommand}); err != nil {
+		t.Fatalf("seed root meta failed: %v", err)
+	}
+	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{TaskID: child, ProjectID: "p1", CurrentCommand: &agentCommand}); err != nil {
+		t.Fatalf("seed child meta failed: %v", err)
+	}
+
+	cloneTask := func(body string) (int, []instantiatedTemplateTask) {
+		t.Helper()
+		resp, err := http.Post(ts.URL+"/api/v1/tasks/"+root+"/clone", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("POST clone failed: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var out struct {
+			Data struct {
+				Tasks []instantiatedTemplateTask `json:"tasks"`
+			} `json:"data"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out.Data.Tasks
+	}
+
+	code, created := cloneTask(`{}`)
+	if code != http.StatusOK || len(created) != 3 {
+		t.Fatalf("expected 3 cloned tasks, got status=%d tasks=%#v", code, created)
+	}
+	if created[0].Command != "" || created[1].Command != agentCommand || created[0].PaneTarget != "" {
+		t.Fatalf("unexpected clone without launch: %#v", created)
+	}
+	rows, err := store.ListTasksByProject("p1")
+	if err != nil {
+		t.Fatalf("ListTasksByProject failed: %v", err)
+	}
+	byID := map[string]projectstate.TaskRecordRow{}
+	for _, row := range rows {
+		byID[row.TaskID] = row
+	}
+	if len(rows) != 6 {
+		t.Fatalf("expected 6 tasks after clone, got %d", len(rows))
+	}
+	cloneRoot := byID[created[0].TaskID]
+	if cloneRoot.Title != "feature" || cloneRoot.Description != description || cloneRoot.ParentTaskID != "" {
+		t.Fatalf("unexpected cloned root: %#v", cloneRoot)
+	}
+	if byID[created[1].TaskID].ParentTaskID != cloneRoot.TaskID || byID[created[2].TaskID].ParentTaskID != created[1].TaskID {
+		t.Fatalf("cloned structure mismatch: %#v", created)
+	}
+
+	code, created = cloneTask(`{"parent_task_id":"` + child + `","launch":true}`)
+	if code != http.StatusOK || len(created) != 3 {
+		t.Fatalf("expected launched clone, got status=%d tasks=%#v", code, created)
+	}
+	if created[0].ParentTaskID != child || created[0].PaneTarget == "" {
+		t.Fatalf("expected clone under child with a pane: %#v", created[0])
+	}
+	// child has no pane of its own, so the cloned root opens a root pane and its copies split from it.
+	if panes.rootCount != 1 || panes.childCount != 2 {
+		t.Fatalf("expected 1 root and 2 child panes, got root=%d child=%d", panes.rootCount, panes.childCount)
+	}
+
+	if code, _ := cloneTask(`{"parent_task_id":"t_missing"}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing parent, got %d", code)
+	}
+}
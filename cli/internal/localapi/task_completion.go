@@ -3,6 +3,7 @@ package localapi
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,12 +18,6 @@ import (
 	"shellman/cli/internal/tmux"
 )
 
-var validTaskFlag = map[string]struct{}{
-	"success": {},
-	"notify":  {},
-	"error":   {},
-}
-
 type completionDispatchDecision struct {
 	Dispatch         bool
 	Reason           string
@@ -79,270 +74,323 @@ func (s *Server) evaluateTaskCompletionDispatch() completionDispatchDecision {
 	return decision
 }
 
-func (s *Server) completeRunAndEnqueueActions(runID, summary, source string, reqMeta map[string]any) error {
+// completeRunAndEnqueueActions finishes runID and its task. A non-zero exitCode fails both instead of completing
+// them; nil means the exit status is unknown and is treated as success.
+func (s *Server) completeRunAndEnqueueActions(runID, summary, source string, exitCode *int, reqMeta map[string]any) error {
 	projectID, store, run, err := s.findRun(runID)
 	if err != nil {
 		return err
 	}
-	if err := store.MarkRunCompleted(runID); err != nil {
+	taskStatus := runOutcomeStatus(exitCode)
+	if taskStatus == projectstate.StatusFailed {
+		if err := store.MarkRunFailed(runID, projectstate.RunStatusFailed, runExitError(*exitCode)); err != nil {
+			return err
+		}
+		_ = store.AppendRunEvent(runID, "run.failed", map[string]any{"exit_code": *exitCode})
+	} else if err := store.MarkRunCompleted(runID); err != nil {
 		return err
 	}
+	s.recordRunFinished(store, projectID, runID, taskStatus, summary, source, exitCode)
 	_, taskStore, _, err := s.findTask(run.TaskID)
 	if err != nil {
 		return err
 	}
-	if err := s.updateTaskStatusInternal(taskStore, run.TaskID, projectID, projectstate.StatusCompleted); err != nil {
-		return err
+	// A task canceled while its run was still going stays canceled; the run itself is finished either way.
+	if err := s.updateTaskStatusInternalWithSource(taskStore, run.TaskID, projectID, taskStatus, projectstate.StatusTriggerUpdate, projectstate.TaskRevisionSourceAutoProgress); err != nil {
+		if !errors.Is(err, projectstate.ErrInvalidStatusTransition) {
+			return err
+		}
+		slog.Info("run.complete.task_status_kept", "run_id", runID, "task_id", run.TaskID, "err", err)
 	}
 
-	if err := store.EnqueueRunAction(runID, "run_completion_dispatch", map[string]any{
-		"task_id": run.TaskID,
-		"summary": strings.TrimSpace(summary),
-		"source":  strings.TrimSpace(source),
-	}); err != nil {
-		return err
+	statusPayload := map[string]any{"status": taskStatus}
+	if exitCode != nil {
+		statusPayload["exit_code"] = *exitCode
+	}
+	if s.completionNeedsDispatch(source) {
+		if err := store.EnqueueRunAction(runID, runActionCompletionDispatch, completionActionPayload(projectID, run.TaskID, summary, source, taskStatus, exitCode, reqMeta)); err != nil {
+			return err
+		}
+	}
+	// Capturing scrollback and git state can be slow, so it runs from the outbox rather than on the completion path.
+	if err := store.EnqueueRunAction(runID, runActionCaptureArtifacts, map[string]any{"project_id": projectID}); err != nil {
+		slog.Warn("run artifacts enqueue failed", "run_id", runID, "err", err)
 	}
-	s.enqueueRunCompletionActions(runID, projectID, run.TaskID, summary, source, reqMeta)
-	s.publishEvent("task.status.updated", projectID, run.TaskID, map[string]any{"status": projectstate.StatusCompleted})
+	s.kickRunActionDispatcher()
+	s.enqueueRunCompletionActions(runID, projectID, run.TaskID, summary, source, exitCode, reqMeta)
+	s.publishEvent("task.status.updated", projectID, run.TaskID, statusPayload)
 	s.publishEvent("task.return.reported", projectID, run.TaskID, map[string]any{"summary": summary, "run_id": runID})
 	s.publishEvent("task.tree.updated", projectID, run.TaskID, map[string]any{})
 	return nil
 }
 
-func (s *Server) enqueueTaskCompletionActions(projectID, taskID, summary, source string, reqMeta map[string]any) {
+// completionNeedsDispatch reports whether a completion from source has anything for the action outbox to do:
+// pane-idle completions always go to the task agent loop, others only when a notify command is enabled.
+func (s *Server) completionNeedsDispatch(source string) bool {
 	if strings.EqualFold(strings.TrimSpace(source), "pane-idle") {
-		promptInput := s.buildAutoProgressPromptInput(projectID, taskID, summary, "")
-		_, repoErr := s.findProjectRepoRoot(projectID)
+		return true
+	}
+	return s.evaluateTaskCompletionDispatch().Dispatch
+}
+
+func completionActionPayload(projectID, taskID, summary, source, status string, exitCode *int, reqMeta map[string]any) map[string]any {
+	payload := map[string]any{
+		"project_id": strings.TrimSpace(projectID),
+		"task_id":    strings.TrimSpace(taskID),
+		"summary":    strings.TrimSpace(summary),
+		"source":     strings.TrimSpace(source),
+		"status":     status,
+	}
+	if exitCode != nil {
+		payload["exit_code"] = *exitCode
+	}
+	if len(reqMeta) > 0 {
+		payload["request_meta"] = reqMeta
+	}
+	return payload
+}
+
+// enqueueTaskCompletionActions handles the completion of a task that has no run: the follow-up actions go to the
+// action outbox under an empty run id.
+func (s *Server) enqueueTaskCompletionActions(projectID, taskID, summary, source string, exitCode *int, reqMeta map[string]any) {
+	s.releaseTaskDependents(projectID, taskID)
+	s.notifyWebhooks(webhookPayload{
+		Event:     global.WebhookEventTaskCompleted,
+		ProjectID: strings.TrimSpace(projectID),
+		TaskID:    strings.TrimSpace(taskID),
+		Status:    runOutcomeStatus(exitCode),
+		ExitCode:  exitCode,
+		Summary:   strings.TrimSpace(summary),
+		Source:    strings.TrimSpace(source),
+	})
+	if !s.auditCompletionTrigger(projectID, taskID, "", source, reqMeta) {
+		return
+	}
+	payload := completionActionPayload(projectID, taskID, summary, source, runOutcomeStatus(exitCode), exitCode, reqMeta)
+	if err := runActionStore().EnqueueRunAction("", runActionTaskCompletionDispatch, payload); err != nil {
+		slog.Error("task completion action enqueue failed", "project_id", projectID, "task_id", taskID, "err", err)
+		return
+	}
+	s.kickRunActionDispatcher()
+}
+
+func (s *Server) enqueueRunCompletionActions(runID, projectID, taskID, summary, source string, exitCode *int, reqMeta map[string]any) {
+	s.releaseTaskDependents(projectID, taskID)
+	s.notifyWebhooks(webhookPayload{
+		Event:     global.WebhookEventTaskCompleted,
+		ProjectID: strings.TrimSpace(projectID),
+		TaskID:    strings.TrimSpace(taskID),
+		RunID:     strings.TrimSpace(runID),
+		Status:    runOutcomeStatus(exitCode),
+		ExitCode:  exitCode,
+		Summary:   strings.TrimSpace(summary),
+		Source:    strings.TrimSpace(source),
+	})
+	s.auditCompletionTrigger(projectID, taskID, runID, source, reqMeta)
+}
+
+// auditCompletionTrigger writes the trigger audit entries of a completion and reports whether it has follow-up
+// actions for the action outbox.
+func (s *Server) auditCompletionTrigger(projectID, taskID, runID, source string, reqMeta map[string]any) bool {
+	runID = strings.TrimSpace(runID)
+	source = strings.TrimSpace(source)
+	_, repoErr := s.findProjectRepoRoot(projectID)
+	if strings.EqualFold(source, "pane-idle") {
 		if repoErr == nil {
 			s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.received", taskCompletionAuditFields(map[string]any{
+				"run_id":       runID,
 				"source":       "pane-idle",
 				"will_enqueue": true,
 				"target":       "task-agent-loop",
 			}, reqMeta))
-		}
-		prompt := buildTaskAgentAutoProgressPrompt(promptInput)
-		displayContent := buildTaskAgentAutoProgressDisplayContent(taskID, promptInput.Summary, "")
-		if err := s.sendTaskAgentLoop(context.Background(), TaskAgentLoopEvent{
-			TaskID:         strings.TrimSpace(taskID),
-			ProjectID:      strings.TrimSpace(projectID),
-			Source:         "tty_output",
-			DisplayContent: displayContent,
-			AgentPrompt:    prompt,
-			TriggerMeta:    reqMeta,
-		}); err != nil {
-			if repoErr == nil {
-				s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.skipped", taskCompletionAuditFields(map[string]any{
-					"source": "pane-idle",
-					"reason": "agent-loop-enqueue-failed",
-					"error":  err.Error(),
-				}, reqMeta))
-			}
-			return
-		}
-		if repoErr == nil {
 			s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.enqueued", taskCompletionAuditFields(map[string]any{
+				"run_id": runID,
 				"source": "pane-idle",
-				"status": "queued",
+				"target": "action-outbox",
 			}, reqMeta))
 		}
-		return
+		return true
 	}
 	decision := s.evaluateTaskCompletionDispatch()
-	_, err := s.findProjectRepoRoot(projectID)
-	if err == nil {
-		fields := map[string]any{
-			"source":             strings.TrimSpace(source),
+	if repoErr == nil {
+		s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.received", taskCompletionAuditFields(map[string]any{
+			"run_id":             runID,
+			"source":             source,
 			"will_dispatch":      decision.Dispatch,
 			"reason":             decision.Reason,
 			"notify_enabled":     decision.NotifyEnabled,
 			"notify_command_set": decision.NotifyCommandSet,
-		}
-		for k, v := range reqMeta {
-			fields[k] = v
-		}
-		s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.received", fields)
+		}, reqMeta))
 		if !decision.Dispatch {
 			s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.skipped", map[string]any{
-				"source": strings.TrimSpace(source),
+				"run_id": runID,
+				"source": source,
 				"reason": decision.Reason,
 			})
+		} else {
+			s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.enqueued", map[string]any{
+				"run_id": runID,
+				"source": source,
+				"target": "action-outbox",
+			})
 		}
 	}
-	if !decision.Dispatch {
-		return
-	}
-	go s.dispatchTaskCompletionActions(projectID, taskID, summary)
+	return decision.Dispatch
 }
 
-func (s *Server) enqueueRunCompletionActions(runID, projectID, taskID, summary, source string, reqMeta map[string]any) {
-	if strings.EqualFold(strings.TrimSpace(source), "pane-idle") {
-		promptInput := s.buildAutoProgressPromptInput(projectID, taskID, summary, runID)
-		_, repoErr := s.findProjectRepoRoot(projectID)
-		if repoErr == nil {
-			s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.received", taskCompletionAuditFields(map[string]any{
-				"run_id":       strings.TrimSpace(runID),
-				"source":       "pane-idle",
-				"will_enqueue": true,
-				"target":       "task-agent-loop",
-			}, reqMeta))
-		}
-		prompt := buildTaskAgentAutoProgressPrompt(promptInput)
-		displayContent := buildTaskAgentAutoProgressDisplayContent(taskID, promptInput.Summary, runID)
-		if err := s.sendTaskAgentLoop(context.Background(), TaskAgentLoopEvent{
-			TaskID:         strings.TrimSpace(taskID),
-			ProjectID:      strings.TrimSpace(projectID),
-			Source:         "tty_output",
-			DisplayContent: displayContent,
-			AgentPrompt:    prompt,
-			TriggerMeta:    reqMeta,
-		}); err != nil {
-			if repoErr == nil {
-				s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.skipped", taskCompletionAuditFields(map[string]any{
-					"run_id": strings.TrimSpace(runID),
-					"source": "pane-idle",
-					"reason": "agent-loop-enqueue-failed",
-					"error":  err.Error(),
-				}, reqMeta))
-			}
-			return
-		}
+// sendCompletionToTaskAgentLoop hands a pane-idle completion to the task agent loop as a tty_output event.
+func (s *Server) sendCompletionToTaskAgentLoop(ctx context.Context, runID, projectID, taskID, summary string, exitCode *int, reqMeta map[string]any) error {
+	promptInput := s.buildAutoProgressPromptInput(projectID, taskID, summary, runID)
+	promptInput.ExitCode = exitCode
+	prompt := buildTaskAgentAutoProgressPrompt(promptInput)
+	displayContent := buildTaskAgentAutoProgressDisplayContent(taskID, promptInput.Summary, runID)
+	_, repoErr := s.findProjectRepoRoot(projectID)
+	if err := s.sendTaskAgentLoop(ctx, TaskAgentLoopEvent{
+		TaskID:         strings.TrimSpace(taskID),
+		ProjectID:      strings.TrimSpace(projectID),
+		Source:         "tty_output",
+		DisplayContent: displayContent,
+		AgentPrompt:    prompt,
+		TriggerMeta:    reqMeta,
+	}); err != nil {
 		if repoErr == nil {
-			s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.enqueued", taskCompletionAuditFields(map[string]any{
+			s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.skipped", taskCompletionAuditFields(map[string]any{
 				"run_id": strings.TrimSpace(runID),
 				"source": "pane-idle",
-				"status": "queued",
+				"reason": "agent-loop-enqueue-failed",
+				"error":  err.Error(),
 			}, reqMeta))
 		}
-		return
-	}
-	decision := s.evaluateTaskCompletionDispatch()
-	_, err := s.findProjectRepoRoot(projectID)
-	if err == nil {
-		fields := map[string]any{
-			"run_id":             strings.TrimSpace(runID),
-			"source":             strings.TrimSpace(source),
-			"will_dispatch":      decision.Dispatch,
-			"reason":             decision.Reason,
-			"notify_enabled":     decision.NotifyEnabled,
-			"notify_command_set": decision.NotifyCommandSet,
-		}
-		for k, v := range reqMeta {
-			fields[k] = v
-		}
-		s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.received", fields)
-		if !decision.Dispatch {
-			s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.skipped", map[string]any{
-				"run_id": strings.TrimSpace(runID),
-				"source": strings.TrimSpace(source),
-				"reason": decision.Reason,
-			})
-		}
+		return err
 	}
-	if !decision.Dispatch {
-		return
+	if repoErr == nil {
+		s.writeTaskCompletionAuditLog(projectID, taskID, "trigger.dispatched", taskCompletionAuditFields(map[string]any{
+			"run_id": strings.TrimSpace(runID),
+			"source": "pane-idle",
+			"target": "task-agent-loop",
+		}, reqMeta))
 	}
-	s.dispatchRunCompletionActions(runID, projectID, taskID, summary)
+	return nil
 }
 
-func (s *Server) dispatchTaskCompletionActions(projectID, taskID, summary string) {
+// dispatchRunCompletionActions runs the notify command of a completion action, or defers it until the task pane
+// has stayed idle long enough.
+func (s *Server) dispatchRunCompletionActions(ctx context.Context, action projectstate.RunActionRecord, projectID, taskID, summary string, outcome completionOutcome) error {
+	runID := action.RunID
 	_, err := s.findProjectRepoRoot(projectID)
 	if err != nil {
-		return
+		return err
 	}
 	s.writeTaskCompletionAuditLog(projectID, taskID, "start", map[string]any{
+		"run_id":      strings.TrimSpace(runID),
 		"summary_len": len(strings.TrimSpace(summary)),
 	})
 
+	var dispatchErr error
 	cfg, err := s.deps.ConfigStore.LoadOrInit()
 	if err != nil {
+		dispatchErr = err
 		s.writeTaskCompletionAuditLog(projectID, taskID, "command.config.error", map[string]any{"error": err.Error()})
 	} else {
 		command := strings.TrimSpace(cfg.TaskCompletion.NotifyCommand)
 		if cfg.TaskCompletion.NotifyEnabled && command != "" {
-			if cfg.TaskCompletion.NotifyIdleDuration > 0 {
-				s.writeTaskCompletionAuditLog(projectID, taskID, "command.idle.ignored", map[string]any{
-					"idle_threshold": cfg.TaskCompletion.NotifyIdleDuration,
-				})
-			}
-			now := time.Now().UTC()
-			payload := map[string]string{
-				"task_id":      taskID,
-				"project_id":   projectID,
-				"status":       "completed",
-				"summary":      strings.TrimSpace(summary),
-				"finished_at":  strconv.FormatInt(now.Unix(), 10),
-				"idle_seconds": strconv.Itoa(cfg.TaskCompletion.NotifyIdleDuration),
-			}
-			if err := runTaskCompletionCommand(taskID, command, payload); err != nil {
-				s.writeTaskCompletionAuditLog(projectID, taskID, "command.error", map[string]any{
-					"error":   err.Error(),
-					"command": command,
-				})
+			if deferral, deferred := s.deferCompletionNotifyCommand(action, projectID, taskID, summary, outcome, cfg.TaskCompletion.NotifyIdleDuration); deferred {
+				dispatchErr = deferral
 			} else {
-				s.writeTaskCompletionAuditLog(projectID, taskID, "command.done", map[string]any{
-					"command": command,
-				})
+				dispatchErr = s.runCompletionNotifyCommand(ctx, projectID, taskID, summary, outcome, command, cfg.TaskCompletion.NotifyIdleDuration)
 			}
 		}
 	}
 
-	s.writeTaskCompletionAuditLog(projectID, taskID, "finish", nil)
+	s.writeTaskCompletionAuditLog(projectID, taskID, "finish", map[string]any{
+		"run_id": strings.TrimSpace(runID),
+	})
+	return dispatchErr
 }
 
-func (s *Server) dispatchRunCompletionActions(runID, projectID, taskID, summary string) {
-	_, err := s.findProjectRepoRoot(projectID)
-	if err != nil {
-		return
+// deferCompletionNotifyCommand parks the notify command of action until the task pane has been idle for
+// idleSeconds. It reports false when the command should run right away; otherwise the returned deferral keeps the
+// outbox row pending until the notification has run, so a restart in between re-arms it.
+func (s *Server) deferCompletionNotifyCommand(action projectstate.RunActionRecord, projectID, taskID, summary string, outcome completionOutcome, idleSeconds int) (runActionDeferral, bool) {
+	if idleSeconds <= 0 {
+		return runActionDeferral{}, false
 	}
-	s.writeTaskCompletionAuditLog(projectID, taskID, "start", map[string]any{
-		"run_id":      strings.TrimSpace(runID),
-		"summary_len": len(strings.TrimSpace(summary)),
+	if pending, ok := s.pendingIdleNotificationForAction(action.ID); ok {
+		return idleNotificationDeferral(pending), true
+	}
+	runID := strings.TrimSpace(action.RunID)
+	pending, ok := s.scheduleIdleCompletionNotification(action, projectID, taskID, summary, outcome, idleSeconds)
+	if !ok {
+		s.writeTaskCompletionAuditLog(projectID, taskID, "command.idle.satisfied", map[string]any{
+			"run_id":         runID,
+			"idle_threshold": idleSeconds,
+		})
+		return runActionDeferral{}, false
+	}
+	s.writeTaskCompletionAuditLog(projectID, taskID, "command.idle.pending", map[string]any{
+		"run_id":         runID,
+		"idle_threshold": idleSeconds,
+		"pane_target":    pending.PaneTarget,
+		"due_at":         pending.DueAt,
 	})
+	return idleNotificationDeferral(pending), true
+}
 
-	cfg, err := s.deps.ConfigStore.LoadOrInit()
-	if err != nil {
-		s.writeTaskCompletionAuditLog(projectID, taskID, "command.config.error", map[string]any{"error": err.Error()})
-	} else {
-		command := strings.TrimSpace(cfg.TaskCompletion.NotifyCommand)
-		if cfg.TaskCompletion.NotifyEnabled && command != "" {
-			if cfg.TaskCompletion.NotifyIdleDuration > 0 {
-				s.writeTaskCompletionAuditLog(projectID, taskID, "command.idle.ignored", map[string]any{
-					"idle_threshold": cfg.TaskCompletion.NotifyIdleDuration,
-				})
-			}
-			now := time.Now().UTC()
-			payload := map[string]string{
-				"task_id":      taskID,
-				"project_id":   projectID,
-				"status":       "completed",
-				"summary":      strings.TrimSpace(summary),
-				"finished_at":  strconv.FormatInt(now.Unix(), 10),
-				"idle_seconds": strconv.Itoa(cfg.TaskCompletion.NotifyIdleDuration),
-			}
-			if err := runTaskCompletionCommand(taskID, command, payload); err != nil {
-				s.writeTaskCompletionAuditLog(projectID, taskID, "command.error", map[string]any{
-					"error":   err.Error(),
-					"command": command,
-				})
-			} else {
-				s.writeTaskCompletionAuditLog(projectID, taskID, "command.done", map[string]any{
-					"command": command,
-				})
-			}
-		}
+func idleNotificationDeferral(pending PendingCompletionNotification) runActionDeferral {
+	return runActionDeferral{Until: time.Unix(pending.DueAt, 0).Add(idleNotificationActionGrace)}
+}
+
+// completionOutcome is how the run or task behind a completion ended, as handed to the notify command.
+type completionOutcome struct {
+	Status   string
+	ExitCode *int
+}
+
+// payloadCompletionOutcome reads the outcome of a decoded completion action payload. Rows written before the
+// status was recorded are treated as completed.
+func payloadCompletionOutcome(payload map[string]any) completionOutcome {
+	status, _ := payload["status"].(string)
+	if status = strings.TrimSpace(status); status == "" {
+		status = projectstate.StatusCompleted
 	}
+	return completionOutcome{Status: status, ExitCode: payloadExitCode(payload)}
+}
 
-	s.writeTaskCompletionAuditLog(projectID, taskID, "finish", map[string]any{
-		"run_id": strings.TrimSpace(runID),
+func (s *Server) runCompletionNotifyCommand(ctx context.Context, projectID, taskID, summary string, outcome completionOutcome, command string, idleSeconds int) error {
+	now := time.Now().UTC()
+	payload := map[string]string{
+		"task_id":      taskID,
+		"project_id":   projectID,
+		"status":       outcome.Status,
+		"summary":      strings.TrimSpace(summary),
+		"finished_at":  strconv.FormatInt(now.Unix(), 10),
+		"idle_seconds": strconv.Itoa(idleSeconds),
+	}
+	if outcome.ExitCode != nil {
+		payload["exit_code"] = strconv.Itoa(*outcome.ExitCode)
+	}
+	if err := runTaskCompletionCommand(ctx, taskID, command, payload); err != nil {
+		s.writeTaskCompletionAuditLog(projectID, taskID, "command.error", map[string]any{
+			"error":   err.Error(),
+			"command": command,
+		})
+		return err
+	}
+	s.writeTaskCompletionAuditLog(projectID, taskID, "command.done", map[string]any{
+		"command": command,
 	})
+	return nil
 }
 
-func runTaskCompletionCommand(taskID, command string, payload map[string]string) error {
+func runTaskCompletionCommand(ctx context.Context, taskID, command string, payload map[string]string) error {
 	shell, shellArg, err := resolveShell()
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, 45*time.Second)
 	defer cancel()
 	cmd := exec.CommandContext(ctx, shell, shellArg, command)
 	cmd.Env = append(os.Environ(), "SHELLMAN_TASK_ID="+taskID)
@@ -350,6 +398,10 @@ func runTaskCompletionCommand(taskID, command string, payload map[string]string)
 	cmd.Env = append(cmd.Env, "SHELLMAN_TASK_SUMMARY="+payload["summary"])
 	cmd.Env = append(cmd.Env, "SHELLMAN_TASK_PROJECT_ID="+payload["project_id"])
 	cmd.Env = append(cmd.Env, "SHELLMAN_TASK_COMPLETION_IDLE_SECONDS="+payload["idle_seconds"])
+	cmd.Env = append(cmd.Env, "SHELLMAN_TASK_STATUS="+payload["status"])
+	if exitCode, ok := payload["exit_code"]; ok {
+		cmd.Env = append(cmd.Env, "SHELLMAN_TASK_EXIT_CODE="+exitCode)
+	}
 	_, err = cmd.CombinedOutput()
 	return err
 }
@@ -543,12 +595,12 @@ func (s *Server) buildTaskTTYContext(store *projectstate.Store, entry projectsta
 		}
 	}
 	if strings.TrimSpace(tty.CurrentCommand) == "" {
-		tty.CurrentCommand = strings.TrimSpace(s.detectPaneCurrentCommand(binding.PaneTarget))
+		tty.CurrentCommand = strings.TrimSpace(s.detectPaneCurrentCommand(entry.ProjectID, binding.PaneTarget))
 	}
 	if strings.TrimSpace(tty.CurrentCommand) == "" {
 		tty.CurrentCommand = strings.TrimSpace(binding.PaneTarget)
 	}
-	tty.Cwd = strings.TrimSpace(s.detectPaneCurrentPath(binding.PaneTarget))
+	tty.Cwd = strings.TrimSpace(s.detectPaneCurrentPath(entry.ProjectID, binding.PaneTarget))
 	return tty
 }
 
@@ -601,12 +653,13 @@ func (s *Server) buildTaskFamilyContext(store *projectstate.Store, projectID, ta
 	return parent, children
 }
 
-func (s *Server) detectPaneCurrentCommand(paneTarget string) string {
-	state := s.detectPaneRuntimeState(paneTarget)
+func (s *Server) detectPaneCurrentCommand(projectID, paneTarget string) string {
+	state := s.detectPaneRuntimeState(projectID, paneTarget)
 	return strings.TrimSpace(state.CurrentCommand)
 }
 
-func (s *Server) detectPaneRuntimeState(paneTarget string) progdetector.RuntimeState {
+// detectPaneRuntimeState asks the tmux server projectID lives on what paneTarget is running.
+func (s *Server) detectPaneRuntimeState(projectID, paneTarget string) progdetector.RuntimeState {
 	target := strings.TrimSpace(paneTarget)
 	if target == "" {
 		return progdetector.RuntimeState{}
@@ -621,7 +674,7 @@ func (s *Server) detectPaneRuntimeState(paneTarget string) progdetector.RuntimeS
 	defer cancel()
 	runner := commandRunnerExec{ctx: ctx, run: execute}
 	var adapter *tmux.Adapter
-	if socket := strings.TrimSpace(os.Getenv("SHELLMAN_TMUX_SOCKET")); socket != "" {
+	if socket := resolveTmuxSocket(s.projectTmuxSocket(projectID)); socket != "" {
 		adapter = tmux.NewAdapterWithSocket(runner, socket)
 	} else {
 		adapter = tmux.NewAdapter(runner)
@@ -642,7 +695,8 @@ func (s *Server) detectPaneRuntimeState(paneTarget string) progdetector.RuntimeS
 	}
 }
 
-func (s *Server) detectPaneCurrentPath(paneTarget string) string {
+// detectPaneCurrentPath asks the tmux server projectID lives on for the working directory of paneTarget.
+func (s *Server) detectPaneCurrentPath(projectID, paneTarget string) string {
 	target := strings.TrimSpace(paneTarget)
 	if target == "" {
 		return ""
@@ -655,10 +709,7 @@ func (s *Server) detectPaneCurrentPath(paneTarget string) string {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
 	defer cancel()
-	args := []string{}
-	if socket := strings.TrimSpace(os.Getenv("SHELLMAN_TMUX_SOCKET")); socket != "" {
-		args = append(args, "-L", socket)
-	}
+	args := tmuxSocketArgs(s.projectTmuxSocket(projectID))
 	args = append(args, "display-message", "-p", "-t", target, "#{pane_current_path}")
 	out, err := execute(ctx, "tmux", args...)
 	if err != nil {
@@ -674,10 +725,20 @@ func tailText(input string, max int) string {
 	return input[len(input)-max:]
 }
 
-func (s *Server) setTaskFlagInternal(store *projectstate.Store, projectID, taskID, flag, flagDesc string) error {
-	if flag != "" {
-		if _, ok := validTaskFlag[flag]; !ok {
-			return errors.New("unsupported task flag")
+// setTaskFlagInternal raises flag on taskID after checking it against the project's flag registry; an empty flag
+// clears it. Raising a flag restarts its escalation clock.
+func (s *Server) setTaskFlagInternal(store *projectstate.Store, projectID, taskID, flag, flagDesc, source string) error {
+	nextFlag := strings.TrimSpace(flag)
+	definition := projectstate.ProjectFlagRecord{}
+	if nextFlag != "" {
+		found := false
+		var err error
+		definition, found, err = store.GetProjectFlag(projectID, nextFlag)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return errUnsupportedTaskFlag
 		}
 	}
 	_, ok, err := findTaskEntryInProject(store, projectID, taskID)
@@ -687,7 +748,6 @@ func (s *Server) setTaskFlagInternal(store *projectstate.Store, projectID, taskI
 	if !ok {
 		return errors.New("task not found")
 	}
-	nextFlag := strings.TrimSpace(flag)
 	nextFlagDesc := strings.TrimSpace(flagDesc)
 	nextFlagReaded := false
 	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{
@@ -696,14 +756,29 @@ func (s *Server) setTaskFlagInternal(store *projectstate.Store, projectID, taskI
 		Flag:       &nextFlag,
 		FlagDesc:   &nextFlagDesc,
 		FlagReaded: &nextFlagReaded,
+		Source:     source,
 	}); err != nil {
 		return err
 	}
+	if err := store.ResetTaskFlagEscalation(projectID, taskID, nextFlag, flagEscalationNow().UTC().Unix()); err != nil {
+		return err
+	}
 	s.publishEvent("task.flag.updated", projectID, taskID, map[string]any{
 		"flag":      nextFlag,
 		"flag_desc": nextFlagDesc,
+		"severity":  definition.Severity,
+		"color":     definition.Color,
 	})
 	s.publishEvent("task.tree.updated", projectID, taskID, map[string]any{})
+	if nextFlag != "" {
+		s.notifyWebhooks(webhookPayload{
+			Event:     global.WebhookEventFlagRaised,
+			ProjectID: projectID,
+			TaskID:    taskID,
+			Flag:      nextFlag,
+			FlagDesc:  nextFlagDesc,
+		})
+	}
 	return nil
 }
 
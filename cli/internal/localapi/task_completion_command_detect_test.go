@@ -4,6 +4,8 @@ import (
 	"context"
 	"strings"
 	"testing"
+
+	"shellman/cli/internal/global"
 )
 
 func TestDetectPaneCurrentCommand_UsesConfiguredTmuxSocket(t *testing.T) {
@@ -16,7 +18,7 @@ func TestDetectPaneCurrentCommand_UsesConfiguredTmuxSocket(t *testing.T) {
 		},
 	})
 
-	cmd := srv.detectPaneCurrentCommand("e2e:0.0")
+	cmd := srv.detectPaneCurrentCommand("", "e2e:0.0")
 	if cmd != "bash" {
 		t.Fatalf("expected bash, got %q", cmd)
 	}
@@ -25,3 +27,27 @@ func TestDetectPaneCurrentCommand_UsesConfiguredTmuxSocket(t *testing.T) {
 		t.Fatalf("expected tmux socket flag in args, got %q", joined)
 	}
 }
+
+func TestDetectPaneRuntimeProbes_UseProjectTmuxSocket(t *testing.T) {
+	t.Setenv("SHELLMAN_TMUX_SOCKET", "tt_process")
+	var calls []string
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: t.TempDir(), TmuxSocket: "tt_project"}}}
+	srv := NewServer(Deps{
+		ProjectsStore: projects,
+		ExecuteCommand: func(_ context.Context, _ string, args ...string) ([]byte, error) {
+			calls = append(calls, strings.Join(args, " "))
+			return []byte("pane-title\tbash\tabc\n"), nil
+		},
+	})
+
+	srv.detectPaneCurrentCommand("p1", "e2e:0.0")
+	srv.detectPaneCurrentPath("p1", "e2e:0.0")
+	if len(calls) < 2 {
+		t.Fatalf("expected both probes to call tmux, got %#v", calls)
+	}
+	for _, call := range calls {
+		if !strings.Contains(call, "-L tt_project") || strings.Contains(call, "tt_process") {
+			t.Fatalf("expected probes routed to the project socket, got %q", call)
+		}
+	}
+}
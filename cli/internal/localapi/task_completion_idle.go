@@ -0,0 +1,304 @@
+package localapi
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/projectstate"
+)
+
+const (
+	idleNotificationCheckInterval = 5 * time.Second
+	// idleNotificationActionGrace is how long past its due time the outbox row of a deferred notification waits for
+	// the watcher to settle it before the dispatcher picks it up again, which re-arms it after a restart.
+	idleNotificationActionGrace = 30 * time.Second
+)
+
+// paneActivityProvider is implemented by pane services that can report tmux pane_activity.
+type paneActivityProvider interface {
+	PaneLastActiveAt(target string) (time.Time, error)
+}
+
+// PendingCompletionNotification is a completion notify command waiting for its pane to stay idle.
+type PendingCompletionNotification struct {
+	TaskID      string `json:"task_id"`
+	ProjectID   string `json:"project_id"`
+	RunID       string `json:"run_id,omitempty"`
+	PaneTarget  string `json:"pane_target"`
+	Summary     string `json:"summary"`
+	IdleSeconds int    `json:"idle_seconds"`
+	IdleSince   int64  `json:"idle_since"`
+	CreatedAt   int64  `json:"created_at"`
+	DueAt       int64  `json:"due_at"`
+	Status      string `json:"status"`
+	ExitCode    *int   `json:"exit_code,omitempty"`
+
+	paneID string
+	done   chan struct{}
+	// action is the outbox row that stays pending until the notification runs or is canceled.
+	action projectstate.RunActionRecord
+	// awaitingIdle is set while the pane has not gone idle since the notification was scheduled.
+	awaitingIdle bool
+}
+
+type paneActivityState struct {
+	LastActiveAt time.Time
+	Running      bool
+	// Tracked is true when LastActiveAt comes from tmux pane_activity and can detect new output.
+	Tracked bool
+}
+
+// scheduleIdleCompletionNotification defers the notify command of action until the task pane has been idle for
+// idleSeconds. It returns false when the task has no pane or the pane is already idle long enough,
+// in which case the caller should run the command immediately.
+func (s *Server) scheduleIdleCompletionNotification(action projectstate.RunActionRecord, projectID, taskID, summary string, outcome completionOutcome, idleSeconds int) (PendingCompletionNotification, bool) {
+	if idleSeconds <= 0 {
+		return PendingCompletionNotification{}, false
+	}
+	_, store, _, err := s.findTask(taskID)
+	if err != nil {
+		return PendingCompletionNotification{}, false
+	}
+	panes, err := store.LoadPanes()
+	if err != nil {
+		return PendingCompletionNotification{}, false
+	}
+	binding, ok := panes[strings.TrimSpace(taskID)]
+	if !ok {
+		return PendingCompletionNotification{}, false
+	}
+	paneTarget := strings.TrimSpace(binding.PaneTarget)
+	if paneTarget == "" {
+		paneTarget = strings.TrimSpace(binding.PaneID)
+	}
+	if paneTarget == "" {
+		return PendingCompletionNotification{}, false
+	}
+
+	now := s.idleNow().UTC()
+	item := &PendingCompletionNotification{
+		TaskID:      strings.TrimSpace(taskID),
+		ProjectID:   strings.TrimSpace(projectID),
+		RunID:       strings.TrimSpace(action.RunID),
+		PaneTarget:  paneTarget,
+		Summary:     strings.TrimSpace(summary),
+		IdleSeconds: idleSeconds,
+		CreatedAt:   now.Unix(),
+		Status:      outcome.Status,
+		ExitCode:    outcome.ExitCode,
+		paneID:      strings.TrimSpace(binding.PaneID),
+		done:        make(chan struct{}),
+		action:      action,
+	}
+	activity := s.loadPaneActivity(store, item)
+	idleSince := now
+	if activity.Tracked && !activity.LastActiveAt.IsZero() && activity.LastActiveAt.Before(now) {
+		idleSince = activity.LastActiveAt.UTC()
+	}
+	item.IdleSince = idleSince.Unix()
+	item.DueAt = idleSince.Add(time.Duration(idleSeconds) * time.Second).Unix()
+	item.awaitingIdle = activity.Running
+	if !activity.Running && item.DueAt <= now.Unix() {
+		return PendingCompletionNotification{}, false
+	}
+
+	s.idleNotifyMu.Lock()
+	if s.idleNotifications == nil {
+		s.idleNotifications = map[string]*PendingCompletionNotification{}
+	}
+	prev, replaced := s.idleNotifications[item.TaskID]
+	if replaced {
+		close(prev.done)
+	}
+	s.idleNotifications[item.TaskID] = item
+	s.idleNotifyMu.Unlock()
+	if replaced && prev.action.ID != action.ID {
+		// The newer completion notifies instead; the superseded row has nothing left to do.
+		s.settleRunAction(runActionStore(), prev.action, nil)
+	}
+
+	s.publishEvent("task.notification.pending", item.ProjectID, item.TaskID, map[string]any{
+		"run_id": item.RunID,
+		"due_at": item.DueAt,
+	})
+	go s.watchIdleCompletionNotification(item)
+	return *item, true
+}
+
+func (s *Server) watchIdleCompletionNotification(item *PendingCompletionNotification) {
+	for {
+		s.idleNotifyMu.Lock()
+		dueAt := item.DueAt
+		s.idleNotifyMu.Unlock()
+		wait := time.Unix(dueAt, 0).Sub(s.idleNow())
+		if wait > s.idleNotifyInterval {
+			wait = s.idleNotifyInterval
+		}
+		if wait < 0 {
+			wait = 0
+		}
+		select {
+		case <-item.done:
+			return
+		case <-time.After(wait):
+		}
+
+		activity := paneActivityState{}
+		if _, store, _, err := s.findTask(item.TaskID); err == nil {
+			activity = s.loadPaneActivity(store, item)
+		}
+		if item.awaitingIdle {
+			s.idleNotifyMu.Lock()
+			if activity.Running {
+				item.IdleSince = s.idleNow().UTC().Unix()
+				item.DueAt = item.IdleSince + int64(item.IdleSeconds)
+			} else {
+				item.awaitingIdle = false
+				if activity.Tracked && activity.LastActiveAt.Unix() > item.IdleSince {
+					item.IdleSince = activity.LastActiveAt.Unix()
+					item.DueAt = item.IdleSince + int64(item.IdleSeconds)
+				}
+			}
+			s.idleNotifyMu.Unlock()
+			continue
+		}
+		if activity.Running || (activity.Tracked && activity.LastActiveAt.Unix() > item.IdleSince) {
+			if s.takeIdleCompletionNotification(item) {
+				s.writeTaskCompletionAuditLog(item.ProjectID, item.TaskID, "command.idle.canceled", map[string]any{
+					"run_id":      item.RunID,
+					"pane_target": item.PaneTarget,
+					"reason":      "pane-active",
+				})
+				s.publishEvent("task.notification.canceled", item.ProjectID, item.TaskID, map[string]any{
+					"run_id": item.RunID,
+					"reason": "pane-active",
+				})
+				s.settleRunAction(runActionStore(), item.action, nil)
+			}
+			return
+		}
+		if s.idleNow().UTC().Unix() < dueAt {
+			continue
+		}
+		if !s.takeIdleCompletionNotification(item) {
+			return
+		}
+		s.settleRunAction(runActionStore(), item.action, s.runIdleCompletionNotification(item))
+		return
+	}
+}
+
+func (s *Server) runIdleCompletionNotification(item *PendingCompletionNotification) error {
+	cfg, err := s.deps.ConfigStore.LoadOrInit()
+	if err != nil {
+		s.writeTaskCompletionAuditLog(item.ProjectID, item.TaskID, "command.config.error", map[string]any{"error": err.Error()})
+		return err
+	}
+	command := strings.TrimSpace(cfg.TaskCompletion.NotifyCommand)
+	if !cfg.TaskCompletion.NotifyEnabled || command == "" {
+		s.writeTaskCompletionAuditLog(item.ProjectID, item.TaskID, "command.idle.skipped", map[string]any{
+			"run_id": item.RunID,
+			"reason": "no-enabled-actions",
+		})
+		return nil
+	}
+	if err := s.runCompletionNotifyCommand(context.Background(), item.ProjectID, item.TaskID, item.Summary, completionOutcome{Status: item.Status, ExitCode: item.ExitCode}, command, item.IdleSeconds); err != nil {
+		return err
+	}
+	s.publishEvent("task.notification.sent", item.ProjectID, item.TaskID, map[string]any{
+		"run_id": item.RunID,
+	})
+	return nil
+}
+
+// pendingIdleNotificationForAction returns the notification still waiting on behalf of the outbox row actionID.
+func (s *Server) pendingIdleNotificationForAction(actionID int64) (PendingCompletionNotification, bool) {
+	s.idleNotifyMu.Lock()
+	defer s.idleNotifyMu.Unlock()
+	for _, item := range s.idleNotifications {
+		if item.action.ID == actionID {
+			return *item, true
+		}
+	}
+	return PendingCompletionNotification{}, false
+}
+
+func (s *Server) idleNow() time.Time {
+	if s.idleNotifyNow != nil {
+		return s.idleNotifyNow()
+	}
+	return time.Now()
+}
+
+// takeIdleCompletionNotification removes item from the pending set; it reports false if item was already replaced or canceled.
+func (s *Server) takeIdleCompletionNotification(item *PendingCompletionNotification) bool {
+	s.idleNotifyMu.Lock()
+	defer s.idleNotifyMu.Unlock()
+	current, ok := s.idleNotifications[item.TaskID]
+	if !ok || current != item {
+		return false
+	}
+	delete(s.idleNotifications, item.TaskID)
+	return true
+}
+
+// CancelPendingCompletionNotification drops the pending notification of taskID, if any.
+func (s *Server) CancelPendingCompletionNotification(taskID, reason string) bool {
+	s.idleNotifyMu.Lock()
+	item, ok := s.idleNotifications[strings.TrimSpace(taskID)]
+	if ok {
+		delete(s.idleNotifications, item.TaskID)
+		close(item.done)
+	}
+	s.idleNotifyMu.Unlock()
+	if !ok {
+		return false
+	}
+	s.writeTaskCompletionAuditLog(item.ProjectID, item.TaskID, "command.idle.canceled", map[string]any{
+		"run_id":      item.RunID,
+		"pane_target": item.PaneTarget,
+		"reason":      reason,
+	})
+	s.publishEvent("task.notification.canceled", item.ProjectID, item.TaskID, map[string]any{
+		"run_id": item.RunID,
+		"reason": reason,
+	})
+	s.settleRunAction(runActionStore(), item.action, nil)
+	return true
+}
+
+func (s *Server) listPendingCompletionNotifications(taskID string) []PendingCompletionNotification {
+	s.idleNotifyMu.Lock()
+	defer s.idleNotifyMu.Unlock()
+	out := make([]PendingCompletionNotification, 0, len(s.idleNotifications))
+	for _, item := range s.idleNotifications {
+		if taskID != "" && item.TaskID != taskID {
+			continue
+		}
+		out = append(out, *item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DueAt < out[j].DueAt })
+	return out
+}
+
+func (s *Server) loadPaneActivity(store *projectstate.Store, item *PendingCompletionNotification) paneActivityState {
+	state := paneActivityState{}
+	if provider, ok := s.paneServiceFor(item.ProjectID).(paneActivityProvider); ok && item.PaneTarget != "" {
+		if lastActive, err := provider.PaneLastActiveAt(item.PaneTarget); err == nil && !lastActive.IsZero() {
+			state.LastActiveAt = lastActive.UTC()
+			state.Tracked = true
+		}
+	}
+	runtimePaneID := item.paneID
+	if runtimePaneID == "" {
+		runtimePaneID = item.PaneTarget
+	}
+	if store != nil && runtimePaneID != "" {
+		if row, ok, err := store.GetPaneRuntimeByPaneID(runtimePaneID); err == nil && ok {
+			state.Running = strings.EqualFold(strings.TrimSpace(row.RuntimeStatus), "running")
+		}
+	}
+	return state
+}
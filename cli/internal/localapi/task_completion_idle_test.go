@@ -0,0 +1,272 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+type activityPaneService struct {
+	fakePaneService
+	mu         sync.Mutex
+	lastActive time.Time
+}
+
+func (f *activityPaneService) PaneLastActiveAt(target string) (time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastActive, nil
+}
+
+// idleTestClock is the clock of an idle notification fixture; it only moves when a test advances it.
+type idleTestClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *idleTestClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *idleTestClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type idleNotifyFixture struct {
+	srv     *Server
+	ts      *httptest.Server
+	deps    Deps
+	clock   *idleTestClock
+	store   *projectstate.Store
+	taskID  string
+	outFile string
+}
+
+func newIdleNotifyFixture(t *testing.T, paneService PaneService, idleSeconds int) idleNotifyFixture {
+	t.Helper()
+	repo := t.TempDir()
+	outFile := filepath.Join(t.TempDir(), "notified.txt")
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	cfgStore := &mutableConfigStore{cfg: global.GlobalConfig{
+		LocalPort: 4621,
+		TaskCompletion: global.TaskCompletionConfig{
+			NotifyEnabled:      true,
+			NotifyCommand:      "echo completed > " + outFile,
+			NotifyIdleDuration: idleSeconds,
+		},
+	}}
+	deps := Deps{ConfigStore: cfgStore, ProjectsStore: projects, PaneService: paneService}
+	clock := &idleTestClock{now: time.Now().UTC().Truncate(time.Second)}
+	srv := newIdleNotifyServer(deps, clock)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	createResp, err := http.Post(ts.URL+"/api/v1/tasks", "application/json", bytes.NewBufferString(`{"project_id":"p1","title":"idle notify"}`))
+	if err != nil {
+		t.Fatalf("POST tasks failed: %v", err)
+	}
+	defer func() { _ = createResp.Body.Close() }()
+	var created struct {
+		Data struct {
+			TaskID string `json:"task_id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(createResp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode create response failed: %v", err)
+	}
+	t.Cleanup(func() { srv.CancelPendingCompletionNotification(created.Data.TaskID, "test-cleanup") })
+
+	store := projectstate.NewStore(filepath.Clean(repo))
+	if err := store.SavePanes(projectstate.PanesIndex{
+		created.Data.TaskID: {TaskID: created.Data.TaskID, PaneID: "%idle1", PaneTarget: "idle:1.0"},
+	}); err != nil {
+		t.Fatalf("SavePanes failed: %v", err)
+	}
+	setPaneRuntimeStatus(t, store, "ready")
+	return idleNotifyFixture{srv: srv, ts: ts, deps: deps, clock: clock, store: store, taskID: created.Data.TaskID, outFile: outFile}
+}
+
+func newIdleNotifyServer(deps Deps, clock *idleTestClock) *Server {
+	srv := NewServer(deps)
+	srv.idleNotifyInterval = 10 * time.Millisecond
+	srv.idleNotifyNow = clock.Now
+	return srv
+}
+
+func setPaneRuntimeStatus(t *testing.T, store *projectstate.Store, status string) {
+	t.Helper()
+	if err := store.BatchUpsertRuntime(projectstate.RuntimeBatchUpdate{
+		Panes: []projectstate.PaneRuntimeRecord{{PaneID: "%idle1", PaneTarget: "idle:1.0", RuntimeStatus: status}},
+	}); err != nil {
+		t.Fatalf("BatchUpsertRuntime failed: %v", err)
+	}
+}
+
+func (f idleNotifyFixture) completeTask(t *testing.T) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPatch, f.ts.URL+"/api/v1/tasks/"+f.taskID+"/status", bytes.NewBufferString(`{"status":"completed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PATCH status failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from status patch, got %d", resp.StatusCode)
+	}
+	drainRunActions(t, f.srv)
+}
+
+func (f idleNotifyFixture) pending(t *testing.T) []PendingCompletionNotification {
+	t.Helper()
+	resp, err := http.Get(f.ts.URL + "/api/v1/tasks/" + f.taskID + "/notifications")
+	if err != nil {
+		t.Fatalf("GET notifications failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var out struct {
+		Data struct {
+			Pending []PendingCompletionNotification `json:"pending"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode notifications failed: %v", err)
+	}
+	return out.Data.Pending
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return cond()
+}
+
+func (f idleNotifyFixture) notified() bool {
+	_, err := os.Stat(f.outFile)
+	return err == nil
+}
+
+func TestIdleCompletionNotification_RunsAfterPaneStaysIdle(t *testing.T) {
+	panes := &activityPaneService{}
+	f := newIdleNotifyFixture(t, panes, 1)
+	panes.mu.Lock()
+	panes.lastActive = f.clock.Now()
+	panes.mu.Unlock()
+	f.completeTask(t)
+
+	pending := f.pending(t)
+	if len(pending) != 1 {
+		t.Fatalf("expected a pending notification while the idle window runs, got %#v", pending)
+	}
+	item := pending[0]
+	if item.IdleSeconds != 1 || item.PaneTarget != "idle:1.0" || item.IdleSince != f.clock.Now().Unix() || item.DueAt != item.IdleSince+1 {
+		t.Fatalf("unexpected pending notification: %#v", item)
+	}
+	// The clock stands still, so the watcher keeps waiting however often it checks.
+	time.Sleep(100 * time.Millisecond)
+	if f.notified() {
+		t.Fatal("expected notify command deferred until the pane is idle")
+	}
+
+	f.clock.Advance(time.Second)
+	if !waitFor(t, 4*time.Second, f.notified) {
+		t.Fatal("expected notify command to run after the idle window")
+	}
+	if got := f.pending(t); len(got) != 0 {
+		t.Fatalf("expected no pending notifications after dispatch, got %#v", got)
+	}
+}
+
+func TestIdleCompletionNotification_CanceledWhenPaneTurnsActive(t *testing.T) {
+	f := newIdleNotifyFixture(t, &fakePaneService{}, 2)
+	f.completeTask(t)
+
+	if got := f.pending(t); len(got) != 1 {
+		t.Fatalf("expected a pending notification, got %#v", got)
+	}
+	setPaneRuntimeStatus(t, f.store, "running")
+
+	if !waitFor(t, time.Second, func() bool { return len(f.pending(t)) == 0 }) {
+		t.Fatal("expected pending notification to be canceled once the pane turned active")
+	}
+	f.clock.Advance(3 * time.Second)
+	time.Sleep(100 * time.Millisecond)
+	if f.notified() {
+		t.Fatal("expected canceled notification not to run the notify command")
+	}
+}
+
+func TestIdleCompletionNotification_RearmedFromOutboxAfterRestart(t *testing.T) {
+	panes := &activityPaneService{}
+	f := newIdleNotifyFixture(t, panes, 1)
+	panes.mu.Lock()
+	panes.lastActive = f.clock.Now()
+	panes.mu.Unlock()
+	f.completeTask(t)
+	if got := f.pending(t); len(got) != 1 {
+		t.Fatalf("expected a pending notification, got %#v", got)
+	}
+
+	// A restarted server has no in-memory notifications; only the deferred outbox row is left.
+	restartedClock := &idleTestClock{now: f.clock.Now().Add(time.Second + idleNotificationActionGrace)}
+	restarted := newIdleNotifyServer(f.deps, restartedClock)
+	origNow := runActionNow
+	runActionNow = restartedClock.Now
+	defer func() { runActionNow = origNow }()
+
+	drainRunActions(t, restarted)
+	if !f.notified() {
+		t.Fatal("expected the restarted server to run the deferred notify command")
+	}
+	if got := restarted.listPendingCompletionNotifications(f.taskID); len(got) != 0 {
+		t.Fatalf("expected nothing left pending after the idle window, got %#v", got)
+	}
+}
+
+func TestIdleCompletionNotification_DeleteCancels(t *testing.T) {
+	f := newIdleNotifyFixture(t, &fakePaneService{}, 30)
+	f.completeTask(t)
+	if !waitFor(t, time.Second, func() bool { return len(f.pending(t)) == 1 }) {
+		t.Fatal("expected a pending notification")
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, f.ts.URL+"/api/v1/tasks/"+f.taskID+"/notifications", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE notifications failed: %v", err)
+	}
+	var out struct {
+		Data struct {
+			Canceled bool `json:"canceled"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode cancel response failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if !out.Data.Canceled {
+		t.Fatal("expected canceled=true")
+	}
+	if got := f.pending(t); len(got) != 0 {
+		t.Fatalf("expected no pending notifications, got %#v", got)
+	}
+}
@@ -0,0 +1,309 @@
+package localapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"shellman/cli/internal/projectstate"
+)
+
+type taskDependencyView struct {
+	TaskID       string `json:"task_id"`
+	Title        string `json:"title"`
+	Status       string `json:"status"`
+	AutoProgress bool   `json:"auto_progress,omitempty"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+func (s *Server) handleGetTaskDependencies(w http.ResponseWriter, _ *http.Request, taskID string) {
+	projectID, store, _, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	rows, err := store.ListTasksByProjectWithArchived(projectID, true)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_DEPENDENCIES_LOAD_FAILED", err.Error())
+		return
+	}
+	byID := make(map[string]projectstate.TaskRecordRow, len(rows))
+	for _, row := range rows {
+		byID[row.TaskID] = row
+	}
+	blockers, err := store.ListTaskBlockers(taskID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_DEPENDENCIES_LOAD_FAILED", err.Error())
+		return
+	}
+	dependents, err := store.ListTaskDependents(taskID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_DEPENDENCIES_LOAD_FAILED", err.Error())
+		return
+	}
+	blockedBy := make([]taskDependencyView, 0, len(blockers))
+	for _, edge := range blockers {
+		row := byID[edge.BlockerTaskID]
+		blockedBy = append(blockedBy, taskDependencyView{
+			TaskID:       edge.BlockerTaskID,
+			Title:        row.Title,
+			Status:       row.Status,
+			AutoProgress: edge.AutoProgress,
+			CreatedAt:    edge.CreatedAt,
+		})
+	}
+	blocking := make([]taskDependencyView, 0, len(dependents))
+	for _, edge := range dependents {
+		row := byID[edge.TaskID]
+		blocking = append(blocking, taskDependencyView{
+			TaskID:       edge.TaskID,
+			Title:        row.Title,
+			Status:       row.Status,
+			AutoProgress: edge.AutoProgress,
+			CreatedAt:    edge.CreatedAt,
+		})
+	}
+	respondOK(w, map[string]any{
+		"task_id":    taskID,
+		"blocked":    isTaskBlocked(blockers, byID),
+		"blocked_by": blockedBy,
+		"blocking":   blocking,
+	})
+}
+
+func (s *Server) handleAddTaskDependency(w http.ResponseWriter, r *http.Request, taskID string) {
+	var req struct {
+		BlockerTaskID string `json:"blocker_task_id"`
+		AutoProgress  bool   `json:"auto_progress"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	blockerTaskID := strings.TrimSpace(req.BlockerTaskID)
+	if blockerTaskID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_DEPENDENCY", "blocker_task_id is required")
+		return
+	}
+	projectID, store, _, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	if _, ok, err := findTaskEntryInProject(store, projectID, blockerTaskID); err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_DEPENDENCY_SAVE_FAILED", err.Error())
+		return
+	} else if !ok {
+		respondError(w, http.StatusNotFound, "BLOCKER_NOT_FOUND", "blocker task not found in project")
+		return
+	}
+	if err := store.AddTaskDependency(projectID, taskID, blockerTaskID, req.AutoProgress); err != nil {
+		switch {
+		case errors.Is(err, projectstate.ErrTaskDependencySelf):
+			respondError(w, http.StatusBadRequest, "INVALID_DEPENDENCY", err.Error())
+		case errors.Is(err, projectstate.ErrTaskDependencyCycle):
+			respondError(w, http.StatusConflict, "DEPENDENCY_CYCLE", err.Error())
+		default:
+			respondError(w, http.StatusInternalServerError, "TASK_DEPENDENCY_SAVE_FAILED", err.Error())
+		}
+		return
+	}
+	s.publishEvent("task.dependencies.updated", projectID, taskID, map[string]any{
+		"blocker_task_id": blockerTaskID,
+		"op":              "added",
+	})
+	s.publishEvent("task.tree.updated", projectID, taskID, map[string]any{})
+	respondOK(w, map[string]any{
+		"task_id":         taskID,
+		"blocker_task_id": blockerTaskID,
+		"auto_progress":   req.AutoProgress,
+	})
+}
+
+func (s *Server) handleRemoveTaskDependency(w http.ResponseWriter, r *http.Request, taskID, blockerTaskID string) {
+	blockerTaskID = strings.TrimSpace(blockerTaskID)
+	if blockerTaskID == "" {
+		blockerTaskID = strings.TrimSpace(r.URL.Query().Get("blocker_task_id"))
+	}
+	if blockerTaskID == "" {
+		var req struct {
+			BlockerTaskID string `json:"blocker_task_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err.Error() != "EOF" {
+			respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+			return
+		}
+		blockerTaskID = strings.TrimSpace(req.BlockerTaskID)
+	}
+	if blockerTaskID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_DEPENDENCY", "blocker_task_id is required")
+		return
+	}
+	projectID, store, _, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	blocker, blockerFound, _ := findTaskEntryInProject(store, projectID, blockerTaskID)
+	removed, err := store.RemoveTaskDependency(taskID, blockerTaskID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_DEPENDENCY_DELETE_FAILED", err.Error())
+		return
+	}
+	if !removed {
+		respondError(w, http.StatusNotFound, "DEPENDENCY_NOT_FOUND", "dependency not found")
+		return
+	}
+	s.publishEvent("task.dependencies.updated", projectID, taskID, map[string]any{
+		"blocker_task_id": blockerTaskID,
+		"op":              "removed",
+	})
+	s.publishEvent("task.tree.updated", projectID, taskID, map[string]any{})
+	if blockerFound && strings.TrimSpace(blocker.Status) != projectstate.StatusCompleted {
+		s.releaseTaskIfUnblocked(projectID, store, taskID, "dependency_removed")
+	}
+	respondOK(w, map[string]any{
+		"task_id":         taskID,
+		"blocker_task_id": blockerTaskID,
+	})
+}
+
+// releaseTaskDependents emits task.unblocked for every task waiting on completedTaskID whose blockers are now all completed.
+func (s *Server) releaseTaskDependents(projectID, completedTaskID string) {
+	_, store, _, err := s.findTask(completedTaskID)
+	if err != nil {
+		return
+	}
+	dependents, err := store.ListTaskDependents(completedTaskID)
+	if err != nil || len(dependents) == 0 {
+		return
+	}
+	for _, edge := range dependents {
+		s.releaseTaskIfUnblocked(projectID, store, edge.TaskID, completedTaskID)
+	}
+}
+
+// releaseDependentEdges emits task.unblocked for the dependents in edges once their blocker stopped blocking by
+// being archived or purged. Edges are passed in because a purge deletes them together with the blocker.
+func (s *Server) releaseDependentEdges(projectID string, store *projectstate.Store, edges []projectstate.TaskDependencyRecord, trigger string) {
+	released := map[string]struct{}{}
+	for _, edge := range edges {
+		if _, done := released[edge.TaskID]; done {
+			continue
+		}
+		released[edge.TaskID] = struct{}{}
+		s.releaseTaskIfUnblocked(projectID, store, edge.TaskID, trigger)
+	}
+}
+
+func (s *Server) releaseTaskIfUnblocked(projectID string, store *projectstate.Store, taskID, trigger string) {
+	blockers, err := store.ListTaskBlockers(taskID)
+	if err != nil {
+		return
+	}
+	rows, err := store.ListTasksByProjectWithArchived(projectID, true)
+	if err != nil {
+		return
+	}
+	byID := make(map[string]projectstate.TaskRecordRow, len(rows))
+	for _, row := range rows {
+		byID[row.TaskID] = row
+	}
+	task, ok := byID[taskID]
+	if !ok || task.Archived || isTaskTerminalStatus(task.Status) || isTaskBlocked(blockers, byID) {
+		return
+	}
+	autoProgress := false
+	blockerIDs := make([]string, 0, len(blockers))
+	for _, edge := range blockers {
+		blockerIDs = append(blockerIDs, edge.BlockerTaskID)
+		autoProgress = autoProgress || edge.AutoProgress
+	}
+	s.publishEvent("task.unblocked", projectID, taskID, map[string]any{
+		"blockers": blockerIDs,
+		"trigger":  trigger,
+	})
+	s.publishEvent("task.tree.updated", projectID, taskID, map[string]any{})
+	s.writeTaskCompletionAuditLog(projectID, taskID, "dependency.unblocked", map[string]any{
+		"blockers":      blockerIDs,
+		"trigger":       trigger,
+		"auto_progress": autoProgress,
+	})
+	if !autoProgress || len(blockerIDs) == 0 {
+		return
+	}
+	content := buildDependencyUnblockedPrompt(blockerIDs, byID)
+	agentPrompt, historyBlock, _ := s.buildUserPromptWithHistoryMeta(taskID, content)
+	if err := s.sendTaskAgentLoop(context.Background(), TaskAgentLoopEvent{
+		TaskID:         taskID,
+		ProjectID:      projectID,
+		Source:         "dependency_unblocked",
+		DisplayContent: content,
+		AgentPrompt:    agentPrompt,
+		HistoryBlock:   historyBlock,
+		TriggerMeta: map[string]any{
+			"op":       "task.dependencies.unblocked",
+			"blockers": blockerIDs,
+			"trigger":  trigger,
+		},
+	}); err != nil {
+		s.writeTaskCompletionAuditLog(projectID, taskID, "dependency.auto_progress.skipped", map[string]any{
+			"error": err.Error(),
+		})
+	}
+}
+
+func buildDependencyUnblockedPrompt(blockerIDs []string, byID map[string]projectstate.TaskRecordRow) string {
+	lines := make([]string, 0, len(blockerIDs)+1)
+	lines = append(lines, "All tasks blocking this task are completed. Continue with this task.")
+	for _, id := range blockerIDs {
+		title := strings.TrimSpace(byID[id].Title)
+		if title == "" {
+			title = id
+		}
+		lines = append(lines, fmt.Sprintf("- %s (%s)", title, id))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isTaskBlocked reports whether any blocker is still present and not completed. Archived or deleted blockers do not block.
+func isTaskBlocked(blockers []projectstate.TaskDependencyRecord, byID map[string]projectstate.TaskRecordRow) bool {
+	for _, edge := range blockers {
+		row, ok := byID[edge.BlockerTaskID]
+		if !ok || row.Archived {
+			continue
+		}
+		if strings.TrimSpace(row.Status) != projectstate.StatusCompleted {
+			return true
+		}
+	}
+	return false
+}
+
+func applyTaskDependenciesToNodes(nodes []projectstate.TaskNode, rows []projectstate.TaskRecordRow, edges []projectstate.TaskDependencyRecord) []projectstate.TaskNode {
+	if len(edges) == 0 {
+		return nodes
+	}
+	byID := make(map[string]projectstate.TaskRecordRow, len(rows))
+	for _, row := range rows {
+		byID[row.TaskID] = row
+	}
+	blockersOf := map[string][]projectstate.TaskDependencyRecord{}
+	for _, edge := range edges {
+		blockersOf[edge.TaskID] = append(blockersOf[edge.TaskID], edge)
+	}
+	for i := range nodes {
+		blockers := blockersOf[nodes[i].TaskID]
+		if len(blockers) == 0 {
+			continue
+		}
+		for _, edge := range blockers {
+			nodes[i].BlockedBy = append(nodes[i].BlockedBy, edge.BlockerTaskID)
+		}
+		nodes[i].Blocked = !isTaskTerminalStatus(nodes[i].Status) && isTaskBlocked(blockers, byID)
+	}
+	return nodes
+}
@@ -0,0 +1,173 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func loadDependencyTestNode(t *testing.T, baseURL, taskID string) projectstate.TaskNode {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/api/v1/projects/p1/tree")
+	if err != nil {
+		t.Fatalf("GET tree failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var out struct {
+		Data projectstate.TaskTree `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode tree failed: %v", err)
+	}
+	for _, node := range out.Data.Nodes {
+		if node.TaskID == taskID {
+			return node
+		}
+	}
+	t.Fatalf("task %s missing from tree", taskID)
+	return projectstate.TaskNode{}
+}
+
+func TestTaskDependencies_BlockAndReleaseOnCompletion(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	blocker := createTestTask(t, ts.URL, "blocker", nil)
+	waiting := createTestTask(t, ts.URL, "waiting", nil)
+
+	resp, err := http.Post(ts.URL+"/api/v1/tasks/"+waiting+"/dependencies", "application/json", bytes.NewBufferString(`{"blocker_task_id":"`+blocker+`"}`))
+	if err != nil {
+		t.Fatalf("POST dependencies failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 adding dependency, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post(ts.URL+"/api/v1/tasks/"+blocker+"/dependencies", "application/json", bytes.NewBufferString(`{"blocker_task_id":"`+waiting+`"}`))
+	if err != nil {
+		t.Fatalf("POST cyclic dependency failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 for cycle, got %d", resp.StatusCode)
+	}
+
+	node := loadDependencyTestNode(t, ts.URL, waiting)
+	if !node.Blocked || len(node.BlockedBy) != 1 || node.BlockedBy[0] != blocker {
+		t.Fatalf("expected waiting task blocked by %s, got %#v", blocker, node)
+	}
+
+	req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/api/v1/tasks/"+blocker+"/status", bytes.NewBufferString(`{"status":"completed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PATCH status failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	node = loadDependencyTestNode(t, ts.URL, waiting)
+	if node.Blocked || len(node.BlockedBy) != 1 {
+		t.Fatalf("expected waiting task unblocked with edge kept, got %#v", node)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/tasks/" + blocker + "/dependencies")
+	if err != nil {
+		t.Fatalf("GET dependencies failed: %v", err)
+	}
+	var deps struct {
+		Data struct {
+			Blocking []taskDependencyView `json:"blocking"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&deps); err != nil {
+		t.Fatalf("decode dependencies failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if len(deps.Data.Blocking) != 1 || deps.Data.Blocking[0].TaskID != waiting || deps.Data.Blocking[0].Title != "waiting" {
+		t.Fatalf("unexpected blocking list: %#v", deps.Data.Blocking)
+	}
+
+	req, _ = http.NewRequest(http.MethodDelete, ts.URL+"/api/v1/tasks/"+waiting+"/dependencies/"+blocker, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE dependency failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 removing dependency, got %d", resp.StatusCode)
+	}
+	node = loadDependencyTestNode(t, ts.URL, waiting)
+	if node.Blocked || len(node.BlockedBy) != 0 {
+		t.Fatalf("expected no dependencies after delete, got %#v", node)
+	}
+}
+
+func TestTaskDependencies_ReleaseWhenBlockerArchivedOrPurged(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	var mu sync.Mutex
+	triggers := map[string][]string{}
+	srv.SetExternalEventSink(func(topic, _, taskID string, payload map[string]any) {
+		if topic != "task.unblocked" {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		trigger, _ := payload["trigger"].(string)
+		triggers[taskID] = append(triggers[taskID], trigger)
+	})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	archived := createTestTask(t, ts.URL, "archived blocker", nil)
+	purged := createTestTask(t, ts.URL, "purged blocker", nil)
+	waitArchived := createTestTask(t, ts.URL, "waits on archived", nil)
+	waitPurged := createTestTask(t, ts.URL, "waits on purged", nil)
+	for waiting, blocker := range map[string]string{waitArchived: archived, waitPurged: purged} {
+		resp, err := http.Post(ts.URL+"/api/v1/tasks/"+waiting+"/dependencies", "application/json", bytes.NewBufferString(`{"blocker_task_id":"`+blocker+`"}`))
+		if err != nil {
+			t.Fatalf("POST dependencies failed: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	if code, _ := postTaskBatch(t, ts.URL, map[string]any{"project_id": "p1", "op": "archive", "task_ids": []string{archived}}); code != http.StatusOK {
+		t.Fatalf("expected archive batch 200, got %d", code)
+	}
+	// Archive the second blocker behind the server's back so only the purge can release its dependent.
+	archivedFlag := true
+	if err := projectstate.NewStore(repo).BatchUpsertTaskMeta([]projectstate.TaskMetaUpsert{{TaskID: purged, ProjectID: "p1", Archived: &archivedFlag}}); err != nil {
+		t.Fatalf("BatchUpsertTaskMeta failed: %v", err)
+	}
+	prevNow := archiveRetentionNow
+	archiveRetentionNow = func() time.Time { return time.Now().Add(48 * time.Hour) }
+	t.Cleanup(func() { archiveRetentionNow = prevNow })
+	resp, err := http.Post(ts.URL+"/api/v1/projects/p1/archive/purge", "application/json", bytes.NewBufferString(`{"older_than_days":1}`))
+	if err != nil {
+		t.Fatalf("POST purge failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(triggers[waitArchived]) == 0 || triggers[waitArchived][0] != "blocker_archived" {
+		t.Fatalf("expected task.unblocked after archive, got %#v", triggers)
+	}
+	if !reflect.DeepEqual(triggers[waitPurged], []string{"blocker_purged"}) {
+		t.Fatalf("expected task.unblocked after purge, got %#v", triggers)
+	}
+}
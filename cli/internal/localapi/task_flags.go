@@ -0,0 +1,207 @@
+package localapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+const flagEscalationInterval = 30 * time.Second
+
+var flagEscalationNow = time.Now
+
+var errUnsupportedTaskFlag = errors.New("unsupported task flag")
+
+func (s *Server) handleProjectFlagRoutes(w http.ResponseWriter, r *http.Request, projectID string, parts []string) bool {
+	if len(parts) < 2 || parts[1] != "flags" {
+		return false
+	}
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.handleListProjectFlags(w, projectID)
+	case len(parts) == 3 && r.Method == http.MethodPut:
+		s.handleUpsertProjectFlag(w, r, projectID, parts[2])
+	case len(parts) == 3 && r.Method == http.MethodDelete:
+		s.handleDeleteProjectFlag(w, projectID, parts[2])
+	case len(parts) <= 3:
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+	default:
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "route not found")
+	}
+	return true
+}
+
+func (s *Server) handleListProjectFlags(w http.ResponseWriter, projectID string) {
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	flags, err := projectstate.NewStore(repoRoot).ListProjectFlags(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "PROJECT_FLAGS_LOAD_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{"project_id": projectID, "flags": flags})
+}
+
+func (s *Server) handleUpsertProjectFlag(w http.ResponseWriter, r *http.Request, projectID, name string) {
+	var req struct {
+		Severity             string `json:"severity"`
+		Color                string `json:"color"`
+		EscalationTimeoutSec int64  `json:"escalation_timeout_sec"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	flag, err := projectstate.NewStore(repoRoot).UpsertProjectFlag(projectID, projectstate.ProjectFlagRecord{
+		Name:                 name,
+		Severity:             req.Severity,
+		Color:                req.Color,
+		EscalationTimeoutSec: req.EscalationTimeoutSec,
+	})
+	if err != nil {
+		if errors.Is(err, projectstate.ErrInvalidProjectFlag) {
+			respondError(w, http.StatusBadRequest, "INVALID_PROJECT_FLAG", err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "PROJECT_FLAG_SAVE_FAILED", err.Error())
+		return
+	}
+	s.publishEvent("project.flags.updated", projectID, "", map[string]any{"flag": flag})
+	respondOK(w, map[string]any{"project_id": projectID, "flag": flag})
+}
+
+func (s *Server) handleDeleteProjectFlag(w http.ResponseWriter, projectID, name string) {
+	repoRoot, err := s.findProjectRepoRoot(projectID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "PROJECT_NOT_FOUND", err.Error())
+		return
+	}
+	if err := projectstate.NewStore(repoRoot).DeleteProjectFlag(projectID, name); err != nil {
+		if errors.Is(err, projectstate.ErrProjectFlagNotFound) {
+			respondError(w, http.StatusNotFound, "PROJECT_FLAG_NOT_FOUND", err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "PROJECT_FLAG_SAVE_FAILED", err.Error())
+		return
+	}
+	s.publishEvent("project.flags.updated", projectID, "", map[string]any{"deleted": strings.TrimSpace(name)})
+	respondOK(w, map[string]any{"project_id": projectID, "name": strings.TrimSpace(name)})
+}
+
+// FlagEscalationLoop escalates unread high and critical task flags whose registry timeout has passed, once per
+// timeout period, until ctx is canceled.
+func (s *Server) FlagEscalationLoop(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	ticker := time.NewTicker(flagEscalationInterval)
+	defer ticker.Stop()
+	for {
+		s.runFlagEscalationPass()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) runFlagEscalationPass() {
+	if s.deps.ProjectsStore == nil {
+		return
+	}
+	projects, err := s.deps.ProjectsStore.ListProjects()
+	if err != nil {
+		slog.Warn("flag escalation project list failed", "err", err)
+		return
+	}
+	for _, p := range projects {
+		if err := s.escalateProjectFlags(projectstate.NewStore(p.RepoRoot), p.ProjectID); err != nil {
+			slog.Warn("flag escalation failed", "project_id", p.ProjectID, "err", err)
+		}
+	}
+}
+
+func (s *Server) escalateProjectFlags(store *projectstate.Store, projectID string) error {
+	flags, err := store.ListProjectFlags(projectID)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]projectstate.ProjectFlagRecord, len(flags))
+	for _, flag := range flags {
+		if flag.Escalates() {
+			byName[flag.Name] = flag
+		}
+	}
+	if len(byName) == 0 {
+		return nil
+	}
+	rows, err := store.ListTasksByProject(projectID)
+	if err != nil {
+		return err
+	}
+	escalations, err := store.ListTaskFlagEscalations(projectID)
+	if err != nil {
+		return err
+	}
+	now := flagEscalationNow().UTC().Unix()
+	for _, row := range rows {
+		flag, ok := byName[strings.TrimSpace(row.Flag)]
+		if !ok || row.FlagReaded {
+			continue
+		}
+		state, tracked := escalations[row.TaskID]
+		if !tracked || state.Flag != flag.Name {
+			// Flags raised before tracking existed, or changed without setTaskFlagInternal, start their clock now.
+			if err := store.ResetTaskFlagEscalation(projectID, row.TaskID, flag.Name, now); err != nil {
+				return err
+			}
+			continue
+		}
+		since := state.RaisedAt
+		if state.LastEscalatedAt > since {
+			since = state.LastEscalatedAt
+		}
+		if now-since < flag.EscalationTimeoutSec {
+			continue
+		}
+		if err := store.MarkTaskFlagEscalated(row.TaskID, now); err != nil {
+			return err
+		}
+		count := state.EscalationCount + 1
+		slog.Info("task.flag.escalated", "project_id", projectID, "task_id", row.TaskID, "flag", flag.Name, "escalation", count)
+		s.publishEvent("task.flag.escalated", projectID, row.TaskID, map[string]any{
+			"flag":       flag.Name,
+			"flag_desc":  row.FlagDesc,
+			"severity":   flag.Severity,
+			"color":      flag.Color,
+			"raised_at":  state.RaisedAt,
+			"escalation": count,
+		})
+		s.notifyWebhooks(webhookPayload{
+			Event:      global.WebhookEventFlagEscalated,
+			ProjectID:  projectID,
+			TaskID:     row.TaskID,
+			Flag:       flag.Name,
+			FlagDesc:   row.FlagDesc,
+			Severity:   flag.Severity,
+			Escalation: count,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func TestProjectFlags_RegistryValidationAndEscalation(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/api/v1/projects/p1/flags/blocked", bytes.NewBufferString(`{"severity":"critical","color":"#ff0000","escalation_timeout_sec":60}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT flag failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 registering flag, got %d", resp.StatusCode)
+	}
+	req, _ = http.NewRequest(http.MethodPut, ts.URL+"/api/v1/projects/p1/flags/blocked", bytes.NewBufferString(`{"severity":"urgent"}`))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT flag failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown severity, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/v1/projects/p1/flags")
+	if err != nil {
+		t.Fatalf("GET flags failed: %v", err)
+	}
+	var listed struct {
+		Data struct {
+			Flags []projectstate.ProjectFlagRecord `json:"flags"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode flags failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if len(listed.Data.Flags) != 4 {
+		t.Fatalf("expected defaults plus blocked, got %#v", listed.Data.Flags)
+	}
+
+	taskID := createTestTask(t, ts.URL, "flagged", nil)
+	setFlag := func(flag string) int {
+		t.Helper()
+		body := `{"source":"task_set_flag","flag":"` + flag + `","status_message":"stuck on review"}`
+		resp, err := http.Post(ts.URL+"/api/v1/tasks/"+taskID+"/messages", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("POST set_flag failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := setFlag("unknown"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unregistered flag, got %d", code)
+	}
+	if code := setFlag("blocked"); code != http.StatusOK {
+		t.Fatalf("expected 200 for registered flag, got %d", code)
+	}
+
+	store := projectstate.NewStore(filepath.Clean(repo))
+	escalationCount := func() int64 {
+		t.Helper()
+		states, err := store.ListTaskFlagEscalations("p1")
+		if err != nil {
+			t.Fatalf("ListTaskFlagEscalations failed: %v", err)
+		}
+		return states[taskID].EscalationCount
+	}
+	prevNow := flagEscalationNow
+	t.Cleanup(func() { flagEscalationNow = prevNow })
+
+	flagEscalationNow = func() time.Time { return time.Now().Add(30 * time.Second) }
+	srv.runFlagEscalationPass()
+	if got := escalationCount(); got != 0 {
+		t.Fatalf("expected no escalation before timeout, got %d", got)
+	}
+	flagEscalationNow = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	srv.runFlagEscalationPass()
+	srv.runFlagEscalationPass()
+	if got := escalationCount(); got != 1 {
+		t.Fatalf("expected one escalation per timeout period, got %d", got)
+	}
+
+	readed := true
+	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{TaskID: taskID, ProjectID: "p1", FlagReaded: &readed}); err != nil {
+		t.Fatalf("mark flag read failed: %v", err)
+	}
+	flagEscalationNow = func() time.Time { return time.Now().Add(10 * time.Minute) }
+	srv.runFlagEscalationPass()
+	if got := escalationCount(); got != 1 {
+		t.Fatalf("expected read flags to stop escalating, got %d", got)
+	}
+}
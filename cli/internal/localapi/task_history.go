@@ -0,0 +1,140 @@
+package localapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"shellman/cli/internal/projectstate"
+)
+
+func (s *Server) handleGetTaskHistory(w http.ResponseWriter, r *http.Request, taskID string) {
+	_, store, _, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	limit := 100
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > 500 {
+			respondError(w, http.StatusBadRequest, "INVALID_LIMIT", "limit must be between 1 and 500")
+			return
+		}
+		limit = parsed
+	}
+	revisions, err := store.ListTaskRevisions(taskID, r.URL.Query().Get("field"), limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_HISTORY_LOAD_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{"task_id": taskID, "revisions": revisions})
+}
+
+// handleRestoreTaskRevision writes the value a field held before revision rev; the restore itself is recorded
+// as a new revision so it can be undone the same way.
+func (s *Server) handleRestoreTaskRevision(w http.ResponseWriter, _ *http.Request, taskID, rawRev string) {
+	rev, err := strconv.ParseInt(strings.TrimSpace(rawRev), 10, 64)
+	if err != nil || rev <= 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_REVISION", "revision must be a positive integer")
+		return
+	}
+	projectID, store, entry, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	revision, err := store.GetTaskRevision(taskID, rev)
+	if err != nil {
+		if errors.Is(err, projectstate.ErrTaskRevisionNotFound) {
+			respondError(w, http.StatusNotFound, "TASK_REVISION_NOT_FOUND", err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "TASK_HISTORY_LOAD_FAILED", err.Error())
+		return
+	}
+	value := revision.OldValue
+	if err := validateRestoredTaskField(revision.Field, value); err != nil {
+		respondError(w, http.StatusConflict, "TASK_REVISION_NOT_RESTORABLE", err.Error())
+		return
+	}
+	if revision.Field == projectstate.TaskFieldFlag && strings.TrimSpace(value) != "" {
+		if _, found, err := store.GetProjectFlag(projectID, value); err != nil {
+			respondError(w, http.StatusInternalServerError, "TASK_HISTORY_LOAD_FAILED", err.Error())
+			return
+		} else if !found {
+			respondError(w, http.StatusConflict, "TASK_REVISION_NOT_RESTORABLE", errUnsupportedTaskFlag.Error())
+			return
+		}
+	}
+	if revision.Field == projectstate.TaskFieldStatus {
+		if err := projectstate.ValidateStatusTransition(entry.Status, value, projectstate.StatusTriggerUpdate); err != nil {
+			respondTaskStatusError(w, err, "TASK_UPDATE_FAILED")
+			return
+		}
+	}
+	input, err := projectstate.TaskMetaUpsertForField(projectID, taskID, revision.Field, value)
+	if err != nil {
+		respondError(w, http.StatusConflict, "TASK_REVISION_NOT_RESTORABLE", err.Error())
+		return
+	}
+	input.Source = projectstate.TaskRevisionSourceRestore
+	if err := store.UpsertTaskMeta(input); err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_UPDATE_FAILED", err.Error())
+		return
+	}
+
+	switch revision.Field {
+	case projectstate.TaskFieldTitle:
+		s.publishEvent("task.title.updated", projectID, taskID, map[string]any{"title": value})
+	case projectstate.TaskFieldDescription:
+		s.publishEvent("task.description.updated", projectID, taskID, map[string]any{"description": value})
+	case projectstate.TaskFieldStatus:
+		s.publishEvent("task.status.updated", projectID, taskID, map[string]any{"status": value})
+	case projectstate.TaskFieldSidecarMode:
+		if s.taskAgentSupervisor != nil {
+			_ = s.taskAgentSupervisor.SetSidecarMode(taskID, value)
+		}
+	}
+	if revision.Field == projectstate.TaskFieldStatus {
+		if err := s.syncParentWaitingChildren(store, projectID, entry.ParentTaskID); err != nil {
+			respondError(w, http.StatusInternalServerError, "TASK_UPDATE_FAILED", err.Error())
+			return
+		}
+	}
+	s.publishEvent("task.history.restored", projectID, taskID, map[string]any{
+		"rev":   revision.Rev,
+		"field": revision.Field,
+		"value": value,
+	})
+	s.publishEvent("task.tree.updated", projectID, taskID, map[string]any{})
+	respondOK(w, map[string]any{
+		"task_id": taskID,
+		"rev":     revision.Rev,
+		"field":   revision.Field,
+		"value":   value,
+	})
+}
+
+func validateRestoredTaskField(field, value string) error {
+	switch field {
+	case projectstate.TaskFieldTitle:
+		if strings.TrimSpace(value) == "" {
+			return errors.New("title cannot be restored to empty")
+		}
+	case projectstate.TaskFieldStatus:
+		if _, ok := validTaskStatus[value]; !ok {
+			return errors.New("unsupported status")
+		}
+	case projectstate.TaskFieldSidecarMode:
+		if !validSidecarMode(value) {
+			return errInvalidSidecarMode
+		}
+	case projectstate.TaskFieldTaskRole:
+		if normalizeTaskRole(value) == "" {
+			return errInvalidTaskRole
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func loadTaskHistory(t *testing.T, baseURL, taskID, query string) []projectstate.TaskRevisionRecord {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/api/v1/tasks/" + taskID + "/history" + query)
+	if err != nil {
+		t.Fatalf("GET history failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from history, got %d", resp.StatusCode)
+	}
+	var out struct {
+		Data struct {
+			Revisions []projectstate.TaskRevisionRecord `json:"revisions"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode history failed: %v", err)
+	}
+	return out.Data.Revisions
+}
+
+func TestTaskHistory_RecordsSourcesAndRestoresField(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "original", nil)
+	req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/api/v1/tasks/"+taskID+"/title", bytes.NewBufferString(`{"title":"renamed"}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PATCH title failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	_, store, _, err := srv.findTask(taskID)
+	if err != nil {
+		t.Fatalf("findTask failed: %v", err)
+	}
+	if err := srv.setTaskFlagInternal(store, "p1", taskID, "error", "broke", projectstate.TaskRevisionSourceSidecarTool); err != nil {
+		t.Fatalf("setTaskFlagInternal failed: %v", err)
+	}
+
+	history := loadTaskHistory(t, ts.URL, taskID, "")
+	sources := map[string]string{}
+	for _, rev := range history {
+		sources[rev.Field] = rev.Source
+	}
+	if sources[projectstate.TaskFieldTitle] != projectstate.TaskRevisionSourceUser || sources[projectstate.TaskFieldFlag] != projectstate.TaskRevisionSourceSidecarTool {
+		t.Fatalf("unexpected revision sources: %#v", history)
+	}
+
+	flagRevs := loadTaskHistory(t, ts.URL, taskID, "?field=flag")
+	if len(flagRevs) != 1 {
+		t.Fatalf("expected one flag revision, got %#v", flagRevs)
+	}
+	resp, err = http.Post(fmt.Sprintf("%s/api/v1/tasks/%s/history/%d/restore", ts.URL, taskID, flagRevs[0].Rev), "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST restore failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from restore, got %d", resp.StatusCode)
+	}
+	if node := loadDependencyTestNode(t, ts.URL, taskID); node.Flag != "" || node.Title != "renamed" {
+		t.Fatalf("expected flag cleared and title kept, got flag=%q title=%q", node.Flag, node.Title)
+	}
+	if latest := loadTaskHistory(t, ts.URL, taskID, "?limit=1"); len(latest) != 1 || latest[0].Source != projectstate.TaskRevisionSourceRestore {
+		t.Fatalf("expected restore recorded as newest revision, got %#v", latest)
+	}
+
+	resp, err = http.Post(ts.URL+"/api/v1/tasks/"+taskID+"/history/999999/restore", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST restore failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown revision, got %d", resp.StatusCode)
+	}
+}
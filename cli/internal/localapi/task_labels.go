@@ -0,0 +1,94 @@
+package localapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"shellman/cli/internal/projectstate"
+)
+
+func (s *Server) handleGetTaskLabels(w http.ResponseWriter, _ *http.Request, taskID string) {
+	_, store, _, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	labels, err := store.ListTaskLabels(taskID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_LABELS_LOAD_FAILED", err.Error())
+		return
+	}
+	respondOK(w, map[string]any{"task_id": taskID, "labels": labels})
+}
+
+// handlePatchTaskLabels replaces the label set when "labels" is given, otherwise applies "add" then "remove".
+func (s *Server) handlePatchTaskLabels(w http.ResponseWriter, r *http.Request, taskID string) {
+	var req struct {
+		Labels *[]string `json:"labels"`
+		Add    []string  `json:"add"`
+		Remove []string  `json:"remove"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.Labels == nil && len(req.Add) == 0 && len(req.Remove) == 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_LABELS", "labels, add or remove is required")
+		return
+	}
+	projectID, store, _, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	next := []string{}
+	if req.Labels != nil {
+		next = append(next, (*req.Labels)...)
+	} else {
+		current, err := store.ListTaskLabels(taskID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "TASK_LABELS_LOAD_FAILED", err.Error())
+			return
+		}
+		next, err = projectstate.NormalizeTaskLabels(append(append(next, current...), req.Add...))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_LABELS", err.Error())
+			return
+		}
+		if len(req.Remove) > 0 {
+			remove, err := projectstate.NormalizeTaskLabels(req.Remove)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "INVALID_LABELS", err.Error())
+				return
+			}
+			drop := make(map[string]struct{}, len(remove))
+			for _, label := range remove {
+				drop[label] = struct{}{}
+			}
+			kept := next[:0]
+			for _, label := range next {
+				if _, ok := drop[label]; !ok {
+					kept = append(kept, label)
+				}
+			}
+			next = kept
+		}
+	}
+	labels, err := store.SetTaskLabels(projectID, taskID, next)
+	if err != nil {
+		if errors.Is(err, projectstate.ErrInvalidTaskLabel) {
+			respondError(w, http.StatusBadRequest, "INVALID_LABELS", err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "TASK_LABELS_SAVE_FAILED", err.Error())
+		return
+	}
+	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{TaskID: taskID, ProjectID: projectID}); err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_UPDATE_FAILED", err.Error())
+		return
+	}
+	s.publishEvent("task.labels.updated", projectID, taskID, map[string]any{"labels": labels})
+	s.publishEvent("task.tree.updated", projectID, taskID, map[string]any{})
+	respondOK(w, map[string]any{"task_id": taskID, "labels": labels})
+}
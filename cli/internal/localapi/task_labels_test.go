@@ -0,0 +1,106 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func loadFilteredTree(t *testing.T, baseURL, query string) (int, []projectstate.TaskNode) {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/api/v1/projects/p1/tree?" + query)
+	if err != nil {
+		t.Fatalf("GET tree failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var out struct {
+		Data projectstate.TaskTree `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out.Data.Nodes
+}
+
+func TestTaskLabels_PatchAndFilterTreeKeepsAncestors(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	root := createTestTask(t, ts.URL, "root", nil)
+	child := createTestTask(t, ts.URL, "child", map[string]any{"parent_task_id": root})
+	leaf := createTestTask(t, ts.URL, "leaf", map[string]any{"parent_task_id": child})
+	sibling := createTestTask(t, ts.URL, "sibling", map[string]any{"parent_task_id": root})
+
+	patch := func(taskID, body string) []string {
+		req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/api/v1/tasks/"+taskID+"/labels", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PATCH labels failed: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 from PATCH labels, got %d", resp.StatusCode)
+		}
+		var out struct {
+			Data struct {
+				Labels []string `json:"labels"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatalf("decode labels failed: %v", err)
+		}
+		return out.Data.Labels
+	}
+	if got := patch(leaf, `{"labels":["db","urgent"]}`); !reflect.DeepEqual(got, []string{"db", "urgent"}) {
+		t.Fatalf("unexpected labels after replace: %#v", got)
+	}
+	if got := patch(leaf, `{"add":["backend"],"remove":["urgent"]}`); !reflect.DeepEqual(got, []string{"backend", "db"}) {
+		t.Fatalf("unexpected labels after add/remove: %#v", got)
+	}
+	if got := patch(leaf, `{"add":["Bug"],"remove":["bug"]}`); !reflect.DeepEqual(got, []string{"backend", "db"}) {
+		t.Fatalf("expected add to be normalized before remove, got %#v", got)
+	}
+	patch(sibling, `{"labels":["UI"]}`)
+
+	status, nodes := loadFilteredTree(t, ts.URL, "label=db")
+	if status != http.StatusOK {
+		t.Fatalf("expected 200 from filtered tree, got %d", status)
+	}
+	byID := map[string]projectstate.TaskNode{}
+	for _, node := range nodes {
+		byID[node.TaskID] = node
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("expected leaf plus its two ancestors, got %#v", nodes)
+	}
+	if !byID[leaf].Matched || byID[child].Matched || byID[root].Matched {
+		t.Fatalf("expected only the leaf marked as matched, got %#v", nodes)
+	}
+	if !reflect.DeepEqual(byID[root].Children, []string{child}) {
+		t.Fatalf("expected root children pruned to kept nodes, got %#v", byID[root].Children)
+	}
+	if !reflect.DeepEqual(byID[leaf].Labels, []string{"backend", "db"}) {
+		t.Fatalf("expected labels on tree node, got %#v", byID[leaf].Labels)
+	}
+
+	_, nodes = loadFilteredTree(t, ts.URL, "label=db&label=ui&role=full")
+	if len(nodes) != 4 {
+		t.Fatalf("expected repeated label values to be ORed, got %d nodes", len(nodes))
+	}
+	_, nodes = loadFilteredTree(t, ts.URL, "status=completed")
+	if len(nodes) != 0 {
+		t.Fatalf("expected no completed tasks, got %#v", nodes)
+	}
+	if status, _ := loadFilteredTree(t, ts.URL, "status=bogus"); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown status, got %d", status)
+	}
+}
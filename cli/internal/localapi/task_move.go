@@ -0,0 +1,105 @@
+package localapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"shellman/cli/internal/projectstate"
+)
+
+// handleMoveTask reparents a task (empty parent_task_id moves it to the root) and places it at position among
+// its new siblings; a missing position appends.
+func (s *Server) handleMoveTask(w http.ResponseWriter, r *http.Request, taskID string) {
+	var req struct {
+		ParentTaskID string `json:"parent_task_id"`
+		Position     *int   `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	newParentTaskID := strings.TrimSpace(req.ParentTaskID)
+	position := -1
+	if req.Position != nil {
+		if *req.Position < 0 {
+			respondError(w, http.StatusBadRequest, "INVALID_POSITION", "position must be >= 0")
+			return
+		}
+		position = *req.Position
+	}
+	projectID, store, entry, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	if newParentTaskID != "" {
+		parent, ok, err := findTaskEntryInProject(store, projectID, newParentTaskID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "TASK_MOVE_FAILED", err.Error())
+			return
+		}
+		if !ok {
+			respondError(w, http.StatusNotFound, "PARENT_NOT_FOUND", "parent task not found")
+			return
+		}
+		if err := validateTaskParentRole(parent.TaskRole, entry.TaskRole); err != nil {
+			switch {
+			case errors.Is(err, errExecutorCannotDelegate):
+				respondError(w, http.StatusBadRequest, "EXECUTOR_CANNOT_DELEGATE", err.Error())
+			default:
+				respondError(w, http.StatusBadRequest, "PLANNER_ONLY_SPAWN_EXECUTOR", err.Error())
+			}
+			return
+		}
+	}
+
+	oldParentTaskID, err := store.MoveTask(projectID, taskID, newParentTaskID, position)
+	if err != nil {
+		switch {
+		case errors.Is(err, projectstate.ErrTaskMoveCycle):
+			respondError(w, http.StatusConflict, "TASK_MOVE_CYCLE", err.Error())
+		case errors.Is(err, projectstate.ErrTaskMoveNotFound):
+			respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		default:
+			respondError(w, http.StatusInternalServerError, "TASK_MOVE_FAILED", err.Error())
+		}
+		return
+	}
+	if err := s.syncMovedTaskParents(store, projectID, oldParentTaskID, newParentTaskID); err != nil {
+		respondError(w, http.StatusInternalServerError, "TASK_MOVE_FAILED", err.Error())
+		return
+	}
+
+	s.publishEvent("task.moved", projectID, taskID, map[string]any{
+		"old_parent_task_id": oldParentTaskID,
+		"parent_task_id":     newParentTaskID,
+		"position":           position,
+	})
+	for _, parentID := range []string{oldParentTaskID, newParentTaskID} {
+		if parentID == "" {
+			parentID = taskID
+		}
+		s.publishEvent("task.tree.updated", projectID, parentID, map[string]any{})
+		if oldParentTaskID == newParentTaskID {
+			break
+		}
+	}
+	respondOK(w, map[string]any{
+		"task_id":            taskID,
+		"old_parent_task_id": oldParentTaskID,
+		"parent_task_id":     newParentTaskID,
+	})
+}
+
+// syncMovedTaskParents re-evaluates waiting_children on both ends of a move.
+func (s *Server) syncMovedTaskParents(store *projectstate.Store, projectID, oldParentTaskID, newParentTaskID string) error {
+	if oldParentTaskID == newParentTaskID {
+		return nil
+	}
+	if err := s.syncParentWaitingChildren(store, projectID, oldParentTaskID); err != nil {
+		return err
+	}
+	return s.syncParentWaitingChildren(store, projectID, newParentTaskID)
+}
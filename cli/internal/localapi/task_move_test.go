@@ -0,0 +1,72 @@
+package localapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func postTaskMove(t *testing.T, baseURL, taskID, body string) int {
+	t.Helper()
+	resp, err := http.Post(baseURL+"/api/v1/tasks/"+taskID+"/move", "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("POST move failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestTaskMove_ReparentReorderAndValidation(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	parentA := createTestTask(t, ts.URL, "parent-a", nil)
+	parentB := createTestTask(t, ts.URL, "parent-b", nil)
+	child1 := createTestTask(t, ts.URL, "child-1", map[string]any{"parent_task_id": parentA})
+	child2 := createTestTask(t, ts.URL, "child-2", map[string]any{"parent_task_id": parentA})
+	executor := createTestTask(t, ts.URL, "exec", map[string]any{"parent_task_id": parentB, "task_role": projectstate.TaskRoleExecutor})
+
+	if code := postTaskMove(t, ts.URL, child2, `{"parent_task_id":"`+parentA+`","position":0}`); code != http.StatusOK {
+		t.Fatalf("expected 200 reordering, got %d", code)
+	}
+	if got := loadDependencyTestNode(t, ts.URL, parentA).Children; !reflect.DeepEqual(got, []string{child2, child1}) {
+		t.Fatalf("unexpected children after reorder: %#v", got)
+	}
+
+	if code := postTaskMove(t, ts.URL, parentA, `{"parent_task_id":"`+child1+`"}`); code != http.StatusConflict {
+		t.Fatalf("expected 409 moving under a descendant, got %d", code)
+	}
+	if code := postTaskMove(t, ts.URL, child1, `{"parent_task_id":"`+executor+`"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 moving under an executor, got %d", code)
+	}
+	if code := postTaskMove(t, ts.URL, child1, `{"parent_task_id":"t_missing"}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing parent, got %d", code)
+	}
+
+	if code := postTaskMove(t, ts.URL, child1, `{"parent_task_id":"`+parentB+`","position":0}`); code != http.StatusOK {
+		t.Fatalf("expected 200 reparenting, got %d", code)
+	}
+	if code := postTaskMove(t, ts.URL, child2, `{"parent_task_id":""}`); code != http.StatusOK {
+		t.Fatalf("expected 200 moving to root, got %d", code)
+	}
+	nodeA := loadDependencyTestNode(t, ts.URL, parentA)
+	if len(nodeA.Children) != 0 || nodeA.Status != projectstate.StatusPending {
+		t.Fatalf("expected empty pending parent-a, got children=%#v status=%q", nodeA.Children, nodeA.Status)
+	}
+	nodeB := loadDependencyTestNode(t, ts.URL, parentB)
+	if !reflect.DeepEqual(nodeB.Children, []string{child1, executor}) || nodeB.Status != projectstate.StatusWaitingChildren {
+		t.Fatalf("unexpected parent-b: children=%#v status=%q", nodeB.Children, nodeB.Status)
+	}
+	if got := loadDependencyTestNode(t, ts.URL, child2).ParentTaskID; got != "" {
+		t.Fatalf("expected child-2 at root, got parent %q", got)
+	}
+}
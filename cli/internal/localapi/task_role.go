@@ -27,3 +27,19 @@ func normalizeTaskRole(role string) string {
 func validTaskRole(role string) bool {
 	return normalizeTaskRole(role) != ""
 }
+
+// validateTaskParentRole applies the delegation rules behind applyTaskRoleToolScope:
+// executors cannot have children and planners can only hold executors.
+func validateTaskParentRole(parentRole, childRole string) error {
+	parentRole = normalizeTaskRole(parentRole)
+	if parentRole == "" {
+		parentRole = projectstate.TaskRoleFull
+	}
+	if parentRole == projectstate.TaskRoleExecutor {
+		return errExecutorCannotDelegate
+	}
+	if parentRole == projectstate.TaskRolePlanner && normalizeTaskRole(childRole) != projectstate.TaskRoleExecutor {
+		return errPlannerOnlySpawnExecutor
+	}
+	return nil
+}
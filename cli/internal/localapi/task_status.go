@@ -0,0 +1,148 @@
+package localapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"shellman/cli/internal/projectstate"
+)
+
+// transitionTaskStatus moves taskID to status when the transition table allows it for trigger, then keeps its
+// parent's waiting_children state in line; canceling also cancels the task's unfinished runs. It reports whether
+// the status actually changed.
+func (s *Server) transitionTaskStatus(store *projectstate.Store, projectID, taskID, status, trigger, source string) (bool, error) {
+	entry, ok, err := findTaskEntryInProject(store, projectID, taskID)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, errors.New("task not found")
+	}
+	nextStatus := strings.TrimSpace(status)
+	if nextStatus == "" {
+		return false, errors.New("status is required")
+	}
+	if err := projectstate.ValidateStatusTransition(entry.Status, nextStatus, trigger); err != nil {
+		return false, err
+	}
+	if entry.Status == nextStatus {
+		return false, nil
+	}
+	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{
+		TaskID:    taskID,
+		ProjectID: projectID,
+		Status:    &nextStatus,
+		Source:    source,
+	}); err != nil {
+		return false, err
+	}
+	if nextStatus == projectstate.StatusCanceled {
+		if err := s.cancelTaskRuns(store, projectID, taskID, "task canceled", source); err != nil {
+			return true, err
+		}
+	}
+	return true, s.syncParentWaitingChildren(store, projectID, entry.ParentTaskID)
+}
+
+// cancelTaskRuns ends whatever run was still attached to taskID, e.g. because the task was canceled or its pane was
+// closed on purpose, so nothing tries to bring the run's pane back.
+func (s *Server) cancelTaskRuns(store *projectstate.Store, projectID, taskID, reason, source string) error {
+	runIDs, err := store.CancelRunningRuns(taskID, reason)
+	if err != nil {
+		return err
+	}
+	for _, runID := range runIDs {
+		_ = store.AppendRunEvent(runID, "run.canceled", map[string]any{"source": source, "reason": reason})
+		s.recordRunFinished(store, projectID, runID, projectstate.StatusCanceled, "", source, nil)
+	}
+	return nil
+}
+
+// syncParentWaitingChildren puts parentTaskID in waiting_children while it has unfinished children and returns it
+// to pending once they are all done. Parents the table does not let move that way (finished ones) are left alone.
+func (s *Server) syncParentWaitingChildren(store *projectstate.Store, projectID, parentTaskID string) error {
+	parentTaskID = strings.TrimSpace(parentTaskID)
+	if parentTaskID == "" {
+		return nil
+	}
+	rows, err := store.ListTasksByProject(projectID)
+	if err != nil {
+		return err
+	}
+	parentStatus := ""
+	found := false
+	pendingChildren := 0
+	for _, row := range rows {
+		if row.TaskID == parentTaskID {
+			parentStatus = row.Status
+			found = true
+		}
+		if strings.TrimSpace(row.ParentTaskID) == parentTaskID && !isTaskTerminalStatus(row.Status) {
+			pendingChildren++
+		}
+	}
+	if !found {
+		return nil
+	}
+	nextStatus := parentStatus
+	if pendingChildren > 0 {
+		nextStatus = projectstate.StatusWaitingChildren
+	} else if parentStatus == projectstate.StatusWaitingChildren {
+		nextStatus = projectstate.StatusPending
+	}
+	if nextStatus == parentStatus || projectstate.ValidateStatusTransition(parentStatus, nextStatus, projectstate.StatusTriggerChildren) != nil {
+		return nil
+	}
+	if err := store.UpsertTaskMeta(projectstate.TaskMetaUpsert{
+		TaskID:    parentTaskID,
+		ProjectID: projectID,
+		Status:    &nextStatus,
+		Source:    projectstate.TaskRevisionSourceSystem,
+	}); err != nil {
+		return err
+	}
+	s.publishEvent("task.status.updated", projectID, parentTaskID, map[string]any{
+		"status":                 nextStatus,
+		"pending_children_count": pendingChildren,
+	})
+	return nil
+}
+
+// respondTaskStatusError maps status update failures; rejected transitions carry from, to and the statuses the
+// task could move to instead.
+func respondTaskStatusError(w http.ResponseWriter, err error, fallbackCode string) {
+	var transitionErr *projectstate.StatusTransitionError
+	if errors.As(err, &transitionErr) {
+		writeJSON(w, http.StatusConflict, map[string]any{"ok": false, "error": map[string]any{
+			"code":    "INVALID_STATUS_TRANSITION",
+			"message": err.Error(),
+			"from":    transitionErr.From,
+			"to":      transitionErr.To,
+			"allowed": projectstate.AllowedStatusTransitions(transitionErr.From, transitionErr.Trigger),
+		}})
+		return
+	}
+	respondError(w, http.StatusInternalServerError, fallbackCode, err.Error())
+}
+
+// handleReopenTask returns a completed, failed or canceled task to pending so it can be worked on again.
+func (s *Server) handleReopenTask(w http.ResponseWriter, _ *http.Request, taskID string) {
+	projectID, store, entry, err := s.findTask(taskID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "TASK_NOT_FOUND", err.Error())
+		return
+	}
+	if !isTaskTerminalStatus(entry.Status) {
+		respondError(w, http.StatusConflict, "TASK_NOT_REOPENABLE", "only completed, failed or canceled tasks can be reopened")
+		return
+	}
+	if _, err := s.transitionTaskStatus(store, projectID, taskID, projectstate.StatusPending, projectstate.StatusTriggerReopen, projectstate.TaskRevisionSourceUser); err != nil {
+		respondTaskStatusError(w, err, "TASK_UPDATE_FAILED")
+		return
+	}
+	s.publishEvent("task.status.updated", projectID, taskID, map[string]any{"status": projectstate.StatusPending})
+	s.publishEvent("task.reopened", projectID, taskID, map[string]any{"previous_status": entry.Status})
+	s.publishEvent("task.tree.updated", projectID, taskID, map[string]any{})
+	respondOK(w, map[string]any{"task_id": taskID, "status": projectstate.StatusPending, "previous_status": entry.Status})
+}
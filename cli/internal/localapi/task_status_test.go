@@ -0,0 +1,128 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+type taskStatusTestError struct {
+	Code    string   `json:"code"`
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Allowed []string `json:"allowed"`
+}
+
+func patchTaskStatus(t *testing.T, baseURL, taskID, status string) (int, taskStatusTestError) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPatch, baseURL+"/api/v1/tasks/"+taskID+"/status", bytes.NewBufferString(`{"status":"`+status+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PATCH status failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var out struct {
+		Error taskStatusTestError `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out.Error
+}
+
+func TestTaskStatus_TransitionsReopenAndWaitingChildren(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	parent := createTestTask(t, ts.URL, "parent", nil)
+	child := createTestTask(t, ts.URL, "child", map[string]any{"parent_task_id": parent})
+	if node := loadDependencyTestNode(t, ts.URL, parent); node.Status != projectstate.StatusWaitingChildren || node.PendingChildrenCount != 1 {
+		t.Fatalf("expected parent waiting on one child, got %#v", node)
+	}
+
+	if code, _ := patchTaskStatus(t, ts.URL, child, projectstate.StatusCompleted); code != http.StatusOK {
+		t.Fatalf("expected 200 completing child, got %d", code)
+	}
+	if node := loadDependencyTestNode(t, ts.URL, parent); node.Status != projectstate.StatusPending || node.PendingChildrenCount != 0 {
+		t.Fatalf("expected parent back to pending, got %#v", node)
+	}
+
+	code, apiErr := patchTaskStatus(t, ts.URL, child, projectstate.StatusRunning)
+	if code != http.StatusConflict || apiErr.Code != "INVALID_STATUS_TRANSITION" || apiErr.From != projectstate.StatusCompleted || apiErr.To != projectstate.StatusRunning {
+		t.Fatalf("expected structured 409 for completed -> running, got %d %#v", code, apiErr)
+	}
+	if len(apiErr.Allowed) != 0 {
+		t.Fatalf("expected no update transitions out of completed, got %#v", apiErr.Allowed)
+	}
+
+	resp, err := http.Post(ts.URL+"/api/v1/tasks/"+child+"/reopen", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST reopen failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 reopening, got %d", resp.StatusCode)
+	}
+	if node := loadDependencyTestNode(t, ts.URL, child); node.Status != projectstate.StatusPending {
+		t.Fatalf("expected reopened child pending, got %q", node.Status)
+	}
+	if node := loadDependencyTestNode(t, ts.URL, parent); node.Status != projectstate.StatusWaitingChildren {
+		t.Fatalf("expected parent waiting again after reopen, got %q", node.Status)
+	}
+
+	resp, err = http.Post(ts.URL+"/api/v1/tasks/"+child+"/reopen", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST reopen failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 reopening a pending task, got %d", resp.StatusCode)
+	}
+
+	if code, _ := patchTaskStatus(t, ts.URL, child, projectstate.StatusCanceled); code != http.StatusOK {
+		t.Fatalf("expected 200 canceling, got %d", code)
+	}
+	code, apiErr = patchTaskStatus(t, ts.URL, child, projectstate.StatusPending)
+	if code != http.StatusConflict || !reflect.DeepEqual(apiErr.Allowed, []string{}) {
+		t.Fatalf("expected canceled to be terminal, got %d %#v", code, apiErr)
+	}
+}
+
+func TestTaskStatus_ManualLaunchIsAnUpdateAndValidatedBeforeOpeningAPane(t *testing.T) {
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: filepath.Clean(repo)}}}
+	panes := &fakePaneService{}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: panes})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	taskID := createTestTask(t, ts.URL, "done", nil)
+	if code, _ := patchTaskStatus(t, ts.URL, taskID, projectstate.StatusCompleted); code != http.StatusOK {
+		t.Fatalf("expected 200 completing task, got %d", code)
+	}
+
+	resp, err := http.Post(ts.URL+"/api/v1/tasks/"+taskID+"/panes/manual", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST manual launch failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var out struct {
+		Error taskStatusTestError `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	if resp.StatusCode != http.StatusConflict || out.Error.Code != "INVALID_STATUS_TRANSITION" {
+		t.Fatalf("expected 409 INVALID_STATUS_TRANSITION, got %d %#v", resp.StatusCode, out.Error)
+	}
+	if panes.manualRootCount != 0 || panes.manualSiblingCount != 0 {
+		t.Fatalf("expected no pane opened for a rejected launch, got root=%d sibling=%d", panes.manualRootCount, panes.manualSiblingCount)
+	}
+}
@@ -0,0 +1,322 @@
+package localapi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+
+	"shellman/cli/internal/global"
+)
+
+const (
+	templateSourceSystem  = "system"
+	templateSourceProject = "project"
+)
+
+type TaskTemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type TaskTemplateNode struct {
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	Role        string             `json:"role,omitempty"`
+	SidecarMode string             `json:"sidecar_mode,omitempty"`
+	Command     string             `json:"command,omitempty"`
+	Children    []TaskTemplateNode `json:"children,omitempty"`
+}
+
+type TaskTemplate struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Variables   []TaskTemplateVariable `json:"variables,omitempty"`
+	Tasks       []TaskTemplateNode     `json:"tasks"`
+	Path        string                 `json:"path"`
+	Source      string                 `json:"source"`
+}
+
+var taskTemplateVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// BuildTaskTemplateIndex loads recipes from both bases; project templates override system templates with the same name.
+func BuildTaskTemplateIndex(systemBase, projectBase string) (map[string]TaskTemplate, error) {
+	index := map[string]TaskTemplate{}
+	if err := scanTaskTemplateBase(index, strings.TrimSpace(systemBase), templateSourceSystem); err != nil {
+		return nil, err
+	}
+	if err := scanTaskTemplateBase(index, strings.TrimSpace(projectBase), templateSourceProject); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func scanTaskTemplateBase(index map[string]TaskTemplate, basePath, source string) error {
+	if strings.TrimSpace(basePath) == "" {
+		return nil
+	}
+	info, err := os.Stat(basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("template base path is not a directory: %s", basePath)
+	}
+	return filepath.WalkDir(basePath, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() || !isTaskTemplateFile(d.Name()) {
+			return nil
+		}
+		tpl, err := loadTaskTemplateFile(path)
+		if err != nil {
+			return fmt.Errorf("parse template %s: %w", path, err)
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		tpl.Path = filepath.ToSlash(absPath)
+		tpl.Source = source
+		index[tpl.Name] = tpl
+		return nil
+	})
+}
+
+func isTaskTemplateFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(strings.TrimSpace(name))) {
+	case ".yaml", ".yml", ".toml":
+		return true
+	default:
+		return false
+	}
+}
+
+func loadTaskTemplateFile(path string) (TaskTemplate, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return TaskTemplate{}, err
+	}
+	doc := map[string]any{}
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		if err := toml.Unmarshal(raw, &doc); err != nil {
+			return TaskTemplate{}, err
+		}
+	} else if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return TaskTemplate{}, err
+	}
+	tpl, err := decodeTaskTemplate(doc)
+	if err != nil {
+		return TaskTemplate{}, err
+	}
+	if tpl.Name == "" {
+		tpl.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return tpl, nil
+}
+
+func decodeTaskTemplate(doc map[string]any) (TaskTemplate, error) {
+	tpl := TaskTemplate{
+		Name:        templateString(doc["name"]),
+		Description: templateString(doc["description"]),
+	}
+	vars, err := templateList(doc["variables"], "variables")
+	if err != nil {
+		return TaskTemplate{}, err
+	}
+	seen := map[string]struct{}{}
+	for idx, item := range vars {
+		name := templateString(item["name"])
+		if name == "" {
+			return TaskTemplate{}, fmt.Errorf("variables[%d]: name is required", idx)
+		}
+		if _, ok := seen[name]; ok {
+			return TaskTemplate{}, fmt.Errorf("variables[%d]: duplicate variable %q", idx, name)
+		}
+		seen[name] = struct{}{}
+		tpl.Variables = append(tpl.Variables, TaskTemplateVariable{
+			Name:        name,
+			Description: templateString(item["description"]),
+			Default:     templateString(item["default"]),
+			Required:    templateBool(item["required"]),
+		})
+	}
+	tpl.Tasks, err = decodeTaskTemplateNodes(doc["tasks"], "tasks")
+	if err != nil {
+		return TaskTemplate{}, err
+	}
+	if len(tpl.Tasks) == 0 {
+		return TaskTemplate{}, fmt.Errorf("tasks: at least one task is required")
+	}
+	return tpl, nil
+}
+
+func decodeTaskTemplateNodes(raw any, path string) ([]TaskTemplateNode, error) {
+	items, err := templateList(raw, path)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]TaskTemplateNode, 0, len(items))
+	for idx, item := range items {
+		itemPath := fmt.Sprintf("%s[%d]", path, idx)
+		node := TaskTemplateNode{
+			Title:       templateString(item["title"]),
+			Description: templateString(item["description"]),
+			Role:        templateString(item["role"]),
+			SidecarMode: templateString(item["sidecar_mode"]),
+			Command:     templateString(item["command"]),
+		}
+		if node.Title == "" {
+			return nil, fmt.Errorf("%s: title is required", itemPath)
+		}
+		node.Children, err = decodeTaskTemplateNodes(item["children"], itemPath+".children")
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, node)
+	}
+	return out, nil
+}
+
+func templateList(raw any, path string) ([]map[string]any, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a list", path)
+	}
+	out := make([]map[string]any, 0, len(items))
+	for idx, item := range items {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d]: expected a mapping", path, idx)
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+func templateString(raw any) string {
+	switch v := raw.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+func templateBool(raw any) bool {
+	switch v := raw.(type) {
+	case bool:
+		return v
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "on", "1":
+			return true
+		}
+	}
+	return false
+}
+
+// renderTaskTemplate resolves variables and returns a copy of tpl.Tasks with every {{name}} substituted.
+func renderTaskTemplate(tpl TaskTemplate, provided map[string]string) ([]TaskTemplateNode, error) {
+	values := map[string]string{}
+	for key, value := range provided {
+		values[strings.TrimSpace(key)] = value
+	}
+	missing := []string{}
+	for _, item := range tpl.Variables {
+		if strings.TrimSpace(values[item.Name]) != "" {
+			continue
+		}
+		if item.Default != "" {
+			values[item.Name] = item.Default
+			continue
+		}
+		if item.Required {
+			missing = append(missing, item.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required variables: %s", strings.Join(missing, ", "))
+	}
+	undefined := map[string]struct{}{}
+	substitute := func(text string) string {
+		return taskTemplateVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+			name := taskTemplateVariablePattern.FindStringSubmatch(match)[1]
+			value, ok := values[name]
+			if !ok {
+				undefined[name] = struct{}{}
+				return match
+			}
+			return value
+		})
+	}
+	var render func(nodes []TaskTemplateNode) []TaskTemplateNode
+	render = func(nodes []TaskTemplateNode) []TaskTemplateNode {
+		out := make([]TaskTemplateNode, 0, len(nodes))
+		for _, node := range nodes {
+			out = append(out, TaskTemplateNode{
+				Title:       strings.TrimSpace(substitute(node.Title)),
+				Description: substitute(node.Description),
+				Role:        node.Role,
+				SidecarMode: node.SidecarMode,
+				Command:     strings.TrimSpace(substitute(node.Command)),
+				Children:    render(node.Children),
+			})
+		}
+		return out
+	}
+	rendered := render(tpl.Tasks)
+	if len(undefined) > 0 {
+		names := make([]string, 0, len(undefined))
+		for name := range undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("undefined variables: %s", strings.Join(names, ", "))
+	}
+	return rendered, nil
+}
+
+func (s *Server) taskTemplateBases(projectID string) (string, string) {
+	systemBase := ""
+	if configDir, err := global.DefaultConfigDir(); err == nil {
+		systemBase = filepath.Join(strings.TrimSpace(configDir), "templates")
+	}
+	projectBase := ""
+	if strings.TrimSpace(projectID) != "" {
+		if repoRoot, err := s.findProjectRepoRoot(projectID); err == nil {
+			projectBase = filepath.Join(strings.TrimSpace(repoRoot), ".shellman", "templates")
+		}
+	}
+	return systemBase, projectBase
+}
+
+func (s *Server) loadTaskTemplates(projectID string) ([]TaskTemplate, error) {
+	systemBase, projectBase := s.taskTemplateBases(projectID)
+	index, err := BuildTaskTemplateIndex(systemBase, projectBase)
+	if err != nil {
+		return []TaskTemplate{}, err
+	}
+	out := make([]TaskTemplate, 0, len(index))
+	for _, item := range index {
+		out = append(out, item)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
@@ -0,0 +1,152 @@
+package localapi
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const featureTemplateYAML = `# planner with two executors
+name: feature
+description: "Plan and build a feature"
+variables:
+  - name: feature
+    required: true
+  - name: branch
+    default: main
+tasks:
+  - title: "Plan {{ feature }}"
+    role: planner
+    sidecar_mode: autopilot
+    description: |
+      Split {{feature}} into steps.
+      Base branch: {{branch}}
+    children:
+      - title: Implement {{feature}}
+        command: git checkout {{branch}} # launch in the pane
+      - title: Test {{feature}}
+        role: executor
+`
+
+func TestBuildTaskTemplateIndex_ParsesYAMLAndTOMLWithProjectOverride(t *testing.T) {
+	systemBase := t.TempDir()
+	projectBase := filepath.Join(t.TempDir(), ".shellman", "templates")
+	if err := os.MkdirAll(filepath.Join(projectBase, "nested"), 0o755); err != nil {
+		t.Fatalf("mkdir project templates failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(systemBase, "feature.yaml"), []byte("name: feature\ndescription: system\ntasks:\n  - title: sys\n"), 0o644); err != nil {
+		t.Fatalf("write system template failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectBase, "nested", "feature.yml"), []byte(featureTemplateYAML), 0o644); err != nil {
+		t.Fatalf("write project template failed: %v", err)
+	}
+	bugfix := "description = \"Fix a bug\"\n\n[[tasks]]\ntitle = \"Fix {{issue}}\"\nrole = \"executor\"\n\n[[variables]]\nname = \"issue\"\nrequired = true\n"
+	if err := os.WriteFile(filepath.Join(systemBase, "bugfix.toml"), []byte(bugfix), 0o644); err != nil {
+		t.Fatalf("write toml template failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(systemBase, "README.md"), []byte("ignored"), 0o644); err != nil {
+		t.Fatalf("write readme failed: %v", err)
+	}
+
+	index, err := BuildTaskTemplateIndex(systemBase, projectBase)
+	if err != nil {
+		t.Fatalf("BuildTaskTemplateIndex failed: %v", err)
+	}
+	if len(index) != 2 {
+		t.Fatalf("expected 2 templates, got %d", len(index))
+	}
+
+	feature := index["feature"]
+	if feature.Source != templateSourceProject || feature.Description != "Plan and build a feature" {
+		t.Fatalf("expected project feature template, got %#v", feature)
+	}
+	if len(feature.Variables) != 2 || !feature.Variables[0].Required || feature.Variables[1].Default != "main" {
+		t.Fatalf("unexpected variables: %#v", feature.Variables)
+	}
+	if len(feature.Tasks) != 1 || len(feature.Tasks[0].Children) != 2 {
+		t.Fatalf("unexpected task tree: %#v", feature.Tasks)
+	}
+	root := feature.Tasks[0]
+	if root.Role != "planner" || root.SidecarMode != "autopilot" || root.Description != "Split {{feature}} into steps.\nBase branch: {{branch}}" {
+		t.Fatalf("unexpected root node: %#v", root)
+	}
+	if root.Children[0].Command != "git checkout {{branch}}" {
+		t.Fatalf("expected inline comment stripped from command, got %q", root.Children[0].Command)
+	}
+
+	bug := index["bugfix"]
+	if bug.Source != templateSourceSystem || len(bug.Tasks) != 1 || bug.Tasks[0].Title != "Fix {{issue}}" || !bug.Variables[0].Required {
+		t.Fatalf("expected toml template named after its file, got %#v", bug)
+	}
+}
+
+func TestRenderTaskTemplate_SubstitutesAndValidatesVariables(t *testing.T) {
+	doc := map[string]any{}
+	if err := yaml.Unmarshal([]byte(featureTemplateYAML), &doc); err != nil {
+		t.Fatalf("yaml.Unmarshal failed: %v", err)
+	}
+	tpl, err := decodeTaskTemplate(doc)
+	if err != nil {
+		t.Fatalf("decodeTaskTemplate failed: %v", err)
+	}
+
+	if _, err := renderTaskTemplate(tpl, nil); err == nil || !strings.Contains(err.Error(), "feature") {
+		t.Fatalf("expected missing required variable error, got %v", err)
+	}
+	nodes, err := renderTaskTemplate(tpl, map[string]string{"feature": "login"})
+	if err != nil {
+		t.Fatalf("renderTaskTemplate failed: %v", err)
+	}
+	if nodes[0].Title != "Plan login" || !strings.Contains(nodes[0].Description, "Base branch: main") {
+		t.Fatalf("unexpected rendered root: %#v", nodes[0])
+	}
+	if nodes[0].Children[0].Command != "git checkout main" {
+		t.Fatalf("unexpected rendered command: %q", nodes[0].Children[0].Command)
+	}
+	if tpl.Tasks[0].Title != "Plan {{ feature }}" {
+		t.Fatalf("expected template left untouched, got %q", tpl.Tasks[0].Title)
+	}
+
+	tpl.Tasks[0].Title = "Plan {{unknown}}"
+	if _, err := renderTaskTemplate(tpl, map[string]string{"feature": "login"}); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Fatalf("expected undefined variable error, got %v", err)
+	}
+}
+
+func TestLoadTaskTemplateFile_ReadsFlowCollectionsAndAnchors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "review.yaml")
+	raw := `name: 'review: {{ area }}'
+variables: [{name: area, default: "api, web"}]
+step: &step
+  role: executor
+  sidecar_mode: observer
+tasks:
+  - title: Review
+    children:
+      - {title: "Read #1", <<: *step}
+      - <<: *step
+        title: Write notes
+`
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tpl, err := loadTaskTemplateFile(path)
+	if err != nil {
+		t.Fatalf("loadTaskTemplateFile failed: %v", err)
+	}
+	if tpl.Name != "review: {{ area }}" || len(tpl.Variables) != 1 || tpl.Variables[0].Default != "api, web" {
+		t.Fatalf("unexpected template header: %#v", tpl)
+	}
+	children := tpl.Tasks[0].Children
+	if len(children) != 2 || children[0].Title != "Read #1" || children[1].Title != "Write notes" {
+		t.Fatalf("unexpected children: %#v", children)
+	}
+	for _, child := range children {
+		if child.Role != "executor" || child.SidecarMode != "observer" {
+			t.Fatalf("expected anchored fields merged into %#v", child)
+		}
+	}
+}
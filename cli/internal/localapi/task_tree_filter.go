@@ -0,0 +1,179 @@
+package localapi
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/projectstate"
+)
+
+// taskTreeFilter narrows the project tree. Values within one parameter are ORed, parameters are ANDed.
+type taskTreeFilter struct {
+	Statuses     map[string]struct{}
+	Flags        map[string]struct{}
+	Roles        map[string]struct{}
+	Labels       map[string]struct{}
+	SidecarModes map[string]struct{}
+	UpdatedSince int64
+}
+
+func parseTaskTreeFilter(query url.Values) (taskTreeFilter, error) {
+	filter := taskTreeFilter{
+		Statuses:     parseTaskTreeFilterSet(query, "status"),
+		Flags:        parseTaskTreeFilterSet(query, "flag"),
+		Roles:        parseTaskTreeFilterSet(query, "role"),
+		Labels:       parseTaskTreeFilterSet(query, "label"),
+		SidecarModes: parseTaskTreeFilterSet(query, "sidecar_mode"),
+	}
+	if len(filter.Labels) > 0 {
+		// Stored labels are lower-cased by NormalizeTaskLabels.
+		labels := make(map[string]struct{}, len(filter.Labels))
+		for label := range filter.Labels {
+			labels[strings.ToLower(label)] = struct{}{}
+		}
+		filter.Labels = labels
+	}
+	for status := range filter.Statuses {
+		if _, ok := validTaskStatus[status]; !ok {
+			return taskTreeFilter{}, fmt.Errorf("unsupported status: %s", status)
+		}
+	}
+	for role := range filter.Roles {
+		if normalizeTaskRole(role) != role {
+			return taskTreeFilter{}, fmt.Errorf("unsupported role: %s", role)
+		}
+	}
+	for mode := range filter.SidecarModes {
+		if normalizeSidecarMode(mode) != mode {
+			return taskTreeFilter{}, fmt.Errorf("unsupported sidecar_mode: %s", mode)
+		}
+	}
+	if raw := strings.TrimSpace(query.Get("updated_since")); raw != "" {
+		since, err := parseTaskTreeUpdatedSince(raw)
+		if err != nil {
+			return taskTreeFilter{}, err
+		}
+		filter.UpdatedSince = since
+	}
+	return filter, nil
+}
+
+// parseTaskTreeFilterSet accepts both repeated parameters and comma separated values.
+func parseTaskTreeFilterSet(query url.Values, key string) map[string]struct{} {
+	var out map[string]struct{}
+	for _, raw := range query[key] {
+		for _, part := range strings.Split(raw, ",") {
+			value := strings.TrimSpace(part)
+			if value == "" {
+				continue
+			}
+			if out == nil {
+				out = map[string]struct{}{}
+			}
+			out[value] = struct{}{}
+		}
+	}
+	return out
+}
+
+func parseTaskTreeUpdatedSince(raw string) (int64, error) {
+	if unix, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return unix, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return 0, fmt.Errorf("updated_since must be unix seconds or RFC3339")
+	}
+	return parsed.UTC().Unix(), nil
+}
+
+func (f taskTreeFilter) active() bool {
+	return len(f.Statuses) > 0 || len(f.Flags) > 0 || len(f.Roles) > 0 || len(f.Labels) > 0 ||
+		len(f.SidecarModes) > 0 || f.UpdatedSince > 0
+}
+
+func (f taskTreeFilter) matches(row projectstate.TaskRecordRow, labels []string) bool {
+	if len(f.Statuses) > 0 && !taskTreeFilterHas(f.Statuses, strings.TrimSpace(row.Status)) {
+		return false
+	}
+	if len(f.Flags) > 0 {
+		flag := strings.TrimSpace(row.Flag)
+		switch {
+		case taskTreeFilterHas(f.Flags, flag):
+		case flag == "" && taskTreeFilterHas(f.Flags, "none"):
+		case flag != "" && taskTreeFilterHas(f.Flags, "any"):
+		default:
+			return false
+		}
+	}
+	if len(f.Roles) > 0 && !taskTreeFilterHas(f.Roles, normalizeTaskRole(row.TaskRole)) {
+		return false
+	}
+	if len(f.SidecarModes) > 0 && !taskTreeFilterHas(f.SidecarModes, normalizeSidecarMode(row.SidecarMode)) {
+		return false
+	}
+	if len(f.Labels) > 0 {
+		found := false
+		for _, label := range labels {
+			if taskTreeFilterHas(f.Labels, label) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.UpdatedSince > 0 && row.LastModified < f.UpdatedSince {
+		return false
+	}
+	return true
+}
+
+func taskTreeFilterHas(set map[string]struct{}, value string) bool {
+	_, ok := set[value]
+	return ok
+}
+
+// filterTaskTreeNodes keeps nodes matching filter plus all of their ancestors, and prunes child lists to kept nodes.
+func filterTaskTreeNodes(nodes []projectstate.TaskNode, rows []projectstate.TaskRecordRow, labels map[string][]string, filter taskTreeFilter) []projectstate.TaskNode {
+	if !filter.active() {
+		return nodes
+	}
+	parentOf := make(map[string]string, len(rows))
+	matched := map[string]struct{}{}
+	for _, row := range rows {
+		parentOf[row.TaskID] = strings.TrimSpace(row.ParentTaskID)
+		if filter.matches(row, labels[row.TaskID]) {
+			matched[row.TaskID] = struct{}{}
+		}
+	}
+	keep := make(map[string]struct{}, len(matched))
+	for taskID := range matched {
+		for current := taskID; current != ""; current = parentOf[current] {
+			if _, ok := keep[current]; ok {
+				break
+			}
+			keep[current] = struct{}{}
+		}
+	}
+	out := make([]projectstate.TaskNode, 0, len(keep))
+	for _, node := range nodes {
+		if _, ok := keep[node.TaskID]; !ok {
+			continue
+		}
+		_, node.Matched = matched[node.TaskID]
+		children := make([]string, 0, len(node.Children))
+		for _, childID := range node.Children {
+			if _, ok := keep[childID]; ok {
+				children = append(children, childID)
+			}
+		}
+		node.Children = children
+		out = append(out, node)
+	}
+	return out
+}
@@ -0,0 +1,226 @@
+package localapi
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"shellman/cli/internal/projectstate"
+	"shellman/cli/internal/tmux"
+)
+
+const defaultTmuxSocketName = "default"
+
+var tmuxSocketNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// tmuxSocketRouter is implemented by pane services that can reach tmux servers on other sockets than their own.
+type tmuxSocketRouter interface {
+	ForSocket(socket string) *tmux.Adapter
+}
+
+// tmuxServerProbe is what the tmux server listing needs to tell whether a server is up.
+type tmuxServerProbe interface {
+	ServerInstanceID() (string, error)
+	SocketPath() (string, error)
+}
+
+type tmuxServerItem struct {
+	Socket           string   `json:"socket"`
+	Default          bool     `json:"default"`
+	Alive            bool     `json:"alive"`
+	ServerInstanceID string   `json:"server_instance_id,omitempty"`
+	PID              string   `json:"pid,omitempty"`
+	SocketPath       string   `json:"socket_path,omitempty"`
+	LastSeenAt       int64    `json:"last_seen_at,omitempty"`
+	Projects         []string `json:"projects"`
+	Error            string   `json:"error,omitempty"`
+}
+
+func validateTmuxSocketName(socket string) bool {
+	socket = strings.TrimSpace(socket)
+	return socket == "" || tmuxSocketNamePattern.MatchString(socket)
+}
+
+// resolveTmuxSocket returns socket, or the process-wide SHELLMAN_TMUX_SOCKET when socket is empty.
+func resolveTmuxSocket(socket string) string {
+	if socket = strings.TrimSpace(socket); socket != "" {
+		return socket
+	}
+	return strings.TrimSpace(os.Getenv("SHELLMAN_TMUX_SOCKET"))
+}
+
+// tmuxSocketArgs returns the -L arguments addressing socket, or the process-wide SHELLMAN_TMUX_SOCKET when socket is
+// empty.
+func tmuxSocketArgs(socket string) []string {
+	socket = resolveTmuxSocket(socket)
+	if socket == "" {
+		return nil
+	}
+	return []string{"-L", socket}
+}
+
+// tmuxSocketFromServerID returns the socket part of a "<socket>:<pid>" tmux server id, with the default socket as "".
+func tmuxSocketFromServerID(serverID string) string {
+	if strings.TrimSpace(serverID) == "" {
+		return ""
+	}
+	socket := projectstate.TmuxServerRecord{ServerInstanceID: serverID}.Socket()
+	if socket == defaultTmuxSocketName {
+		return ""
+	}
+	return socket
+}
+
+// projectTmuxSocket returns the socket projectID chose, "" for the default server.
+func (s *Server) projectTmuxSocket(projectID string) string {
+	projectID = strings.TrimSpace(projectID)
+	if s.deps.ProjectsStore == nil || projectID == "" {
+		return ""
+	}
+	projects, err := s.deps.ProjectsStore.ListProjects()
+	if err != nil {
+		return ""
+	}
+	for _, p := range projects {
+		if p.ProjectID == projectID {
+			return strings.TrimSpace(p.TmuxSocket)
+		}
+	}
+	return ""
+}
+
+// paneServiceOnSocket returns the pane service talking to the tmux server on socket; "" is the default server.
+func (s *Server) paneServiceOnSocket(socket string) PaneService {
+	if socket = strings.TrimSpace(socket); socket != "" {
+		if router, ok := s.deps.PaneService.(tmuxSocketRouter); ok {
+			return router.ForSocket(socket)
+		}
+	}
+	return s.deps.PaneService
+}
+
+// paneServiceFor returns the pane service of the tmux server projectID lives on.
+func (s *Server) paneServiceFor(projectID string) PaneService {
+	return s.paneServiceOnSocket(s.projectTmuxSocket(projectID))
+}
+
+// paneServiceForBinding routes to the server a run's pane was last observed on and otherwise to the project's, so
+// a pane keeps being found after its project moves to another socket.
+func (s *Server) paneServiceForBinding(projectID, tmuxServerID string) PaneService {
+	if socket := tmuxSocketFromServerID(tmuxServerID); socket != "" {
+		return s.paneServiceOnSocket(socket)
+	}
+	return s.paneServiceFor(projectID)
+}
+
+// promptSenderFor returns the sender typing into panes of the tmux server projectID lives on.
+func (s *Server) promptSenderFor(projectID string) TaskPromptSender {
+	if socket := s.projectTmuxSocket(projectID); socket != "" {
+		if router, ok := s.deps.TaskPromptSender.(tmuxSocketRouter); ok {
+			return router.ForSocket(socket)
+		}
+	}
+	return s.deps.TaskPromptSender
+}
+
+// handleSystemTmuxServers lists the tmux servers shellman knows about: the default one, every socket a project
+// chose and every server recorded before, each probed for liveness. Servers found alive are recorded.
+func (s *Server) handleSystemTmuxServers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+		return
+	}
+	items := map[string]*tmuxServerItem{}
+	item := func(socket string) *tmuxServerItem {
+		if existing, ok := items[socket]; ok {
+			return existing
+		}
+		next := &tmuxServerItem{Socket: socket, Projects: []string{}}
+		items[socket] = next
+		return next
+	}
+
+	defaultSocket := ""
+	if router, ok := s.deps.PaneService.(interface{ SocketName() string }); ok {
+		defaultSocket = router.SocketName()
+	}
+	if defaultSocket == "" {
+		defaultSocket = defaultTmuxSocketName
+	}
+	item(defaultSocket).Default = true
+
+	if s.deps.ProjectsStore != nil {
+		projects, err := s.deps.ProjectsStore.ListProjects()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "PROJECTS_LIST_FAILED", err.Error())
+			return
+		}
+		for _, p := range projects {
+			socket := strings.TrimSpace(p.TmuxSocket)
+			if socket == "" {
+				socket = defaultSocket
+			}
+			entry := item(socket)
+			entry.Projects = append(entry.Projects, p.ProjectID)
+		}
+	}
+	store := projectstate.NewStore("")
+	recorded, err := store.ListTmuxServers()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "TMUX_SERVERS_LOAD_FAILED", err.Error())
+		return
+	}
+	for _, rec := range recorded {
+		entry := item(rec.Socket())
+		if entry.LastSeenAt == 0 {
+			entry.ServerInstanceID = rec.ServerInstanceID
+			entry.PID = rec.PID
+			entry.SocketPath = rec.SocketPath
+			entry.LastSeenAt = rec.LastSeenAt
+		}
+	}
+
+	for socket, entry := range items {
+		target := socket
+		if entry.Default {
+			target = ""
+		}
+		probe, ok := s.paneServiceOnSocket(target).(tmuxServerProbe)
+		if !ok {
+			continue
+		}
+		serverID, err := probe.ServerInstanceID()
+		if err != nil {
+			entry.Error = strings.TrimSpace(err.Error())
+			continue
+		}
+		entry.Alive = true
+		entry.ServerInstanceID = serverID
+		entry.PID = strings.TrimPrefix(serverID, projectstate.TmuxServerRecord{ServerInstanceID: serverID}.Socket()+":")
+		if path, err := probe.SocketPath(); err == nil {
+			entry.SocketPath = path
+		}
+		if err := store.ObserveTmuxServer(projectstate.TmuxServerRecord{ServerInstanceID: serverID, SocketPath: entry.SocketPath, PID: entry.PID}); err != nil {
+			slog.Warn("tmux server record failed", "server_instance_id", serverID, "err", err)
+			continue
+		}
+		entry.LastSeenAt = time.Now().UTC().Unix()
+	}
+
+	out := make([]tmuxServerItem, 0, len(items))
+	for _, entry := range items {
+		sort.Strings(entry.Projects)
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Default != out[j].Default {
+			return out[i].Default
+		}
+		return out[i].Socket < out[j].Socket
+	})
+	respondOK(w, map[string]any{"items": out})
+}
@@ -0,0 +1,177 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/tmux"
+)
+
+// socketExec answers tmux commands per -L socket; servers missing from pids are down.
+type socketExec struct {
+	mu    sync.Mutex
+	pids  map[string]string
+	calls []string
+}
+
+func (e *socketExec) Output(name string, args ...string) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls = append(e.calls, strings.Join(append([]string{name}, args...), " "))
+	socket := ""
+	if len(args) >= 2 && args[0] == "-L" {
+		socket = args[1]
+	}
+	pid, ok := e.pids[socket]
+	if !ok {
+		return nil, errors.New("no server running on " + socket)
+	}
+	switch args[len(args)-1] {
+	case "#{pid}":
+		return []byte(pid + "\n"), nil
+	case "#{socket_path}":
+		return []byte("/tmp/tmux-0/" + socket + "\n"), nil
+	}
+	return []byte("history of " + socket + "\n"), nil
+}
+
+func (e *socketExec) Run(name string, args ...string) error {
+	_, err := e.Output(name, args...)
+	return err
+}
+
+type socketProjectsStore struct {
+	memProjectsStore
+}
+
+func (m *socketProjectsStore) AddProject(project global.ActiveProject) error {
+	for i := range m.projects {
+		if m.projects[i].ProjectID == project.ProjectID {
+			project.TmuxSocket = m.projects[i].TmuxSocket
+			m.projects[i] = project
+			return nil
+		}
+	}
+	m.projects = append(m.projects, project)
+	return nil
+}
+
+func (m *socketProjectsStore) SetProjectTmuxSocket(projectID, socket string) error {
+	for i := range m.projects {
+		if m.projects[i].ProjectID == projectID {
+			m.projects[i].TmuxSocket = socket
+		}
+	}
+	return nil
+}
+
+func TestPaneServiceFor_RoutesByProjectSocketAndBinding(t *testing.T) {
+	exec := &socketExec{pids: map[string]string{"routed_main": "10", "routed_work": "20", "routed_old": "30"}}
+	projects := &memProjectsStore{projects: []global.ActiveProject{
+		{ProjectID: "p_main", RepoRoot: t.TempDir()},
+		{ProjectID: "p_work", RepoRoot: t.TempDir(), TmuxSocket: "routed_work"},
+	}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: tmux.NewAdapterWithSocket(exec, "routed_main")})
+
+	cases := []struct {
+		name string
+		svc  PaneService
+		want string
+	}{
+		{name: "default project", svc: srv.paneServiceFor("p_main"), want: "routed_main"},
+		{name: "project socket", svc: srv.paneServiceFor("p_work"), want: "routed_work"},
+		{name: "binding server", svc: srv.paneServiceForBinding("p_work", "routed_old:30"), want: "routed_old"},
+		{name: "default binding server", svc: srv.paneServiceForBinding("p_work", "default:30"), want: "routed_work"},
+	}
+	for _, tc := range cases {
+		history, err := tc.svc.CaptureHistory("%1", 10)
+		if err != nil || strings.TrimSpace(history) != "history of "+tc.want {
+			t.Fatalf("%s: expected pane on %s, got %q err=%v", tc.name, tc.want, history, err)
+		}
+	}
+}
+
+func TestSystemTmuxServers_ListsServersWithLiveness(t *testing.T) {
+	exec := &socketExec{pids: map[string]string{"listed_main": "101", "listed_work": "202"}}
+	projects := &socketProjectsStore{memProjectsStore{projects: []global.ActiveProject{
+		{ProjectID: "p_listed_a", RepoRoot: filepath.Clean(t.TempDir())},
+		{ProjectID: "p_listed_b", RepoRoot: filepath.Clean(t.TempDir())},
+	}}}
+	srv := NewServer(Deps{ConfigStore: &staticConfigStore{}, ProjectsStore: projects, PaneService: tmux.NewAdapterWithSocket(exec, "listed_main")})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	patch := func(projectID, body string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/api/v1/projects/active/"+projectID, bytes.NewBufferString(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("PATCH project failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := patch("p_listed_a", `{"tmux_socket":"bad socket"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid socket, got %d", code)
+	}
+	if code := patch("p_listed_a", `{"tmux_socket":"listed_work"}`); code != http.StatusOK {
+		t.Fatalf("expected 200 moving project socket, got %d", code)
+	}
+	if code := patch("p_listed_b", `{"tmux_socket":"listed_gone"}`); code != http.StatusOK {
+		t.Fatalf("expected 200 moving project socket, got %d", code)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/system/tmux-servers")
+	if err != nil {
+		t.Fatalf("GET tmux servers failed: %v", err)
+	}
+	var body struct {
+		Data struct {
+			Items []tmuxServerItem `json:"items"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode tmux servers failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	items := map[string]tmuxServerItem{}
+	for _, item := range body.Data.Items {
+		items[item.Socket] = item
+	}
+	if len(body.Data.Items) == 0 || body.Data.Items[0].Socket != "listed_main" || !body.Data.Items[0].Default {
+		t.Fatalf("expected the default server listed first, got %#v", body.Data.Items)
+	}
+	if main := items["listed_main"]; !main.Alive || main.PID != "101" || len(main.Projects) != 0 {
+		t.Fatalf("unexpected default server: %#v", main)
+	}
+	if work := items["listed_work"]; !work.Alive || work.ServerInstanceID != "listed_work:202" || work.SocketPath != "/tmp/tmux-0/listed_work" || len(work.Projects) != 1 || work.Projects[0] != "p_listed_a" {
+		t.Fatalf("unexpected work server: %#v", work)
+	}
+	if gone := items["listed_gone"]; gone.Alive || gone.Error == "" || len(gone.Projects) != 1 {
+		t.Fatalf("expected unreachable server reported down, got %#v", gone)
+	}
+
+	// Once seen, a server stays listed with its last sighting after it goes away.
+	delete(exec.pids, "listed_work")
+	resp, err = http.Get(ts.URL + "/api/v1/system/tmux-servers")
+	if err != nil {
+		t.Fatalf("GET tmux servers failed: %v", err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode tmux servers failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	for _, item := range body.Data.Items {
+		if item.Socket == "listed_work" && (item.Alive || item.LastSeenAt == 0 || item.PID != "202") {
+			t.Fatalf("expected last sighting of stopped server, got %#v", item)
+		}
+	}
+}
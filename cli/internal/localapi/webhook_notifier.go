@@ -0,0 +1,191 @@
+package localapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+const (
+	webhookSignatureHeader = "X-Shellman-Signature"
+	webhookEventHeader     = "X-Shellman-Event"
+	webhookDeliveryHeader  = "X-Shellman-Delivery"
+)
+
+type webhookPayload struct {
+	Event      string `json:"event"`
+	DeliveryID string `json:"delivery_id"`
+	ProjectID  string `json:"project_id"`
+	TaskID     string `json:"task_id"`
+	RunID      string `json:"run_id,omitempty"`
+	Status     string `json:"status,omitempty"`
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	Summary    string `json:"summary,omitempty"`
+	Flag       string `json:"flag,omitempty"`
+	FlagDesc   string `json:"flag_desc,omitempty"`
+	Severity   string `json:"severity,omitempty"`
+	Escalation int64  `json:"escalation,omitempty"`
+	Source     string `json:"source,omitempty"`
+	OccurredAt int64  `json:"occurred_at"`
+}
+
+// webhookDelivery is the outbox payload of one delivery. The endpoint is looked up by name when the delivery runs,
+// so its secret never lands in the outbox.
+type webhookDelivery struct {
+	Webhook string         `json:"webhook"`
+	Payload webhookPayload `json:"payload"`
+}
+
+// notifyWebhooks queues a delivery of payload in the action outbox for every configured endpoint subscribed to its
+// event, so deliveries cut short by a shutdown are retried on the next start.
+func (s *Server) notifyWebhooks(payload webhookPayload) {
+	if s == nil || s.deps.ConfigStore == nil {
+		return
+	}
+	cfg, err := s.deps.ConfigStore.LoadOrInit()
+	if err != nil || len(cfg.Webhooks) == 0 {
+		return
+	}
+	if payload.OccurredAt == 0 {
+		payload.OccurredAt = time.Now().UTC().Unix()
+	}
+	queued := false
+	for _, endpoint := range cfg.Webhooks {
+		if unknown := endpoint.UnknownEvents(); len(unknown) > 0 {
+			slog.Warn("webhook subscribes to unknown events", "webhook", endpoint.Name, "events", unknown)
+		}
+		if !endpoint.Accepts(payload.Event) {
+			continue
+		}
+		item := payload
+		item.DeliveryID = uuid.NewString()
+		if err := runActionStore().EnqueueRunAction(item.RunID, runActionWebhookDelivery, map[string]any{
+			"webhook": endpoint.Name,
+			"payload": item,
+		}); err != nil {
+			slog.Error("webhook delivery enqueue failed", "webhook", endpoint.Name, "event", item.Event, "err", err)
+			continue
+		}
+		queued = true
+	}
+	if queued {
+		s.kickRunActionDispatcher()
+	}
+}
+
+// handleWebhookDeliveryAction makes one attempt at a queued delivery. Transport errors, 408, 429 and 5xx responses
+// are retried by the outbox until the endpoint's max_attempts, capped by the outbox's own attempt limit; the outcome
+// is recorded in the task completion audit log.
+func (s *Server) handleWebhookDeliveryAction(ctx context.Context, action projectstate.RunActionRecord) error {
+	var delivery webhookDelivery
+	if err := json.Unmarshal([]byte(action.PayloadJSON), &delivery); err != nil {
+		slog.Error("webhook delivery payload invalid", "action_id", action.ID, "err", err)
+		return nil
+	}
+	cfg, err := s.deps.ConfigStore.LoadOrInit()
+	if err != nil {
+		return err
+	}
+	endpoint, ok := findWebhook(cfg.Webhooks, delivery.Webhook)
+	if !ok {
+		// The endpoint was removed since the delivery was queued.
+		return nil
+	}
+	item := delivery.Payload
+	attempt := action.RetryCount + 1
+	retry, err := deliverWebhook(ctx, http.DefaultClient, endpoint, item)
+	fields := map[string]any{
+		"webhook":     endpoint.Name,
+		"event":       item.Event,
+		"delivery_id": item.DeliveryID,
+		"run_id":      item.RunID,
+		"attempts":    attempt,
+	}
+	if err == nil {
+		s.writeTaskCompletionAuditLog(item.ProjectID, item.TaskID, "webhook.done", fields)
+		return nil
+	}
+	// A delivery cut short by a shutdown stays queued for the next start.
+	if ctx.Err() != nil || (retry && attempt < endpoint.MaxAttempts && attempt < runActionMaxAttempts) {
+		return err
+	}
+	fields["error"] = err.Error()
+	s.writeTaskCompletionAuditLog(item.ProjectID, item.TaskID, "webhook.error", fields)
+	return nil
+}
+
+func findWebhook(webhooks []global.WebhookConfig, name string) (global.WebhookConfig, bool) {
+	for _, endpoint := range webhooks {
+		if endpoint.Name == name {
+			return endpoint, true
+		}
+	}
+	return global.WebhookConfig{}, false
+}
+
+// deliverWebhook posts payload to endpoint once. It reports whether a failure is worth retrying: transport errors,
+// 408, 429 and 5xx responses are.
+func deliverWebhook(ctx context.Context, client *http.Client, endpoint global.WebhookConfig, payload webhookPayload) (bool, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+	timeout := time.Duration(endpoint.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return postWebhook(ctx, client, endpoint, payload, body, timeout)
+}
+
+func postWebhook(ctx context.Context, client *http.Client, endpoint global.WebhookConfig, payload webhookPayload, body []byte, timeout time.Duration) (bool, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "shellman-webhook")
+	req.Header.Set(webhookEventHeader, payload.Event)
+	req.Header.Set(webhookDeliveryHeader, payload.DeliveryID)
+	if strings.TrimSpace(endpoint.Secret) != "" {
+		req.Header.Set(webhookSignatureHeader, signWebhookBody(endpoint.Secret, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook %s responded with status %d", endpoint.Name, resp.StatusCode)
+}
+
+// signWebhookBody returns the X-Shellman-Signature value: "sha256=" followed by the hex HMAC-SHA256 of body.
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(strings.TrimSpace(secret)))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,185 @@
+package localapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"shellman/cli/internal/global"
+	"shellman/cli/internal/projectstate"
+)
+
+func TestWebhookDelivery_SignsPayloadAndRetriesServerErrorsThroughOutbox(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	var gotBody []byte
+	var gotSignature, gotEvent string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gotBody = body
+		gotSignature = r.Header.Get(webhookSignatureHeader)
+		gotEvent = r.Header.Get(webhookEventHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	cfgStore := &mutableConfigStore{cfg: global.GlobalConfig{LocalPort: 4621, Webhooks: []global.WebhookConfig{
+		{Name: "ci", URL: receiver.URL, Secret: "s3cret", TimeoutSeconds: 5, MaxAttempts: 3},
+	}}}
+	srv := NewServer(Deps{ConfigStore: cfgStore, ProjectsStore: &memProjectsStore{}})
+	runID := fmt.Sprintf("r_webhook_retry_%d", time.Now().UnixNano())
+
+	clock := time.Now()
+	origNow := runActionNow
+	runActionNow = func() time.Time { return clock }
+	defer func() { runActionNow = origNow }()
+
+	srv.notifyWebhooks(webhookPayload{
+		Event:     global.WebhookEventTaskCompleted,
+		ProjectID: "p1",
+		TaskID:    "t1",
+		RunID:     runID,
+		Summary:   "done",
+	})
+	for i := 0; i < 3; i++ {
+		drainRunActions(t, srv)
+		clock = clock.Add(time.Hour)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+	actions, err := runActionStore().ListRunActions(runID)
+	if err != nil {
+		t.Fatalf("ListRunActions failed: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Status != projectstate.OutboxStatusDone || actions[0].RetryCount != 2 {
+		t.Fatalf("expected the delivery done after two retries, got %#v", actions)
+	}
+	if strings.Contains(actions[0].PayloadJSON, "s3cret") {
+		t.Fatalf("expected the secret kept out of the outbox, got %s", actions[0].PayloadJSON)
+	}
+	if gotEvent != global.WebhookEventTaskCompleted {
+		t.Fatalf("expected event header, got %q", gotEvent)
+	}
+	if want := signWebhookBody("s3cret", gotBody); gotSignature != want {
+		t.Fatalf("expected signature %q, got %q", want, gotSignature)
+	}
+	var payload webhookPayload
+	if err := json.Unmarshal(gotBody, &payload); err != nil {
+		t.Fatalf("decode payload failed: %v", err)
+	}
+	if payload.TaskID != "t1" || payload.RunID != runID || payload.ProjectID != "p1" || payload.Summary != "done" || payload.DeliveryID == "" {
+		t.Fatalf("unexpected payload: %#v", payload)
+	}
+}
+
+func TestWebhookDelivery_DoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer receiver.Close()
+
+	cfgStore := &mutableConfigStore{cfg: global.GlobalConfig{LocalPort: 4621, Webhooks: []global.WebhookConfig{
+		{Name: "ci", URL: receiver.URL, TimeoutSeconds: 5, MaxAttempts: 5},
+	}}}
+	srv := NewServer(Deps{ConfigStore: cfgStore, ProjectsStore: &memProjectsStore{}})
+	runID := fmt.Sprintf("r_webhook_400_%d", time.Now().UnixNano())
+
+	clock := time.Now()
+	origNow := runActionNow
+	runActionNow = func() time.Time { return clock }
+	defer func() { runActionNow = origNow }()
+
+	srv.notifyWebhooks(webhookPayload{Event: global.WebhookEventFlagRaised, RunID: runID})
+	for i := 0; i < 3; i++ {
+		drainRunActions(t, srv)
+		clock = clock.Add(time.Hour)
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single attempt, got %d", calls)
+	}
+}
+
+func TestWebhookNotifier_TaskCompletedHonorsEventFilter(t *testing.T) {
+	received := make(chan webhookPayload, 4)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		payload.Source = r.URL.Path + "|" + payload.Source
+		received <- payload
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	repo := t.TempDir()
+	projects := &memProjectsStore{projects: []global.ActiveProject{{ProjectID: "p1", RepoRoot: repo}}}
+	cfgStore := &mutableConfigStore{cfg: global.GlobalConfig{
+		LocalPort: 4621,
+		Webhooks: []global.WebhookConfig{
+			{Name: "done", URL: receiver.URL + "/done", Events: []string{global.WebhookEventTaskCompleted}, TimeoutSeconds: 5, MaxAttempts: 1},
+			{Name: "flags", URL: receiver.URL + "/flags", Events: []string{global.WebhookEventFlagRaised}, TimeoutSeconds: 5, MaxAttempts: 1},
+		},
+	}}
+	srv := NewServer(Deps{ConfigStore: cfgStore, ProjectsStore: projects})
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	createResp, err := http.Post(ts.URL+"/api/v1/tasks", "application/json", bytes.NewBufferString(`{"project_id":"p1","title":"root"}`))
+	if err != nil {
+		t.Fatalf("create task failed: %v", err)
+	}
+	var created struct {
+		Data struct {
+			TaskID string `json:"task_id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(createResp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode create task failed: %v", err)
+	}
+	_ = createResp.Body.Close()
+
+	req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/api/v1/tasks/"+created.Data.TaskID+"/status", bytes.NewBufferString(`{"status":"completed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("patch status failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from status patch, got %d", resp.StatusCode)
+	}
+	drainRunActions(t, srv)
+
+	select {
+	case got := <-received:
+		if got.Event != global.WebhookEventTaskCompleted || got.TaskID != created.Data.TaskID || got.ProjectID != "p1" {
+			t.Fatalf("unexpected webhook payload: %#v", got)
+		}
+		if got.Source != "/done|status.patch" {
+			t.Fatalf("expected delivery to the task.completed endpoint, got %q", got.Source)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for webhook delivery")
+	}
+	select {
+	case got := <-received:
+		t.Fatalf("expected flag endpoint to stay silent, got %#v", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
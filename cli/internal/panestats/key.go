@@ -0,0 +1,20 @@
+package panestats
+
+import "strings"
+
+const paneKeySep = "\x1f"
+
+// PaneKey is the key a Sampler keeps a pane under. Pane ids like %3 repeat across tmux servers, so a pane is
+// scoped by the repo root of the project it belongs to and the socket of the tmux server it lives on.
+func PaneKey(repoRoot, socket, paneID string) string {
+	return strings.TrimSpace(repoRoot) + paneKeySep + strings.TrimSpace(socket) + paneKeySep + strings.TrimSpace(paneID)
+}
+
+// SplitPaneKey returns the parts of a key made by PaneKey; any other key is returned as the pane id.
+func SplitPaneKey(key string) (repoRoot, socket, paneID string) {
+	parts := strings.SplitN(key, paneKeySep, 3)
+	if len(parts) != 3 {
+		return "", "", key
+	}
+	return parts[0], parts[1], parts[2]
+}
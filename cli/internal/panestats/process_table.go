@@ -0,0 +1,53 @@
+package panestats
+
+import (
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// systemProcessTable reads processes from the OS, /proc on Linux.
+type systemProcessTable struct{}
+
+func (systemProcessTable) Parents() map[int]int {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	out := make(map[int]int, len(procs))
+	for _, proc := range procs {
+		ppid, err := proc.Ppid()
+		if err != nil || ppid <= 0 {
+			continue
+		}
+		out[int(proc.Pid)] = int(ppid)
+	}
+	return out
+}
+
+func (systemProcessTable) Read(pid int) (processReading, bool) {
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return processReading{}, false
+	}
+	times, err := proc.Times()
+	if err != nil {
+		return processReading{}, false
+	}
+	reading := processReading{CPUSeconds: times.User + times.System}
+	if ppid, err := proc.Ppid(); err == nil {
+		reading.PPID = int(ppid)
+	}
+	reading.Name, _ = proc.Name()
+	reading.Command, _ = proc.Cmdline()
+	if created, err := proc.CreateTime(); err == nil && created > 0 {
+		reading.CreatedAt = time.UnixMilli(created)
+	}
+	if mem, err := proc.MemoryInfo(); err == nil && mem != nil {
+		reading.RSSBytes = mem.RSS
+	}
+	if threads, err := proc.NumThreads(); err == nil {
+		reading.Threads = int(threads)
+	}
+	return reading, true
+}
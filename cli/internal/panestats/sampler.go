@@ -0,0 +1,279 @@
+package panestats
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRecentSamples = 120
+	defaultBucketSize    = time.Minute
+)
+
+// Process is the resource use of one process running in a pane.
+type Process struct {
+	PID        int     `json:"pid"`
+	PPID       int     `json:"ppid"`
+	Name       string  `json:"name"`
+	Command    string  `json:"command,omitempty"`
+	CPUPercent float64 `json:"cpu_percent"`
+	RSSBytes   uint64  `json:"rss_bytes"`
+	Threads    int     `json:"threads"`
+}
+
+// Sample is the resource use of everything running in one pane at one moment: the totals over the pane's process
+// tree and the processes below the pane's own shell.
+type Sample struct {
+	PaneID     string    `json:"pane_id"`
+	PID        int       `json:"pid"`
+	CPUPercent float64   `json:"cpu_percent"`
+	RSSBytes   uint64    `json:"rss_bytes"`
+	Threads    int       `json:"threads"`
+	Processes  int       `json:"processes"`
+	Children   []Process `json:"children"`
+	SampledAt  int64     `json:"sampled_at"`
+}
+
+// Bucket summarizes the samples of one pane taken within one bucket period, the form history is kept in.
+type Bucket struct {
+	PaneID        string  `json:"pane_id"`
+	Start         int64   `json:"start"`
+	Samples       int     `json:"samples"`
+	CPUPercentAvg float64 `json:"cpu_percent_avg"`
+	CPUPercentMax float64 `json:"cpu_percent_max"`
+	RSSBytesMax   uint64  `json:"rss_bytes_max"`
+	ThreadsMax    int     `json:"threads_max"`
+	ProcessesMax  int     `json:"processes_max"`
+}
+
+// Options configure a Sampler.
+type Options struct {
+	// Recent is how many samples are kept in memory per pane; 120 when zero.
+	Recent int
+	// BucketSize is the period samples are downsampled to; one minute when zero.
+	BucketSize time.Duration
+}
+
+// Sampler samples the process trees of panes, keeps their recent samples and downsamples them into buckets.
+type Sampler struct {
+	opts  Options
+	table processTable
+
+	mu     sync.Mutex
+	panes  map[string]*paneSeries
+	closed []Bucket
+}
+
+type paneSeries struct {
+	recent []Sample
+	marks  map[int]cpuMark
+	bucket Bucket
+	cpuSum float64
+}
+
+// cpuMark is the cpu time a process had used when it was last sampled.
+type cpuMark struct {
+	seconds float64
+	at      time.Time
+}
+
+// processReading is what is read about one process.
+type processReading struct {
+	PPID       int
+	Name       string
+	Command    string
+	CPUSeconds float64
+	CreatedAt  time.Time
+	RSSBytes   uint64
+	Threads    int
+}
+
+// processTable reads the live processes of the host.
+type processTable interface {
+	Parents() map[int]int
+	Read(pid int) (processReading, bool)
+}
+
+func NewSampler(opts Options) *Sampler {
+	if opts.Recent <= 0 {
+		opts.Recent = defaultRecentSamples
+	}
+	if opts.BucketSize <= 0 {
+		opts.BucketSize = defaultBucketSize
+	}
+	return &Sampler{opts: opts, table: systemProcessTable{}, panes: map[string]*paneSeries{}}
+}
+
+// Sample samples every pane in panes, pane id to the pid of the pane's shell, with one pass over the process table.
+func (s *Sampler) Sample(now time.Time, panes map[string]int) []Sample {
+	if s == nil || len(panes) == 0 {
+		return nil
+	}
+	parents := s.table.Parents()
+	children := map[int][]int{}
+	for pid, ppid := range parents {
+		children[ppid] = append(children[ppid], pid)
+	}
+	paneIDs := make([]string, 0, len(panes))
+	for paneID := range panes {
+		paneIDs = append(paneIDs, paneID)
+	}
+	sort.Strings(paneIDs)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Sample, 0, len(panes))
+	for _, paneID := range paneIDs {
+		panePID := panes[paneID]
+		if strings.TrimSpace(paneID) == "" || panePID <= 0 {
+			continue
+		}
+		series := s.panes[paneID]
+		if series == nil {
+			series = &paneSeries{marks: map[int]cpuMark{}}
+			s.panes[paneID] = series
+		}
+		sample := Sample{PaneID: paneID, PID: panePID, Children: []Process{}, SampledAt: now.UTC().Unix()}
+		marks := map[int]cpuMark{}
+		for _, pid := range processTree(panePID, children) {
+			reading, ok := s.table.Read(pid)
+			if !ok {
+				continue
+			}
+			proc := Process{
+				PID:        pid,
+				PPID:       reading.PPID,
+				Name:       reading.Name,
+				Command:    reading.Command,
+				CPUPercent: cpuPercent(series.marks[pid], reading, now),
+				RSSBytes:   reading.RSSBytes,
+				Threads:    reading.Threads,
+			}
+			marks[pid] = cpuMark{seconds: reading.CPUSeconds, at: now}
+			sample.CPUPercent += proc.CPUPercent
+			sample.RSSBytes += proc.RSSBytes
+			sample.Threads += proc.Threads
+			sample.Processes++
+			if pid != panePID {
+				sample.Children = append(sample.Children, proc)
+			}
+		}
+		sample.CPUPercent = roundPercent(sample.CPUPercent)
+		series.marks = marks
+		series.recent = append(series.recent, sample)
+		if over := len(series.recent) - s.opts.Recent; over > 0 {
+			series.recent = append([]Sample(nil), series.recent[over:]...)
+		}
+		s.addToBucket(series, sample, now)
+		out = append(out, sample)
+	}
+	return out
+}
+
+func (s *Sampler) addToBucket(series *paneSeries, sample Sample, now time.Time) {
+	start := now.UTC().Truncate(s.opts.BucketSize).Unix()
+	if series.bucket.Samples > 0 && series.bucket.Start != start {
+		s.closeBucket(series)
+	}
+	b := &series.bucket
+	if b.Samples == 0 {
+		*b = Bucket{PaneID: sample.PaneID, Start: start}
+		series.cpuSum = 0
+	}
+	b.Samples++
+	series.cpuSum += sample.CPUPercent
+	b.CPUPercentMax = math.Max(b.CPUPercentMax, sample.CPUPercent)
+	b.RSSBytesMax = max(b.RSSBytesMax, sample.RSSBytes)
+	b.ThreadsMax = max(b.ThreadsMax, sample.Threads)
+	b.ProcessesMax = max(b.ProcessesMax, sample.Processes)
+}
+
+func (s *Sampler) closeBucket(series *paneSeries) {
+	if series.bucket.Samples == 0 {
+		return
+	}
+	b := series.bucket
+	b.CPUPercentAvg = roundPercent(series.cpuSum / float64(b.Samples))
+	s.closed = append(s.closed, b)
+	series.bucket = Bucket{}
+	series.cpuSum = 0
+}
+
+// Recent returns the samples kept for paneID, oldest first.
+func (s *Sampler) Recent(paneID string) []Sample {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series := s.panes[paneID]
+	if series == nil {
+		return nil
+	}
+	return append([]Sample(nil), series.recent...)
+}
+
+// Forget drops what is kept for a pane that is gone; its partly filled bucket is still handed out by TakeBuckets.
+func (s *Sampler) Forget(paneID string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series := s.panes[paneID]
+	if series == nil {
+		return
+	}
+	s.closeBucket(series)
+	delete(s.panes, paneID)
+}
+
+// TakeBuckets returns the buckets completed since the last call, for the caller to persist.
+func (s *Sampler) TakeBuckets() []Bucket {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.closed
+	s.closed = nil
+	return out
+}
+
+// processTree returns root followed by its descendants in children, parents first.
+func processTree(root int, children map[int][]int) []int {
+	tree := []int{root}
+	seen := map[int]bool{root: true}
+	for i := 0; i < len(tree); i++ {
+		for _, child := range children[tree[i]] {
+			if !seen[child] {
+				seen[child] = true
+				tree = append(tree, child)
+			}
+		}
+	}
+	return tree
+}
+
+// cpuPercent is the share of one cpu a process used since it was last sampled, or over its lifetime the first
+// time it is seen.
+func cpuPercent(prev cpuMark, reading processReading, now time.Time) float64 {
+	used, elapsed := reading.CPUSeconds, 0.0
+	if !prev.at.IsZero() && reading.CPUSeconds >= prev.seconds {
+		used -= prev.seconds
+		elapsed = now.Sub(prev.at).Seconds()
+	} else if !reading.CreatedAt.IsZero() {
+		elapsed = now.Sub(reading.CreatedAt).Seconds()
+	}
+	if elapsed <= 0 {
+		return 0
+	}
+	return roundPercent(used / elapsed * 100)
+}
+
+func roundPercent(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,97 @@
+package panestats
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+type fakeProcessTable struct {
+	parents  map[int]int
+	readings map[int]processReading
+}
+
+func (f *fakeProcessTable) Parents() map[int]int { return f.parents }
+
+func (f *fakeProcessTable) Read(pid int) (processReading, bool) {
+	reading, ok := f.readings[pid]
+	return reading, ok
+}
+
+func TestSampler_SamplesPaneTreeWithCPUDeltas(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	table := &fakeProcessTable{
+		parents: map[int]int{100: 1, 200: 100, 300: 200, 900: 1},
+		readings: map[int]processReading{
+			100: {PPID: 1, Name: "bash", CPUSeconds: 1, CreatedAt: start.Add(-10 * time.Second), RSSBytes: 4 << 20, Threads: 1},
+			200: {PPID: 100, Name: "node", Command: "node agent.js", CPUSeconds: 5, CreatedAt: start.Add(-10 * time.Second), RSSBytes: 100 << 20, Threads: 8},
+			300: {PPID: 200, Name: "rg", CPUSeconds: 0, CreatedAt: start, RSSBytes: 2 << 20, Threads: 2},
+			900: {PPID: 1, Name: "unrelated", CPUSeconds: 50, RSSBytes: 1 << 30, Threads: 40},
+		},
+	}
+	s := NewSampler(Options{Recent: 2})
+	s.table = table
+
+	first := s.Sample(start, map[string]int{"%1": 100})
+	if len(first) != 1 {
+		t.Fatalf("expected one sample, got %#v", first)
+	}
+	// First sight: lifetime averages, 1s and 5s of cpu over 10s.
+	if got := first[0]; got.CPUPercent != 60 || got.RSSBytes != 106<<20 || got.Threads != 11 || got.Processes != 3 || len(got.Children) != 2 {
+		t.Fatalf("unexpected first sample: %#v", got)
+	}
+	if first[0].Children[0].PID != 200 || first[0].Children[0].Command != "node agent.js" {
+		t.Fatalf("expected children parents first, got %#v", first[0].Children)
+	}
+
+	table.readings[200] = processReading{PPID: 100, Name: "node", CPUSeconds: 7, RSSBytes: 120 << 20, Threads: 8}
+	delete(table.readings, 300)
+	second := s.Sample(start.Add(10*time.Second), map[string]int{"%1": 100})
+	if got := second[0]; got.CPUPercent != 20 || got.Processes != 2 || got.RSSBytes != 124<<20 {
+		t.Fatalf("unexpected second sample: %#v", got)
+	}
+
+	s.Sample(start.Add(70*time.Second), map[string]int{"%1": 100})
+	if recent := s.Recent("%1"); len(recent) != 2 || recent[0].SampledAt != second[0].SampledAt {
+		t.Fatalf("expected the two latest samples kept, got %#v", recent)
+	}
+	buckets := s.TakeBuckets()
+	if len(buckets) != 1 {
+		t.Fatalf("expected the first minute closed, got %#v", buckets)
+	}
+	if b := buckets[0]; b.Start != start.Unix() || b.Samples != 2 || b.CPUPercentAvg != 40 || b.CPUPercentMax != 60 || b.RSSBytesMax != 124<<20 || b.ThreadsMax != 11 || b.ProcessesMax != 3 {
+		t.Fatalf("unexpected bucket: %#v", b)
+	}
+	if again := s.TakeBuckets(); len(again) != 0 {
+		t.Fatalf("expected buckets handed out once, got %#v", again)
+	}
+
+	s.Forget("%1")
+	if recent := s.Recent("%1"); recent != nil {
+		t.Fatalf("expected forgotten pane dropped, got %#v", recent)
+	}
+	if buckets := s.TakeBuckets(); len(buckets) != 1 || buckets[0].Start != start.Add(time.Minute).Unix() {
+		t.Fatalf("expected open bucket closed on forget, got %#v", buckets)
+	}
+}
+
+func TestSampler_SamplesOwnProcess(t *testing.T) {
+	s := NewSampler(Options{})
+	samples := s.Sample(time.Now(), map[string]int{"%self": os.Getpid()})
+	if len(samples) != 1 || samples[0].Processes == 0 || samples[0].RSSBytes == 0 || samples[0].Threads == 0 {
+		t.Fatalf("expected own process sampled, got %#v", samples)
+	}
+}
+
+func TestPaneKey_SplitsBackIntoScope(t *testing.T) {
+	if PaneKey("/repo/a", "work", "%3") == PaneKey("/repo/b", "work", "%3") || PaneKey("/repo/a", "", "%3") == PaneKey("/repo/a", "work", "%3") {
+		t.Fatal("expected the same pane id in another project or on another server to get another key")
+	}
+	repoRoot, socket, paneID := SplitPaneKey(PaneKey("/repo/a", "work", "%3"))
+	if repoRoot != "/repo/a" || socket != "work" || paneID != "%3" {
+		t.Fatalf("unexpected split: %q %q %q", repoRoot, socket, paneID)
+	}
+	if _, _, paneID := SplitPaneKey("%3"); paneID != "%3" {
+		t.Fatalf("expected a plain key returned as the pane id, got %q", paneID)
+	}
+}
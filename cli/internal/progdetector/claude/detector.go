@@ -14,6 +14,8 @@ const (
 	enterTimeoutMs  = 15000
 	submitTimeoutMs = 1000
 	submitInput     = "\n"
+	interruptInput  = "\x1b"
+	resumeCommand   = "claude --continue"
 )
 
 type Detector struct{}
@@ -42,6 +44,16 @@ func (d Detector) HasExitedMode(_ context.Context, state progdetector.RuntimeSta
 	return !d.MatchCurrentCommand(state.CurrentCommand), nil
 }
 
+// InterruptInput is Esc, which stops the current turn without quitting.
+func (Detector) InterruptInput() string {
+	return interruptInput
+}
+
+// ResumeCommand continues the most recent session in the pane's directory.
+func (Detector) ResumeCommand() string {
+	return resumeCommand
+}
+
 func (Detector) BuildInputPromptSteps(prompt string) ([]progdetector.PromptStep, error) {
 	prompt = strings.TrimSpace(prompt)
 	if prompt == "" {
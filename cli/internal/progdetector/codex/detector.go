@@ -17,6 +17,8 @@ const (
 	submitTimeoutMs   = 1000
 	submitDelay       = 50 * time.Millisecond
 	submitInputReturn = "\r"
+	interruptInput    = "\x1b"
+	resumeCommand     = "codex resume --last"
 )
 
 type Detector struct{}
@@ -92,6 +94,16 @@ func normalizeProgramToken(raw string) string {
 	return strings.ToLower(base)
 }
 
+// InterruptInput is Esc, which stops the current turn without quitting.
+func (Detector) InterruptInput() string {
+	return interruptInput
+}
+
+// ResumeCommand continues the most recent session in the pane's directory.
+func (Detector) ResumeCommand() string {
+	return resumeCommand
+}
+
 func (Detector) BuildInputPromptSteps(prompt string) ([]progdetector.PromptStep, error) {
 	prompt = strings.TrimSpace(prompt)
 	if prompt == "" {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"shellman/cli/internal/progdetector"
+	"shellman/cli/internal/programadapter"
 )
 
 func TestDetectorBuildInputPromptSteps(t *testing.T) {
@@ -101,3 +102,15 @@ func TestDetectorIsAvailable_RespectsCanceledContext(t *testing.T) {
 		t.Fatalf("expected ok=false when context already canceled")
 	}
 }
+
+func TestDetectorInterruptInput_UsesEscape(t *testing.T) {
+	if got := programadapter.InterruptInput(New()); got != "\x1b" {
+		t.Fatalf("expected Esc interrupt, got %q", got)
+	}
+}
+
+func TestDetectorResumeCommand_ResumesLastSession(t *testing.T) {
+	if got := programadapter.ResumeCommand(New()); got != "codex resume --last" {
+		t.Fatalf("unexpected resume command: %q", got)
+	}
+}
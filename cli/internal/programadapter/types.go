@@ -30,3 +30,36 @@ type ProgramAdapter interface {
 	HasExitedMode(ctx context.Context, state RuntimeState) (bool, error)
 	BuildInputPromptSteps(prompt string) ([]PromptStep, error)
 }
+
+// DefaultInterruptInput is Ctrl-C, which stops a foreground shell command.
+const DefaultInterruptInput = "\x03"
+
+// Interrupter is implemented by adapters whose program interrupts its current turn on something other than Ctrl-C
+// (which would quit it instead).
+type Interrupter interface {
+	InterruptInput() string
+}
+
+// InterruptInput returns the raw input that interrupts adapter's program, DefaultInterruptInput when it has no
+// sequence of its own.
+func InterruptInput(adapter ProgramAdapter) string {
+	if interrupter, ok := adapter.(Interrupter); ok {
+		if input := interrupter.InterruptInput(); input != "" {
+			return input
+		}
+	}
+	return DefaultInterruptInput
+}
+
+// Resumer is implemented by adapters whose program can pick its previous session back up from a fresh shell.
+type Resumer interface {
+	ResumeCommand() string
+}
+
+// ResumeCommand returns the shell command that resumes adapter's last session, or "" when it has none.
+func ResumeCommand(adapter ProgramAdapter) string {
+	if resumer, ok := adapter.(Resumer); ok {
+		return resumer.ResumeCommand()
+	}
+	return ""
+}
@@ -0,0 +1,255 @@
+package projectstate
+
+import (
+	"database/sql"
+	"errors"
+	dbmodel "shellman/cli/internal/db"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const runActionColumns = `id, run_id, action_type, payload_json, status, retry_count, next_retry_at, last_error, created_at, updated_at`
+
+// ClaimDueRunActions moves up to limit pending outbox rows whose retry time has passed into running state and returns them.
+func (s *Store) ClaimDueRunActions(now int64, limit int) ([]RunActionRecord, error) {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	if limit <= 0 {
+		limit = 16
+	}
+	if now <= 0 {
+		now = time.Now().UTC().Unix()
+	}
+	out := make([]RunActionRecord, 0, limit)
+	err = gdb.Transaction(func(tx *gorm.DB) error {
+		var rows []dbmodel.ActionOutbox
+		if err := tx.Where("status = ? AND next_retry_at <= ?", OutboxStatusPending, now).
+			Order("next_retry_at ASC, id ASC").
+			Limit(limit).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			res := tx.Model(&dbmodel.ActionOutbox{}).
+				Where("id = ? AND status = ?", row.ID, OutboxStatusPending).
+				Updates(map[string]any{
+					"status":     OutboxStatusRunning,
+					"updated_at": now,
+				})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				continue
+			}
+			row.Status = OutboxStatusRunning
+			row.UpdatedAt = now
+			out = append(out, runActionRecordFromModel(row))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RequeueRunningRunActions returns rows left in running state (e.g. after a crash) to pending.
+func (s *Store) RequeueRunningRunActions() (int64, error) {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = release() }()
+
+	tx := gdb.Model(&dbmodel.ActionOutbox{}).
+		Where("status = ?", OutboxStatusRunning).
+		Updates(map[string]any{
+			"status":     OutboxStatusPending,
+			"updated_at": time.Now().UTC().Unix(),
+		})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+	return tx.RowsAffected, nil
+}
+
+func (s *Store) MarkRunActionDone(id int64) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	return gdb.Model(&dbmodel.ActionOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":     OutboxStatusDone,
+			"last_error": "",
+			"updated_at": time.Now().UTC().Unix(),
+		}).Error
+}
+
+// DeferRunAction returns a claimed row to pending until nextRetryAt without counting a failed attempt. A row that was
+// settled meanwhile is left alone.
+func (s *Store) DeferRunAction(id int64, nextRetryAt int64) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	return gdb.Model(&dbmodel.ActionOutbox{}).
+		Where("id = ? AND status = ?", id, OutboxStatusRunning).
+		Updates(map[string]any{
+			"status":        OutboxStatusPending,
+			"next_retry_at": nextRetryAt,
+			"updated_at":    time.Now().UTC().Unix(),
+		}).Error
+}
+
+// MarkRunActionFailed records a failed attempt. When dead is true the row is dead-lettered, otherwise it is rescheduled at nextRetryAt.
+func (s *Store) MarkRunActionFailed(id int64, errText string, nextRetryAt int64, dead bool) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	status := OutboxStatusPending
+	if dead {
+		status = OutboxStatusDead
+		nextRetryAt = 0
+	}
+	return gdb.Model(&dbmodel.ActionOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":        status,
+			"retry_count":   gorm.Expr("retry_count + 1"),
+			"next_retry_at": nextRetryAt,
+			"last_error":    strings.TrimSpace(errText),
+			"updated_at":    time.Now().UTC().Unix(),
+		}).Error
+}
+
+func (s *Store) GetRunAction(id int64) (RunActionRecord, bool, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return RunActionRecord{}, false, err
+	}
+	defer func() { _ = release() }()
+
+	row := db.QueryRow(`SELECT `+runActionColumns+` FROM action_outbox WHERE id = ?`, id)
+	item, err := scanRunAction(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RunActionRecord{}, false, nil
+	}
+	if err != nil {
+		return RunActionRecord{}, false, err
+	}
+	return item, true, nil
+}
+
+func (s *Store) ListRunActions(runID string) ([]RunActionRecord, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	runID = strings.TrimSpace(runID)
+	if runID == "" {
+		return []RunActionRecord{}, nil
+	}
+	rows, err := db.Query(`
+SELECT `+runActionColumns+`
+FROM action_outbox
+WHERE run_id = ?
+ORDER BY id ASC
+`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]RunActionRecord, 0)
+	for rows.Next() {
+		item, err := scanRunAction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RetryRunActions resets dead or backing-off rows of a run to pending so they are picked up immediately.
+// An empty ids list retries every non-done row of the run.
+func (s *Store) RetryRunActions(runID string, ids []int64) (int64, error) {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = release() }()
+
+	q := gdb.Model(&dbmodel.ActionOutbox{}).
+		Where("run_id = ? AND status IN ?", strings.TrimSpace(runID), []string{OutboxStatusPending, OutboxStatusDead})
+	if len(ids) > 0 {
+		q = q.Where("id IN ?", ids)
+	}
+	tx := q.Updates(map[string]any{
+		"status":        OutboxStatusPending,
+		"retry_count":   0,
+		"next_retry_at": 0,
+		"updated_at":    time.Now().UTC().Unix(),
+	})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+	return tx.RowsAffected, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRunAction(row rowScanner) (RunActionRecord, error) {
+	var item RunActionRecord
+	err := row.Scan(
+		&item.ID,
+		&item.RunID,
+		&item.ActionType,
+		&item.PayloadJSON,
+		&item.Status,
+		&item.RetryCount,
+		&item.NextRetryAt,
+		&item.LastError,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	return item, err
+}
+
+func runActionRecordFromModel(row dbmodel.ActionOutbox) RunActionRecord {
+	return RunActionRecord{
+		ID:          row.ID,
+		RunID:       row.RunID,
+		ActionType:  row.ActionType,
+		PayloadJSON: row.PayloadJSON,
+		Status:      row.Status,
+		RetryCount:  row.RetryCount,
+		NextRetryAt: row.NextRetryAt,
+		LastError:   row.LastError,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
@@ -0,0 +1,155 @@
+package projectstate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOutboxStore_ClaimFailRetryFlow(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "shellman.db")
+	if err := InitGlobalDB(dbPath); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+
+	st := NewStore(t.TempDir())
+	if err := st.InsertTask(TaskRecord{TaskID: "t_1", ProjectID: "p1", Title: "root"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.InsertRun(RunRecord{RunID: "r_1", TaskID: "t_1", RunStatus: RunStatusRunning}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.EnqueueRunAction("r_1", "notify", map[string]any{"k": "v"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC().Unix()
+	claimed, err := st.ClaimDueRunActions(now, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 || claimed[0].Status != OutboxStatusRunning || claimed[0].ActionType != "notify" {
+		t.Fatalf("unexpected claim: %#v", claimed)
+	}
+	again, err := st.ClaimDueRunActions(now, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("expected running row not to be claimed twice, got %d", len(again))
+	}
+
+	id := claimed[0].ID
+	if err := st.MarkRunActionFailed(id, "boom", now+60, false); err != nil {
+		t.Fatal(err)
+	}
+	item, ok, err := st.GetRunAction(id)
+	if err != nil || !ok {
+		t.Fatalf("GetRunAction failed: ok=%v err=%v", ok, err)
+	}
+	if item.Status != OutboxStatusPending || item.RetryCount != 1 || item.LastError != "boom" || item.NextRetryAt != now+60 {
+		t.Fatalf("unexpected failed row: %#v", item)
+	}
+	if due, _ := st.ClaimDueRunActions(now, 10); len(due) != 0 {
+		t.Fatalf("expected backoff to defer claim, got %d", len(due))
+	}
+
+	due, err := st.ClaimDueRunActions(now+60, 10)
+	if err != nil || len(due) != 1 {
+		t.Fatalf("expected claim after backoff, got %d err=%v", len(due), err)
+	}
+	if err := st.MarkRunActionFailed(id, "boom again", now+120, true); err != nil {
+		t.Fatal(err)
+	}
+	item, _, _ = st.GetRunAction(id)
+	if item.Status != OutboxStatusDead || item.RetryCount != 2 {
+		t.Fatalf("expected dead-lettered row, got %#v", item)
+	}
+
+	retried, err := st.RetryRunActions("r_1", nil)
+	if err != nil || retried != 1 {
+		t.Fatalf("expected 1 retried row, got %d err=%v", retried, err)
+	}
+	items, err := st.ListRunActions("r_1")
+	if err != nil || len(items) != 1 {
+		t.Fatalf("ListRunActions failed: %d err=%v", len(items), err)
+	}
+	if items[0].Status != OutboxStatusPending || items[0].RetryCount != 0 || items[0].NextRetryAt != 0 {
+		t.Fatalf("unexpected retried row: %#v", items[0])
+	}
+}
+
+func TestOutboxStore_RequeueRunningAndMarkDone(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "shellman.db")
+	if err := InitGlobalDB(dbPath); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+
+	st := NewStore(t.TempDir())
+	if err := st.EnqueueRunAction("r_2", "notify", nil); err != nil {
+		t.Fatal(err)
+	}
+	claimed, err := st.ClaimDueRunActions(0, 0)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("expected one claimed row, got %d err=%v", len(claimed), err)
+	}
+	requeued, err := st.RequeueRunningRunActions()
+	if err != nil || requeued != 1 {
+		t.Fatalf("expected one requeued row, got %d err=%v", requeued, err)
+	}
+	claimed, err = st.ClaimDueRunActions(0, 0)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("expected requeued row to be claimable, got %d err=%v", len(claimed), err)
+	}
+	if err := st.MarkRunActionDone(claimed[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	item, _, _ := st.GetRunAction(claimed[0].ID)
+	if item.Status != OutboxStatusDone {
+		t.Fatalf("expected done row, got %q", item.Status)
+	}
+	if retried, _ := st.RetryRunActions("r_2", nil); retried != 0 {
+		t.Fatalf("expected done rows not to be retried, got %d", retried)
+	}
+}
+
+func TestOutboxStore_DeferRunActionKeepsAttemptsAndSkipsSettledRows(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "shellman.db")
+	if err := InitGlobalDB(dbPath); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+
+	st := NewStore(t.TempDir())
+	if err := st.EnqueueRunAction("r_3", "notify", nil); err != nil {
+		t.Fatal(err)
+	}
+	claimed, err := st.ClaimDueRunActions(100, 0)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("expected one claimed row, got %d err=%v", len(claimed), err)
+	}
+	if err := st.DeferRunAction(claimed[0].ID, 200); err != nil {
+		t.Fatal(err)
+	}
+	item, _, _ := st.GetRunAction(claimed[0].ID)
+	if item.Status != OutboxStatusPending || item.NextRetryAt != 200 || item.RetryCount != 0 {
+		t.Fatalf("expected deferred pending row without a counted attempt, got %#v", item)
+	}
+	if due, _ := st.ClaimDueRunActions(150, 0); len(due) != 0 {
+		t.Fatalf("expected deferred row not due before next_retry_at, got %d", len(due))
+	}
+
+	claimed, err = st.ClaimDueRunActions(200, 0)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("expected deferred row claimable at next_retry_at, got %d err=%v", len(claimed), err)
+	}
+	if err := st.MarkRunActionDone(claimed[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.DeferRunAction(claimed[0].ID, 300); err != nil {
+		t.Fatal(err)
+	}
+	item, _, _ = st.GetRunAction(claimed[0].ID)
+	if item.Status != OutboxStatusDone {
+		t.Fatalf("expected settled row to stay done, got %q", item.Status)
+	}
+}
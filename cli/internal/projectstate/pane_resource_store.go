@@ -0,0 +1,101 @@
+package projectstate
+
+import (
+	"strings"
+
+	dbmodel "shellman/cli/internal/db"
+)
+
+// PaneResourceBucket is the downsampled resource use of a pane over one bucket period starting at BucketStart.
+// TmuxSocket is the socket of the tmux server the pane lives on; "" is the default server.
+type PaneResourceBucket struct {
+	TmuxSocket    string  `json:"tmux_socket,omitempty"`
+	PaneID        string  `json:"pane_id"`
+	BucketStart   int64   `json:"bucket_start"`
+	Samples       int     `json:"samples"`
+	CPUPercentAvg float64 `json:"cpu_percent_avg"`
+	CPUPercentMax float64 `json:"cpu_percent_max"`
+	RSSBytesMax   int64   `json:"rss_bytes_max"`
+	ThreadsMax    int     `json:"threads_max"`
+	ProcessesMax  int     `json:"processes_max"`
+}
+
+// AppendPaneResourceHistory stores buckets under the store's repo root and drops every bucket, of any project, that
+// started before keepSince.
+func (s *Store) AppendPaneResourceHistory(buckets []PaneResourceBucket, keepSince int64) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	rows := make([]dbmodel.PaneResourceSample, 0, len(buckets))
+	for _, b := range buckets {
+		paneID := strings.TrimSpace(b.PaneID)
+		if paneID == "" || b.Samples <= 0 {
+			continue
+		}
+		rows = append(rows, dbmodel.PaneResourceSample{
+			RepoRoot:      s.repoRoot,
+			TmuxSocket:    strings.TrimSpace(b.TmuxSocket),
+			PaneID:        paneID,
+			BucketStart:   b.BucketStart,
+			Samples:       b.Samples,
+			CPUPercentAvg: b.CPUPercentAvg,
+			CPUPercentMax: b.CPUPercentMax,
+			RSSBytesMax:   b.RSSBytesMax,
+			ThreadsMax:    b.ThreadsMax,
+			ProcessesMax:  b.ProcessesMax,
+		})
+	}
+	if len(rows) > 0 {
+		if err := gdb.Create(&rows).Error; err != nil {
+			return err
+		}
+	}
+	if keepSince <= 0 {
+		return nil
+	}
+	return gdb.Where("bucket_start < ?", keepSince).Delete(&dbmodel.PaneResourceSample{}).Error
+}
+
+// ListPaneResourceHistory returns the latest limit buckets of paneID on the tmux server at tmuxSocket kept under the
+// store's repo root, oldest first.
+func (s *Store) ListPaneResourceHistory(tmuxSocket, paneID string, limit int) ([]PaneResourceBucket, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	if limit <= 0 {
+		limit = 60
+	}
+	rows, err := db.Query(`
+SELECT tmux_socket, pane_id, bucket_start, samples, cpu_percent_avg, cpu_percent_max, rss_bytes_max, threads_max, processes_max
+FROM pane_resource_samples
+WHERE repo_root = ? AND tmux_socket = ? AND pane_id = ?
+ORDER BY bucket_start DESC, id DESC
+LIMIT ?
+`, s.repoRoot, strings.TrimSpace(tmuxSocket), strings.TrimSpace(paneID), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]PaneResourceBucket, 0)
+	for rows.Next() {
+		var b PaneResourceBucket
+		if err := rows.Scan(&b.TmuxSocket, &b.PaneID, &b.BucketStart, &b.Samples, &b.CPUPercentAvg, &b.CPUPercentMax, &b.RSSBytesMax, &b.ThreadsMax, &b.ProcessesMax); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package projectstate
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPaneResourceStore_AppendListAndPrune(t *testing.T) {
+	st := newTaskStateStore(t)
+	paneID := fmt.Sprintf("%%res_%d", time.Now().UTC().UnixNano())
+	now := time.Now().UTC().Unix()
+
+	if err := st.AppendPaneResourceHistory([]PaneResourceBucket{
+		{PaneID: paneID, BucketStart: now - 7200, Samples: 12, CPUPercentAvg: 5, CPUPercentMax: 9, RSSBytesMax: 1 << 20, ThreadsMax: 2, ProcessesMax: 1},
+		{PaneID: paneID, BucketStart: now - 120, Samples: 12, CPUPercentAvg: 80, CPUPercentMax: 190.5, RSSBytesMax: 3 << 30, ThreadsMax: 40, ProcessesMax: 6},
+		{PaneID: paneID, BucketStart: now - 60, Samples: 12, CPUPercentAvg: 10, CPUPercentMax: 20, RSSBytesMax: 2 << 30, ThreadsMax: 30, ProcessesMax: 4},
+		{PaneID: "", BucketStart: now, Samples: 1},
+	}, 0); err != nil {
+		t.Fatalf("AppendPaneResourceHistory failed: %v", err)
+	}
+	history, err := st.ListPaneResourceHistory("", paneID, 2)
+	if err != nil {
+		t.Fatalf("ListPaneResourceHistory failed: %v", err)
+	}
+	if len(history) != 2 || history[0].BucketStart != now-120 || history[1].BucketStart != now-60 {
+		t.Fatalf("expected latest buckets oldest first, got %#v", history)
+	}
+	if history[0].CPUPercentMax != 190.5 || history[0].RSSBytesMax != 3<<30 || history[0].ProcessesMax != 6 {
+		t.Fatalf("unexpected bucket: %#v", history[0])
+	}
+
+	if err := st.AppendPaneResourceHistory(nil, now-3600); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	history, err = st.ListPaneResourceHistory("", paneID, 10)
+	if err != nil {
+		t.Fatalf("ListPaneResourceHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected buckets older than an hour pruned, got %#v", history)
+	}
+}
+
+func TestPaneResourceStore_ScopesHistoryByProjectAndSocket(t *testing.T) {
+	st := newTaskStateStore(t)
+	other := NewStore(t.TempDir())
+	now := time.Now().UTC().Unix()
+
+	// The same pane id on another tmux server and in another project is a different pane.
+	if err := st.AppendPaneResourceHistory([]PaneResourceBucket{
+		{PaneID: "%3", BucketStart: now - 60, Samples: 12, CPUPercentMax: 10},
+		{TmuxSocket: "work", PaneID: "%3", BucketStart: now - 60, Samples: 12, CPUPercentMax: 20},
+	}, 0); err != nil {
+		t.Fatalf("AppendPaneResourceHistory failed: %v", err)
+	}
+	if err := other.AppendPaneResourceHistory([]PaneResourceBucket{{PaneID: "%3", BucketStart: now - 60, Samples: 12, CPUPercentMax: 30}}, 0); err != nil {
+		t.Fatalf("AppendPaneResourceHistory failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		store  *Store
+		socket string
+		cpu    float64
+	}{{st, "", 10}, {st, "work", 20}, {other, "", 30}} {
+		history, err := tc.store.ListPaneResourceHistory(tc.socket, "%3", 10)
+		if err != nil {
+			t.Fatalf("ListPaneResourceHistory failed: %v", err)
+		}
+		if len(history) != 1 || history[0].CPUPercentMax != tc.cpu || history[0].TmuxSocket != tc.socket {
+			t.Fatalf("expected only the %q bucket of its project, got %#v", tc.socket, history)
+		}
+	}
+	if history, err := other.ListPaneResourceHistory("work", "%3", 10); err != nil || len(history) != 0 {
+		t.Fatalf("expected no history for a socket the project never sampled, got %#v err=%v", history, err)
+	}
+}
@@ -0,0 +1,294 @@
+package projectstate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	dbmodel "shellman/cli/internal/db"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	FlagSeverityInfo     = "info"
+	FlagSeverityWarning  = "warning"
+	FlagSeverityHigh     = "high"
+	FlagSeverityCritical = "critical"
+
+	MaxProjectFlagNameLength = 32
+)
+
+var (
+	ErrInvalidProjectFlag  = errors.New("invalid project flag")
+	ErrProjectFlagNotFound = errors.New("project flag not found")
+
+	projectFlagNamePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	projectFlagColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+)
+
+type ProjectFlagRecord struct {
+	Name                 string `json:"name"`
+	Severity             string `json:"severity"`
+	Color                string `json:"color,omitempty"`
+	EscalationTimeoutSec int64  `json:"escalation_timeout_sec,omitempty"`
+}
+
+// Escalates reports whether an unread task flag of this kind should be escalated after its timeout.
+func (f ProjectFlagRecord) Escalates() bool {
+	return f.EscalationTimeoutSec > 0 && (f.Severity == FlagSeverityHigh || f.Severity == FlagSeverityCritical)
+}
+
+type TaskFlagEscalationRecord struct {
+	TaskID          string `json:"task_id"`
+	Flag            string `json:"flag"`
+	RaisedAt        int64  `json:"raised_at"`
+	LastEscalatedAt int64  `json:"last_escalated_at,omitempty"`
+	EscalationCount int64  `json:"escalation_count"`
+}
+
+// DefaultProjectFlags is the vocabulary of a project that has not registered flags of its own: the three flags
+// sidecars have always been able to raise.
+func DefaultProjectFlags() []ProjectFlagRecord {
+	return []ProjectFlagRecord{
+		{Name: "success", Severity: FlagSeverityInfo, Color: "#22c55e"},
+		{Name: "notify", Severity: FlagSeverityWarning, Color: "#f59e0b"},
+		{Name: "error", Severity: FlagSeverityHigh, Color: "#ef4444"},
+	}
+}
+
+// NormalizeProjectFlag trims rec and checks its name, severity, color and timeout. Severity defaults to info.
+func NormalizeProjectFlag(rec ProjectFlagRecord) (ProjectFlagRecord, error) {
+	rec.Name = strings.TrimSpace(rec.Name)
+	rec.Severity = strings.ToLower(strings.TrimSpace(rec.Severity))
+	rec.Color = strings.TrimSpace(rec.Color)
+	if rec.Name == "" || len(rec.Name) > MaxProjectFlagNameLength || !projectFlagNamePattern.MatchString(rec.Name) {
+		return rec, fmt.Errorf("%w: name must be 1-%d lowercase letters, digits, '-' or '_'", ErrInvalidProjectFlag, MaxProjectFlagNameLength)
+	}
+	switch rec.Severity {
+	case "":
+		rec.Severity = FlagSeverityInfo
+	case FlagSeverityInfo, FlagSeverityWarning, FlagSeverityHigh, FlagSeverityCritical:
+	default:
+		return rec, fmt.Errorf("%w: unsupported severity %q", ErrInvalidProjectFlag, rec.Severity)
+	}
+	if rec.Color != "" && !projectFlagColorPattern.MatchString(rec.Color) {
+		return rec, fmt.Errorf("%w: color must be #rgb or #rrggbb", ErrInvalidProjectFlag)
+	}
+	if rec.EscalationTimeoutSec < 0 {
+		return rec, fmt.Errorf("%w: escalation_timeout_sec must be >= 0", ErrInvalidProjectFlag)
+	}
+	return rec, nil
+}
+
+// ListProjectFlags returns the flag registry of projectID ordered by name, or DefaultProjectFlags when the project
+// has not registered any flag yet.
+func (s *Store) ListProjectFlags(projectID string) ([]ProjectFlagRecord, error) {
+	out, err := s.listRegisteredProjectFlags(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return DefaultProjectFlags(), nil
+	}
+	return out, nil
+}
+
+func (s *Store) listRegisteredProjectFlags(projectID string) ([]ProjectFlagRecord, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	rows, err := db.Query(`
+SELECT name, severity, color, escalation_timeout_sec
+FROM project_flags
+WHERE repo_root = ? AND project_id = ?
+ORDER BY name ASC
+`, s.repoRoot, strings.TrimSpace(projectID))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]ProjectFlagRecord, 0)
+	for rows.Next() {
+		var rec ProjectFlagRecord
+		if err := rows.Scan(&rec.Name, &rec.Severity, &rec.Color, &rec.EscalationTimeoutSec); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetProjectFlag looks name up in the project's registry (or the defaults).
+func (s *Store) GetProjectFlag(projectID, name string) (ProjectFlagRecord, bool, error) {
+	flags, err := s.ListProjectFlags(projectID)
+	if err != nil {
+		return ProjectFlagRecord{}, false, err
+	}
+	name = strings.TrimSpace(name)
+	for _, flag := range flags {
+		if flag.Name == name {
+			return flag, true, nil
+		}
+	}
+	return ProjectFlagRecord{}, false, nil
+}
+
+// UpsertProjectFlag creates or replaces a registry entry. The first write to a project copies the defaults in,
+// so registering one custom flag does not silently drop success, notify and error.
+func (s *Store) UpsertProjectFlag(projectID string, rec ProjectFlagRecord) (ProjectFlagRecord, error) {
+	rec, err := NormalizeProjectFlag(rec)
+	if err != nil {
+		return rec, err
+	}
+	err = s.updateProjectFlags(projectID, func(tx *gorm.DB, now int64) error {
+		row := s.projectFlagRow(projectID, rec, now)
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "repo_root"}, {Name: "project_id"}, {Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"severity", "color", "escalation_timeout_sec", "updated_at"}),
+		}).Create(&row).Error
+	})
+	if err != nil {
+		return rec, err
+	}
+	return rec, nil
+}
+
+// DeleteProjectFlag removes name from the registry. Tasks already carrying the flag keep it; removing the last
+// registered flag brings the defaults back.
+func (s *Store) DeleteProjectFlag(projectID, name string) error {
+	name = strings.TrimSpace(name)
+	if _, found, err := s.GetProjectFlag(projectID, name); err != nil {
+		return err
+	} else if !found {
+		return ErrProjectFlagNotFound
+	}
+	return s.updateProjectFlags(projectID, func(tx *gorm.DB, _ int64) error {
+		return tx.Where("repo_root = ? AND project_id = ? AND name = ?", s.repoRoot, projectID, name).
+			Delete(&dbmodel.ProjectFlag{}).Error
+	})
+}
+
+// updateProjectFlags runs fn in a transaction after materializing the default vocabulary for a project that has
+// no registered flags yet.
+func (s *Store) updateProjectFlags(projectID string, fn func(tx *gorm.DB, now int64) error) error {
+	registered, err := s.listRegisteredProjectFlags(projectID)
+	if err != nil {
+		return err
+	}
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	now := time.Now().UTC().Unix()
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		if len(registered) == 0 {
+			for _, flag := range DefaultProjectFlags() {
+				row := s.projectFlagRow(projectID, flag, now)
+				if err := tx.Create(&row).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return fn(tx, now)
+	})
+}
+
+func (s *Store) projectFlagRow(projectID string, flag ProjectFlagRecord, now int64) dbmodel.ProjectFlag {
+	return dbmodel.ProjectFlag{
+		RepoRoot:             s.repoRoot,
+		ProjectID:            projectID,
+		Name:                 flag.Name,
+		Severity:             flag.Severity,
+		Color:                flag.Color,
+		EscalationTimeoutSec: flag.EscalationTimeoutSec,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+}
+
+// ResetTaskFlagEscalation starts the escalation clock for the flag now on taskID; an empty flag clears it.
+func (s *Store) ResetTaskFlagEscalation(projectID, taskID, flag string, raisedAt int64) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	taskID = strings.TrimSpace(taskID)
+	flag = strings.TrimSpace(flag)
+	if flag == "" {
+		return gdb.Where("repo_root = ? AND task_id = ?", s.repoRoot, taskID).Delete(&dbmodel.TaskFlagEscalation{}).Error
+	}
+	row := dbmodel.TaskFlagEscalation{
+		TaskID:    taskID,
+		RepoRoot:  s.repoRoot,
+		ProjectID: projectID,
+		Flag:      flag,
+		RaisedAt:  raisedAt,
+	}
+	return gdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"repo_root", "project_id", "flag", "raised_at", "last_escalated_at", "escalation_count"}),
+	}).Create(&row).Error
+}
+
+// ListTaskFlagEscalations returns the escalation state of projectID keyed by task id.
+func (s *Store) ListTaskFlagEscalations(projectID string) (map[string]TaskFlagEscalationRecord, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	rows, err := db.Query(`
+SELECT task_id, flag, raised_at, last_escalated_at, escalation_count
+FROM task_flag_escalations
+WHERE repo_root = ? AND project_id = ?
+`, s.repoRoot, strings.TrimSpace(projectID))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := map[string]TaskFlagEscalationRecord{}
+	for rows.Next() {
+		var rec TaskFlagEscalationRecord
+		if err := rows.Scan(&rec.TaskID, &rec.Flag, &rec.RaisedAt, &rec.LastEscalatedAt, &rec.EscalationCount); err != nil {
+			return nil, err
+		}
+		out[rec.TaskID] = rec
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MarkTaskFlagEscalated records one more escalation of taskID's flag at the given time.
+func (s *Store) MarkTaskFlagEscalated(taskID string, at int64) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	return gdb.Model(&dbmodel.TaskFlagEscalation{}).
+		Where("repo_root = ? AND task_id = ?", s.repoRoot, strings.TrimSpace(taskID)).
+		Updates(map[string]any{
+			"last_escalated_at": at,
+			"escalation_count":  gorm.Expr("escalation_count + 1"),
+		}).Error
+}
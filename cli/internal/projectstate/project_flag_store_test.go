@@ -0,0 +1,89 @@
+package projectstate
+
+import (
+	"errors"
+	"testing"
+)
+
+func projectFlagNames(flags []ProjectFlagRecord) []string {
+	out := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		out = append(out, flag.Name)
+	}
+	return out
+}
+
+func TestProjectFlagStore_DefaultsUpsertAndDelete(t *testing.T) {
+	st := newTaskStateStore(t)
+
+	flags, err := st.ListProjectFlags("p1")
+	if err != nil {
+		t.Fatalf("ListProjectFlags failed: %v", err)
+	}
+	if len(flags) != 3 {
+		t.Fatalf("expected default vocabulary, got %#v", flags)
+	}
+
+	blocked, err := st.UpsertProjectFlag("p1", ProjectFlagRecord{Name: "blocked", Severity: "Critical", Color: "#f00", EscalationTimeoutSec: 600})
+	if err != nil {
+		t.Fatalf("UpsertProjectFlag failed: %v", err)
+	}
+	if blocked.Severity != FlagSeverityCritical || !blocked.Escalates() {
+		t.Fatalf("unexpected normalized flag: %#v", blocked)
+	}
+	flags, err = st.ListProjectFlags("p1")
+	if err != nil {
+		t.Fatalf("ListProjectFlags failed: %v", err)
+	}
+	if got := projectFlagNames(flags); len(got) != 4 || got[0] != "blocked" {
+		t.Fatalf("expected defaults kept next to the new flag, got %#v", got)
+	}
+
+	if err := st.DeleteProjectFlag("p1", "notify"); err != nil {
+		t.Fatalf("DeleteProjectFlag failed: %v", err)
+	}
+	if _, found, err := st.GetProjectFlag("p1", "notify"); err != nil || found {
+		t.Fatalf("expected notify removed, found=%v err=%v", found, err)
+	}
+	if err := st.DeleteProjectFlag("p1", "notify"); !errors.Is(err, ErrProjectFlagNotFound) {
+		t.Fatalf("expected ErrProjectFlagNotFound, got %v", err)
+	}
+	if _, err := st.UpsertProjectFlag("p1", ProjectFlagRecord{Name: "Bad Name"}); !errors.Is(err, ErrInvalidProjectFlag) {
+		t.Fatalf("expected ErrInvalidProjectFlag, got %v", err)
+	}
+	if flags, _ := st.ListProjectFlags("p2"); len(flags) != 3 {
+		t.Fatalf("expected other projects to keep defaults, got %#v", flags)
+	}
+}
+
+func TestProjectFlagStore_EscalationState(t *testing.T) {
+	st := newTaskStateStore(t)
+
+	if err := st.ResetTaskFlagEscalation("p1", "t1", "error", 100); err != nil {
+		t.Fatalf("ResetTaskFlagEscalation failed: %v", err)
+	}
+	if err := st.MarkTaskFlagEscalated("t1", 200); err != nil {
+		t.Fatalf("MarkTaskFlagEscalated failed: %v", err)
+	}
+	got, err := st.ListTaskFlagEscalations("p1")
+	if err != nil {
+		t.Fatalf("ListTaskFlagEscalations failed: %v", err)
+	}
+	if rec := got["t1"]; rec.RaisedAt != 100 || rec.LastEscalatedAt != 200 || rec.EscalationCount != 1 {
+		t.Fatalf("unexpected escalation state: %#v", rec)
+	}
+
+	if err := st.ResetTaskFlagEscalation("p1", "t1", "error", 300); err != nil {
+		t.Fatalf("ResetTaskFlagEscalation failed: %v", err)
+	}
+	got, _ = st.ListTaskFlagEscalations("p1")
+	if rec := got["t1"]; rec.RaisedAt != 300 || rec.EscalationCount != 0 {
+		t.Fatalf("expected reset escalation state, got %#v", rec)
+	}
+	if err := st.ResetTaskFlagEscalation("p1", "t1", "", 400); err != nil {
+		t.Fatalf("clear escalation failed: %v", err)
+	}
+	if got, _ = st.ListTaskFlagEscalations("p1"); len(got) != 0 {
+		t.Fatalf("expected cleared escalation, got %#v", got)
+	}
+}
@@ -0,0 +1,186 @@
+package projectstate
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	dbmodel "shellman/cli/internal/db"
+
+	"gorm.io/gorm/clause"
+)
+
+const MaxProjectLayoutNameLength = 64
+
+var (
+	ErrInvalidProjectLayout  = errors.New("invalid project layout")
+	ErrProjectLayoutNotFound = errors.New("project layout not found")
+
+	projectLayoutNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+)
+
+// LayoutPane is one pane of a saved window, in tmux pane index order.
+type LayoutPane struct {
+	TaskID string `json:"task_id"`
+	Cwd    string `json:"cwd"`
+}
+
+// LayoutWindow is a saved tmux window: its window_layout string and the panes it is applied to.
+type LayoutWindow struct {
+	Layout string       `json:"layout"`
+	Panes  []LayoutPane `json:"panes"`
+}
+
+type ProjectLayoutRecord struct {
+	Name      string         `json:"name"`
+	Windows   []LayoutWindow `json:"windows"`
+	CreatedAt int64          `json:"created_at"`
+	UpdatedAt int64          `json:"updated_at"`
+}
+
+// NormalizeProjectLayoutName trims name and checks it can be used in a URL path segment.
+func NormalizeProjectLayoutName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > MaxProjectLayoutNameLength || !projectLayoutNamePattern.MatchString(name) {
+		return name, fmt.Errorf("%w: name must be 1-%d letters, digits, '.', '-' or '_'", ErrInvalidProjectLayout, MaxProjectLayoutNameLength)
+	}
+	return name, nil
+}
+
+// SaveProjectLayout creates or replaces the layout rec.Name of projectID, keeping its creation time on replace.
+func (s *Store) SaveProjectLayout(projectID string, rec ProjectLayoutRecord) (ProjectLayoutRecord, error) {
+	name, err := NormalizeProjectLayoutName(rec.Name)
+	if err != nil {
+		return rec, err
+	}
+	if len(rec.Windows) == 0 {
+		return rec, fmt.Errorf("%w: layout has no windows", ErrInvalidProjectLayout)
+	}
+	rec.Name = name
+	raw, err := json.Marshal(rec.Windows)
+	if err != nil {
+		return rec, err
+	}
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return rec, err
+	}
+	defer func() { _ = release() }()
+
+	now := time.Now().UTC().Unix()
+	row := dbmodel.ProjectLayout{
+		RepoRoot:    s.repoRoot,
+		ProjectID:   strings.TrimSpace(projectID),
+		Name:        name,
+		WindowsJSON: string(raw),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := gdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repo_root"}, {Name: "project_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"windows_json", "updated_at"}),
+	}).Create(&row).Error; err != nil {
+		return rec, err
+	}
+	saved, _, err := s.GetProjectLayout(projectID, name)
+	return saved, err
+}
+
+// GetProjectLayout returns the layout name of projectID.
+func (s *Store) GetProjectLayout(projectID, name string) (ProjectLayoutRecord, bool, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return ProjectLayoutRecord{}, false, err
+	}
+	defer func() { _ = release() }()
+
+	var (
+		rec         ProjectLayoutRecord
+		windowsJSON string
+	)
+	err = db.QueryRow(`
+SELECT name, windows_json, created_at, updated_at
+FROM project_layouts
+WHERE repo_root = ? AND project_id = ? AND name = ?
+`, s.repoRoot, strings.TrimSpace(projectID), strings.TrimSpace(name)).Scan(&rec.Name, &windowsJSON, &rec.CreatedAt, &rec.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ProjectLayoutRecord{}, false, nil
+	}
+	if err != nil {
+		return ProjectLayoutRecord{}, false, err
+	}
+	if err := decodeLayoutWindows(windowsJSON, &rec); err != nil {
+		return ProjectLayoutRecord{}, false, err
+	}
+	return rec, true, nil
+}
+
+// ListProjectLayouts returns the saved layouts of projectID ordered by name.
+func (s *Store) ListProjectLayouts(projectID string) ([]ProjectLayoutRecord, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	rows, err := db.Query(`
+SELECT name, windows_json, created_at, updated_at
+FROM project_layouts
+WHERE repo_root = ? AND project_id = ?
+ORDER BY name ASC
+`, s.repoRoot, strings.TrimSpace(projectID))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]ProjectLayoutRecord, 0)
+	for rows.Next() {
+		var (
+			rec         ProjectLayoutRecord
+			windowsJSON string
+		)
+		if err := rows.Scan(&rec.Name, &windowsJSON, &rec.CreatedAt, &rec.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := decodeLayoutWindows(windowsJSON, &rec); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteProjectLayout removes the layout name of projectID.
+func (s *Store) DeleteProjectLayout(projectID, name string) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	res := gdb.Where("repo_root = ? AND project_id = ? AND name = ?", s.repoRoot, strings.TrimSpace(projectID), strings.TrimSpace(name)).
+		Delete(&dbmodel.ProjectLayout{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrProjectLayoutNotFound
+	}
+	return nil
+}
+
+func decodeLayoutWindows(raw string, rec *ProjectLayoutRecord) error {
+	rec.Windows = []LayoutWindow{}
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(raw), &rec.Windows)
+}
@@ -0,0 +1,60 @@
+package projectstate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProjectLayoutStore_SaveReplaceListAndDelete(t *testing.T) {
+	st := newTaskStateStore(t)
+
+	windows := []LayoutWindow{{
+		Layout: "b25d,80x24,0,0{40x24,0,0,1,39x24,41,0,2}",
+		Panes:  []LayoutPane{{TaskID: "t1", Cwd: "/repo"}, {TaskID: "t2", Cwd: "/repo/web"}},
+	}}
+	saved, err := st.SaveProjectLayout("p1", ProjectLayoutRecord{Name: " dev ", Windows: windows})
+	if err != nil {
+		t.Fatalf("SaveProjectLayout failed: %v", err)
+	}
+	if saved.Name != "dev" || len(saved.Windows) != 1 || len(saved.Windows[0].Panes) != 2 || saved.CreatedAt == 0 {
+		t.Fatalf("unexpected saved layout: %#v", saved)
+	}
+
+	windows[0].Panes = windows[0].Panes[:1]
+	replaced, err := st.SaveProjectLayout("p1", ProjectLayoutRecord{Name: "dev", Windows: windows})
+	if err != nil {
+		t.Fatalf("SaveProjectLayout replace failed: %v", err)
+	}
+	if len(replaced.Windows[0].Panes) != 1 || replaced.CreatedAt != saved.CreatedAt {
+		t.Fatalf("expected layout replaced in place, got %#v", replaced)
+	}
+	if _, err := st.SaveProjectLayout("p1", ProjectLayoutRecord{Name: "review", Windows: windows}); err != nil {
+		t.Fatalf("SaveProjectLayout failed: %v", err)
+	}
+	layouts, err := st.ListProjectLayouts("p1")
+	if err != nil {
+		t.Fatalf("ListProjectLayouts failed: %v", err)
+	}
+	if len(layouts) != 2 || layouts[0].Name != "dev" || layouts[1].Name != "review" {
+		t.Fatalf("unexpected layouts: %#v", layouts)
+	}
+	if other, _ := st.ListProjectLayouts("p2"); len(other) != 0 {
+		t.Fatalf("expected layouts scoped to their project, got %#v", other)
+	}
+
+	if _, err := st.SaveProjectLayout("p1", ProjectLayoutRecord{Name: "bad name", Windows: windows}); !errors.Is(err, ErrInvalidProjectLayout) {
+		t.Fatalf("expected ErrInvalidProjectLayout for bad name, got %v", err)
+	}
+	if _, err := st.SaveProjectLayout("p1", ProjectLayoutRecord{Name: "empty"}); !errors.Is(err, ErrInvalidProjectLayout) {
+		t.Fatalf("expected ErrInvalidProjectLayout for empty layout, got %v", err)
+	}
+	if err := st.DeleteProjectLayout("p1", "dev"); err != nil {
+		t.Fatalf("DeleteProjectLayout failed: %v", err)
+	}
+	if _, found, err := st.GetProjectLayout("p1", "dev"); err != nil || found {
+		t.Fatalf("expected dev removed, found=%v err=%v", found, err)
+	}
+	if err := st.DeleteProjectLayout("p1", "dev"); !errors.Is(err, ErrProjectLayoutNotFound) {
+		t.Fatalf("expected ErrProjectLayoutNotFound, got %v", err)
+	}
+}
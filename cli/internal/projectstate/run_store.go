@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	dbmodel "shellman/cli/internal/db"
 	"strings"
 	"time"
@@ -45,7 +46,17 @@ func (s *Store) InsertTask(task TaskRecord) error {
 		CreatedAt:     now,
 		LastModified:  now,
 	}
-	return gdb.Create(&row).Error
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		var maxSortOrder int64
+		if err := tx.Model(&dbmodel.Task{}).
+			Where("repo_root = ? AND project_id = ? AND parent_task_id = ?", s.repoRoot, task.ProjectID, task.ParentTaskID).
+			Select("COALESCE(MAX(sort_order), 0)").
+			Scan(&maxSortOrder).Error; err != nil {
+			return err
+		}
+		row.SortOrder = maxSortOrder + 1
+		return tx.Create(&row).Error
+	})
 }
 
 func (s *Store) InsertRun(run RunRecord) error {
@@ -85,10 +96,10 @@ func (s *Store) GetRun(runID string) (RunRecord, error) {
 
 	var run RunRecord
 	err = db.QueryRow(`
-SELECT run_id, task_id, run_status, started_at, completed_at, updated_at, last_error
+SELECT run_id, task_id, run_status, started_at, completed_at, updated_at, last_error, artifact_dir
 FROM task_runs
 WHERE run_id = ?
-`, runID).Scan(&run.RunID, &run.TaskID, &run.RunStatus, &run.StartedAt, &run.CompletedAt, &run.UpdatedAt, &run.LastError)
+`, runID).Scan(&run.RunID, &run.TaskID, &run.RunStatus, &run.StartedAt, &run.CompletedAt, &run.UpdatedAt, &run.LastError, &run.ArtifactDir)
 	if err != nil {
 		return RunRecord{}, err
 	}
@@ -116,28 +127,63 @@ func (s *Store) UpsertRunBinding(binding RunBinding) error {
 		StaleReason:      binding.StaleReason,
 		UpdatedAt:        now,
 	}
-	return gdb.Clauses(clause.OnConflict{
-		Columns: []clause.Column{{Name: "run_id"}},
-		DoUpdates: clause.Assignments(map[string]any{
-			"server_instance_id": gorm.Expr("excluded.server_instance_id"),
-			"pane_id":            gorm.Expr("excluded.pane_id"),
-			"pane_target":        gorm.Expr("excluded.pane_target"),
-			"binding_status":     gorm.Expr("excluded.binding_status"),
-			"stale_reason":       gorm.Expr("excluded.stale_reason"),
-			"updated_at":         gorm.Expr("excluded.updated_at"),
-		}),
-	}).Create(&row).Error
+	// run_bindings only keeps the current binding; the history is kept as run events.
+	raw, err := json.Marshal(map[string]any{
+		"server_instance_id": row.ServerInstanceID,
+		"pane_id":            row.PaneID,
+		"pane_target":        row.PaneTarget,
+		"binding_status":     row.BindingStatus,
+		"stale_reason":       row.StaleReason,
+	})
+	if err != nil {
+		return err
+	}
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		// A rebound pane has not been observed yet, so its tmux server is forgotten; the cwd stays as a hint.
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "run_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"server_instance_id": gorm.Expr("excluded.server_instance_id"),
+				"pane_id":            gorm.Expr("excluded.pane_id"),
+				"pane_target":        gorm.Expr("excluded.pane_target"),
+				"binding_status":     gorm.Expr("excluded.binding_status"),
+				"stale_reason":       gorm.Expr("excluded.stale_reason"),
+				"tmux_server_id":     "",
+				"updated_at":         gorm.Expr("excluded.updated_at"),
+			}),
+		}).Create(&row).Error; err != nil {
+			return err
+		}
+		return tx.Create(&dbmodel.RunEvent{
+			RunID:       row.RunID,
+			EventType:   RunEventBindingUpdated,
+			PayloadJSON: string(raw),
+			CreatedAt:   now,
+		}).Error
+	})
 }
 
-func (s *Store) MarkBindingsStaleByServer(serverInstanceID, reason string) error {
+// MarkBindingsStaleByServer marks every live binding on serverInstanceID stale with reason and moves the active
+// runs of those bindings to needs_rebind. It returns the ids of the runs it moved, for the caller to notify about.
+func (s *Store) MarkBindingsStaleByServer(serverInstanceID, reason string) ([]string, error) {
 	gdb, release, err := s.dbGORM()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer func() { _ = release() }()
 
 	now := time.Now().UTC().Unix()
-	return gdb.Transaction(func(tx *gorm.DB) error {
+	var runIDs []string
+	err = gdb.Transaction(func(tx *gorm.DB) error {
+		live := tx.Model(&dbmodel.RunBinding{}).
+			Select("run_id").
+			Where("server_instance_id = ? AND binding_status = ?", serverInstanceID, BindingStatusLive)
+		if err := tx.Model(&dbmodel.TaskRun{}).
+			Where("run_id IN (?) AND run_status IN ?", live, []string{RunStatusRunning, RunStatusStalled}).
+			Order("run_id ASC").
+			Pluck("run_id", &runIDs).Error; err != nil {
+			return err
+		}
 		if err := tx.Model(&dbmodel.RunBinding{}).
 			Where("server_instance_id = ? AND binding_status = ?", serverInstanceID, BindingStatusLive).
 			Updates(map[string]any{
@@ -147,17 +193,20 @@ func (s *Store) MarkBindingsStaleByServer(serverInstanceID, reason string) error
 			}).Error; err != nil {
 			return err
 		}
-
-		sub := tx.Model(&dbmodel.RunBinding{}).
-			Select("run_id").
-			Where("server_instance_id = ? AND binding_status = ?", serverInstanceID, BindingStatusStale)
+		if len(runIDs) == 0 {
+			return nil
+		}
 		return tx.Model(&dbmodel.TaskRun{}).
-			Where("run_id IN (?)", sub).
+			Where("run_id IN ?", runIDs).
 			Updates(map[string]any{
 				"run_status": RunStatusNeedsRebind,
 				"updated_at": now,
 			}).Error
 	})
+	if err != nil {
+		return nil, err
+	}
+	return runIDs, nil
 }
 
 func (s *Store) AppendRunEvent(runID, eventType string, payload map[string]any) error {
@@ -286,6 +335,79 @@ func (s *Store) MarkRunCompleted(runID string) error {
 		}).Error
 }
 
+// MarkRunFailed finishes runID with a failed or canceled status and records why on last_error.
+func (s *Store) MarkRunFailed(runID, status, lastError string) error {
+	if status != RunStatusFailed && status != RunStatusCanceled {
+		return fmt.Errorf("unsupported terminal run status %q", status)
+	}
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	now := time.Now().UTC().Unix()
+	return gdb.Model(&dbmodel.TaskRun{}).
+		Where("run_id = ?", runID).
+		Updates(map[string]any{
+			"run_status":   status,
+			"completed_at": now,
+			"updated_at":   now,
+			"last_error":   strings.TrimSpace(lastError),
+		}).Error
+}
+
+// CancelRunningRuns marks every unfinished (running, stalled or needs_rebind) run of taskID canceled with reason
+// and returns their ids.
+func (s *Store) CancelRunningRuns(taskID, reason string) ([]string, error) {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	var runIDs []string
+	now := time.Now().UTC().Unix()
+	err = gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&dbmodel.TaskRun{}).
+			Where("task_id = ? AND run_status IN ?", taskID, []string{RunStatusRunning, RunStatusStalled, RunStatusNeedsRebind}).
+			Pluck("run_id", &runIDs).Error; err != nil {
+			return err
+		}
+		if len(runIDs) == 0 {
+			return nil
+		}
+		return tx.Model(&dbmodel.TaskRun{}).
+			Where("run_id IN ?", runIDs).
+			Updates(map[string]any{
+				"run_status":   RunStatusCanceled,
+				"completed_at": now,
+				"updated_at":   now,
+				"last_error":   strings.TrimSpace(reason),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return runIDs, nil
+}
+
+// SetRunArtifactDir records where the artifact bundle of runID was written.
+func (s *Store) SetRunArtifactDir(runID, dir string) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	return gdb.Model(&dbmodel.TaskRun{}).
+		Where("run_id = ?", runID).
+		Updates(map[string]any{
+			"artifact_dir": dir,
+			"updated_at":   time.Now().UTC().Unix(),
+		}).Error
+}
+
 func (s *Store) SetRunStatus(runID, status string) error {
 	gdb, release, err := s.dbGORM()
 	if err != nil {
@@ -301,6 +423,27 @@ func (s *Store) SetRunStatus(runID, status string) error {
 		}).Error
 }
 
+// TransitionRunStatus moves runID from status from to status to and reports whether it did; it does nothing when
+// the run has already left from, e.g. because it finished or another pass moved it first.
+func (s *Store) TransitionRunStatus(runID, from, to string) (bool, error) {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = release() }()
+
+	tx := gdb.Model(&dbmodel.TaskRun{}).
+		Where("run_id = ? AND run_status = ?", runID, from).
+		Updates(map[string]any{
+			"run_status": to,
+			"updated_at": time.Now().UTC().Unix(),
+		})
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	return tx.RowsAffected > 0, nil
+}
+
 func (s *Store) FindLiveRunningRunByPaneTarget(paneTarget string) (RunRecord, bool, error) {
 	db, release, err := s.db()
 	if err != nil {
@@ -313,10 +456,10 @@ func (s *Store) FindLiveRunningRunByPaneTarget(paneTarget string) (RunRecord, bo
 SELECT tr.run_id, tr.task_id, tr.run_status, tr.started_at, tr.completed_at, tr.updated_at, tr.last_error
 FROM task_runs tr
 JOIN run_bindings rb ON rb.run_id = tr.run_id
-WHERE rb.binding_status = ? AND tr.run_status = ? AND (rb.pane_target = ? OR rb.pane_id = ?)
+WHERE rb.binding_status = ? AND tr.run_status IN (?, ?) AND (rb.pane_target = ? OR rb.pane_id = ?)
 ORDER BY tr.updated_at DESC
 LIMIT 1
-`, BindingStatusLive, RunStatusRunning, paneTarget, paneTarget).Scan(
+`, BindingStatusLive, RunStatusRunning, RunStatusStalled, paneTarget, paneTarget).Scan(
 		&run.RunID,
 		&run.TaskID,
 		&run.RunStatus,
@@ -334,6 +477,88 @@ LIMIT 1
 	return run, true, nil
 }
 
+// ListActiveRunsByProject returns the running and stalled runs of projectID's tasks with their current binding.
+func (s *Store) ListActiveRunsByProject(projectID string) ([]ActiveRunRecord, error) {
+	return s.listRunsWithBindingByProject(projectID, RunStatusRunning, RunStatusStalled)
+}
+
+// ListRecoverableRunsByProject returns the active runs of projectID plus those waiting for a rebind, i.e. every run
+// whose pane is worth bringing back after the tmux server went away.
+func (s *Store) ListRecoverableRunsByProject(projectID string) ([]ActiveRunRecord, error) {
+	return s.listRunsWithBindingByProject(projectID, RunStatusRunning, RunStatusStalled, RunStatusNeedsRebind)
+}
+
+func (s *Store) listRunsWithBindingByProject(projectID string, statuses ...string) ([]ActiveRunRecord, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")
+	args := []any{s.repoRoot, strings.TrimSpace(projectID)}
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+	rows, err := db.Query(`
+SELECT tr.run_id, tr.task_id, tr.run_status, tr.started_at, tr.updated_at, t.active_adapter, t.current_command,
+       COALESCE(rb.server_instance_id, ''), COALESCE(rb.pane_id, ''), COALESCE(rb.pane_target, ''),
+       COALESCE(rb.tmux_server_id, ''), COALESCE(rb.pane_cwd, ''), COALESCE(rb.binding_status, '')
+FROM task_runs tr
+JOIN tasks t ON t.task_id = tr.task_id
+LEFT JOIN run_bindings rb ON rb.run_id = tr.run_id
+WHERE t.repo_root = ? AND t.project_id = ? AND tr.run_status IN (`+placeholders+`)
+ORDER BY tr.started_at ASC, tr.run_id ASC
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]ActiveRunRecord, 0)
+	for rows.Next() {
+		var item ActiveRunRecord
+		if err := rows.Scan(
+			&item.RunID,
+			&item.TaskID,
+			&item.RunStatus,
+			&item.StartedAt,
+			&item.UpdatedAt,
+			&item.ActiveAdapter,
+			&item.CurrentCommand,
+			&item.ServerInstanceID,
+			&item.PaneID,
+			&item.PaneTarget,
+			&item.TmuxServerID,
+			&item.PaneCwd,
+			&item.BindingStatus,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ObserveRunPane records the tmux server runID's pane was last seen on and the working directory it was in, so the
+// pane can be told apart from a reused id after a server restart and recreated where it was.
+func (s *Store) ObserveRunPane(runID, tmuxServerID, cwd string) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	updates := map[string]any{"tmux_server_id": strings.TrimSpace(tmuxServerID)}
+	if cwd = strings.TrimSpace(cwd); cwd != "" {
+		updates["pane_cwd"] = cwd
+	}
+	return gdb.Model(&dbmodel.RunBinding{}).Where("run_id = ?", runID).Updates(updates).Error
+}
+
 func (s *Store) ListRunCandidatesByPaneTarget(paneTarget string, limit int) ([]RunLookupCandidate, error) {
 	db, release, err := s.db()
 	if err != nil {
@@ -403,7 +628,7 @@ func (s *Store) EnqueueRunAction(runID, actionType string, payload map[string]an
 		RunID:       runID,
 		ActionType:  actionType,
 		PayloadJSON: string(raw),
-		Status:      "pending",
+		Status:      OutboxStatusPending,
 		RetryCount:  0,
 		NextRetryAt: 0,
 		CreatedAt:   now,
@@ -24,14 +24,144 @@ func TestRunStore_CreateBindMarkStaleFlow(t *testing.T) {
 	if err := st.UpsertRunBinding(RunBinding{RunID: runID, ServerInstanceID: "srvA", PaneID: "%12", PaneTarget: "botworks:1.0", BindingStatus: BindingStatusLive}); err != nil {
 		t.Fatal(err)
 	}
-	if err := st.MarkBindingsStaleByServer("srvA", "tmux_restarted"); err != nil {
+	if err := st.InsertRun(RunRecord{RunID: "r_2", TaskID: taskID, RunStatus: RunStatusRunning}); err != nil {
 		t.Fatal(err)
 	}
-	run, err := st.GetRun(runID)
+	if err := st.UpsertRunBinding(RunBinding{RunID: "r_2", ServerInstanceID: "srvA", PaneID: "%13", PaneTarget: "botworks:1.1", BindingStatus: BindingStatusLive}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.InsertRun(RunRecord{RunID: "r_done", TaskID: taskID, RunStatus: RunStatusCompleted}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpsertRunBinding(RunBinding{RunID: "r_done", ServerInstanceID: "srvA", PaneID: "%14", PaneTarget: "botworks:1.2", BindingStatus: BindingStatusLive}); err != nil {
+		t.Fatal(err)
+	}
+	runIDs, err := st.MarkBindingsStaleByServer("srvA", "tmux_restarted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runIDs) != 2 || runIDs[0] != runID || runIDs[1] != "r_2" {
+		t.Fatalf("expected both active runs reported, got %v", runIDs)
+	}
+	for _, id := range runIDs {
+		run, err := st.GetRun(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run.RunStatus != RunStatusNeedsRebind {
+			t.Fatalf("run %s: got %s", id, run.RunStatus)
+		}
+	}
+	if run, err := st.GetRun("r_done"); err != nil || run.RunStatus != RunStatusCompleted {
+		t.Fatalf("expected the finished run left alone, got %#v err=%v", run, err)
+	}
+}
+
+func TestRunStore_MarkRunFailedAndCancelRunningRuns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "shellman.db")
+	if err := InitGlobalDB(dbPath); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+
+	st := NewStore(t.TempDir())
+	if err := st.InsertTask(TaskRecord{TaskID: "t_1", ProjectID: "p1", Title: "root"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, run := range []RunRecord{
+		{RunID: "r_failed", TaskID: "t_1", RunStatus: RunStatusRunning},
+		{RunID: "r_live", TaskID: "t_1", RunStatus: RunStatusRunning},
+		{RunID: "r_rebind", TaskID: "t_1", RunStatus: RunStatusNeedsRebind},
+		{RunID: "r_done", TaskID: "t_1", RunStatus: RunStatusCompleted},
+	} {
+		if err := st.InsertRun(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.MarkRunFailed("r_failed", RunStatusCompleted, "x"); err == nil {
+		t.Fatal("expected MarkRunFailed to reject a non-failure status")
+	}
+	if err := st.MarkRunFailed("r_failed", RunStatusFailed, "exit status 2"); err != nil {
+		t.Fatal(err)
+	}
+	run, err := st.GetRun("r_failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.RunStatus != RunStatusFailed || run.LastError != "exit status 2" || run.CompletedAt == 0 {
+		t.Fatalf("unexpected failed run: %#v", run)
+	}
+
+	canceled, err := st.CancelRunningRuns("t_1", "task canceled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(canceled) != 2 {
+		t.Fatalf("expected live and needs_rebind runs canceled, got %v", canceled)
+	}
+	for runID, want := range map[string]string{"r_live": RunStatusCanceled, "r_rebind": RunStatusCanceled, "r_done": RunStatusCompleted, "r_failed": RunStatusFailed} {
+		run, err := st.GetRun(runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run.RunStatus != want {
+			t.Fatalf("run %s: expected %s, got %s", runID, want, run.RunStatus)
+		}
+	}
+}
+
+func TestRunStore_ListTaskRunsPagesNewestFirstAndKeepsBindingHistory(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "shellman.db")
+	if err := InitGlobalDB(dbPath); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+
+	st := NewStore(t.TempDir())
+	if err := st.InsertTask(TaskRecord{TaskID: "t_page", ProjectID: "p1", Title: "root"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, run := range []RunRecord{
+		{RunID: "r_page_a", TaskID: "t_page", RunStatus: RunStatusCompleted, StartedAt: 100},
+		{RunID: "r_page_b", TaskID: "t_page", RunStatus: RunStatusCompleted, StartedAt: 200},
+		{RunID: "r_page_c", TaskID: "t_page", RunStatus: RunStatusRunning, StartedAt: 200},
+	} {
+		if err := st.InsertRun(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first, err := st.ListTaskRuns("t_page", nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || first[0].RunID != "r_page_c" || first[1].RunID != "r_page_b" {
+		t.Fatalf("unexpected first page: %#v", first)
+	}
+	last := first[len(first)-1]
+	second, err := st.ListTaskRuns("t_page", &RunPageCursor{StartedAt: last.StartedAt, RunID: last.RunID}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 1 || second[0].RunID != "r_page_a" {
+		t.Fatalf("unexpected second page: %#v", second)
+	}
+
+	for _, target := range []string{"s:1.0", "s:2.0"} {
+		if err := st.UpsertRunBinding(RunBinding{RunID: "r_page_c", ServerInstanceID: "srv", PaneID: target, PaneTarget: target}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events, err := st.ListRunEvents("r_page_c", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].EventType != RunEventBindingUpdated || events[1].Payload["pane_target"] != "s:2.0" {
+		t.Fatalf("unexpected binding history: %#v", events)
+	}
+	tail, err := st.ListRunEvents("r_page_c", events[0].ID, 10)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if run.RunStatus != RunStatusNeedsRebind {
-		t.Fatalf("got %s", run.RunStatus)
+	if len(tail) != 1 || tail[0].ID != events[1].ID {
+		t.Fatalf("expected events after %d only, got %#v", events[0].ID, tail)
 	}
 }
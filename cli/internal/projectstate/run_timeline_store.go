@@ -0,0 +1,118 @@
+package projectstate
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Run event types written by the store itself.
+const (
+	RunEventBindingUpdated = "binding.updated"
+)
+
+type RunEventRecord struct {
+	ID        int64          `json:"id"`
+	RunID     string         `json:"run_id"`
+	EventType string         `json:"event_type"`
+	Payload   map[string]any `json:"payload"`
+	CreatedAt int64          `json:"created_at"`
+}
+
+// RunPageCursor marks the last run of a newest-first page; the next page starts right after it.
+type RunPageCursor struct {
+	StartedAt int64
+	RunID     string
+}
+
+// ListTaskRuns returns up to limit runs of taskID, newest first, starting after the run after points at (nil for
+// the first page).
+func (s *Store) ListTaskRuns(taskID string, after *RunPageCursor, limit int) ([]RunRecord, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	query := `
+SELECT run_id, task_id, run_status, started_at, completed_at, updated_at, last_error, artifact_dir
+FROM task_runs
+WHERE task_id = ?`
+	args := []any{strings.TrimSpace(taskID)}
+	if after != nil {
+		query += ` AND (started_at < ? OR (started_at = ? AND run_id < ?))`
+		args = append(args, after.StartedAt, after.StartedAt, after.RunID)
+	}
+	query += `
+ORDER BY started_at DESC, run_id DESC
+LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]RunRecord, 0, limit)
+	for rows.Next() {
+		var run RunRecord
+		if err := rows.Scan(&run.RunID, &run.TaskID, &run.RunStatus, &run.StartedAt, &run.CompletedAt, &run.UpdatedAt, &run.LastError, &run.ArtifactDir); err != nil {
+			return nil, err
+		}
+		out = append(out, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListRunEvents returns the events of runID in the order they were appended, after event id afterID. A limit <= 0
+// returns them all.
+func (s *Store) ListRunEvents(runID string, afterID int64, limit int) ([]RunEventRecord, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	query := `
+SELECT id, run_id, event_type, payload_json, created_at
+FROM run_events
+WHERE run_id = ? AND id > ?
+ORDER BY id ASC`
+	args := []any{strings.TrimSpace(runID), afterID}
+	if limit > 0 {
+		query += `
+LIMIT ?`
+		args = append(args, limit)
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]RunEventRecord, 0)
+	for rows.Next() {
+		var (
+			item    RunEventRecord
+			payload string
+		)
+		if err := rows.Scan(&item.ID, &item.RunID, &item.EventType, &payload, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		item.Payload = map[string]any{}
+		if strings.TrimSpace(payload) != "" {
+			_ = json.Unmarshal([]byte(payload), &item.Payload)
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -6,6 +6,9 @@ const (
 	RunStatusRunning     = "running"
 	RunStatusNeedsRebind = "needs_rebind"
 	RunStatusCompleted   = "completed"
+	RunStatusFailed      = "failed"
+	RunStatusCanceled    = "canceled"
+	RunStatusStalled     = "stalled"
 )
 
 const (
@@ -13,6 +16,19 @@ const (
 	BindingStatusStale = "stale"
 )
 
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusRunning = "running"
+	OutboxStatusDone    = "done"
+	OutboxStatusDead    = "dead"
+)
+
+// IsRunActive reports whether a run in status is still going. A stalled run is active: it has only gone quiet and
+// can still finish or resume.
+func IsRunActive(status string) bool {
+	return status == RunStatusRunning || status == RunStatusStalled
+}
+
 var ErrDuplicateInboxRequest = errors.New("duplicate inbox request")
 
 type TaskRecord struct {
@@ -52,6 +68,7 @@ type TaskRecordRow struct {
 	Archived       bool
 	CreatedAt      int64
 	LastModified   int64
+	SortOrder      int64
 }
 
 type TaskMetaUpsert struct {
@@ -71,6 +88,8 @@ type TaskMetaUpsert struct {
 	Checked        *bool
 	Archived       *bool
 	LastModified   int64
+	// Source is recorded on the task_revisions rows written for changed fields; empty means TaskRevisionSourceSystem.
+	Source string
 }
 
 type PaneRuntimeRecord struct {
@@ -108,6 +127,24 @@ type RunRecord struct {
 	CompletedAt int64
 	UpdatedAt   int64
 	LastError   string
+	ArtifactDir string
+}
+
+// ActiveRunRecord is an active run of a project together with its pane binding and the task's adapter.
+type ActiveRunRecord struct {
+	RunID            string
+	TaskID           string
+	RunStatus        string
+	StartedAt        int64
+	UpdatedAt        int64
+	ActiveAdapter    string
+	CurrentCommand   string
+	ServerInstanceID string
+	PaneID           string
+	PaneTarget       string
+	TmuxServerID     string
+	PaneCwd          string
+	BindingStatus    string
 }
 
 type RunBinding struct {
@@ -131,3 +168,16 @@ type RunLookupCandidate struct {
 	RunUpdatedAt     int64
 	BindingUpdatedAt int64
 }
+
+type RunActionRecord struct {
+	ID          int64  `json:"id"`
+	RunID       string `json:"run_id"`
+	ActionType  string `json:"action_type"`
+	PayloadJSON string `json:"payload_json"`
+	Status      string `json:"status"`
+	RetryCount  int    `json:"retry_count"`
+	NextRetryAt int64  `json:"next_retry_at"`
+	LastError   string `json:"last_error,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
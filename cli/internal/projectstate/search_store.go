@@ -0,0 +1,123 @@
+package projectstate
+
+import (
+	"errors"
+	"html"
+	"strings"
+
+	dbmodel "shellman/cli/internal/db"
+)
+
+var ErrInvalidSearchQuery = errors.New("search query is empty")
+
+// FTS5 wraps matches in these control characters; they are turned into <mark> tags only after the indexed text has
+// been HTML-escaped, so user content can never inject markup into a snippet.
+const (
+	searchSnippetOpen  = "\x02"
+	searchSnippetClose = "\x03"
+)
+
+var searchSnippetReplacer = strings.NewReplacer(searchSnippetOpen, "<mark>", searchSnippetClose, "</mark>")
+
+var searchKinds = map[string]struct{}{
+	dbmodel.SearchKindTask:        {},
+	dbmodel.SearchKindNote:        {},
+	dbmodel.SearchKindTaskMessage: {},
+	dbmodel.SearchKindPMMessage:   {},
+}
+
+// SearchHit is one ranked match. Snippet is HTML-escaped text with each match wrapped in <mark></mark>, so clients
+// can render it as HTML.
+type SearchHit struct {
+	Kind      string  `json:"kind"`
+	RefID     string  `json:"ref_id"`
+	ProjectID string  `json:"project_id"`
+	TaskID    string  `json:"task_id,omitempty"`
+	TaskTitle string  `json:"task_title,omitempty"`
+	SessionID string  `json:"session_id,omitempty"`
+	Archived  bool    `json:"archived,omitempty"`
+	Snippet   string  `json:"snippet"`
+	Score     float64 `json:"score"`
+}
+
+// IsSearchKind reports whether kind names an indexed source.
+func IsSearchKind(kind string) bool {
+	_, ok := searchKinds[strings.TrimSpace(kind)]
+	return ok
+}
+
+// BuildSearchMatchQuery turns free text into an FTS5 query: every term is quoted and prefix-matched, terms are ANDed.
+func BuildSearchMatchQuery(raw string) string {
+	terms := strings.Fields(raw)
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.ReplaceAll(term, `"`, `""`)
+		parts = append(parts, `"`+term+`"*`)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Search returns ranked hits for query within projectID, best match first. An empty kinds slice searches every source.
+func (s *Store) Search(projectID, query string, kinds []string, limit int) ([]SearchHit, error) {
+	match := BuildSearchMatchQuery(query)
+	if match == "" {
+		return nil, ErrInvalidSearchQuery
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	args := []any{searchSnippetOpen, searchSnippetClose, match, s.repoRoot, projectID, s.repoRoot, projectID}
+	kindFilter := ""
+	if len(kinds) > 0 {
+		placeholders := make([]string, 0, len(kinds))
+		for _, kind := range kinds {
+			placeholders = append(placeholders, "?")
+			args = append(args, kind)
+		}
+		kindFilter = " AND si.kind IN (" + strings.Join(placeholders, ",") + ")"
+	}
+	args = append(args, limit)
+
+	rows, err := db.Query(`
+SELECT si.kind, si.ref_id, si.task_id, si.session_id,
+       COALESCE(t.project_id, ps.project_id, ''), COALESCE(t.title, ''), COALESCE(t.archived, 0),
+       snippet(search_index, -1, ?, ?, '…', 16),
+       -bm25(search_index, 0, 0, 0, 0, 10.0, 1.0) AS score
+FROM search_index si
+LEFT JOIN tasks t ON si.task_id <> '' AND t.task_id = si.task_id
+LEFT JOIN pm_sessions ps ON si.session_id <> '' AND ps.session_id = si.session_id
+WHERE search_index MATCH ?
+  AND ((t.task_id IS NOT NULL AND t.repo_root = ? AND t.project_id = ?)
+    OR (ps.session_id IS NOT NULL AND ps.repo_root = ? AND ps.project_id = ?))`+kindFilter+`
+ORDER BY score DESC
+LIMIT ?
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]SearchHit, 0)
+	for rows.Next() {
+		var item SearchHit
+		if err := rows.Scan(
+			&item.Kind, &item.RefID, &item.TaskID, &item.SessionID,
+			&item.ProjectID, &item.TaskTitle, &item.Archived,
+			&item.Snippet, &item.Score,
+		); err != nil {
+			return nil, err
+		}
+		item.Snippet = searchSnippetReplacer.Replace(html.EscapeString(item.Snippet))
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package projectstate
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoreSearch_IndexesWritersAndScopesByProject(t *testing.T) {
+	if err := InitGlobalDB(filepath.Join(t.TempDir(), "shellman.db")); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+	st := NewStore(t.TempDir())
+	other := NewStore(t.TempDir())
+
+	if err := st.InsertTask(TaskRecord{TaskID: "t_mig", ProjectID: "p1", Title: "Fix migration bug", Description: "schema drift"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.InsertTask(TaskRecord{TaskID: "t_ui", ProjectID: "p1", Title: "Polish header"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.InsertTask(TaskRecord{TaskID: "t_other", ProjectID: "p2", Title: "Another migration"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.InsertTaskNote("t_ui", "the migration runner needs a lock", ""); err != nil {
+		t.Fatal(err)
+	}
+	msgID, err := st.InsertTaskMessage("t_ui", "assistant", "", "running", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateTaskMessage(msgID, "rolled back the migrations table", "completed", ""); err != nil {
+		t.Fatal(err)
+	}
+	sessionID, err := st.CreatePMSession("p1", "planning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.InsertPMMessage(sessionID, "user", "which migration broke prod?", "completed", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := st.Search("p1", "migration", nil, 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	kinds := map[string]SearchHit{}
+	for _, hit := range hits {
+		if hit.ProjectID != "p1" {
+			t.Fatalf("expected hits scoped to p1, got %#v", hit)
+		}
+		kinds[hit.Kind] = hit
+	}
+	if len(hits) != 4 || len(kinds) != 4 {
+		t.Fatalf("expected one hit per source, got %#v", hits)
+	}
+	if hits[0].Kind != "task" || hits[0].TaskID != "t_mig" {
+		t.Fatalf("expected title match ranked first, got %#v", hits[0])
+	}
+	if !strings.Contains(kinds["task_message"].Snippet, "<mark>migrations</mark>") {
+		t.Fatalf("expected updated message content highlighted, got %q", kinds["task_message"].Snippet)
+	}
+	if err := st.InsertTaskNote("t_ui", `<img src=x onerror=alert(1)>`, ""); err != nil {
+		t.Fatal(err)
+	}
+	escaped, err := st.Search("p1", "onerror", []string{"note"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(escaped) != 1 || escaped[0].Snippet != "&lt;img src=x <mark>onerror</mark>=alert(1)&gt;" {
+		t.Fatalf("expected escaped snippet with only mark tags, got %#v", escaped)
+	}
+	if kinds["note"].TaskTitle != "Polish header" || kinds["pm_message"].SessionID != sessionID {
+		t.Fatalf("unexpected hit metadata: %#v", kinds)
+	}
+
+	notesOnly, err := st.Search("p1", "migration", []string{"note"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notesOnly) != 1 || notesOnly[0].Kind != "note" {
+		t.Fatalf("expected kind filter to keep only notes, got %#v", notesOnly)
+	}
+
+	title := "Fix locking bug"
+	if err := st.UpsertTaskMeta(TaskMetaUpsert{TaskID: "t_mig", ProjectID: "p1", Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	hits, err = st.Search("p1", "lock", []string{"task"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].TaskID != "t_mig" {
+		t.Fatalf("expected renamed task to be found by prefix, got %#v", hits)
+	}
+	if err := st.DeleteTask("t_mig"); err != nil {
+		t.Fatal(err)
+	}
+	hits, err = st.Search("p1", "lock", []string{"task"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 0 {
+		t.Fatalf("expected deleted task removed from index, got %#v", hits)
+	}
+
+	if _, err := st.Search("p1", "  ", nil, 10); !errors.Is(err, ErrInvalidSearchQuery) {
+		t.Fatalf("expected ErrInvalidSearchQuery, got %v", err)
+	}
+	if _, err := st.Search("p1", `bad "quote AND (`, nil, 10); err != nil {
+		t.Fatalf("expected raw syntax to be escaped, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package projectstate
+
+import (
+	"sort"
+	"strings"
+
+	dbmodel "shellman/cli/internal/db"
+
+	"gorm.io/gorm"
+)
+
+type ArchivedTaskPage struct {
+	Tasks  []TaskRecordRow
+	Total  int64
+	Limit  int
+	Offset int
+}
+
+// ListArchivedTasks pages through archived tasks of projectID, most recently modified first.
+func (s *Store) ListArchivedTasks(projectID string, limit, offset int) (ArchivedTaskPage, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	page := ArchivedTaskPage{Tasks: []TaskRecordRow{}, Limit: limit, Offset: offset}
+	rows, err := s.ListTasksByProjectWithArchived(projectID, true)
+	if err != nil {
+		return page, err
+	}
+	archived := make([]TaskRecordRow, 0)
+	for _, row := range rows {
+		if row.Archived {
+			archived = append(archived, row)
+		}
+	}
+	sort.SliceStable(archived, func(i, j int) bool {
+		if archived[i].LastModified != archived[j].LastModified {
+			return archived[i].LastModified > archived[j].LastModified
+		}
+		return archived[i].TaskID < archived[j].TaskID
+	})
+	page.Total = int64(len(archived))
+	if offset >= len(archived) {
+		return page, nil
+	}
+	end := offset + limit
+	if end > len(archived) {
+		end = len(archived)
+	}
+	page.Tasks = append(page.Tasks, archived[offset:end]...)
+	return page, nil
+}
+
+// ArchivePurgeResult is what PurgeArchivedTasks removed.
+type ArchivePurgeResult struct {
+	TaskIDs []string
+	// RunArtifactDirs are the artifact bundles of the purged runs; removing the files is up to the caller.
+	RunArtifactDirs []string
+}
+
+// PurgeArchivedTasks permanently deletes archived tasks of projectID last modified before cutoff, together with
+// their notes, messages, runs, run events and pane runtime rows. A task whose children are not all purged is kept
+// so the tree never gains orphans.
+func (s *Store) PurgeArchivedTasks(projectID string, cutoff int64) (ArchivePurgeResult, error) {
+	result := ArchivePurgeResult{TaskIDs: []string{}}
+	rows, err := s.ListTasksByProjectWithArchived(projectID, true)
+	if err != nil {
+		return ArchivePurgeResult{}, err
+	}
+	candidates := map[string]struct{}{}
+	for _, row := range rows {
+		if row.Archived && row.LastModified < cutoff {
+			candidates[row.TaskID] = struct{}{}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, row := range rows {
+			parentID := strings.TrimSpace(row.ParentTaskID)
+			if _, childPurged := candidates[row.TaskID]; childPurged || parentID == "" {
+				continue
+			}
+			if _, ok := candidates[parentID]; ok {
+				delete(candidates, parentID)
+				changed = true
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return result, nil
+	}
+	taskIDs := make([]string, 0, len(candidates))
+	for _, row := range rows {
+		if _, ok := candidates[row.TaskID]; ok {
+			taskIDs = append(taskIDs, row.TaskID)
+		}
+	}
+
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return ArchivePurgeResult{}, err
+	}
+	defer func() { _ = release() }()
+
+	err = gdb.Transaction(func(tx *gorm.DB) error {
+		runIDs := tx.Model(&dbmodel.TaskRun{}).Select("run_id").Where("task_id IN ?", taskIDs)
+		if err := tx.Model(&dbmodel.TaskRun{}).Where("task_id IN ? AND artifact_dir <> ''", taskIDs).
+			Pluck("artifact_dir", &result.RunArtifactDirs).Error; err != nil {
+			return err
+		}
+		otherRunIDs := tx.Model(&dbmodel.TaskRun{}).Select("run_id").Where("task_id NOT IN ?", taskIDs)
+		purgedPanes := tx.Model(&dbmodel.RunBinding{}).Select("pane_id").Where("run_id IN (?) AND pane_id <> ''", runIDs)
+		livePanes := tx.Model(&dbmodel.RunBinding{}).Select("pane_id").Where("run_id IN (?)", otherRunIDs)
+		if err := tx.Where("pane_id IN (?) AND pane_id NOT IN (?)", purgedPanes, livePanes).Delete(&dbmodel.PaneRuntime{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []any{&dbmodel.RunEvent{}, &dbmodel.RunBinding{}, &dbmodel.CompletionInbox{}, &dbmodel.ActionOutbox{}} {
+			if err := tx.Where("run_id IN (?)", runIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		for _, model := range []any{&dbmodel.TaskRun{}, &dbmodel.Note{}, &dbmodel.TaskMessage{}, &dbmodel.TaskRuntime{}} {
+			if err := tx.Where("task_id IN ?", taskIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("repo_root = ? AND (task_id IN ? OR blocker_task_id IN ?)", s.repoRoot, taskIDs, taskIDs).
+			Delete(&dbmodel.TaskDependency{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []any{&dbmodel.TaskLabel{}, &dbmodel.TaskRevision{}, &dbmodel.TaskFlagEscalation{}, &dbmodel.Task{}} {
+			if err := tx.Where("repo_root = ? AND task_id IN ?", s.repoRoot, taskIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return ArchivePurgeResult{}, err
+	}
+
+	panes, err := s.LoadPanes()
+	if err != nil {
+		return ArchivePurgeResult{}, err
+	}
+	dirty := false
+	for _, taskID := range taskIDs {
+		if _, ok := panes[taskID]; ok {
+			delete(panes, taskID)
+			dirty = true
+		}
+	}
+	if dirty {
+		if err := s.SavePanes(panes); err != nil {
+			return ArchivePurgeResult{}, err
+		}
+	}
+	result.TaskIDs = taskIDs
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package projectstate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func countRows(t *testing.T, st *Store, query string, args ...any) int {
+	t.Helper()
+	db, release, err := st.db()
+	if err != nil {
+		t.Fatalf("open db failed: %v", err)
+	}
+	defer func() { _ = release() }()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("count query failed: %v", err)
+	}
+	return n
+}
+
+func TestTaskArchiveStore_ListAndPurge(t *testing.T) {
+	st := newTaskStateStore(t)
+	archived := true
+	for _, task := range []TaskRecord{
+		{TaskID: "t_old", ProjectID: "p1", Title: "old", Status: StatusCompleted},
+		{TaskID: "t_parent", ProjectID: "p1", Title: "parent", Status: StatusCompleted},
+		{TaskID: "t_live_child", ProjectID: "p1", ParentTaskID: "t_parent", Title: "live", Status: StatusRunning},
+		{TaskID: "t_recent", ProjectID: "p1", Title: "recent", Status: StatusCompleted},
+	} {
+		if err := st.InsertTask(task); err != nil {
+			t.Fatalf("InsertTask %s failed: %v", task.TaskID, err)
+		}
+	}
+	for taskID, modified := range map[string]int64{"t_old": 100, "t_parent": 100, "t_recent": 5000} {
+		if err := st.UpsertTaskMeta(TaskMetaUpsert{TaskID: taskID, ProjectID: "p1", Archived: &archived, LastModified: modified}); err != nil {
+			t.Fatalf("archive %s failed: %v", taskID, err)
+		}
+	}
+
+	page, err := st.ListArchivedTasks("p1", 2, 0)
+	if err != nil {
+		t.Fatalf("ListArchivedTasks failed: %v", err)
+	}
+	if page.Total != 3 || len(page.Tasks) != 2 || page.Tasks[0].TaskID != "t_recent" {
+		t.Fatalf("unexpected first page: %#v", page)
+	}
+	page, err = st.ListArchivedTasks("p1", 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 1 {
+		t.Fatalf("unexpected second page: %#v", page)
+	}
+
+	if err := st.InsertRun(RunRecord{RunID: "r_old", TaskID: "t_old"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetRunArtifactDir("r_old", "/artifacts/r_old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpsertRunBinding(RunBinding{RunID: "r_old", PaneID: "%9", PaneTarget: "s:1.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AppendRunEvent("r_old", "run.started", map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.InsertTaskNote("t_old", "note", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.InsertTaskMessage("t_old", "user", "hello", "completed", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.BatchUpsertRuntime(RuntimeBatchUpdate{Panes: []PaneRuntimeRecord{{PaneID: "%9", SnapshotHash: "h"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := st.PurgeArchivedTasks("p1", 1000)
+	if err != nil {
+		t.Fatalf("PurgeArchivedTasks failed: %v", err)
+	}
+	if !reflect.DeepEqual(purged.TaskIDs, []string{"t_old"}) {
+		t.Fatalf("expected only t_old purged (parent keeps a live child), got %#v", purged)
+	}
+	if !reflect.DeepEqual(purged.RunArtifactDirs, []string{"/artifacts/r_old"}) {
+		t.Fatalf("expected the artifact dir of r_old reported, got %#v", purged.RunArtifactDirs)
+	}
+	for _, table := range []string{"task_runs", "notes", "task_messages"} {
+		if n := countRows(t, st, "SELECT COUNT(1) FROM "+table+" WHERE task_id = ?", "t_old"); n != 0 {
+			t.Fatalf("expected %s rows purged, got %d", table, n)
+		}
+	}
+	if n := countRows(t, st, "SELECT COUNT(1) FROM run_bindings WHERE run_id = ?", "r_old"); n != 0 {
+		t.Fatalf("expected run_bindings purged, got %d", n)
+	}
+	if n := countRows(t, st, "SELECT COUNT(1) FROM run_events WHERE run_id = ?", "r_old"); n != 0 {
+		t.Fatalf("expected run_events purged, got %d", n)
+	}
+	if n := countRows(t, st, "SELECT COUNT(1) FROM pane_runtime WHERE pane_id = ?", "%9"); n != 0 {
+		t.Fatalf("expected pane_runtime purged, got %d", n)
+	}
+	if n := countRows(t, st, "SELECT COUNT(1) FROM tasks WHERE task_id IN ('t_parent','t_recent','t_live_child')"); n != 3 {
+		t.Fatalf("expected remaining tasks kept, got %d", n)
+	}
+}
@@ -0,0 +1,148 @@
+package projectstate
+
+import (
+	"errors"
+	dbmodel "shellman/cli/internal/db"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTaskDependencySelf  = errors.New("task cannot depend on itself")
+	ErrTaskDependencyCycle = errors.New("task dependency would create a cycle")
+)
+
+type TaskDependencyRecord struct {
+	TaskID        string `json:"task_id"`
+	BlockerTaskID string `json:"blocker_task_id"`
+	ProjectID     string `json:"project_id"`
+	AutoProgress  bool   `json:"auto_progress,omitempty"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
+// AddTaskDependency records that taskID is blocked by blockerTaskID. Re-adding an existing edge updates AutoProgress.
+func (s *Store) AddTaskDependency(projectID, taskID, blockerTaskID string, autoProgress bool) error {
+	taskID = strings.TrimSpace(taskID)
+	blockerTaskID = strings.TrimSpace(blockerTaskID)
+	if taskID == "" || blockerTaskID == "" {
+		return errors.New("task id and blocker task id are required")
+	}
+	if taskID == blockerTaskID {
+		return ErrTaskDependencySelf
+	}
+
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		var edges []dbmodel.TaskDependency
+		if err := tx.Where("repo_root = ? AND project_id = ?", s.repoRoot, projectID).Find(&edges).Error; err != nil {
+			return err
+		}
+		blockersOf := make(map[string][]string, len(edges))
+		for _, edge := range edges {
+			blockersOf[edge.TaskID] = append(blockersOf[edge.TaskID], edge.BlockerTaskID)
+		}
+		if dependencyPathExists(blockersOf, blockerTaskID, taskID) {
+			return ErrTaskDependencyCycle
+		}
+		row := dbmodel.TaskDependency{
+			TaskID:        taskID,
+			BlockerTaskID: blockerTaskID,
+			RepoRoot:      s.repoRoot,
+			ProjectID:     projectID,
+			AutoProgress:  autoProgress,
+			CreatedAt:     time.Now().UTC().Unix(),
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "task_id"}, {Name: "blocker_task_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"auto_progress"}),
+		}).Create(&row).Error
+	})
+}
+
+// dependencyPathExists reports whether to is reachable from from by following blocked-by edges.
+func dependencyPathExists(blockersOf map[string][]string, from, to string) bool {
+	seen := map[string]struct{}{}
+	stack := []string{from}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == to {
+			return true
+		}
+		if _, ok := seen[current]; ok {
+			continue
+		}
+		seen[current] = struct{}{}
+		stack = append(stack, blockersOf[current]...)
+	}
+	return false
+}
+
+func (s *Store) RemoveTaskDependency(taskID, blockerTaskID string) (bool, error) {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = release() }()
+
+	tx := gdb.Where("repo_root = ? AND task_id = ? AND blocker_task_id = ?", s.repoRoot, strings.TrimSpace(taskID), strings.TrimSpace(blockerTaskID)).
+		Delete(&dbmodel.TaskDependency{})
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+func (s *Store) ListTaskDependenciesByProject(projectID string) ([]TaskDependencyRecord, error) {
+	return s.listTaskDependencies(`repo_root = ? AND project_id = ?`, s.repoRoot, projectID)
+}
+
+// ListTaskBlockers returns the edges where taskID waits on another task.
+func (s *Store) ListTaskBlockers(taskID string) ([]TaskDependencyRecord, error) {
+	return s.listTaskDependencies(`repo_root = ? AND task_id = ?`, s.repoRoot, strings.TrimSpace(taskID))
+}
+
+// ListTaskDependents returns the edges where other tasks wait on blockerTaskID.
+func (s *Store) ListTaskDependents(blockerTaskID string) ([]TaskDependencyRecord, error) {
+	return s.listTaskDependencies(`repo_root = ? AND blocker_task_id = ?`, s.repoRoot, strings.TrimSpace(blockerTaskID))
+}
+
+func (s *Store) listTaskDependencies(where string, args ...any) ([]TaskDependencyRecord, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	rows, err := db.Query(`
+SELECT task_id, blocker_task_id, project_id, auto_progress, created_at
+FROM task_dependencies
+WHERE `+where+`
+ORDER BY created_at ASC, task_id ASC, blocker_task_id ASC
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]TaskDependencyRecord, 0)
+	for rows.Next() {
+		var item TaskDependencyRecord
+		if err := rows.Scan(&item.TaskID, &item.BlockerTaskID, &item.ProjectID, &item.AutoProgress, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
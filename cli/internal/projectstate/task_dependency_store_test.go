@@ -0,0 +1,100 @@
+package projectstate
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestTaskDependencyStore_AddListRemove(t *testing.T) {
+	if err := InitGlobalDB(filepath.Join(t.TempDir(), "shellman.db")); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+	st := NewStore(t.TempDir())
+
+	if err := st.AddTaskDependency("p1", "t_b", "t_a", false); err != nil {
+		t.Fatalf("AddTaskDependency failed: %v", err)
+	}
+	if err := st.AddTaskDependency("p1", "t_b", "t_a", true); err != nil {
+		t.Fatalf("re-adding dependency failed: %v", err)
+	}
+	blockers, err := st.ListTaskBlockers("t_b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blockers) != 1 || blockers[0].BlockerTaskID != "t_a" || !blockers[0].AutoProgress {
+		t.Fatalf("unexpected blockers: %#v", blockers)
+	}
+	dependents, err := st.ListTaskDependents("t_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dependents) != 1 || dependents[0].TaskID != "t_b" {
+		t.Fatalf("unexpected dependents: %#v", dependents)
+	}
+
+	removed, err := st.RemoveTaskDependency("t_b", "t_a")
+	if err != nil || !removed {
+		t.Fatalf("RemoveTaskDependency: removed=%v err=%v", removed, err)
+	}
+	removed, err = st.RemoveTaskDependency("t_b", "t_a")
+	if err != nil || removed {
+		t.Fatalf("expected second remove to report missing edge: removed=%v err=%v", removed, err)
+	}
+	all, err := st.ListTaskDependenciesByProject("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 0 {
+		t.Fatalf("expected no edges left, got %#v", all)
+	}
+}
+
+func TestTaskDependencyStore_RejectsSelfAndCycles(t *testing.T) {
+	if err := InitGlobalDB(filepath.Join(t.TempDir(), "shellman.db")); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+	st := NewStore(t.TempDir())
+
+	if err := st.AddTaskDependency("p1", "t_a", "t_a", false); !errors.Is(err, ErrTaskDependencySelf) {
+		t.Fatalf("expected ErrTaskDependencySelf, got %v", err)
+	}
+	if err := st.AddTaskDependency("p1", "t_b", "t_a", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddTaskDependency("p1", "t_c", "t_b", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddTaskDependency("p1", "t_a", "t_b", false); !errors.Is(err, ErrTaskDependencyCycle) {
+		t.Fatalf("expected direct cycle rejected, got %v", err)
+	}
+	if err := st.AddTaskDependency("p1", "t_a", "t_c", false); !errors.Is(err, ErrTaskDependencyCycle) {
+		t.Fatalf("expected transitive cycle rejected, got %v", err)
+	}
+	if err := st.AddTaskDependency("p1", "t_c", "t_a", false); err != nil {
+		t.Fatalf("expected redundant non-cyclic edge accepted, got %v", err)
+	}
+}
+
+func TestTaskDependencyStore_DeleteTaskDropsEdges(t *testing.T) {
+	if err := InitGlobalDB(filepath.Join(t.TempDir(), "shellman.db")); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+	st := NewStore(t.TempDir())
+	if err := st.InsertTask(TaskRecord{TaskID: "t_a", ProjectID: "p1", Title: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddTaskDependency("p1", "t_b", "t_a", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.DeleteTask("t_a"); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	blockers, err := st.ListTaskBlockers("t_b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blockers) != 0 {
+		t.Fatalf("expected edges of deleted task removed, got %#v", blockers)
+	}
+}
@@ -0,0 +1,130 @@
+package projectstate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	dbmodel "shellman/cli/internal/db"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MaxTaskLabels      = 32
+	MaxTaskLabelLength = 64
+)
+
+var ErrInvalidTaskLabel = errors.New("invalid task label")
+
+// NormalizeTaskLabels trims, lower-cases, de-duplicates and sorts labels; it rejects empty, oversized or
+// comma-containing labels.
+func NormalizeTaskLabels(labels []string) ([]string, error) {
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(labels))
+	for _, raw := range labels {
+		label := strings.ToLower(strings.TrimSpace(raw))
+		if label == "" {
+			return nil, fmt.Errorf("%w: label is empty", ErrInvalidTaskLabel)
+		}
+		if len(label) > MaxTaskLabelLength {
+			return nil, fmt.Errorf("%w: %q is longer than %d bytes", ErrInvalidTaskLabel, label, MaxTaskLabelLength)
+		}
+		if strings.Contains(label, ",") {
+			return nil, fmt.Errorf("%w: %q contains a comma", ErrInvalidTaskLabel, label)
+		}
+		if _, ok := seen[label]; ok {
+			continue
+		}
+		seen[label] = struct{}{}
+		out = append(out, label)
+	}
+	if len(out) > MaxTaskLabels {
+		return nil, fmt.Errorf("%w: at most %d labels per task", ErrInvalidTaskLabel, MaxTaskLabels)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// SetTaskLabels replaces the labels of taskID with labels.
+func (s *Store) SetTaskLabels(projectID, taskID string, labels []string) ([]string, error) {
+	normalized, err := NormalizeTaskLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	now := time.Now().UTC().Unix()
+	err = gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo_root = ? AND task_id = ?", s.repoRoot, taskID).Delete(&dbmodel.TaskLabel{}).Error; err != nil {
+			return err
+		}
+		if len(normalized) == 0 {
+			return nil
+		}
+		rows := make([]dbmodel.TaskLabel, 0, len(normalized))
+		for _, label := range normalized {
+			rows = append(rows, dbmodel.TaskLabel{
+				TaskID:    taskID,
+				Label:     label,
+				RepoRoot:  s.repoRoot,
+				ProjectID: projectID,
+				CreatedAt: now,
+			})
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func (s *Store) ListTaskLabels(taskID string) ([]string, error) {
+	byTask, err := s.listTaskLabels(`repo_root = ? AND task_id = ?`, s.repoRoot, strings.TrimSpace(taskID))
+	if err != nil {
+		return nil, err
+	}
+	if labels := byTask[strings.TrimSpace(taskID)]; labels != nil {
+		return labels, nil
+	}
+	return []string{}, nil
+}
+
+// ListTaskLabelsByProject returns labels keyed by task id.
+func (s *Store) ListTaskLabelsByProject(projectID string) (map[string][]string, error) {
+	return s.listTaskLabels(`repo_root = ? AND project_id = ?`, s.repoRoot, projectID)
+}
+
+func (s *Store) listTaskLabels(where string, args ...any) (map[string][]string, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	rows, err := db.Query(`SELECT task_id, label FROM task_labels WHERE `+where+` ORDER BY task_id ASC, label ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := map[string][]string{}
+	for rows.Next() {
+		var taskID, label string
+		if err := rows.Scan(&taskID, &label); err != nil {
+			return nil, err
+		}
+		out[taskID] = append(out[taskID], label)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,47 @@
+package projectstate
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTaskLabelStore_SetAndList(t *testing.T) {
+	if err := InitGlobalDB(filepath.Join(t.TempDir(), "shellman.db")); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+	st := NewStore(t.TempDir())
+
+	labels, err := st.SetTaskLabels("p1", "t_1", []string{" backend", "db", "backend"})
+	if err != nil {
+		t.Fatalf("SetTaskLabels failed: %v", err)
+	}
+	if !reflect.DeepEqual(labels, []string{"backend", "db"}) {
+		t.Fatalf("expected normalized labels, got %#v", labels)
+	}
+	if _, err := st.SetTaskLabels("p1", "t_2", []string{"ui"}); err != nil {
+		t.Fatal(err)
+	}
+	byTask, err := st.ListTaskLabelsByProject("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byTask) != 2 || !reflect.DeepEqual(byTask["t_2"], []string{"ui"}) {
+		t.Fatalf("unexpected project labels: %#v", byTask)
+	}
+
+	if _, err := st.SetTaskLabels("p1", "t_1", []string{"a,b"}); !errors.Is(err, ErrInvalidTaskLabel) {
+		t.Fatalf("expected ErrInvalidTaskLabel, got %v", err)
+	}
+	if _, err := st.SetTaskLabels("p1", "t_1", nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := st.ListTaskLabels("t_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected labels cleared, got %#v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package projectstate
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	dbmodel "shellman/cli/internal/db"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTaskMoveNotFound = errors.New("task not found")
+	ErrTaskMoveCycle    = errors.New("task cannot be moved under itself or its descendants")
+)
+
+// MoveTask reparents taskID under newParentTaskID (empty for root) at position among its visible siblings.
+// A negative or out-of-range position appends. Sibling sort orders are renumbered; it returns the previous parent.
+func (s *Store) MoveTask(projectID, taskID, newParentTaskID string, position int) (string, error) {
+	taskID = strings.TrimSpace(taskID)
+	newParentTaskID = strings.TrimSpace(newParentTaskID)
+	if taskID == newParentTaskID {
+		return "", ErrTaskMoveCycle
+	}
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = release() }()
+
+	oldParentTaskID := ""
+	err = gdb.Transaction(func(tx *gorm.DB) error {
+		var rows []dbmodel.Task
+		if err := tx.Select("task_id", "parent_task_id", "archived", "created_at", "sort_order").
+			Where("repo_root = ? AND project_id = ?", s.repoRoot, projectID).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		byID := make(map[string]dbmodel.Task, len(rows))
+		for _, row := range rows {
+			byID[row.TaskID] = row
+		}
+		moved, ok := byID[taskID]
+		if !ok {
+			return ErrTaskMoveNotFound
+		}
+		oldParentTaskID = strings.TrimSpace(moved.ParentTaskID)
+		if newParentTaskID != "" {
+			if _, ok := byID[newParentTaskID]; !ok {
+				return ErrTaskMoveNotFound
+			}
+			seen := map[string]struct{}{}
+			for current := newParentTaskID; current != ""; current = strings.TrimSpace(byID[current].ParentTaskID) {
+				if current == taskID {
+					return ErrTaskMoveCycle
+				}
+				if _, loop := seen[current]; loop {
+					break
+				}
+				seen[current] = struct{}{}
+			}
+		}
+
+		siblings := make([]dbmodel.Task, 0)
+		for _, row := range rows {
+			if row.TaskID == taskID || row.Archived || strings.TrimSpace(row.ParentTaskID) != newParentTaskID {
+				continue
+			}
+			siblings = append(siblings, row)
+		}
+		sort.SliceStable(siblings, func(i, j int) bool {
+			if siblings[i].SortOrder != siblings[j].SortOrder {
+				return siblings[i].SortOrder < siblings[j].SortOrder
+			}
+			if siblings[i].CreatedAt != siblings[j].CreatedAt {
+				return siblings[i].CreatedAt < siblings[j].CreatedAt
+			}
+			return siblings[i].TaskID < siblings[j].TaskID
+		})
+		if position < 0 || position > len(siblings) {
+			position = len(siblings)
+		}
+		ordered := make([]string, 0, len(siblings)+1)
+		for idx, row := range siblings {
+			if idx == position {
+				ordered = append(ordered, taskID)
+			}
+			ordered = append(ordered, row.TaskID)
+		}
+		if position == len(siblings) {
+			ordered = append(ordered, taskID)
+		}
+
+		now := time.Now().UTC().Unix()
+		for idx, id := range ordered {
+			updates := map[string]any{"sort_order": int64(idx + 1)}
+			if id == taskID {
+				updates["parent_task_id"] = newParentTaskID
+				updates["last_modified"] = now
+			} else if byID[id].SortOrder == int64(idx+1) {
+				continue
+			}
+			if err := tx.Model(&dbmodel.Task{}).
+				Where("repo_root = ? AND task_id = ?", s.repoRoot, id).
+				Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return oldParentTaskID, nil
+}
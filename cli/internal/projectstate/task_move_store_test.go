@@ -0,0 +1,82 @@
+package projectstate
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func childIDs(t *testing.T, st *Store, parentTaskID string) []string {
+	t.Helper()
+	rows, err := st.ListTasksByProject("p1")
+	if err != nil {
+		t.Fatalf("ListTasksByProject failed: %v", err)
+	}
+	out := []string{}
+	for _, row := range rows {
+		if row.ParentTaskID == parentTaskID {
+			out = append(out, row.TaskID)
+		}
+	}
+	return out
+}
+
+func TestStore_MoveTask_ReorderReparentAndCycle(t *testing.T) {
+	if err := InitGlobalDB(filepath.Join(t.TempDir(), "shellman.db")); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+	st := NewStore(t.TempDir())
+	for _, task := range []TaskRecord{
+		{TaskID: "t_root", ProjectID: "p1", Title: "root", Status: StatusPending},
+		{TaskID: "t_a", ProjectID: "p1", ParentTaskID: "t_root", Title: "a", Status: StatusPending},
+		{TaskID: "t_b", ProjectID: "p1", ParentTaskID: "t_root", Title: "b", Status: StatusPending},
+		{TaskID: "t_c", ProjectID: "p1", ParentTaskID: "t_root", Title: "c", Status: StatusPending},
+		{TaskID: "t_a1", ProjectID: "p1", ParentTaskID: "t_a", Title: "a1", Status: StatusPending},
+	} {
+		if err := st.InsertTask(task); err != nil {
+			t.Fatalf("InsertTask %s failed: %v", task.TaskID, err)
+		}
+	}
+	if got := childIDs(t, st, "t_root"); !reflect.DeepEqual(got, []string{"t_a", "t_b", "t_c"}) {
+		t.Fatalf("unexpected initial order: %#v", got)
+	}
+
+	oldParent, err := st.MoveTask("p1", "t_c", "t_root", 0)
+	if err != nil {
+		t.Fatalf("reorder failed: %v", err)
+	}
+	if oldParent != "t_root" {
+		t.Fatalf("expected old parent t_root, got %q", oldParent)
+	}
+	if got := childIDs(t, st, "t_root"); !reflect.DeepEqual(got, []string{"t_c", "t_a", "t_b"}) {
+		t.Fatalf("unexpected order after reorder: %#v", got)
+	}
+
+	if _, err := st.MoveTask("p1", "t_b", "t_a", -1); err != nil {
+		t.Fatalf("reparent failed: %v", err)
+	}
+	if got := childIDs(t, st, "t_a"); !reflect.DeepEqual(got, []string{"t_a1", "t_b"}) {
+		t.Fatalf("unexpected children after reparent: %#v", got)
+	}
+	if got := childIDs(t, st, "t_root"); !reflect.DeepEqual(got, []string{"t_c", "t_a"}) {
+		t.Fatalf("unexpected old siblings after reparent: %#v", got)
+	}
+
+	if _, err := st.MoveTask("p1", "t_root", "t_a1", -1); !errors.Is(err, ErrTaskMoveCycle) {
+		t.Fatalf("expected ErrTaskMoveCycle, got %v", err)
+	}
+	if _, err := st.MoveTask("p1", "t_a", "t_a", -1); !errors.Is(err, ErrTaskMoveCycle) {
+		t.Fatalf("expected ErrTaskMoveCycle for self move, got %v", err)
+	}
+	if _, err := st.MoveTask("p1", "t_missing", "", -1); !errors.Is(err, ErrTaskMoveNotFound) {
+		t.Fatalf("expected ErrTaskMoveNotFound, got %v", err)
+	}
+
+	if _, err := st.MoveTask("p1", "t_a1", "", -1); err != nil {
+		t.Fatalf("move to root failed: %v", err)
+	}
+	if got := childIDs(t, st, ""); !reflect.DeepEqual(got, []string{"t_root", "t_a1"}) {
+		t.Fatalf("unexpected roots: %#v", got)
+	}
+}
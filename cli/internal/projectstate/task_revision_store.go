@@ -0,0 +1,173 @@
+package projectstate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	dbmodel "shellman/cli/internal/db"
+)
+
+const (
+	TaskRevisionSourceUser         = "user"
+	TaskRevisionSourceSidecarTool  = "sidecar_tool"
+	TaskRevisionSourceAutoProgress = "auto_progress"
+	TaskRevisionSourceRestore      = "restore"
+	TaskRevisionSourceSystem       = "system"
+)
+
+const (
+	TaskFieldTitle       = "title"
+	TaskFieldDescription = "description"
+	TaskFieldStatus      = "status"
+	TaskFieldFlag        = "flag"
+	TaskFieldFlagDesc    = "flag_desc"
+	TaskFieldSidecarMode = "sidecar_mode"
+	TaskFieldTaskRole    = "task_role"
+	TaskFieldChecked     = "checked"
+	TaskFieldArchived    = "archived"
+)
+
+var (
+	ErrTaskRevisionNotFound = errors.New("task revision not found")
+	ErrTaskFieldNotTracked  = errors.New("task field is not tracked")
+)
+
+type TaskRevisionRecord struct {
+	Rev       int64  `json:"rev"`
+	TaskID    string `json:"task_id"`
+	ProjectID string `json:"project_id"`
+	Field     string `json:"field"`
+	OldValue  string `json:"old_value"`
+	NewValue  string `json:"new_value"`
+	Source    string `json:"source"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// buildTaskRevisions diffs the fields set on input against prev; only values that actually change are recorded.
+func buildTaskRevisions(prev dbmodel.Task, input TaskMetaUpsert, now int64) []dbmodel.TaskRevision {
+	source := strings.TrimSpace(input.Source)
+	if source == "" {
+		source = TaskRevisionSourceSystem
+	}
+	out := make([]dbmodel.TaskRevision, 0)
+	add := func(field, oldValue string, next *string) {
+		if next == nil || *next == oldValue {
+			return
+		}
+		out = append(out, dbmodel.TaskRevision{
+			TaskID:    input.TaskID,
+			Field:     field,
+			OldValue:  oldValue,
+			NewValue:  *next,
+			Source:    source,
+			CreatedAt: now,
+		})
+	}
+	boolText := func(v *bool) *string {
+		if v == nil {
+			return nil
+		}
+		text := strconv.FormatBool(*v)
+		return &text
+	}
+	add(TaskFieldTitle, prev.Title, input.Title)
+	add(TaskFieldDescription, prev.Description, input.Description)
+	add(TaskFieldStatus, prev.Status, input.Status)
+	add(TaskFieldFlag, prev.Flag, input.Flag)
+	add(TaskFieldFlagDesc, prev.FlagDesc, input.FlagDesc)
+	add(TaskFieldSidecarMode, prev.SidecarMode, input.SidecarMode)
+	add(TaskFieldTaskRole, prev.TaskRole, input.TaskRole)
+	add(TaskFieldChecked, strconv.FormatBool(prev.Checked), boolText(input.Checked))
+	add(TaskFieldArchived, strconv.FormatBool(prev.Archived), boolText(input.Archived))
+	return out
+}
+
+// TaskMetaUpsertForField builds the upsert that writes value back into a tracked field.
+func TaskMetaUpsertForField(projectID, taskID, field, value string) (TaskMetaUpsert, error) {
+	input := TaskMetaUpsert{TaskID: taskID, ProjectID: projectID}
+	switch field {
+	case TaskFieldTitle:
+		input.Title = &value
+	case TaskFieldDescription:
+		input.Description = &value
+	case TaskFieldStatus:
+		input.Status = &value
+	case TaskFieldFlag:
+		input.Flag = &value
+	case TaskFieldFlagDesc:
+		input.FlagDesc = &value
+	case TaskFieldSidecarMode:
+		input.SidecarMode = &value
+	case TaskFieldTaskRole:
+		input.TaskRole = &value
+	case TaskFieldChecked, TaskFieldArchived:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return TaskMetaUpsert{}, fmt.Errorf("invalid %s value %q: %w", field, value, err)
+		}
+		if field == TaskFieldChecked {
+			input.Checked = &parsed
+		} else {
+			input.Archived = &parsed
+		}
+	default:
+		return TaskMetaUpsert{}, fmt.Errorf("%w: %s", ErrTaskFieldNotTracked, field)
+	}
+	return input, nil
+}
+
+// ListTaskRevisions returns the newest revisions of taskID first, optionally restricted to one field.
+func (s *Store) ListTaskRevisions(taskID, field string, limit int) ([]TaskRevisionRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	where := `repo_root = ? AND task_id = ?`
+	args := []any{s.repoRoot, strings.TrimSpace(taskID)}
+	if field = strings.TrimSpace(field); field != "" {
+		where += ` AND field = ?`
+		args = append(args, field)
+	}
+	args = append(args, limit)
+	return s.listTaskRevisions(where+` ORDER BY id DESC LIMIT ?`, args...)
+}
+
+func (s *Store) GetTaskRevision(taskID string, rev int64) (TaskRevisionRecord, error) {
+	rows, err := s.listTaskRevisions(`repo_root = ? AND task_id = ? AND id = ?`, s.repoRoot, strings.TrimSpace(taskID), rev)
+	if err != nil {
+		return TaskRevisionRecord{}, err
+	}
+	if len(rows) == 0 {
+		return TaskRevisionRecord{}, ErrTaskRevisionNotFound
+	}
+	return rows[0], nil
+}
+
+func (s *Store) listTaskRevisions(where string, args ...any) ([]TaskRevisionRecord, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	rows, err := db.Query(`SELECT id, task_id, project_id, field, old_value, new_value, source, created_at
+FROM task_revisions WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]TaskRevisionRecord, 0)
+	for rows.Next() {
+		var item TaskRevisionRecord
+		if err := rows.Scan(&item.Rev, &item.TaskID, &item.ProjectID, &item.Field, &item.OldValue, &item.NewValue, &item.Source, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,64 @@
+package projectstate
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_UpsertTaskMeta_RecordsRevisions(t *testing.T) {
+	if err := InitGlobalDB(filepath.Join(t.TempDir(), "shellman.db")); err != nil {
+		t.Fatalf("InitGlobalDB failed: %v", err)
+	}
+	st := NewStore(t.TempDir())
+	if err := st.InsertTask(TaskRecord{TaskID: "t_1", ProjectID: "p1", Title: "first", Status: StatusPending}); err != nil {
+		t.Fatalf("InsertTask failed: %v", err)
+	}
+
+	title := "second"
+	status := StatusPending
+	if err := st.UpsertTaskMeta(TaskMetaUpsert{TaskID: "t_1", ProjectID: "p1", Title: &title, Status: &status, Source: TaskRevisionSourceUser}); err != nil {
+		t.Fatalf("UpsertTaskMeta failed: %v", err)
+	}
+	flag := "error"
+	checked := true
+	if err := st.UpsertTaskMeta(TaskMetaUpsert{TaskID: "t_1", ProjectID: "p1", Flag: &flag, Checked: &checked}); err != nil {
+		t.Fatalf("UpsertTaskMeta failed: %v", err)
+	}
+
+	revisions, err := st.ListTaskRevisions("t_1", "", 0)
+	if err != nil {
+		t.Fatalf("ListTaskRevisions failed: %v", err)
+	}
+	if len(revisions) != 3 {
+		t.Fatalf("expected 3 revisions (unchanged status skipped), got %#v", revisions)
+	}
+	if revisions[0].Field != TaskFieldChecked || revisions[0].OldValue != "false" || revisions[0].NewValue != "true" || revisions[0].Source != TaskRevisionSourceSystem {
+		t.Fatalf("unexpected newest revision: %#v", revisions[0])
+	}
+	titles, err := st.ListTaskRevisions("t_1", TaskFieldTitle, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(titles) != 1 || titles[0].OldValue != "first" || titles[0].NewValue != "second" || titles[0].Source != TaskRevisionSourceUser {
+		t.Fatalf("unexpected title revisions: %#v", titles)
+	}
+
+	got, err := st.GetTaskRevision("t_1", titles[0].Rev)
+	if err != nil || got.Field != TaskFieldTitle {
+		t.Fatalf("GetTaskRevision returned %#v, %v", got, err)
+	}
+	if _, err := st.GetTaskRevision("t_other", titles[0].Rev); !errors.Is(err, ErrTaskRevisionNotFound) {
+		t.Fatalf("expected ErrTaskRevisionNotFound, got %v", err)
+	}
+	if _, err := TaskMetaUpsertForField("p1", "t_1", "current_command", "x"); !errors.Is(err, ErrTaskFieldNotTracked) {
+		t.Fatalf("expected ErrTaskFieldNotTracked, got %v", err)
+	}
+
+	if err := st.DeleteTask("t_1"); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if revisions, err := st.ListTaskRevisions("t_1", "", 0); err != nil || len(revisions) != 0 {
+		t.Fatalf("expected revisions removed with task, got %#v, %v", revisions, err)
+	}
+}
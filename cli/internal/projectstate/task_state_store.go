@@ -3,6 +3,7 @@ package projectstate
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	dbmodel "shellman/cli/internal/db"
 	"shellman/cli/internal/progdetector"
 	_ "shellman/cli/internal/progdetector/builtin"
@@ -29,7 +30,7 @@ func (s *Store) listTasksByProject(projectID string, includeArchived bool) ([]Ta
 	defer func() { _ = release() }()
 
 	query := `
-SELECT task_id, project_id, parent_task_id, title, current_command, active_adapter, status, sidecar_mode, task_role, description, flag, flag_desc, flag_readed, checked, archived, created_at, last_modified
+SELECT task_id, project_id, parent_task_id, title, current_command, active_adapter, status, sidecar_mode, task_role, description, flag, flag_desc, flag_readed, checked, archived, created_at, last_modified, sort_order
 FROM tasks
 WHERE repo_root = ? AND project_id = ?
 `
@@ -38,7 +39,7 @@ WHERE repo_root = ? AND project_id = ?
 		query += " AND archived = false"
 	}
 	query += `
-ORDER BY created_at ASC, task_id ASC
+ORDER BY sort_order ASC, created_at ASC, task_id ASC
 	`
 	rows, err := db.Query(query, args...)
 	if err != nil {
@@ -67,6 +68,7 @@ ORDER BY created_at ASC, task_id ASC
 			&row.Archived,
 			&row.CreatedAt,
 			&row.LastModified,
+			&row.SortOrder,
 		); err != nil {
 			return nil, err
 		}
@@ -79,6 +81,37 @@ ORDER BY created_at ASC, task_id ASC
 }
 
 func (s *Store) UpsertTaskMeta(input TaskMetaUpsert) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		return s.upsertTaskMetaTx(tx, input)
+	})
+}
+
+// BatchUpsertTaskMeta applies every upsert in one transaction; any failure rolls back the whole batch.
+func (s *Store) BatchUpsertTaskMeta(inputs []TaskMetaUpsert) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		for _, input := range inputs {
+			if err := s.upsertTaskMetaTx(tx, input); err != nil {
+				return fmt.Errorf("task %s: %w", input.TaskID, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Store) upsertTaskMetaTx(tx *gorm.DB, input TaskMetaUpsert) error {
 	if input.TaskID == "" {
 		return errors.New("task id is required")
 	}
@@ -105,12 +138,6 @@ func (s *Store) UpsertTaskMeta(input TaskMetaUpsert) error {
 	hasChecked := input.Checked != nil
 	hasArchived := input.Archived != nil
 
-	gdb, release, err := s.dbGORM()
-	if err != nil {
-		return err
-	}
-	defer func() { _ = release() }()
-
 	row := dbmodel.Task{
 		TaskID:         input.TaskID,
 		RepoRoot:       s.repoRoot,
@@ -176,10 +203,29 @@ func (s *Store) UpsertTaskMeta(input TaskMetaUpsert) error {
 		assignments["archived"] = gorm.Expr("excluded.archived")
 	}
 
-	return gdb.Clauses(clause.OnConflict{
+	var prev dbmodel.Task
+	found := tx.Where("task_id = ?", input.TaskID).Limit(1).Find(&prev)
+	if found.Error != nil {
+		return found.Error
+	}
+	if err := tx.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "task_id"}},
 		DoUpdates: clause.Assignments(assignments),
-	}).Create(&row).Error
+	}).Create(&row).Error; err != nil {
+		return err
+	}
+	if found.RowsAffected == 0 {
+		return nil
+	}
+	revisions := buildTaskRevisions(prev, input, now)
+	if len(revisions) == 0 {
+		return nil
+	}
+	for i := range revisions {
+		revisions[i].RepoRoot = s.repoRoot
+		revisions[i].ProjectID = input.ProjectID
+	}
+	return tx.Create(&revisions).Error
 }
 
 func (s *Store) ArchiveCheckedTasksByProject(projectID string) (int64, error) {
@@ -335,6 +381,35 @@ WHERE pane_id = ?
 	return row, true, nil
 }
 
+func (s *Store) GetTaskRuntimeByTaskID(taskID string) (TaskRuntimeRecord, bool, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return TaskRuntimeRecord{}, false, err
+	}
+	defer func() { _ = release() }()
+
+	var row TaskRuntimeRecord
+	err = db.QueryRow(`
+SELECT task_id, source_pane_id, current_command, runtime_status, snapshot_hash, updated_at
+FROM task_runtime
+WHERE task_id = ?
+`, taskID).Scan(
+		&row.TaskID,
+		&row.SourcePaneID,
+		&row.CurrentCommand,
+		&row.RuntimeStatus,
+		&row.SnapshotHash,
+		&row.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TaskRuntimeRecord{}, false, nil
+	}
+	if err != nil {
+		return TaskRuntimeRecord{}, false, err
+	}
+	return row, true, nil
+}
+
 func (s *Store) GetProjectMaxTaskLastModified(projectID string) (int64, error) {
 	db, release, err := s.db()
 	if err != nil {
@@ -355,7 +430,22 @@ func (s *Store) DeleteTask(taskID string) error {
 		return err
 	}
 	defer func() { _ = release() }()
-	return gdb.Where("repo_root = ? AND task_id = ?", s.repoRoot, taskID).Delete(&dbmodel.Task{}).Error
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo_root = ? AND (task_id = ? OR blocker_task_id = ?)", s.repoRoot, taskID, taskID).
+			Delete(&dbmodel.TaskDependency{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("repo_root = ? AND task_id = ?", s.repoRoot, taskID).Delete(&dbmodel.TaskLabel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("repo_root = ? AND task_id = ?", s.repoRoot, taskID).Delete(&dbmodel.TaskRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("repo_root = ? AND task_id = ?", s.repoRoot, taskID).Delete(&dbmodel.TaskFlagEscalation{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("repo_root = ? AND task_id = ?", s.repoRoot, taskID).Delete(&dbmodel.Task{}).Error
+	})
 }
 
 func strPtrOrDefault(v *string, fallback string) string {
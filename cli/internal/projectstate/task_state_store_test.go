@@ -277,3 +277,46 @@ func seedTasks(t *testing.T, st *Store) {
 		}
 	}
 }
+
+func TestTaskStateStore_BatchUpsertTaskMeta_RollsBackOnFailure(t *testing.T) {
+	st := newTaskStateStore(t)
+	seedTasks(t, st)
+
+	checked := true
+	err := st.BatchUpsertTaskMeta([]TaskMetaUpsert{
+		{TaskID: "t1", ProjectID: "p1", Checked: &checked},
+		{TaskID: "t2", ProjectID: "", Checked: &checked},
+	})
+	if err == nil {
+		t.Fatal("expected batch failure for missing project id")
+	}
+	rows, err := st.ListTasksByProject("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if row.Checked {
+			t.Fatalf("expected batch rolled back, %s is checked", row.TaskID)
+		}
+	}
+
+	if err := st.BatchUpsertTaskMeta([]TaskMetaUpsert{
+		{TaskID: "t1", ProjectID: "p1", Checked: &checked},
+		{TaskID: "t2", ProjectID: "p1", Checked: &checked},
+	}); err != nil {
+		t.Fatalf("BatchUpsertTaskMeta failed: %v", err)
+	}
+	rows, err = st.ListTasksByProject("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkedCount := 0
+	for _, row := range rows {
+		if row.Checked {
+			checkedCount++
+		}
+	}
+	if checkedCount != 2 {
+		t.Fatalf("expected 2 checked tasks, got %d", checkedCount)
+	}
+}
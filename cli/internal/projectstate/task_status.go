@@ -0,0 +1,125 @@
+package projectstate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Status triggers name what is asking for a status change; the transition table allows some moves only for a
+// specific trigger.
+const (
+	// StatusTriggerUpdate is an explicit status change by a user, a sidecar tool or run auto-progress.
+	StatusTriggerUpdate = "update"
+	// StatusTriggerRun is a new run (pane) starting on the task.
+	StatusTriggerRun = "run"
+	// StatusTriggerReopen is the reopen action on a finished task.
+	StatusTriggerReopen = "reopen"
+	// StatusTriggerChildren is the automatic waiting_children bookkeeping when children start or finish.
+	StatusTriggerChildren = "children"
+)
+
+var ErrInvalidStatusTransition = errors.New("invalid task status transition")
+
+// StatusTransitionError is returned for a transition the table rejects; it matches ErrInvalidStatusTransition.
+type StatusTransitionError struct {
+	From    string
+	To      string
+	Trigger string
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("%s: %s -> %s (%s)", ErrInvalidStatusTransition, e.From, e.To, e.Trigger)
+}
+
+func (e *StatusTransitionError) Unwrap() error {
+	return ErrInvalidStatusTransition
+}
+
+var (
+	statusTriggersUpdate         = []string{StatusTriggerUpdate}
+	statusTriggersUpdateRun      = []string{StatusTriggerUpdate, StatusTriggerRun}
+	statusTriggersUpdateChildren = []string{StatusTriggerUpdate, StatusTriggerChildren}
+)
+
+// taskStatusTransitions lists, per current status, the statuses a task may move to and the triggers allowed to
+// make that move. Finished tasks only leave their state through a new run or a reopen; canceled only via reopen.
+var taskStatusTransitions = map[string]map[string][]string{
+	StatusPending: {
+		StatusRunning:         statusTriggersUpdateRun,
+		StatusWaitingUser:     statusTriggersUpdate,
+		StatusWaitingChildren: statusTriggersUpdateChildren,
+		StatusCompleted:       statusTriggersUpdate,
+		StatusFailed:          statusTriggersUpdate,
+		StatusCanceled:        statusTriggersUpdate,
+	},
+	StatusRunning: {
+		StatusPending:         statusTriggersUpdate,
+		StatusWaitingUser:     statusTriggersUpdate,
+		StatusWaitingChildren: statusTriggersUpdateChildren,
+		StatusCompleted:       statusTriggersUpdate,
+		StatusFailed:          statusTriggersUpdate,
+		StatusCanceled:        statusTriggersUpdate,
+	},
+	StatusWaitingUser: {
+		StatusPending:         statusTriggersUpdate,
+		StatusRunning:         statusTriggersUpdateRun,
+		StatusWaitingChildren: statusTriggersUpdateChildren,
+		StatusCompleted:       statusTriggersUpdate,
+		StatusFailed:          statusTriggersUpdate,
+		StatusCanceled:        statusTriggersUpdate,
+	},
+	StatusWaitingChildren: {
+		StatusPending:     statusTriggersUpdateChildren,
+		StatusRunning:     statusTriggersUpdateRun,
+		StatusWaitingUser: statusTriggersUpdate,
+		StatusCompleted:   statusTriggersUpdate,
+		StatusFailed:      statusTriggersUpdate,
+		StatusCanceled:    statusTriggersUpdate,
+	},
+	StatusCompleted: {
+		StatusRunning: {StatusTriggerRun},
+		StatusPending: {StatusTriggerReopen},
+	},
+	StatusFailed: {
+		StatusRunning: {StatusTriggerRun},
+		StatusPending: {StatusTriggerReopen},
+	},
+	StatusCanceled: {
+		StatusPending: {StatusTriggerReopen},
+	},
+}
+
+// ValidateStatusTransition reports whether trigger may move a task from status from to status to. Staying in the
+// same status is always allowed; an empty from (a task without a recorded status) behaves like pending.
+func ValidateStatusTransition(from, to, trigger string) error {
+	from = strings.TrimSpace(from)
+	to = strings.TrimSpace(to)
+	if from == "" {
+		from = StatusPending
+	}
+	if from == to {
+		return nil
+	}
+	for _, allowed := range taskStatusTransitions[from][to] {
+		if allowed == trigger {
+			return nil
+		}
+	}
+	return &StatusTransitionError{From: from, To: to, Trigger: trigger}
+}
+
+// AllowedStatusTransitions returns the statuses trigger may move a task in status from to.
+func AllowedStatusTransitions(from, trigger string) []string {
+	from = strings.TrimSpace(from)
+	if from == "" {
+		from = StatusPending
+	}
+	out := make([]string, 0)
+	for _, to := range []string{StatusPending, StatusRunning, StatusWaitingUser, StatusWaitingChildren, StatusCompleted, StatusFailed, StatusCanceled} {
+		if to != from && ValidateStatusTransition(from, to, trigger) == nil {
+			out = append(out, to)
+		}
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package projectstate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateStatusTransition(t *testing.T) {
+	cases := []struct {
+		from, to, trigger string
+		ok                bool
+	}{
+		{StatusPending, StatusRunning, StatusTriggerUpdate, true},
+		{StatusRunning, StatusCompleted, StatusTriggerUpdate, true},
+		{StatusCompleted, StatusRunning, StatusTriggerUpdate, false},
+		{StatusCompleted, StatusRunning, StatusTriggerRun, true},
+		{StatusFailed, StatusRunning, StatusTriggerRun, true},
+		{StatusCanceled, StatusRunning, StatusTriggerRun, false},
+		{StatusCanceled, StatusPending, StatusTriggerUpdate, false},
+		{StatusCanceled, StatusPending, StatusTriggerReopen, true},
+		{StatusRunning, StatusWaitingUser, StatusTriggerChildren, false},
+		{StatusWaitingChildren, StatusPending, StatusTriggerChildren, true},
+		{StatusCompleted, StatusWaitingChildren, StatusTriggerChildren, false},
+		{StatusCanceled, StatusCanceled, StatusTriggerUpdate, true},
+		{"", StatusRunning, StatusTriggerRun, true},
+	}
+	for _, tc := range cases {
+		err := ValidateStatusTransition(tc.from, tc.to, tc.trigger)
+		if tc.ok && err != nil {
+			t.Fatalf("%s -> %s (%s): unexpected error %v", tc.from, tc.to, tc.trigger, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrInvalidStatusTransition) {
+			t.Fatalf("%s -> %s (%s): expected ErrInvalidStatusTransition, got %v", tc.from, tc.to, tc.trigger, err)
+		}
+	}
+}
+
+func TestAllowedStatusTransitions(t *testing.T) {
+	if got := AllowedStatusTransitions(StatusCompleted, StatusTriggerReopen); !reflect.DeepEqual(got, []string{StatusPending}) {
+		t.Fatalf("unexpected reopen targets: %#v", got)
+	}
+	if got := AllowedStatusTransitions(StatusCanceled, StatusTriggerUpdate); len(got) != 0 {
+		t.Fatalf("expected canceled to be terminal for updates, got %#v", got)
+	}
+}
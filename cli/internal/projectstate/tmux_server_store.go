@@ -0,0 +1,80 @@
+package projectstate
+
+import (
+	"strings"
+	"time"
+
+	dbmodel "shellman/cli/internal/db"
+
+	"gorm.io/gorm/clause"
+)
+
+// TmuxServerRecord is a tmux server shellman has talked to. ServerInstanceID is "<socket>:<pid>", so a restarted
+// server on the same socket gets a record of its own.
+type TmuxServerRecord struct {
+	ServerInstanceID string `json:"server_instance_id"`
+	SocketPath       string `json:"socket_path"`
+	PID              string `json:"pid"`
+	LastSeenAt       int64  `json:"last_seen_at"`
+}
+
+// Socket returns the socket name part of the server instance id.
+func (r TmuxServerRecord) Socket() string {
+	id := strings.TrimSpace(r.ServerInstanceID)
+	if idx := strings.LastIndex(id, ":"); idx >= 0 {
+		return id[:idx]
+	}
+	return id
+}
+
+// ObserveTmuxServer records rec as seen now.
+func (s *Store) ObserveTmuxServer(rec TmuxServerRecord) error {
+	gdb, release, err := s.dbGORM()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	row := dbmodel.TmuxServer{
+		ServerInstanceID: strings.TrimSpace(rec.ServerInstanceID),
+		SocketPath:       strings.TrimSpace(rec.SocketPath),
+		PID:              strings.TrimSpace(rec.PID),
+		LastSeenAt:       time.Now().UTC().Unix(),
+	}
+	return gdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "server_instance_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"socket_path", "pid", "last_seen_at"}),
+	}).Create(&row).Error
+}
+
+// ListTmuxServers returns every recorded tmux server, most recently seen first.
+func (s *Store) ListTmuxServers() ([]TmuxServerRecord, error) {
+	db, release, err := s.db()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = release() }()
+
+	rows, err := db.Query(`
+SELECT server_instance_id, socket_path, pid, last_seen_at
+FROM tmux_servers
+ORDER BY last_seen_at DESC, server_instance_id ASC
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]TmuxServerRecord, 0)
+	for rows.Next() {
+		var rec TmuxServerRecord
+		if err := rows.Scan(&rec.ServerInstanceID, &rec.SocketPath, &rec.PID, &rec.LastSeenAt); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
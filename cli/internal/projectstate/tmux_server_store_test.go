@@ -0,0 +1,30 @@
+package projectstate
+
+import "testing"
+
+func TestTmuxServerStore_ObserveAndList(t *testing.T) {
+	st := newTaskStateStore(t)
+
+	if err := st.ObserveTmuxServer(TmuxServerRecord{ServerInstanceID: "tss_work:100", SocketPath: "/tmp/tmux-1000/tss_work", PID: "100"}); err != nil {
+		t.Fatalf("ObserveTmuxServer failed: %v", err)
+	}
+	if err := st.ObserveTmuxServer(TmuxServerRecord{ServerInstanceID: "tss_work:100", SocketPath: "/tmp/tmux-1000/tss_work", PID: "100"}); err != nil {
+		t.Fatalf("ObserveTmuxServer repeat failed: %v", err)
+	}
+	servers, err := st.ListTmuxServers()
+	if err != nil {
+		t.Fatalf("ListTmuxServers failed: %v", err)
+	}
+	found := 0
+	for _, server := range servers {
+		if server.ServerInstanceID == "tss_work:100" {
+			found++
+			if server.Socket() != "tss_work" || server.PID != "100" || server.LastSeenAt == 0 {
+				t.Fatalf("unexpected server record: %#v", server)
+			}
+		}
+	}
+	if found != 1 {
+		t.Fatalf("expected one record per server instance, got %d in %#v", found, servers)
+	}
+}
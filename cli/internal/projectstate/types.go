@@ -15,6 +15,10 @@ type TaskNode struct {
 	Status               string   `json:"status,omitempty"`
 	Children             []string `json:"children,omitempty"`
 	PendingChildrenCount int      `json:"pending_children_count,omitempty"`
+	BlockedBy            []string `json:"blocked_by,omitempty"`
+	Blocked              bool     `json:"blocked,omitempty"`
+	Labels               []string `json:"labels,omitempty"`
+	Matched              bool     `json:"matched,omitempty"`
 	LastModified         int64    `json:"last_modified,omitempty"`
 }
 
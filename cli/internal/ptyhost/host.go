@@ -0,0 +1,731 @@
+// Package ptyhost runs pane shells directly on pseudo-terminals, as an alternative to tmux where tmux is unavailable
+// or where output should be captured as it is written instead of by polling capture-pane.
+package ptyhost
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"shellman/cli/internal/tmux"
+)
+
+const (
+	defaultHistoryLines = 2000
+	defaultCols         = 80
+	defaultRows         = 24
+
+	paneOutputBuffer   = 128
+	pipeOutputBuffer   = 256
+	exitedPaneLifetime = 10 * time.Minute
+
+	// shellmanOSCPrefix starts the operating system commands the bootstrap rcfile uses to set pane options.
+	shellmanOSCPrefix = "777;shellman;"
+)
+
+var ErrPaneNotFound = errors.New("pane not found")
+
+// Options configure a Host.
+type Options struct {
+	// HistoryLines is how many lines scrolled off the top of a pane are kept; 2000 when zero.
+	HistoryLines int
+	// Shell, when set, is started in every new pane instead of the bootstrap bash or the login shell.
+	Shell []string
+}
+
+// Host owns the panes it spawned. It implements the same pane operations as tmux.Adapter, plus streaming output
+// subscriptions like the control-mode hub, so either can back shellman.
+type Host struct {
+	opts       Options
+	instanceID string
+
+	mu      sync.Mutex
+	nextID  int
+	panes   map[string]*pane
+	exited  map[string]exitedPane
+	onClose func(target, reason string)
+}
+
+type exitedPane struct {
+	code int
+	at   time.Time
+}
+
+type pane struct {
+	id     string
+	cmd    *exec.Cmd
+	master *os.File
+	cwd    string
+	done   chan struct{}
+
+	mu          sync.Mutex
+	screen      *Screen
+	lastActive  time.Time
+	options     map[string]string
+	subs        map[int]chan string
+	nextSub     int
+	utf8Pending []byte
+	pipe        *panePipe
+}
+
+// panePipe feeds a pane's output to the stdin of a shell command, like tmux pipe-pane -O.
+type panePipe struct {
+	cmd  *exec.Cmd
+	data chan []byte
+	done chan struct{}
+}
+
+func NewHost(opts Options) *Host {
+	if opts.HistoryLines <= 0 {
+		opts.HistoryLines = defaultHistoryLines
+	}
+	return &Host{
+		opts:       opts,
+		instanceID: "pty:" + strconv.Itoa(os.Getpid()),
+		panes:      map[string]*pane{},
+		exited:     map[string]exitedPane{},
+	}
+}
+
+// ServerInstanceID identifies this host; panes never outlive the process, so it changes on every restart.
+func (h *Host) ServerInstanceID() (string, error) {
+	return h.instanceID, nil
+}
+
+// SetPaneClosedHook registers fn to be called when a pane is closed or its shell exits.
+func (h *Host) SetPaneClosedHook(fn func(target, reason string)) {
+	h.mu.Lock()
+	h.onClose = fn
+	h.mu.Unlock()
+}
+
+func (h *Host) pane(target string) (*pane, error) {
+	target = strings.TrimSpace(target)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p, ok := h.panes[target]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPaneNotFound, target)
+	}
+	return p, nil
+}
+
+// ListSessions returns the ids of all live panes, mirroring tmux list-panes -a.
+func (h *Host) ListSessions() ([]string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]string, 0, len(h.panes))
+	for id := range h.panes {
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return paneNumber(out[i]) < paneNumber(out[j]) })
+	return out, nil
+}
+
+func paneNumber(id string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(id, "%"))
+	return n
+}
+
+func (h *Host) PaneExists(target string) (bool, error) {
+	_, err := h.pane(target)
+	return err == nil, nil
+}
+
+// SelectPane only checks target exists; panes are not laid out on a shared screen.
+func (h *Host) SelectPane(target string) error {
+	_, err := h.pane(target)
+	return err
+}
+
+func (h *Host) SendInput(target, text string) error {
+	p, err := h.pane(target)
+	if err != nil {
+		return err
+	}
+	_, err = p.master.Write([]byte(text))
+	return err
+}
+
+func (h *Host) Resize(target string, cols, rows int) error {
+	p, err := h.pane(target)
+	if err != nil {
+		return err
+	}
+	cols, rows = clampSize(cols, rows)
+	p.mu.Lock()
+	p.screen.Resize(cols, rows)
+	p.mu.Unlock()
+	return setWinsize(p.master, cols, rows)
+}
+
+func (h *Host) CapturePane(target string) (string, error) {
+	p, err := h.pane(target)
+	if err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.screen.Snapshot(), nil
+}
+
+func (h *Host) CaptureHistory(target string, lines int) (string, error) {
+	p, err := h.pane(target)
+	if err != nil {
+		return "", err
+	}
+	if lines <= 0 {
+		lines = defaultHistoryLines
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.screen.History(lines), nil
+}
+
+func (h *Host) CursorPosition(target string) (int, int, error) {
+	p, err := h.pane(target)
+	if err != nil {
+		return 0, 0, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	x, y := p.screen.Cursor()
+	return x, y, nil
+}
+
+// PanePID returns the pid of the pane's shell.
+func (h *Host) PanePID(target string) (int, error) {
+	p, err := h.pane(target)
+	if err != nil {
+		return 0, err
+	}
+	return p.cmd.Process.Pid, nil
+}
+
+// PaneSize returns the pane's width and height in cells.
+func (h *Host) PaneSize(target string) (int, int, error) {
+	p, err := h.pane(target)
+	if err != nil {
+		return 0, 0, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cols, rows := p.screen.Size()
+	return cols, rows, nil
+}
+
+// PaneLastActiveAt returns when the pane last printed anything.
+func (h *Host) PaneLastActiveAt(target string) (time.Time, error) {
+	p, err := h.pane(target)
+	if err != nil {
+		return time.Time{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastActive, nil
+}
+
+// PaneCurrentPath returns the working directory of the pane's shell, or the one it started in where that is unknown.
+func (h *Host) PaneCurrentPath(target string) (string, error) {
+	p, err := h.pane(target)
+	if err != nil {
+		return "", err
+	}
+	if cwd := processCwd(p.cmd.Process.Pid); cwd != "" {
+		return cwd, nil
+	}
+	return p.cwd, nil
+}
+
+// PaneOption returns a pane option the bootstrap rcfile reported, e.g. "@shellman_ready".
+func (h *Host) PaneOption(target, key string) (string, error) {
+	p, err := h.pane(target)
+	if err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.options["@"+strings.TrimPrefix(strings.TrimSpace(key), "@")], nil
+}
+
+// PaneExitStatus reports how the pane's shell exited, or for a live pane the status of the last command the
+// bootstrap shell ran.
+func (h *Host) PaneExitStatus(target string) (tmux.PaneExitStatus, error) {
+	target = strings.TrimSpace(target)
+	h.mu.Lock()
+	p, live := h.panes[target]
+	exited, dead := h.exited[target]
+	h.mu.Unlock()
+	switch {
+	case live:
+		status := tmux.PaneExitStatus{CurrentCommand: foregroundCommand(p.cmd.Process.Pid)}
+		p.mu.Lock()
+		raw := p.options["@shellman_last_exit"]
+		p.mu.Unlock()
+		if code, err := strconv.Atoi(raw); err == nil {
+			status.LastExit = code
+			status.HasLastExit = true
+		}
+		return status, nil
+	case dead:
+		return tmux.PaneExitStatus{Dead: true, DeadStatus: exited.code, HasDeadStatus: true}, nil
+	default:
+		return tmux.PaneExitStatus{}, fmt.Errorf("%w: %s", ErrPaneNotFound, target)
+	}
+}
+
+// Subscribe streams what target prints from now on, split at UTF-8 boundaries. The channel is closed when the pane
+// goes away.
+func (h *Host) Subscribe(target string) (<-chan string, func(), error) {
+	p, err := h.pane(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := make(chan string, paneOutputBuffer)
+	p.mu.Lock()
+	if p.subs == nil {
+		p.mu.Unlock()
+		close(out)
+		return out, func() {}, nil
+	}
+	p.nextSub++
+	subID := p.nextSub
+	p.subs[subID] = out
+	p.mu.Unlock()
+	return out, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if ch, ok := p.subs[subID]; ok {
+			delete(p.subs, subID)
+			close(ch)
+		}
+	}, nil
+}
+
+// StartPipePane copies target's output to the stdin of shellCmd, replacing any pipe already running.
+func (h *Host) StartPipePane(target, shellCmd string) error {
+	p, err := h.pane(target)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("/bin/sh", "-c", shellCmd)
+	cmd.Dir = p.cwd
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	pipe := &panePipe{cmd: cmd, data: make(chan []byte, pipeOutputBuffer), done: make(chan struct{})}
+	go func() {
+		defer close(pipe.done)
+		for chunk := range pipe.data {
+			if _, err := stdin.Write(chunk); err != nil {
+				break
+			}
+		}
+		_ = stdin.Close()
+		_ = cmd.Wait()
+	}()
+	p.mu.Lock()
+	previous := p.pipe
+	p.pipe = pipe
+	p.mu.Unlock()
+	previous.stop()
+	return nil
+}
+
+func (h *Host) StopPipePane(target string) error {
+	p, err := h.pane(target)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	pipe := p.pipe
+	p.pipe = nil
+	p.mu.Unlock()
+	pipe.stop()
+	return nil
+}
+
+func (pp *panePipe) stop() {
+	if pp == nil {
+		return
+	}
+	close(pp.data)
+	select {
+	case <-pp.done:
+	case <-time.After(time.Second):
+		_ = pp.cmd.Process.Kill()
+	}
+}
+
+// ClosePane hangs up target's shell and forgets the pane.
+func (h *Host) ClosePane(target string) error {
+	target = strings.TrimSpace(target)
+	h.mu.Lock()
+	p, ok := h.panes[target]
+	if ok {
+		delete(h.panes, target)
+	}
+	h.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrPaneNotFound, target)
+	}
+	p.hangup()
+	h.emitClosed(target, "pty-closed")
+	return nil
+}
+
+// Close hangs up every pane, for shutdown.
+func (h *Host) Close() error {
+	h.mu.Lock()
+	panes := make([]*pane, 0, len(h.panes))
+	for id, p := range h.panes {
+		panes = append(panes, p)
+		delete(h.panes, id)
+	}
+	h.mu.Unlock()
+	for _, p := range panes {
+		p.hangup()
+	}
+	return nil
+}
+
+func (p *pane) hangup() {
+	if err := hangup(p.cmd.Process); err != nil {
+		_ = p.cmd.Process.Kill()
+	}
+	select {
+	case <-p.done:
+	case <-time.After(2 * time.Second):
+		_ = p.cmd.Process.Kill()
+		_ = p.master.Close()
+	}
+}
+
+func (h *Host) emitClosed(target, reason string) {
+	h.mu.Lock()
+	cb := h.onClose
+	h.mu.Unlock()
+	if cb != nil {
+		cb(target, reason)
+	}
+}
+
+func (h *Host) CreateRootPane() (string, error) {
+	return h.spawn("", "", false)
+}
+
+func (h *Host) CreateRootPaneInDir(cwd string) (string, error) {
+	if strings.TrimSpace(cwd) == "" {
+		return "", errors.New("pane cwd is required")
+	}
+	return h.spawn("", cwd, false)
+}
+
+func (h *Host) CreateRootPaneInDirLoginShell(cwd string) (string, error) {
+	if strings.TrimSpace(cwd) == "" {
+		return "", errors.New("pane cwd is required")
+	}
+	return h.spawn("", cwd, true)
+}
+
+// CreateSiblingPane opens a pane in the current directory of target; PTY panes have no layout, so siblings and
+// children only differ in name.
+func (h *Host) CreateSiblingPane(target string) (string, error) {
+	return h.spawnFrom(target, "", false)
+}
+
+func (h *Host) CreateSiblingPaneInDir(target, cwd string) (string, error) {
+	if strings.TrimSpace(cwd) == "" {
+		return "", errors.New("pane cwd is required")
+	}
+	return h.spawnFrom(target, cwd, false)
+}
+
+func (h *Host) CreateSiblingPaneInDirLoginShell(target, cwd string) (string, error) {
+	if strings.TrimSpace(cwd) == "" {
+		return "", errors.New("pane cwd is required")
+	}
+	return h.spawnFrom(target, cwd, true)
+}
+
+func (h *Host) CreateChildPane(target string) (string, error) {
+	return h.spawnFrom(target, "", false)
+}
+
+func (h *Host) CreateChildPaneInDir(target, cwd string) (string, error) {
+	if strings.TrimSpace(cwd) == "" {
+		return "", errors.New("pane cwd is required")
+	}
+	return h.spawnFrom(target, cwd, false)
+}
+
+func (h *Host) spawnFrom(target, cwd string, login bool) (string, error) {
+	if _, err := h.pane(target); err != nil {
+		return "", err
+	}
+	if cwd == "" {
+		cwd, _ = h.PaneCurrentPath(target)
+	}
+	return h.spawn(target, cwd, login)
+}
+
+// spawn starts a shell on a new PTY in cwd, sized like parent when there is one.
+func (h *Host) spawn(parent, cwd string, login bool) (string, error) {
+	cols, rows := defaultCols, defaultRows
+	if parent != "" {
+		if c, r, err := h.PaneSize(parent); err == nil {
+			cols, rows = c, r
+		}
+	}
+	if strings.TrimSpace(cwd) == "" {
+		cwd, _ = os.UserHomeDir()
+	}
+	argv, err := h.shellCommand(login)
+	if err != nil {
+		return "", err
+	}
+
+	master, slave, err := openPTY()
+	if err != nil {
+		return "", err
+	}
+	if err := setWinsize(master, cols, rows); err != nil {
+		_ = master.Close()
+		_ = slave.Close()
+		return "", err
+	}
+	h.mu.Lock()
+	h.nextID++
+	id := "%" + strconv.Itoa(h.nextID)
+	h.mu.Unlock()
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = cwd
+	cmd.Env = paneEnv(id)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = sessionAttr()
+	err = cmd.Start()
+	_ = slave.Close()
+	if err != nil {
+		_ = master.Close()
+		return "", err
+	}
+
+	p := &pane{
+		id:         id,
+		cmd:        cmd,
+		master:     master,
+		cwd:        cwd,
+		done:       make(chan struct{}),
+		screen:     NewScreen(cols, rows, h.opts.HistoryLines),
+		lastActive: time.Now().UTC(),
+		options:    map[string]string{},
+		subs:       map[int]chan string{},
+	}
+	p.screen.OnOSC = p.handleOSC
+	h.mu.Lock()
+	h.panes[id] = p
+	h.mu.Unlock()
+	go h.readLoop(p)
+	return id, nil
+}
+
+func (h *Host) shellCommand(login bool) ([]string, error) {
+	if len(h.opts.Shell) > 0 {
+		return append([]string{}, h.opts.Shell...), nil
+	}
+	shell := strings.TrimSpace(os.Getenv("SHELL"))
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	if login {
+		return []string{shell, "-l", "-i"}, nil
+	}
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		return []string{shell, "-i"}, nil
+	}
+	rcPath, err := ensureBootstrapRCFile()
+	if err != nil {
+		return nil, err
+	}
+	return []string{bash, "--rcfile", rcPath, "-i"}, nil
+}
+
+// paneEnv is the environment of pane shells: shellman's own, minus anything pointing at a tmux server.
+func paneEnv(id string) []string {
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		switch name {
+		case "TMUX", "TMUX_PANE", "TERM", "SHELLMAN_PANE_ID":
+			continue
+		}
+		env = append(env, kv)
+	}
+	return append(env, "TERM=xterm-256color", "SHELLMAN_PANE_ID="+id)
+}
+
+// readLoop feeds everything the pane prints to its screen, its subscribers and its pipe, until the shell exits.
+func (h *Host) readLoop(p *pane) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := p.master.Read(buf)
+		if n > 0 {
+			p.output(buf[:n])
+		}
+		if err != nil {
+			break
+		}
+	}
+	code := 0
+	if err := p.cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+	}
+	_ = p.master.Close()
+
+	p.mu.Lock()
+	for id, ch := range p.subs {
+		delete(p.subs, id)
+		close(ch)
+	}
+	p.subs = nil
+	pipe := p.pipe
+	p.pipe = nil
+	p.mu.Unlock()
+	pipe.stop()
+	close(p.done)
+
+	now := time.Now()
+	h.mu.Lock()
+	_, live := h.panes[p.id]
+	delete(h.panes, p.id)
+	for id, rec := range h.exited {
+		if now.Sub(rec.at) > exitedPaneLifetime {
+			delete(h.exited, id)
+		}
+	}
+	h.exited[p.id] = exitedPane{code: code, at: now}
+	h.mu.Unlock()
+	if live {
+		h.emitClosed(p.id, "pty-exit")
+	}
+}
+
+func (p *pane) output(data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = p.screen.Write(data)
+	p.lastActive = time.Now().UTC()
+	if p.pipe != nil {
+		select {
+		case p.pipe.data <- append([]byte(nil), data...):
+		default:
+		}
+	}
+	if len(p.subs) == 0 {
+		p.utf8Pending = nil
+		return
+	}
+	chunk := append(p.utf8Pending, data...)
+	cut := utf8Boundary(chunk)
+	p.utf8Pending = append([]byte(nil), chunk[cut:]...)
+	if cut == 0 {
+		return
+	}
+	text := string(chunk[:cut])
+	for _, ch := range p.subs {
+		select {
+		case ch <- text:
+		default:
+		}
+	}
+}
+
+// utf8Boundary returns where the trailing incomplete UTF-8 sequence of data starts, or len(data) if there is none.
+func utf8Boundary(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if utf8.FullRune(data[i:]) {
+				return len(data)
+			}
+			return i
+		}
+	}
+	return len(data)
+}
+
+// handleOSC records the pane options the bootstrap rcfile reports as OSC 777 "shellman;<name>=<value>".
+func (p *pane) handleOSC(payload string) {
+	rest, ok := strings.CutPrefix(payload, shellmanOSCPrefix)
+	if !ok {
+		return
+	}
+	name, value, _ := strings.Cut(rest, "=")
+	if name = strings.TrimSpace(name); name != "" {
+		p.options["@shellman_"+name] = strings.TrimSpace(value)
+	}
+}
+
+func ensureBootstrapRCFile() (string, error) {
+	dir := filepath.Join(os.TempDir(), "shellman-bootstrap")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "bash-shell-ready-pty.rc")
+	if err := os.WriteFile(path, []byte(bootstrapRCContent), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// bootstrapRCContent mirrors the tmux bootstrap rcfile, reporting readiness and exit statuses as OSC sequences the
+// host picks out of the output instead of as tmux pane options.
+const bootstrapRCContent = `
+if [ -f "$HOME/.bashrc" ]; then
+  . "$HOME/.bashrc"
+fi
+
+__shellman_prompt_seen=""
+
+__shellman_record_exit() {
+  local status=$?
+  if [ -n "$__shellman_prompt_seen" ]; then
+    printf '\033]777;shellman;last_exit=%s\007' "$status"
+  fi
+  __shellman_prompt_seen=1
+  return $status
+}
+
+__shellman_ready_once() {
+  printf '\033]777;shellman;ready=1\007'
+  if [ -n "${PROMPT_COMMAND:-}" ]; then
+    PROMPT_COMMAND="${PROMPT_COMMAND/__shellman_ready_once; /}"
+    PROMPT_COMMAND="${PROMPT_COMMAND/__shellman_ready_once;/}"
+    PROMPT_COMMAND="${PROMPT_COMMAND/__shellman_ready_once/}"
+    PROMPT_COMMAND="${PROMPT_COMMAND#; }"
+    PROMPT_COMMAND="${PROMPT_COMMAND#;}"
+  fi
+}
+
+if [ -n "${PROMPT_COMMAND:-}" ]; then
+  PROMPT_COMMAND="__shellman_record_exit; __shellman_ready_once; ${PROMPT_COMMAND}"
+else
+  PROMPT_COMMAND="__shellman_record_exit; __shellman_ready_once"
+fi
+`
@@ -0,0 +1,130 @@
+package ptyhost
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestHost(t *testing.T, shell ...string) *Host {
+	t.Helper()
+	master, slave, err := openPTY()
+	if err != nil {
+		t.Skipf("pty unavailable: %v", err)
+	}
+	_ = master.Close()
+	_ = slave.Close()
+	h := NewHost(Options{Shell: shell})
+	t.Cleanup(func() { _ = h.Close() })
+	return h
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestHost_RunsShellOnPTY(t *testing.T) {
+	h := newTestHost(t, "/bin/sh")
+	var (
+		mu     sync.Mutex
+		closed []string
+	)
+	h.SetPaneClosedHook(func(target, reason string) {
+		mu.Lock()
+		defer mu.Unlock()
+		closed = append(closed, target+" "+reason)
+	})
+	dir := t.TempDir()
+	paneID, err := h.CreateRootPaneInDir(dir)
+	if err != nil {
+		t.Fatalf("CreateRootPaneInDir failed: %v", err)
+	}
+	out, unsubscribe, err := h.Subscribe(paneID)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer unsubscribe()
+
+	if err := h.Resize(paneID, 100, 30); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	if err := h.SendInput(paneID, "echo sum=$((40+2)); stty size; pwd\r"); err != nil {
+		t.Fatalf("SendInput failed: %v", err)
+	}
+	waitFor(t, "command output", func() bool {
+		text, _ := h.CapturePane(paneID)
+		return strings.Contains(text, "sum=42") && strings.Contains(text, "30 100") && strings.Contains(text, dir)
+	})
+	streamed := ""
+	waitFor(t, "streamed output", func() bool {
+		for {
+			select {
+			case chunk := <-out:
+				streamed += chunk
+				continue
+			default:
+			}
+			return strings.Contains(streamed, "sum=42")
+		}
+	})
+	if cols, rows, err := h.PaneSize(paneID); err != nil || cols != 100 || rows != 30 {
+		t.Fatalf("unexpected pane size %dx%d err=%v", cols, rows, err)
+	}
+	if panes, _ := h.ListSessions(); len(panes) != 1 || panes[0] != paneID {
+		t.Fatalf("unexpected pane list %v", panes)
+	}
+
+	childID, err := h.CreateChildPane(paneID)
+	if err != nil {
+		t.Fatalf("CreateChildPane failed: %v", err)
+	}
+	if cwd, err := h.PaneCurrentPath(childID); err != nil || cwd != dir {
+		t.Fatalf("expected child pane in parent's cwd, got %q err=%v", cwd, err)
+	}
+
+	if err := h.ClosePane(paneID); err != nil {
+		t.Fatalf("ClosePane failed: %v", err)
+	}
+	if exists, _ := h.PaneExists(paneID); exists {
+		t.Fatal("expected closed pane gone")
+	}
+	if err := h.SendInput(childID, "exit 4\r"); err != nil {
+		t.Fatalf("SendInput failed: %v", err)
+	}
+	waitFor(t, "child shell exit", func() bool {
+		status, err := h.PaneExitStatus(childID)
+		return err == nil && status.Dead
+	})
+	if status, _ := h.PaneExitStatus(childID); !status.HasDeadStatus || status.DeadStatus != 4 {
+		t.Fatalf("unexpected exit status %#v", status)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(closed) != 2 || closed[0] != paneID+" pty-closed" || closed[1] != childID+" pty-exit" {
+		t.Fatalf("unexpected closed notifications %v", closed)
+	}
+}
+
+func TestHost_RecordsPaneOptionsFromOSC(t *testing.T) {
+	h := newTestHost(t, "/bin/sh", "-c", `printf '\033]777;shellman;ready=1\007\033]777;shellman;last_exit=2\007'; sleep 5`)
+	paneID, err := h.CreateRootPaneInDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("CreateRootPaneInDir failed: %v", err)
+	}
+	waitFor(t, "ready option", func() bool {
+		value, _ := h.PaneOption(paneID, "@shellman_ready")
+		return value == "1"
+	})
+	status, err := h.PaneExitStatus(paneID)
+	if err != nil || status.Dead || !status.HasLastExit || status.LastExit != 2 {
+		t.Fatalf("unexpected live exit status %#v err=%v", status, err)
+	}
+}
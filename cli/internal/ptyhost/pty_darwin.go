@@ -0,0 +1,65 @@
+//go:build darwin
+
+package ptyhost
+
+import (
+	"bytes"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a new pseudo-terminal pair and returns its master and slave ends.
+func openPTY() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	fd := master.Fd()
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, unix.TIOCPTYGRANT, 0); errno != 0 {
+		_ = master.Close()
+		return nil, nil, errno
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, unix.TIOCPTYUNLK, 0); errno != 0 {
+		_ = master.Close()
+		return nil, nil, errno
+	}
+	name := make([]byte, 128)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, unix.TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0]))); errno != 0 {
+		_ = master.Close()
+		return nil, nil, errno
+	}
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+	slave, err := os.OpenFile(string(name), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// foregroundCommand returns the name of the foreground process on the terminal of the session pid leads.
+func foregroundCommand(pid int) string {
+	kproc, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return ""
+	}
+	if tpgid := int(kproc.Eproc.Tpgid); tpgid > 0 && tpgid != pid {
+		if fg, err := unix.SysctlKinfoProc("kern.proc.pid", tpgid); err == nil {
+			kproc = fg
+		}
+	}
+	comm := kproc.Proc.P_comm[:]
+	if end := bytes.IndexByte(comm, 0); end >= 0 {
+		comm = comm[:end]
+	}
+	return string(comm)
+}
+
+// processCwd returns the working directory of pid; darwin has no cheap way to ask, so panes keep their start cwd.
+func processCwd(int) string {
+	return ""
+}
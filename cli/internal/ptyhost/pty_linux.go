@@ -0,0 +1,76 @@
+//go:build linux
+
+package ptyhost
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a new pseudo-terminal pair and returns its master and slave ends.
+func openPTY() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		_ = master.Close()
+		return nil, nil, err
+	}
+	index, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, err
+	}
+	slave, err := os.OpenFile("/dev/pts/"+strconv.Itoa(index), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// foregroundCommand returns the name of the foreground process on the terminal of the session pid leads.
+func foregroundCommand(pid int) string {
+	tpgid := procStatField(pid, 5)
+	if tpgid <= 0 {
+		tpgid = pid
+	}
+	comm, err := os.ReadFile("/proc/" + strconv.Itoa(tpgid) + "/comm")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// processCwd returns the working directory of pid.
+func processCwd(pid int) string {
+	cwd, err := os.Readlink("/proc/" + strconv.Itoa(pid) + "/cwd")
+	if err != nil {
+		return ""
+	}
+	return cwd
+}
+
+// procStatField returns the field-th number after the command name in /proc/<pid>/stat, e.g. 5 for tpgid.
+func procStatField(pid, field int) int {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0
+	}
+	text := string(data)
+	right := strings.LastIndex(text, ")")
+	if right < 0 {
+		return 0
+	}
+	fields := strings.Fields(text[right+1:])
+	if len(fields) <= field {
+		return 0
+	}
+	n, _ := strconv.Atoi(fields[field])
+	return n
+}
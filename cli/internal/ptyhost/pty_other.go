@@ -0,0 +1,35 @@
+//go:build !darwin && !linux
+
+package ptyhost
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+var errPTYUnsupported = errors.New("native pty backend is not supported on this platform")
+
+func openPTY() (*os.File, *os.File, error) {
+	return nil, nil, errPTYUnsupported
+}
+
+func setWinsize(*os.File, int, int) error {
+	return errPTYUnsupported
+}
+
+func sessionAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+func foregroundCommand(int) string {
+	return ""
+}
+
+func processCwd(int) string {
+	return ""
+}
+
+func hangup(*os.Process) error {
+	return errPTYUnsupported
+}
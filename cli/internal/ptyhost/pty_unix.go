@@ -0,0 +1,25 @@
+//go:build darwin || linux
+
+package ptyhost
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setWinsize tells the terminal behind master its new size, which delivers SIGWINCH to its foreground process group.
+func setWinsize(master *os.File, cols, rows int) error {
+	return unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, &unix.Winsize{Col: uint16(cols), Row: uint16(rows)})
+}
+
+// sessionAttr makes the child lead a new session with the slave as its controlling terminal (its stdin).
+func sessionAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+}
+
+// hangup sends SIGHUP to the process group proc leads, the way a closing terminal does.
+func hangup(proc *os.Process) error {
+	return syscall.Kill(-proc.Pid, syscall.SIGHUP)
+}
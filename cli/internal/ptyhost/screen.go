@@ -0,0 +1,506 @@
+package ptyhost
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type parseState int
+
+const (
+	stateGround parseState = iota
+	stateEscape
+	stateCharset
+	stateCSI
+	stateOSC
+	stateOSCEscape
+)
+
+// Screen is a small VT100/xterm emulator: it keeps the visible grid, the cursor and the lines scrolled off the top,
+// which is all capture and cursor queries need. Colors and other attributes are dropped. Screen is not safe for
+// concurrent use.
+type Screen struct {
+	cols, rows    int
+	grid          [][]rune
+	cx, cy        int
+	wrapPending   bool
+	savedX        int
+	savedY        int
+	scrollTop     int
+	scrollBottom  int
+	scrollback    []string
+	maxScrollback int
+	mainGrid      [][]rune // the main screen while the alternate one is shown
+	mainX, mainY  int
+
+	state   parseState
+	params  []byte
+	osc     []byte
+	pending []byte
+
+	// OnOSC receives the payload of every operating system command, e.g. "0;title".
+	OnOSC func(payload string)
+}
+
+// NewScreen returns a blank cols x rows screen keeping at most maxScrollback lines of history.
+func NewScreen(cols, rows, maxScrollback int) *Screen {
+	cols, rows = clampSize(cols, rows)
+	s := &Screen{cols: cols, rows: rows, maxScrollback: maxScrollback}
+	s.grid = blankGrid(cols, rows)
+	s.scrollBottom = rows - 1
+	return s
+}
+
+func clampSize(cols, rows int) (int, int) {
+	if cols < 1 {
+		cols = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	return cols, rows
+}
+
+func blankGrid(cols, rows int) [][]rune {
+	grid := make([][]rune, rows)
+	for i := range grid {
+		grid[i] = blankLine(cols)
+	}
+	return grid
+}
+
+func blankLine(cols int) []rune {
+	line := make([]rune, cols)
+	for i := range line {
+		line[i] = ' '
+	}
+	return line
+}
+
+// Size returns the screen's width and height in cells.
+func (s *Screen) Size() (int, int) {
+	return s.cols, s.rows
+}
+
+// Cursor returns the cursor column and row, both zero-based.
+func (s *Screen) Cursor() (int, int) {
+	return s.cx, s.cy
+}
+
+// Snapshot returns the visible rows, one line each with trailing blanks removed.
+func (s *Screen) Snapshot() string {
+	var b strings.Builder
+	for _, line := range s.grid {
+		b.WriteString(renderLine(line))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// History returns up to lines lines of scrollback followed by the visible rows.
+func (s *Screen) History(lines int) string {
+	start := 0
+	if lines > 0 && len(s.scrollback) > lines {
+		start = len(s.scrollback) - lines
+	}
+	var b strings.Builder
+	for _, line := range s.scrollback[start:] {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	b.WriteString(s.Snapshot())
+	return b.String()
+}
+
+func renderLine(line []rune) string {
+	return strings.TrimRight(string(line), " ")
+}
+
+// Resize changes the screen size. Rows that no longer fit above the cursor move into the scrollback.
+func (s *Screen) Resize(cols, rows int) {
+	cols, rows = clampSize(cols, rows)
+	if cols == s.cols && rows == s.rows {
+		return
+	}
+	if drop := s.cy + 1 - rows; drop > 0 {
+		for _, line := range s.grid[:drop] {
+			s.pushScrollback(line)
+		}
+		s.grid = s.grid[drop:]
+		s.cy -= drop
+	}
+	s.grid = resizeGrid(s.grid, cols, rows)
+	s.cols, s.rows = cols, rows
+	s.cx = min(s.cx, cols-1)
+	s.cy = min(s.cy, rows-1)
+	s.scrollTop, s.scrollBottom = 0, rows-1
+	s.wrapPending = false
+	if s.mainGrid != nil {
+		s.mainGrid = resizeGrid(s.mainGrid, cols, rows)
+		s.mainX, s.mainY = min(s.mainX, cols-1), min(s.mainY, rows-1)
+	}
+}
+
+// resizeGrid copies grid into a cols x rows grid, cutting or padding it at the right and the bottom.
+func resizeGrid(grid [][]rune, cols, rows int) [][]rune {
+	out := make([][]rune, rows)
+	for i := range out {
+		line := blankLine(cols)
+		if i < len(grid) {
+			copy(line, grid[i])
+		}
+		out[i] = line
+	}
+	return out
+}
+
+// Write feeds terminal output to the screen. Sequences split across writes are resumed on the next call.
+func (s *Screen) Write(p []byte) (int, error) {
+	data := p
+	if len(s.pending) > 0 {
+		data = append(s.pending, p...)
+		s.pending = nil
+	}
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size <= 1 && !utf8.FullRune(data) {
+			s.pending = append([]byte(nil), data...)
+			break
+		}
+		data = data[size:]
+		s.feed(r)
+	}
+	return len(p), nil
+}
+
+func (s *Screen) feed(r rune) {
+	switch s.state {
+	case stateEscape:
+		s.escape(r)
+	case stateCharset:
+		s.state = stateGround
+	case stateCSI:
+		switch {
+		case r >= 0x30 && r <= 0x3f, r >= 0x20 && r <= 0x2f:
+			s.params = append(s.params, byte(r))
+		case r >= 0x40 && r <= 0x7e:
+			s.state = stateGround
+			s.csi(r)
+		case r == 0x1b:
+			s.state = stateEscape
+		default:
+			s.state = stateGround
+		}
+	case stateOSC:
+		switch r {
+		case 0x07:
+			s.endOSC()
+		case 0x1b:
+			s.state = stateOSCEscape
+		default:
+			s.osc = utf8.AppendRune(s.osc, r)
+		}
+	case stateOSCEscape:
+		// ESC \ terminates the command; anything else aborts it and starts a new escape.
+		s.endOSC()
+		if r != '\\' {
+			s.state = stateEscape
+			s.escape(r)
+		}
+	default:
+		s.ground(r)
+	}
+}
+
+func (s *Screen) ground(r rune) {
+	switch r {
+	case 0x1b:
+		s.state = stateEscape
+	case '\r':
+		s.cx = 0
+		s.wrapPending = false
+	case '\n', 0x0b, 0x0c:
+		s.lineFeed()
+	case '\b':
+		if s.cx > 0 {
+			s.cx--
+		}
+		s.wrapPending = false
+	case '\t':
+		s.cx = min((s.cx/8+1)*8, s.cols-1)
+		s.wrapPending = false
+	default:
+		if r < 0x20 || r == 0x7f {
+			return
+		}
+		s.put(r)
+	}
+}
+
+func (s *Screen) escape(r rune) {
+	s.state = stateGround
+	switch r {
+	case '[':
+		s.state = stateCSI
+		s.params = s.params[:0]
+	case ']':
+		s.state = stateOSC
+		s.osc = s.osc[:0]
+	case '(', ')', '*', '+':
+		s.state = stateCharset
+	case '7':
+		s.savedX, s.savedY = s.cx, s.cy
+	case '8':
+		s.moveTo(s.savedX, s.savedY)
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.cx = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.grid = blankGrid(s.cols, s.rows)
+		s.moveTo(0, 0)
+		s.scrollTop, s.scrollBottom = 0, s.rows-1
+	}
+}
+
+func (s *Screen) endOSC() {
+	s.state = stateGround
+	if s.OnOSC != nil {
+		s.OnOSC(string(s.osc))
+	}
+}
+
+func (s *Screen) put(r rune) {
+	if s.wrapPending {
+		s.cx = 0
+		s.lineFeed()
+	}
+	s.grid[s.cy][s.cx] = r
+	if s.cx == s.cols-1 {
+		s.wrapPending = true
+		return
+	}
+	s.cx++
+}
+
+func (s *Screen) moveTo(x, y int) {
+	s.cx = max(0, min(x, s.cols-1))
+	s.cy = max(0, min(y, s.rows-1))
+	s.wrapPending = false
+}
+
+func (s *Screen) lineFeed() {
+	s.wrapPending = false
+	if s.cy == s.scrollBottom {
+		s.scrollUp(1)
+		return
+	}
+	if s.cy < s.rows-1 {
+		s.cy++
+	}
+}
+
+func (s *Screen) reverseIndex() {
+	s.wrapPending = false
+	if s.cy == s.scrollTop {
+		s.scrollDown(1)
+		return
+	}
+	if s.cy > 0 {
+		s.cy--
+	}
+}
+
+// scrollUp moves the scroll region up by n lines. Lines leaving the top of the main screen go to the scrollback.
+func (s *Screen) scrollUp(n int) {
+	for n = min(n, s.scrollBottom-s.scrollTop+1); n > 0; n-- {
+		if s.scrollTop == 0 && s.mainGrid == nil {
+			s.pushScrollback(s.grid[0])
+		}
+		copy(s.grid[s.scrollTop:s.scrollBottom], s.grid[s.scrollTop+1:s.scrollBottom+1])
+		s.grid[s.scrollBottom] = blankLine(s.cols)
+	}
+}
+
+func (s *Screen) scrollDown(n int) {
+	for n = min(n, s.scrollBottom-s.scrollTop+1); n > 0; n-- {
+		copy(s.grid[s.scrollTop+1:s.scrollBottom+1], s.grid[s.scrollTop:s.scrollBottom])
+		s.grid[s.scrollTop] = blankLine(s.cols)
+	}
+}
+
+func (s *Screen) pushScrollback(line []rune) {
+	if s.maxScrollback <= 0 {
+		return
+	}
+	s.scrollback = append(s.scrollback, renderLine(line))
+	if over := len(s.scrollback) - s.maxScrollback; over > 0 {
+		s.scrollback = append(s.scrollback[:0], s.scrollback[over:]...)
+	}
+}
+
+func (s *Screen) csi(final rune) {
+	raw := string(s.params)
+	private := strings.HasPrefix(raw, "?")
+	raw = strings.TrimLeft(raw, "?>=<")
+	params := parseParams(raw)
+	arg := func(i, fallback int) int {
+		if i < len(params) && params[i] > 0 {
+			return params[i]
+		}
+		return fallback
+	}
+
+	switch final {
+	case 'A':
+		s.moveTo(s.cx, s.cy-arg(0, 1))
+	case 'B':
+		s.moveTo(s.cx, s.cy+arg(0, 1))
+	case 'C':
+		s.moveTo(s.cx+arg(0, 1), s.cy)
+	case 'D':
+		s.moveTo(s.cx-arg(0, 1), s.cy)
+	case 'E':
+		s.moveTo(0, s.cy+arg(0, 1))
+	case 'F':
+		s.moveTo(0, s.cy-arg(0, 1))
+	case 'G', '`':
+		s.moveTo(arg(0, 1)-1, s.cy)
+	case 'd':
+		s.moveTo(s.cx, arg(0, 1)-1)
+	case 'H', 'f':
+		s.moveTo(arg(1, 1)-1, arg(0, 1)-1)
+	case 'J':
+		s.eraseDisplay(arg(0, 0))
+	case 'K':
+		s.eraseLine(arg(0, 0))
+	case 'L':
+		if s.cy >= s.scrollTop && s.cy <= s.scrollBottom {
+			top := s.scrollTop
+			s.scrollTop = s.cy
+			s.scrollDown(min(arg(0, 1), s.scrollBottom-s.cy+1))
+			s.scrollTop = top
+		}
+	case 'M':
+		if s.cy >= s.scrollTop && s.cy <= s.scrollBottom {
+			top := s.scrollTop
+			s.scrollTop = s.cy
+			n := min(arg(0, 1), s.scrollBottom-s.cy+1)
+			for ; n > 0; n-- {
+				copy(s.grid[s.scrollTop:s.scrollBottom], s.grid[s.scrollTop+1:s.scrollBottom+1])
+				s.grid[s.scrollBottom] = blankLine(s.cols)
+			}
+			s.scrollTop = top
+		}
+	case 'P':
+		line := s.grid[s.cy]
+		n := min(arg(0, 1), s.cols-s.cx)
+		copy(line[s.cx:], line[s.cx+n:])
+		for i := s.cols - n; i < s.cols; i++ {
+			line[i] = ' '
+		}
+	case '@':
+		line := s.grid[s.cy]
+		n := min(arg(0, 1), s.cols-s.cx)
+		copy(line[s.cx+n:], line[s.cx:s.cols-n])
+		for i := s.cx; i < s.cx+n; i++ {
+			line[i] = ' '
+		}
+	case 'X':
+		line := s.grid[s.cy]
+		for i := s.cx; i < min(s.cx+arg(0, 1), s.cols); i++ {
+			line[i] = ' '
+		}
+	case 'S':
+		s.scrollUp(arg(0, 1))
+	case 'T':
+		s.scrollDown(arg(0, 1))
+	case 'r':
+		top, bottom := arg(0, 1)-1, arg(1, s.rows)-1
+		if top < bottom && bottom < s.rows {
+			s.scrollTop, s.scrollBottom = top, bottom
+			s.moveTo(0, 0)
+		}
+	case 's':
+		s.savedX, s.savedY = s.cx, s.cy
+	case 'u':
+		s.moveTo(s.savedX, s.savedY)
+	case 'h', 'l':
+		if private {
+			for _, mode := range params {
+				if mode == 47 || mode == 1047 || mode == 1049 {
+					s.setAltScreen(final == 'h')
+				}
+			}
+		}
+	}
+}
+
+func parseParams(raw string) []int {
+	if raw == "" {
+		return nil
+	}
+	parts := strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == ':' })
+	out := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			n = 0
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+func (s *Screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseLine(0)
+		for y := s.cy + 1; y < s.rows; y++ {
+			s.grid[y] = blankLine(s.cols)
+		}
+	case 1:
+		s.eraseLine(1)
+		for y := 0; y < s.cy; y++ {
+			s.grid[y] = blankLine(s.cols)
+		}
+	case 2:
+		s.grid = blankGrid(s.cols, s.rows)
+	case 3:
+		s.scrollback = nil
+	}
+}
+
+func (s *Screen) eraseLine(mode int) {
+	line := s.grid[s.cy]
+	from, to := 0, s.cols
+	switch mode {
+	case 0:
+		from = s.cx
+	case 1:
+		to = min(s.cx+1, s.cols)
+	}
+	for i := from; i < to; i++ {
+		line[i] = ' '
+	}
+}
+
+// setAltScreen switches to the alternate screen, which full-screen programs draw on without touching the
+// scrollback, or back to the main screen and its cursor.
+func (s *Screen) setAltScreen(on bool) {
+	switch {
+	case on && s.mainGrid == nil:
+		s.mainGrid, s.mainX, s.mainY = s.grid, s.cx, s.cy
+		s.grid = blankGrid(s.cols, s.rows)
+		s.moveTo(0, 0)
+	case !on && s.mainGrid != nil:
+		s.grid = s.mainGrid
+		s.mainGrid = nil
+		s.moveTo(s.mainX, s.mainY)
+	}
+	s.scrollTop, s.scrollBottom = 0, s.rows-1
+}
@@ -0,0 +1,85 @@
+package ptyhost
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScreen_TracksTextAndCursor(t *testing.T) {
+	s := NewScreen(10, 3, 100)
+	_, _ = s.Write([]byte("hello\r\nwor"))
+	if got := s.Snapshot(); got != "hello\nwor\n\n" {
+		t.Fatalf("unexpected snapshot %q", got)
+	}
+	if x, y := s.Cursor(); x != 3 || y != 1 {
+		t.Fatalf("unexpected cursor %d,%d", x, y)
+	}
+
+	_, _ = s.Write([]byte("\x1b[1;3H\x1b[K!\x1b[2;1H\x1b[2K"))
+	if got := s.Snapshot(); got != "he!\n\n\n" {
+		t.Fatalf("unexpected snapshot after erase %q", got)
+	}
+}
+
+func TestScreen_ScrollbackAndResize(t *testing.T) {
+	s := NewScreen(5, 2, 2)
+	_, _ = s.Write([]byte("one\r\ntwo\r\nthree\r\nfour"))
+	if got := s.History(10); got != "one\ntwo\nthree\nfour\n" {
+		t.Fatalf("unexpected history %q", got)
+	}
+	if got := s.History(1); got != "two\nthree\nfour\n" {
+		t.Fatalf("expected history limited to one scrollback line, got %q", got)
+	}
+	_, _ = s.Write([]byte("\r\nfive"))
+	if got := s.History(10); got != "two\nthree\nfour\nfive\n" {
+		t.Fatalf("expected scrollback capped at two lines, got %q", got)
+	}
+
+	s.Resize(3, 1)
+	if got := s.History(10); got != "three\nfour\nfiv\n" {
+		t.Fatalf("unexpected history after shrinking %q", got)
+	}
+	if cols, rows := s.Size(); cols != 3 || rows != 1 {
+		t.Fatalf("unexpected size %dx%d", cols, rows)
+	}
+}
+
+func TestScreen_WrapsLongLines(t *testing.T) {
+	s := NewScreen(4, 3, 10)
+	_, _ = s.Write([]byte("abcdefg"))
+	if got := s.Snapshot(); got != "abcd\nefg\n\n" {
+		t.Fatalf("unexpected wrapped snapshot %q", got)
+	}
+}
+
+func TestScreen_AlternateScreenKeepsMainContent(t *testing.T) {
+	s := NewScreen(10, 2, 10)
+	_, _ = s.Write([]byte("$ top"))
+	_, _ = s.Write([]byte("\x1b[?1049h\x1b[Hfullscreen"))
+	if got := s.Snapshot(); !strings.HasPrefix(got, "fullscreen") {
+		t.Fatalf("expected alternate screen shown, got %q", got)
+	}
+	_, _ = s.Write([]byte("\x1b[?1049l"))
+	if got := s.Snapshot(); got != "$ top\n\n" {
+		t.Fatalf("expected main screen restored, got %q", got)
+	}
+	if x, y := s.Cursor(); x != 5 || y != 0 {
+		t.Fatalf("expected main cursor restored, got %d,%d", x, y)
+	}
+}
+
+func TestScreen_ReportsOSCAndSplitSequences(t *testing.T) {
+	s := NewScreen(10, 2, 10)
+	var payloads []string
+	s.OnOSC = func(payload string) { payloads = append(payloads, payload) }
+	_, _ = s.Write([]byte("\x1b]777;shellman;rea"))
+	_, _ = s.Write([]byte("dy=1\x07\x1b[3"))
+	_, _ = s.Write([]byte("2m\xe4\xbd"))
+	_, _ = s.Write([]byte("\xa0ok\x1b]0;title\x1b\\"))
+	if len(payloads) != 2 || payloads[0] != "777;shellman;ready=1" || payloads[1] != "0;title" {
+		t.Fatalf("unexpected osc payloads %q", payloads)
+	}
+	if got := s.Snapshot(); got != "你ok\n\n" {
+		t.Fatalf("unexpected snapshot %q", got)
+	}
+}
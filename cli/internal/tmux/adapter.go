@@ -19,6 +19,9 @@ type Adapter struct {
 
 	cacheMu           sync.Mutex
 	paneCommandByPane map[string]paneCommandCacheEntry
+
+	socketsMu sync.Mutex
+	sockets   map[string]*Adapter
 }
 
 const rootSessionName = "shellman"
@@ -32,6 +35,27 @@ type PaneRuntimeState struct {
 	CurrentArgs    []string
 }
 
+// PaneExitStatus reports how the pane's last foreground command ended. Dead panes (remain-on-exit) carry tmux's
+// pane_dead_status; live panes carry the status the bootstrap shell recorded at its last prompt, if any.
+type PaneExitStatus struct {
+	CurrentCommand string
+	Dead           bool
+	DeadStatus     int
+	HasDeadStatus  bool
+	LastExit       int
+	HasLastExit    bool
+}
+
+// PaneLayoutInfo places a pane in its window: which window it is in, the window's layout and the pane's position.
+type PaneLayoutInfo struct {
+	SessionName  string
+	WindowID     string
+	WindowIndex  int
+	WindowLayout string
+	PaneIndex    int
+	CurrentPath  string
+}
+
 type paneCommandCacheEntry struct {
 	panePID int
 	tpgid   int
@@ -60,6 +84,26 @@ func (a *Adapter) SocketName() string {
 	return strings.TrimSpace(a.tmuxSocket)
 }
 
+// ForSocket returns an adapter for the tmux server listening on socket, sharing this adapter's exec. An empty socket
+// or this adapter's own socket returns the adapter itself; other adapters are created once per socket and reused.
+func (a *Adapter) ForSocket(socket string) *Adapter {
+	socket = strings.TrimSpace(socket)
+	if a == nil || socket == "" || socket == strings.TrimSpace(a.tmuxSocket) {
+		return a
+	}
+	a.socketsMu.Lock()
+	defer a.socketsMu.Unlock()
+	if a.sockets == nil {
+		a.sockets = map[string]*Adapter{}
+	}
+	if existing, ok := a.sockets[socket]; ok {
+		return existing
+	}
+	next := NewAdapterWithSocket(a.exec, socket)
+	a.sockets[socket] = next
+	return next
+}
+
 func (a *Adapter) ListSessions() ([]string, error) {
 	out, err := a.exec.Output("tmux", a.withSocket("list-panes", "-a", "-F", "#{pane_id}")...)
 	if err != nil {
@@ -222,6 +266,78 @@ func (a *Adapter) PaneLastActiveAt(target string) (time.Time, error) {
 	return time.Unix(sec, 0).UTC(), nil
 }
 
+// PaneCurrentPath returns the working directory of target's foreground process.
+func (a *Adapter) PaneCurrentPath(target string) (string, error) {
+	out, err := a.exec.Output("tmux", a.withSocket("display-message", "-p", "-t", target, "#{pane_current_path}")...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// PanePID returns the pid of the process tmux started in target, usually its shell.
+func (a *Adapter) PanePID(target string) (int, error) {
+	out, err := a.exec.Output("tmux", a.withSocket("display-message", "-p", "-t", target, "#{pane_pid}")...)
+	if err != nil {
+		return 0, err
+	}
+	raw := strings.TrimSpace(string(out))
+	pid, err := strconv.Atoi(raw)
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("unexpected pane pid: %q", raw)
+	}
+	return pid, nil
+}
+
+// PaneSize returns the pane's width and height in cells.
+func (a *Adapter) PaneSize(target string) (int, int, error) {
+	out, err := a.exec.Output("tmux", a.withSocket("display-message", "-p", "-t", target, "#{pane_width}x#{pane_height}")...)
+	if err != nil {
+		return 0, 0, err
+	}
+	raw := strings.TrimSpace(string(out))
+	width, height, ok := strings.Cut(raw, "x")
+	cols, errCols := strconv.Atoi(width)
+	rows, errRows := strconv.Atoi(height)
+	if !ok || errCols != nil || errRows != nil {
+		return 0, 0, fmt.Errorf("unexpected pane size: %q", raw)
+	}
+	return cols, rows, nil
+}
+
+func (a *Adapter) PaneLayout(target string) (PaneLayoutInfo, error) {
+	out, err := a.exec.Output("tmux", a.withSocket("display-message", "-p", "-t", target, "#{session_name}\t#{window_id}\t#{window_index}\t#{window_layout}\t#{pane_index}\t#{pane_current_path}")...)
+	if err != nil {
+		return PaneLayoutInfo{}, err
+	}
+	raw := strings.TrimRight(string(out), "\r\n")
+	parts := strings.Split(raw, "\t")
+	if len(parts) != 6 {
+		return PaneLayoutInfo{}, fmt.Errorf("unexpected pane layout: %q", raw)
+	}
+	windowIndex, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+	if err != nil {
+		return PaneLayoutInfo{}, fmt.Errorf("unexpected window index: %q", parts[2])
+	}
+	paneIndex, err := strconv.Atoi(strings.TrimSpace(parts[4]))
+	if err != nil {
+		return PaneLayoutInfo{}, fmt.Errorf("unexpected pane index: %q", parts[4])
+	}
+	return PaneLayoutInfo{
+		SessionName:  parts[0],
+		WindowID:     strings.TrimSpace(parts[1]),
+		WindowIndex:  windowIndex,
+		WindowLayout: strings.TrimSpace(parts[3]),
+		PaneIndex:    paneIndex,
+		CurrentPath:  parts[5],
+	}, nil
+}
+
+// SelectLayout applies layout, a window_layout string or a preset such as "tiled", to the window of target.
+func (a *Adapter) SelectLayout(target, layout string) error {
+	return a.exec.Run("tmux", a.withSocket("select-layout", "-t", target, layout)...)
+}
+
 func (a *Adapter) PaneTitleAndCurrentCommand(target string) (string, string, error) {
 	state, err := a.PaneRuntimeState(target)
 	if err != nil {
@@ -230,6 +346,30 @@ func (a *Adapter) PaneTitleAndCurrentCommand(target string) (string, string, err
 	return state.Title, state.CurrentCommand, nil
 }
 
+func (a *Adapter) PaneExitStatus(target string) (PaneExitStatus, error) {
+	out, err := a.exec.Output("tmux", a.withSocket("display-message", "-p", "-t", target, "#{pane_dead}\t#{pane_dead_status}\t#{@shellman_last_exit}\t#{pane_current_command}")...)
+	if err != nil {
+		return PaneExitStatus{}, err
+	}
+	parts := strings.Split(strings.TrimRight(string(out), "\r\n"), "\t")
+	for len(parts) < 4 {
+		parts = append(parts, "")
+	}
+	status := PaneExitStatus{
+		Dead:           strings.TrimSpace(parts[0]) == "1",
+		CurrentCommand: strings.TrimSpace(parts[3]),
+	}
+	if code, convErr := strconv.Atoi(strings.TrimSpace(parts[1])); convErr == nil {
+		status.DeadStatus = code
+		status.HasDeadStatus = true
+	}
+	if code, convErr := strconv.Atoi(strings.TrimSpace(parts[2])); convErr == nil {
+		status.LastExit = code
+		status.HasLastExit = true
+	}
+	return status, nil
+}
+
 func (a *Adapter) PaneRuntimeState(target string) (PaneRuntimeState, error) {
 	out, err := a.exec.Output("tmux", a.withSocket("display-message", "-p", "-t", target, "#{pane_title}\t#{pane_current_command}\t#{pane_pid}")...)
 	if err != nil {
@@ -751,6 +891,19 @@ if [ -f "$HOME/.bashrc" ]; then
   . "$HOME/.bashrc"
 fi
 
+__shellman_prompt_seen=""
+
+# Records the exit status of the command that just returned to the prompt, so a finished run can tell success
+# from failure. The first prompt only reflects the rcfile itself and is skipped.
+__shellman_record_exit() {
+  local status=$?
+  if [ -n "$__shellman_prompt_seen" ]; then
+    tmux set-option -p -t "$TMUX_PANE" @shellman_last_exit "$status" >/dev/null 2>&1 || true
+  fi
+  __shellman_prompt_seen=1
+  return $status
+}
+
 __shellman_ready_once() {
   tmux set-option -p -t "$TMUX_PANE" @shellman_ready 1 >/dev/null 2>&1 || true
   if [ -n "${PROMPT_COMMAND:-}" ]; then
@@ -763,9 +916,9 @@ __shellman_ready_once() {
 }
 
 if [ -n "${PROMPT_COMMAND:-}" ]; then
-  PROMPT_COMMAND="__shellman_ready_once; ${PROMPT_COMMAND}"
+  PROMPT_COMMAND="__shellman_record_exit; __shellman_ready_once; ${PROMPT_COMMAND}"
 else
-  PROMPT_COMMAND="__shellman_ready_once"
+  PROMPT_COMMAND="__shellman_record_exit; __shellman_ready_once"
 fi
 `
 
@@ -782,6 +935,15 @@ func (a *Adapter) ServerInstanceID() (string, error) {
 	return socket + ":" + pid, nil
 }
 
+// SocketPath returns the path of the socket the tmux server is listening on.
+func (a *Adapter) SocketPath() (string, error) {
+	out, err := a.exec.Output("tmux", a.withSocket("display-message", "-p", "#{socket_path}")...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 func (a *Adapter) withSocket(args ...string) []string {
 	if a.tmuxSocket == "" {
 		return args
@@ -534,3 +534,119 @@ func TestBuildProcessRuntime_NodeCursorAgentArgs(t *testing.T) {
 		t.Fatalf("unexpected args head: %#v", args)
 	}
 }
+
+func TestAdapter_PaneExitStatus_ParsesDeadAndHookStatus(t *testing.T) {
+	f := &FakeExec{OutputText: "1\t137\t\tcodex\n"}
+	a := NewAdapter(f)
+	got, err := a.PaneExitStatus("e2e:0.0")
+	if err != nil {
+		t.Fatalf("pane exit status failed: %v", err)
+	}
+	if !got.Dead || !got.HasDeadStatus || got.DeadStatus != 137 || got.HasLastExit || got.CurrentCommand != "codex" {
+		t.Fatalf("unexpected dead pane status: %#v", got)
+	}
+	if f.LastArgs != "tmux display-message -p -t e2e:0.0 #{pane_dead}\t#{pane_dead_status}\t#{@shellman_last_exit}\t#{pane_current_command}" {
+		t.Fatalf("unexpected command: %s", f.LastArgs)
+	}
+
+	f.OutputText = "0\t\t2\tbash\n"
+	got, err = a.PaneExitStatus("e2e:0.0")
+	if err != nil {
+		t.Fatalf("pane exit status failed: %v", err)
+	}
+	if got.Dead || got.HasDeadStatus || !got.HasLastExit || got.LastExit != 2 || got.CurrentCommand != "bash" {
+		t.Fatalf("unexpected live pane status: %#v", got)
+	}
+}
+
+func TestAdapter_PaneCurrentPath(t *testing.T) {
+	f := &FakeExec{OutputText: "/work/repo\n"}
+	a := NewAdapterWithSocket(f, "tt_e2e")
+	got, err := a.PaneCurrentPath("%3")
+	if err != nil {
+		t.Fatalf("pane current path failed: %v", err)
+	}
+	if got != "/work/repo" {
+		t.Fatalf("unexpected path: %q", got)
+	}
+	if f.LastArgs != "tmux -L tt_e2e display-message -p -t %3 #{pane_current_path}" {
+		t.Fatalf("unexpected command: %s", f.LastArgs)
+	}
+}
+
+func TestAdapter_PaneSize(t *testing.T) {
+	f := &FakeExec{OutputText: "120x40\n"}
+	a := NewAdapterWithSocket(f, "tt_e2e")
+	cols, rows, err := a.PaneSize("%3")
+	if err != nil {
+		t.Fatalf("pane size failed: %v", err)
+	}
+	if cols != 120 || rows != 40 {
+		t.Fatalf("unexpected size: %dx%d", cols, rows)
+	}
+	if f.LastArgs != "tmux -L tt_e2e display-message -p -t %3 #{pane_width}x#{pane_height}" {
+		t.Fatalf("unexpected command: %s", f.LastArgs)
+	}
+}
+
+func TestAdapter_PanePID(t *testing.T) {
+	f := &FakeExec{OutputText: "4242\n"}
+	a := NewAdapterWithSocket(f, "tt_e2e")
+	pid, err := a.PanePID("%3")
+	if err != nil || pid != 4242 {
+		t.Fatalf("unexpected pane pid %d err=%v", pid, err)
+	}
+	if f.LastArgs != "tmux -L tt_e2e display-message -p -t %3 #{pane_pid}" {
+		t.Fatalf("unexpected command: %s", f.LastArgs)
+	}
+	f.OutputText = "\n"
+	if _, err := a.PanePID("%3"); err == nil {
+		t.Fatal("expected error for empty pane pid")
+	}
+}
+
+func TestAdapter_PaneLayout(t *testing.T) {
+	f := &FakeExec{OutputText: "shellman\t@3\t2\tb25d,80x24,0,0{40x24,0,0,1,39x24,41,0,2}\t1\t/work/repo\n"}
+	a := NewAdapterWithSocket(f, "tt_e2e")
+	got, err := a.PaneLayout("%2")
+	if err != nil {
+		t.Fatalf("pane layout failed: %v", err)
+	}
+	want := PaneLayoutInfo{
+		SessionName:  "shellman",
+		WindowID:     "@3",
+		WindowIndex:  2,
+		WindowLayout: "b25d,80x24,0,0{40x24,0,0,1,39x24,41,0,2}",
+		PaneIndex:    1,
+		CurrentPath:  "/work/repo",
+	}
+	if got != want {
+		t.Fatalf("unexpected layout: %#v", got)
+	}
+
+	if err := a.SelectLayout("%2", want.WindowLayout); err != nil {
+		t.Fatalf("select layout failed: %v", err)
+	}
+	if f.LastArgs != "tmux -L tt_e2e select-layout -t %2 "+want.WindowLayout {
+		t.Fatalf("unexpected command: %s", f.LastArgs)
+	}
+}
+
+func TestAdapter_ForSocket(t *testing.T) {
+	f := &FakeExec{OutputText: "/tmp/tmux-1000/work\n"}
+	a := NewAdapterWithSocket(f, "default_sock")
+	if a.ForSocket("") != a || a.ForSocket("default_sock") != a {
+		t.Fatal("expected the adapter's own socket to route to itself")
+	}
+	work := a.ForSocket(" work ")
+	if work == a || work.SocketName() != "work" || a.ForSocket("work") != work {
+		t.Fatalf("expected one reused adapter for socket work, got %#v", work)
+	}
+	path, err := work.SocketPath()
+	if err != nil {
+		t.Fatalf("socket path failed: %v", err)
+	}
+	if path != "/tmp/tmux-1000/work" || f.LastArgs != "tmux -L work display-message -p #{socket_path}" {
+		t.Fatalf("unexpected socket path %q from %s", path, f.LastArgs)
+	}
+}
@@ -0,0 +1,40 @@
+package tmux
+
+import (
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// ForegroundProcessGroupID returns the foreground process group of the terminal pid is attached to, false when pid has
+// no controlling terminal or the platform cannot tell.
+func ForegroundProcessGroupID(pid int) (int, bool) {
+	return foregroundProcessGroupIDForPID(pid)
+}
+
+// PaneProcessTree returns rootPID followed by every live process descended from it, parents before their children.
+func PaneProcessTree(rootPID int) []int {
+	if rootPID <= 0 {
+		return nil
+	}
+	children := map[int][]int{}
+	if procs, err := process.Processes(); err == nil {
+		for _, proc := range procs {
+			ppid, err := proc.Ppid()
+			if err != nil || ppid <= 0 {
+				continue
+			}
+			children[int(ppid)] = append(children[int(ppid)], int(proc.Pid))
+		}
+	}
+	tree := []int{rootPID}
+	seen := map[int]bool{rootPID: true}
+	for i := 0; i < len(tree); i++ {
+		for _, child := range children[tree[i]] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			tree = append(tree, child)
+		}
+	}
+	return tree
+}
@@ -0,0 +1,34 @@
+package tmux
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestPaneProcessTree_IncludesDescendants(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sleep unavailable: %v", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	tree := PaneProcessTree(os.Getpid())
+	if len(tree) == 0 || tree[0] != os.Getpid() {
+		t.Fatalf("expected root first, got %v", tree)
+	}
+	found := false
+	for _, pid := range tree[1:] {
+		if pid == cmd.Process.Pid {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected child %d in tree %v", cmd.Process.Pid, tree)
+	}
+	if got := PaneProcessTree(0); got != nil {
+		t.Fatalf("expected no tree for pid 0, got %v", got)
+	}
+}